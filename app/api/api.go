@@ -0,0 +1,376 @@
+// Package api defines the request object and the method table which expose
+// the `clock.HashClockService` methods to the hashclock servers, so that
+// each method is validated and called in the same way regardless of how
+// the request was received
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// MaxMemory and MaxCost are the largest memory-hard parameters of a
+	// request: a scratchpad of 16 MiB, with as many reads as it has entries.
+	// Far below the library's limits, they keep each step of a request to a
+	// fraction of a second -- so that its timeout, a cancellation or a
+	// shutdown (checked on every step) still stop it promptly -- and the
+	// memory of each running job to 16 MiB
+	MaxMemory int = 16 << 10
+	MaxCost   int = MaxMemory * 1024 / 32
+)
+
+// ErrInvalidRequest error is wrapped by all errors caused by an invalid
+// request (unknown method, missing or invalid parameters), as opposed to
+// failures while running the method
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Request struct defines the parameters for a `clock.HashClockService`
+// method call. Only the parameters used by the method need to be set
+type Request struct {
+	Method     string `json:"method,omitempty"`
+	Seed       string `json:"seed"`
+	Algorithm  string `json:"algorithm,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	Timeout    int    `json:"timeout,omitempty"`
+	Hash       string `json:"hash,omitempty"`
+
+	// Checkpoint is a trusted point of the chain to continue from, instead
+	// of the seed (which may be empty); see `clock.HashClockService.SetCheckpoint`
+	Checkpoint *clock.Checkpoint `json:"checkpoint,omitempty"`
+
+	// MemoryHard are the parameters of the memory-hard step function, with
+	// the `MEMHARD` algorithm; its defaults if not set
+	MemoryHard *clock.MemoryHard `json:"memory_hard,omitempty"`
+
+	// Mode is the chain's chaining mode (`hex` by default, or `indexed`),
+	// and Tag its domain tag; see `clock.HashClockService.SetMode`
+	Mode string `json:"mode,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+
+	// Prefix is the target of the prefix search methods: a hex prefix of the
+	// hash, or a number of its leading zero bits
+	Prefix *clock.Prefix `json:"prefix,omitempty"`
+}
+
+// Method struct describes a `clock.HashClockService` method: how its
+// parameters are validated and how it is called
+type Method struct {
+	Name string
+
+	// Long is set for methods which can run for an unbounded (or user-defined)
+	// amount of time, and are better suited to run asynchronously
+	Long bool
+
+	validate func(r *Request) error
+	call     func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error)
+}
+
+// Methods maps each method name to its definition
+var Methods = map[string]*Method{
+	"Hash": {
+		Name: "Hash",
+		validate: func(r *Request) error {
+			return clock.ValidateSeed(r.Seed)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.Hash(r.Seed)
+		},
+	},
+	"RecHash": {
+		Name: "RecHash",
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			return clock.ValidateIterations(r.Iterations)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.RecHash(r.Seed, r.Iterations)
+		},
+	},
+	"RecHashTimeout": {
+		Name: "RecHashTimeout",
+		Long: true,
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			return clock.ValidateTimeout(r.Timeout)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.RecHashTimeout(r.Seed, r.Timeout)
+		},
+	},
+	"Verify": {
+		Name: "Verify",
+		Long: true,
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			return clock.ValidateHash(r.Seed, r.Hash)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.Verify(r.Seed, r.Hash)
+		},
+	},
+	"VerifyIndex": {
+		Name: "VerifyIndex",
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			if err := clock.ValidateHash(r.Seed, r.Hash); err != nil {
+				return err
+			}
+			return clock.ValidateIndex(r.Iterations)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.VerifyIndex(r.Seed, r.Hash, r.Iterations)
+		},
+	},
+	"VerifyTimeout": {
+		Name: "VerifyTimeout",
+		Long: true,
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			if err := clock.ValidateHash(r.Seed, r.Hash); err != nil {
+				return err
+			}
+			return clock.ValidateTimeout(r.Timeout)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.VerifyTimeout(r.Seed, r.Hash, r.Timeout)
+		},
+	},
+	"VerifyIndexTimeout": {
+		Name: "VerifyIndexTimeout",
+		Long: true,
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			if err := clock.ValidateHash(r.Seed, r.Hash); err != nil {
+				return err
+			}
+			if err := clock.ValidateIndex(r.Iterations); err != nil {
+				return err
+			}
+			return clock.ValidateTimeout(r.Timeout)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.VerifyIndexTimeout(r.Seed, r.Hash, r.Iterations, r.Timeout)
+		},
+	},
+	"SearchPrefix": {
+		Name: "SearchPrefix",
+		Long: true,
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			return validatePrefix(r)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.SearchPrefix(r.Seed, *r.Prefix)
+		},
+	},
+	"SearchPrefixTimeout": {
+		Name: "SearchPrefixTimeout",
+		Long: true,
+		validate: func(r *Request) error {
+			if err := validateSeed(r); err != nil {
+				return err
+			}
+			if err := validatePrefix(r); err != nil {
+				return err
+			}
+			return clock.ValidateTimeout(r.Timeout)
+		},
+		call: func(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+			return c.SearchPrefixTimeout(r.Seed, *r.Prefix, r.Timeout)
+		},
+	},
+}
+
+// validateSeed function checks the request's seed, which can only be empty
+// when continuing from a checkpoint
+func validateSeed(r *Request) error {
+	if r.Checkpoint != nil {
+		return nil
+	}
+	return clock.ValidateSeed(r.Seed)
+}
+
+// validateCheckpoint function checks the request's checkpoint, if set: the
+// index of the hash to verify or calculate must come after it
+func validateCheckpoint(r *Request) error {
+	if r.Checkpoint == nil {
+		return nil
+	}
+	if r.Checkpoint.Index <= 0 {
+		return errors.New("checkpoint index must be greater than zero")
+	}
+	if err := clock.ValidateHash("", r.Checkpoint.Hash); err != nil {
+		return err
+	}
+	if err := clock.ValidateHashLength(r.Algorithm, r.Checkpoint.Hash); err != nil {
+		return err
+	}
+	if r.Iterations > 0 && r.Iterations <= r.Checkpoint.Index {
+		return fmt.Errorf("index %d does not come after the checkpoint's index %d", r.Iterations, r.Checkpoint.Index)
+	}
+	return nil
+}
+
+// validatePrefix function checks the request's prefix search target, which
+// is required by the prefix search methods
+func validatePrefix(r *Request) error {
+	if r.Prefix == nil {
+		return errors.New("prefix cannot be empty")
+	}
+	return clock.ValidatePrefix(r.Algorithm, *r.Prefix)
+}
+
+// validateMemoryHard function checks the request's memory-hard parameters, if
+// set, which are only used by the memory-hard step function; and cannot be
+// above `MaxMemory` and `MaxCost`
+func validateMemoryHard(r *Request) error {
+	if r.MemoryHard == nil {
+		return nil
+	}
+	if !strings.EqualFold(r.Algorithm, clock.MemoryHardAlgorithm) {
+		return fmt.Errorf("memory_hard parameters are only used with the %s algorithm", clock.MemoryHardAlgorithm)
+	}
+	if err := r.MemoryHard.Validate(); err != nil {
+		return err
+	}
+
+	switch {
+	case r.MemoryHard.Memory > MaxMemory:
+		return fmt.Errorf("memory_hard memory cannot be above %d KiB in a request", MaxMemory)
+	case r.MemoryHard.Cost > MaxCost:
+		return fmt.Errorf("memory_hard cost cannot be above %d in a request", MaxCost)
+	}
+	return nil
+}
+
+// MethodNames function returns the names of all methods, sorted
+func MethodNames() []string {
+	names := make([]string, 0, len(Methods))
+	for name := range Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate function checks the input request: its method must be known, its
+// algorithm (if set) must be supported and its parameters must be valid for
+// the method. The returned error wraps `ErrInvalidRequest`
+func Validate(r *Request) (*Method, error) {
+	m, ok := Methods[r.Method]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown method %q", ErrInvalidRequest, r.Method)
+	}
+
+	if r.Algorithm != "" {
+		if err := clock.ValidateAlgorithm(r.Algorithm); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+		}
+	}
+
+	if err := m.validate(r); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	// a hash of another length than the algorithm's digests cannot match
+	if r.Hash != "" {
+		if err := clock.ValidateHashLength(r.Algorithm, r.Hash); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+		}
+	}
+
+	if err := validateCheckpoint(r); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	if err := validateMemoryHard(r); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	if err := clock.ValidateMode(r.Mode, r.Tag); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	return m, nil
+}
+
+// NewService function creates a `clock.HashClockService` for the input
+// request: configured with its algorithm (SHA256 by default), memory-hard
+// parameters, chaining mode and checkpoint (if set), bound to the input
+// context and without any output for logged hashes
+func NewService(ctx context.Context, r *Request) (*clock.HashClockService, error) {
+	alg := r.Algorithm
+	if alg == "" {
+		alg = "sha256"
+	}
+
+	c := clock.NewService()
+	if err := c.SetHasher(alg); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+	if err := c.SetContext(ctx); err != nil {
+		return nil, err
+	}
+	c.SetWriter(nil)
+
+	if r.MemoryHard != nil {
+		if err := c.SetMemoryHard(r.MemoryHard.Memory, r.MemoryHard.Cost); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+		}
+	}
+
+	if err := c.SetMode(r.Mode, r.Tag); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	if r.Checkpoint != nil {
+		if err := c.SetCheckpoint(r.Checkpoint.Index, r.Checkpoint.Hash); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+		}
+	}
+
+	return c, nil
+}
+
+// Call function validates the input request and calls its method on a new
+// `clock.HashClockService`, bound to the input context
+func Call(ctx context.Context, r *Request) (*clock.HashClockResponse, error) {
+	m, err := Validate(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewService(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Run(c, r)
+}
+
+// Run method calls the method on the input `clock.HashClockService`, with
+// the parameters in the input request. The request is expected to have been
+// validated with `Validate`
+func (m *Method) Run(c *clock.HashClockService, r *Request) (*clock.HashClockResponse, error) {
+	return m.call(c, r)
+}
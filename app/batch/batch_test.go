@@ -0,0 +1,181 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ZalgoNoise/hashclock/api"
+)
+
+const (
+	testSeed string = "Hello World!"
+	testHash string = "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705" // index 10
+)
+
+// readResults function decodes the JSON lines written by `Run`
+func readResults(t *testing.T, out string) []*Result {
+	t.Helper()
+
+	var results []*Result
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		res := &Result{}
+		if err := json.Unmarshal(scanner.Bytes(), res); err != nil {
+			t.Fatalf("FAILED -- [Batch] invalid result line %q: %s", scanner.Text(), err)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		ordered bool
+	}{
+		{
+			name:    "ndjson",
+			ordered: true,
+			input: `{"seed":"Hello World!"}
+{"seed":"Hello World!","iter":10}
+
+{"seed":"Hello World!","iter":11,"hash":"` + testHash + `"}
+{"seed":"Hello World!","alg":"md4","iter":10}
+{"seed":"Hello World!","iter":"10"}
+{"seed":"Hello World!","hash":"` + testHash + `"}
+`,
+		}, {
+			name: "csv",
+			input: `seed,alg,iter,time,hash
+Hello World!,,,,
+"Hello World!",sha256,10,,
+
+Hello World!,,11,,` + testHash + `
+Hello World!,md4,10,,
+Hello World!,,ten,,
+Hello World!,,,,` + testHash + `
+`,
+		},
+	}
+
+	// expected methods and line numbers
+	wantMethods := []string{"Hash", "RecHash", "VerifyIndex", "RecHash", "", "Verify"}
+	wantLines := []int{2, 3, 5, 6, 7, 8}
+
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		sum, err := Run(context.Background(), strings.NewReader(test.input), out, &Config{Workers: 3, Ordered: test.ordered})
+		if err != nil {
+			t.Fatalf("FAILED -- [Batch] %s: Run() failed: %s", test.name, err)
+		}
+
+		if sum.Jobs != 6 || sum.OK != 3 || sum.Mismatches != 1 || sum.Errors != 2 || len(sum.Failures) != 3 {
+			t.Errorf("FAILED -- [Batch] %s: unexpected summary: %+v", test.name, sum)
+		}
+
+		results := readResults(t, out.String())
+		if len(results) != 6 {
+			t.Fatalf("FAILED -- [Batch] %s: %v results ; expected 6", test.name, len(results))
+		}
+		if !test.ordered {
+			sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+		}
+
+		offset := 0
+		if test.name == "ndjson" {
+			offset = -1 // no header line
+		}
+		for idx, res := range results {
+			if res.Line != wantLines[idx]+offset {
+				t.Errorf("FAILED -- [Batch] %s: result #%v is line %v ; expected %v", test.name, idx, res.Line, wantLines[idx]+offset)
+			}
+			if wantMethods[idx] != "" && res.Method != wantMethods[idx] {
+				t.Errorf("FAILED -- [Batch] %s: line %v ran %q ; expected %q", test.name, res.Line, res.Method, wantMethods[idx])
+			}
+		}
+
+		if results[1].HashClockResponse == nil || results[1].Hash != testHash {
+			t.Errorf("FAILED -- [Batch] %s: unexpected RecHash result: %+v", test.name, results[1])
+		}
+		if results[5].HashClockResponse == nil || !results[5].Match || results[5].Iterations != 10 {
+			t.Errorf("FAILED -- [Batch] %s: unexpected Verify result: %+v", test.name, results[5])
+		}
+	}
+}
+
+func TestRunWalks(t *testing.T) {
+	// walks of several algorithms and chaining modes, mixed with jobs of
+	// other methods
+	var (
+		input strings.Builder
+		wants []*api.Request
+	)
+	sizes := map[string]int{"": 64, "sha512": 128, "md5": 32, "SHA224": 56}
+
+	for idx := 0; idx < 60; idx++ {
+		job := &Job{
+			Seed:       fmt.Sprintf("seed %d", idx%7),
+			Algorithm:  []string{"", "sha512", "md5", "SHA224"}[idx%4],
+			Iterations: 1 + idx*37,
+		}
+		if idx%5 == 2 {
+			job.Mode, job.Tag = "indexed", fmt.Sprintf("tag %d", idx%2)
+		}
+		switch {
+		case idx%10 == 9:
+			job.Iterations = 0
+		case idx%3 == 1:
+			job.Hash = strings.Repeat("ab", sizes[job.Algorithm]/2)
+		}
+
+		line, _ := json.Marshal(job)
+		input.Write(append(line, '\n'))
+		wants = append(wants, job.Request())
+	}
+
+	out := &bytes.Buffer{}
+	sum, err := Run(context.Background(), strings.NewReader(input.String()), out, &Config{Workers: 2, Ordered: true})
+	if err != nil {
+		t.Fatalf("FAILED -- [Batch] Run() failed: %s", err)
+	}
+	if sum.Jobs != len(wants) || sum.Errors != 0 {
+		t.Errorf("FAILED -- [Batch] unexpected summary: %+v", sum)
+	}
+
+	for idx, res := range readResults(t, out.String()) {
+		want, err := api.Call(context.Background(), wants[idx])
+		if err != nil {
+			t.Fatalf("FAILED -- [Batch] Call() failed: %s", err)
+		}
+
+		switch {
+		case res.Line != idx+1 || res.Method != wants[idx].Method:
+			t.Errorf("FAILED -- [Batch] result #%v is line %v (%s) ; expected line %v (%s)", idx, res.Line, res.Method, idx+1, wants[idx].Method)
+		case res.HashClockResponse == nil:
+			t.Errorf("FAILED -- [Batch] line %v has no response: %+v", res.Line, res)
+		case res.Hash != want.Hash || res.Iterations != want.Iterations || res.Match != want.Match || res.Algorithm != want.Algorithm || res.Tag != want.Tag:
+			t.Errorf("FAILED -- [Batch] line %v mismatch: wanted %+v ; got %+v", res.Line, want, res.HashClockResponse)
+		}
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	input := strings.Repeat(`{"seed":"Hello World!","hash":"00"}`+"\n", 100)
+	if _, err := Run(ctx, strings.NewReader(input), &bytes.Buffer{}, &Config{Workers: 2}); err != context.Canceled {
+		t.Errorf("FAILED -- [Batch] Run() = %v ; expected context.Canceled", err)
+	}
+
+	if _, err := Run(context.Background(), strings.NewReader("seed,unknown\n"), &bytes.Buffer{}, nil); err == nil {
+		t.Errorf("FAILED -- [Batch] Run() with an unknown CSV column should fail")
+	}
+}
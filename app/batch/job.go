@@ -0,0 +1,254 @@
+// Package batch runs many independent `clock.HashClockService` jobs -- one
+// per record of an NDJSON or CSV file -- on a bounded pool of workers, writing
+// one response per line as the jobs complete (or in input order).
+//
+// A job sets its `seed` and `alg` (and its chaining `mode` and `tag`, as in
+// `clock.HashClockService.SetMode`), and the parameters of its method: `iter`,
+// `time` and / or `hash`. The method is picked from the set parameters (like
+// the `chain`, `proof` and `verify` commands), unless a `method` is set:
+//
+//   - `hash` with `iter` and `time`: `VerifyIndexTimeout`
+//   - `hash` with `time`: `VerifyTimeout`
+//   - `hash` with `iter`: `VerifyIndex`
+//   - `hash` only: `Verify` (runs until the hash is found)
+//   - `iter`: `RecHash`
+//   - `time`: `RecHashTimeout`
+//   - none: `Hash`
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/api"
+)
+
+const (
+	// FormatNDJSON is the format of files with one JSON object per line
+	FormatNDJSON string = "ndjson"
+
+	// FormatCSV is the format of CSV files, with a header row naming the
+	// columns
+	FormatCSV string = "csv"
+
+	// maxLineSize is the maximum size of an NDJSON line
+	maxLineSize int = 1 << 20
+)
+
+// Job struct is a single record of a batch file
+type Job struct {
+	Method     string `json:"method,omitempty"`
+	Seed       string `json:"seed"`
+	Algorithm  string `json:"alg,omitempty"`
+	Iterations int    `json:"iter,omitempty"`
+	Timeout    int    `json:"time,omitempty"`
+	Hash       string `json:"hash,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+// Request method returns the `api.Request` of the job, picking its method
+// from its parameters if it is not set
+func (j *Job) Request() *api.Request {
+	r := &api.Request{
+		Method:     j.Method,
+		Seed:       j.Seed,
+		Algorithm:  j.Algorithm,
+		Iterations: j.Iterations,
+		Timeout:    j.Timeout,
+		Hash:       j.Hash,
+		Mode:       j.Mode,
+		Tag:        j.Tag,
+	}
+	if r.Method != "" {
+		return r
+	}
+
+	switch {
+	case j.Hash != "" && j.Iterations > 0 && j.Timeout > 0:
+		r.Method = "VerifyIndexTimeout"
+	case j.Hash != "" && j.Timeout > 0:
+		r.Method = "VerifyTimeout"
+	case j.Hash != "" && j.Iterations > 0:
+		r.Method = "VerifyIndex"
+	case j.Hash != "":
+		r.Method = "Verify"
+	case j.Iterations > 0:
+		r.Method = "RecHash"
+	case j.Timeout > 0:
+		r.Method = "RecHashTimeout"
+	default:
+		r.Method = "Hash"
+	}
+	return r
+}
+
+// record struct is a job read from a batch file, with its line number; or the
+// error which prevented reading it
+type record struct {
+	line int
+	job  *Job
+	err  error
+}
+
+// reader interface is implemented by the readers of each batch file format
+type reader interface {
+	// next returns the next record; or `io.EOF` once the input is exhausted
+	next() (*record, error)
+}
+
+// newReader function returns a reader for the input format, detecting it
+// from the first non-blank character when empty: a `{` starts an NDJSON file
+func newReader(r io.Reader, format string) (reader, error) {
+	br := bufio.NewReader(r)
+
+	if format == "" {
+		format = FormatCSV
+		for n := 1; ; n++ {
+			b, err := br.Peek(n)
+			if err != nil {
+				break
+			}
+			if c := b[n-1]; c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+				continue
+			}
+			if b[n-1] == '{' {
+				format = FormatNDJSON
+			}
+			break
+		}
+	}
+
+	switch format {
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(br)
+		scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	case FormatCSV:
+		c := csv.NewReader(br)
+		c.FieldsPerRecord = -1
+		c.TrimLeadingSpace = true
+		return &csvReader{r: c}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q; expected %q or %q", format, FormatNDJSON, FormatCSV)
+	}
+}
+
+// ndjsonReader struct reads jobs from an NDJSON file, skipping blank lines
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) next() (*record, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		b := bytes.TrimSpace(r.scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		job := &Job{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(job); err != nil {
+			return &record{line: r.line, err: fmt.Errorf("invalid JSON record -- %s", err)}, nil
+		}
+		return &record{line: r.line, job: job}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// csvReader struct reads jobs from a CSV file; its first record is the header,
+// naming the columns
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+// csvColumns are the columns accepted in a CSV header
+var csvColumns = map[string]bool{"method": true, "seed": true, "alg": true, "iter": true, "time": true, "hash": true, "mode": true, "tag": true}
+
+func (r *csvReader) next() (*record, error) {
+	if r.columns == nil {
+		header, err := r.r.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV header -- %s", err)
+		}
+
+		for _, col := range header {
+			col = strings.ToLower(strings.TrimSpace(col))
+			if !csvColumns[col] {
+				return nil, fmt.Errorf("invalid CSV header: unknown column %q", col)
+			}
+			r.columns = append(r.columns, col)
+		}
+	}
+
+	fields, err := r.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		var pErr *csv.ParseError
+		if errors.As(err, &pErr) {
+			return &record{line: pErr.Line, err: fmt.Errorf("invalid CSV record -- %s", pErr.Err)}, nil
+		}
+		return nil, err
+	}
+
+	line, _ := r.r.FieldPos(0)
+	if len(fields) != len(r.columns) {
+		return &record{line: line, err: fmt.Errorf("invalid CSV record: expected %d fields, got %d", len(r.columns), len(fields))}, nil
+	}
+
+	job := &Job{}
+	for idx, col := range r.columns {
+		value := fields[idx]
+
+		switch col {
+		case "method":
+			job.Method = value
+		case "seed":
+			job.Seed = value
+		case "alg":
+			job.Algorithm = strings.TrimSpace(value)
+		case "hash":
+			job.Hash = strings.TrimSpace(value)
+		case "mode":
+			job.Mode = strings.TrimSpace(value)
+		case "tag":
+			job.Tag = value
+		case "iter", "time":
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return &record{line: line, err: fmt.Errorf("invalid %s %q", col, value)}, nil
+			}
+			if col == "iter" {
+				job.Iterations = n
+			} else {
+				job.Timeout = n
+			}
+		}
+	}
+	return &record{line: line, job: job}, nil
+}
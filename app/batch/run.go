@@ -0,0 +1,409 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// window is the number of jobs (per worker) which can be read ahead of the
+// results being written, which bounds the results held back in input order
+const window int = 4
+
+// Config struct defines how a batch runs
+type Config struct {
+	// Workers is the number of jobs running at the same time;
+	// `runtime.NumCPU()` by default
+	Workers int
+
+	// Format is the format of the batch file: `FormatNDJSON` or `FormatCSV`;
+	// detected from its first character if empty
+	Format string
+
+	// Ordered writes the results in input order, rather than as the jobs
+	// complete
+	Ordered bool
+
+	// Cache is a cache of checkpoints shared by all jobs, so that jobs for the
+	// same seed resume from the nearest checkpoint; nil does not cache any
+	// checkpoints
+	Cache clock.CheckpointCache
+
+	// CacheInterval is the number of indices between two cached checkpoints
+	CacheInterval int
+}
+
+// Result struct is the outcome of a job, written as one JSON line: the job's
+// `clock.HashClockResponse`, with its line number and method; or its error
+type Result struct {
+	Line   int    `json:"line"`
+	Method string `json:"method,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	*clock.HashClockResponse
+}
+
+// Failure struct is a job which failed or did not match
+type Failure struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// Summary struct counts the outcomes of a batch's jobs, listing its failures
+// in the order their results were written
+type Summary struct {
+	Jobs       int       `json:"jobs"`
+	OK         int       `json:"ok"`
+	Mismatches int       `json:"mismatches"`
+	Errors     int       `json:"errors"`
+	Failures   []Failure `json:"failures,omitempty"`
+}
+
+// add method counts the input result
+func (s *Summary) add(res *Result) {
+	s.Jobs++
+
+	switch {
+	case res.Error != "":
+		s.Errors++
+		s.Failures = append(s.Failures, Failure{Line: res.Line, Reason: res.Error})
+	case strings.HasPrefix(res.Method, "Verify") && !res.Match:
+		s.Mismatches++
+
+		reason := "hash does not match"
+		if strings.HasSuffix(res.Method, "Timeout") {
+			reason = fmt.Sprintf("hash does not match within %d seconds", res.Timeout)
+		}
+		s.Failures = append(s.Failures, Failure{Line: res.Line, Reason: reason})
+	default:
+		s.OK++
+	}
+}
+
+// Run function runs the jobs read from the input reader on a pool of workers,
+// writing each job's `Result` to the input writer as a JSON line. Reading
+// stops once the context is cancelled, and the running jobs are interrupted;
+// the summary of the jobs which ran is returned with the context's error
+func Run(ctx context.Context, r io.Reader, w io.Writer, cfg *Config) (*Summary, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.Workers <= 0 {
+		c.Workers = runtime.NumCPU()
+	}
+
+	rd, err := newReader(r, c.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		tasks    = make(chan *task)
+		results  = make(chan done, c.Workers)
+		inFlight = make(chan struct{}, c.Workers*window*clock.LaneWidth())
+		readErr  error
+		wg       sync.WaitGroup
+	)
+
+	go func() {
+		defer close(tasks)
+
+		for seq := 0; ; seq++ {
+			rec, err := rd.next()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case tasks <- &task{seq: seq, rec: rec}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for idx := 0; idx < c.Workers; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(ctx, tasks, results, &c)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		sum     = &Summary{}
+		enc     = json.NewEncoder(w)
+		pending = map[int]*Result{}
+		next    int
+		wErr    error
+	)
+
+	emit := func(res *Result) {
+		if wErr == nil {
+			if wErr = enc.Encode(res); wErr != nil {
+				cancel()
+			}
+		}
+		sum.add(res)
+		<-inFlight
+	}
+
+	for d := range results {
+		if !c.Ordered {
+			emit(d.res)
+			continue
+		}
+
+		pending[d.seq] = d.res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			emit(res)
+			next++
+		}
+	}
+
+	switch {
+	case wErr != nil:
+		return sum, wErr
+	case readErr != nil:
+		return sum, readErr
+	}
+	return sum, ctx.Err()
+}
+
+// task struct is a job read from the batch file, with its sequence number
+type task struct {
+	seq int
+	rec *record
+}
+
+// done struct is the result of a task
+type done struct {
+	seq int
+	res *Result
+}
+
+// work function runs the tasks received from the input channel, sending their
+// results to the results channel; until the tasks channel is closed.
+//
+// The jobs which walk a chain up to a known index (`RecHash` and
+// `VerifyIndex`) run on a `clock.Walker`, several at a time: while its lanes
+// are not all in use, the worker takes in the tasks which are already
+// waiting. A task which cannot join the walks in progress (another method, or
+// another algorithm or chaining mode) waits for them to complete
+func work(ctx context.Context, tasks <-chan *task, results chan<- done, cfg *Config) {
+	var (
+		walkers  = map[walkerKey]*clock.Walker{}
+		walks    = map[*clock.Walk]*task{}
+		cur      *clock.Walker
+		deferred *task
+	)
+
+	busy := func() bool {
+		return cur != nil && cur.Len() > 0
+	}
+
+	// handle runs the input task; or starts its walk. It returns false if
+	// the task has to wait for the walks in progress
+	handle := func(t *task) bool {
+		walk, key, res := newWalk(t.rec, cfg)
+		if res != nil {
+			results <- done{seq: t.seq, res: res}
+			return true
+		}
+
+		if walk == nil {
+			if busy() {
+				return false
+			}
+			results <- done{seq: t.seq, res: run(ctx, t.rec, cfg)}
+			return true
+		}
+
+		w, ok := walkers[key]
+		if !ok {
+			// the algorithm and the mode were validated by `newWalk`
+			w, _ = clock.NewWalker(key.alg)
+			w.SetMode(key.mode, key.tag)
+			walkers[key] = w
+		}
+		if busy() && w != cur {
+			return false
+		}
+		cur = w
+
+		if err := w.Add(walk); err != nil {
+			res := &Result{Line: t.rec.line, Method: t.rec.job.Request().Method, Error: err.Error()}
+			results <- done{seq: t.seq, res: res}
+			return true
+		}
+		walks[walk] = t
+		return true
+	}
+
+	for open := true; open || deferred != nil || busy(); {
+		if deferred != nil && !busy() {
+			t := deferred
+			deferred = nil
+			handle(t)
+			continue
+		}
+
+		var t *task
+		switch {
+		case deferred != nil:
+		case open && !busy():
+			t, open = <-tasks
+		case open && cur.Len() < cur.Width():
+			select {
+			case t, open = <-tasks:
+			default:
+			}
+		}
+
+		if t != nil {
+			if !handle(t) {
+				deferred = t
+			}
+			continue
+		}
+
+		if !busy() {
+			continue
+		}
+
+		completed, err := cur.Step(ctx)
+		for _, walk := range completed {
+			t := walks[walk]
+			delete(walks, walk)
+
+			res := &Result{Line: t.rec.line, Method: t.rec.job.Request().Method}
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				res.HashClockResponse = walk.Response
+			}
+			results <- done{seq: t.seq, res: res}
+		}
+	}
+}
+
+// walkerKey struct identifies the `clock.Walker` of a walk: its algorithm and
+// its chaining mode
+type walkerKey struct {
+	alg  string
+	mode string
+	tag  string
+}
+
+// newWalk function returns the `clock.Walk` of the input record's job, and
+// the key of its walker, if it walks a chain up to a known index (and the
+// batch does not cache checkpoints); or nil, if it runs on a service instead.
+// A job with invalid parameters returns its result instead
+func newWalk(rec *record, cfg *Config) (*clock.Walk, walkerKey, *Result) {
+	if rec.err != nil || cfg.Cache != nil {
+		return nil, walkerKey{}, nil
+	}
+
+	req := rec.job.Request()
+	if req.Method != "RecHash" && req.Method != "VerifyIndex" {
+		return nil, walkerKey{}, nil
+	}
+
+	res := &Result{Line: rec.line, Method: req.Method}
+	if _, err := api.Validate(req); err != nil {
+		res.Error = err.Error()
+		return nil, walkerKey{}, res
+	}
+
+	// the service validates the algorithm, the mode and the checkpoint
+	if _, err := api.NewService(context.Background(), req); err != nil {
+		res.Error = err.Error()
+		return nil, walkerKey{}, res
+	}
+
+	key := walkerKey{alg: req.Algorithm, mode: req.Mode, tag: req.Tag}
+	if key.alg == "" {
+		key.alg = "sha256"
+	}
+	if key.mode == clock.ModeHex {
+		key.mode = ""
+	}
+
+	walk := &clock.Walk{
+		Seed:       req.Seed,
+		Checkpoint: req.Checkpoint,
+		Iterations: req.Iterations,
+	}
+	if req.Method == "VerifyIndex" {
+		walk.Target = req.Hash
+	}
+	return walk, key, nil
+}
+
+// run function runs the job of the input record on a new
+// `clock.HashClockService`, returning its result
+func run(ctx context.Context, rec *record, cfg *Config) *Result {
+	res := &Result{Line: rec.line}
+	if rec.err != nil {
+		res.Error = rec.err.Error()
+		return res
+	}
+
+	req := rec.job.Request()
+	res.Method = req.Method
+
+	m, err := api.Validate(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	svc, err := api.NewService(ctx, req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if cfg.Cache != nil {
+		if err := svc.SetCheckpointCache(cfg.Cache, cfg.CacheInterval); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+
+	out, err := m.Run(svc, req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.HashClockResponse = out
+	return res
+}
@@ -0,0 +1,152 @@
+// Package beacon publishes a randomness beacon from a running clock: every
+// `Every` hashes of a ledger's chain, a round's random value is extracted from
+// the chain's tick (checkpoint) hash, chaining in the previous round's output.
+//
+// The extraction function of round n is:
+//
+//	output(n) = SHA256( "hashclock beacon" || uint64(n) || output(n-1) || tick(n) )
+//
+// where the round number is big-endian; the previous output and the tick hash
+// are hex-encoded (the previous output is empty, for round 1); and the output
+// is hex-encoded. The tick of round n is the ledger's first checkpoint entry
+// at an index of at least `n*Every`, and its index is recorded with the round.
+//
+// With a `Delay`, the round's value is the output hashed `Delay` times more,
+// as in `clock.HashClockService.RecHash` -- a sequential delay function, so
+// that the ledger's operator cannot learn a round's value (and bias it, by
+// mixing in events) before the round's tick is recorded. Otherwise the value
+// is the output itself
+package beacon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// Version is the beacon format version, set in its parameters file
+	Version int = 1
+
+	// extractLabel is the domain tag of the extraction function
+	extractLabel string = "hashclock beacon"
+
+	// checkInterval is the number of delay steps between context checks
+	checkInterval int = 1024
+)
+
+var (
+	// ErrExists error is returned when creating a beacon in a ledger which
+	// already has one
+	ErrExists = errors.New("beacon already exists")
+
+	// ErrNotExist error is returned when opening a ledger without a beacon
+	ErrNotExist = errors.New("beacon does not exist")
+
+	// ErrNotFound error is returned for rounds which were not published yet
+	ErrNotFound = errors.New("round not found")
+
+	// ErrMismatch error is returned when a published round does not match the
+	// one derived from the ledger
+	ErrMismatch = errors.New("round does not match the ledger")
+)
+
+// Params struct holds a beacon's parameters; stored in the ledger's directory
+// when the beacon is created
+type Params struct {
+	Version int `json:"version"`
+
+	// Every is the number of hashes between rounds; a multiple of the ledger's
+	// checkpoint interval
+	Every int `json:"every"`
+
+	// Delay is the number of hashes of the delay function; 0 does not apply it
+	Delay int `json:"delay,omitempty"`
+
+	// Algorithm is the hash function of the delay function; SHA256 by default
+	Algorithm string `json:"algorithm,omitempty"`
+}
+
+// validate method checks the parameters against the input ledger checkpoint
+// interval, setting the default algorithm
+func (p *Params) validate(interval int) error {
+	if p.Every <= 0 || p.Every%interval != 0 {
+		return fmt.Errorf("round period must be a multiple of the ledger's checkpoint interval of %d hashes", interval)
+	}
+	if p.Delay < 0 {
+		return errors.New("delay cannot be negative")
+	}
+
+	if p.Algorithm == "" {
+		p.Algorithm = clock.HasherMapVals[3]
+	}
+	if err := clock.ValidateAlgorithm(p.Algorithm); err != nil {
+		return err
+	}
+	p.Algorithm = strings.ToUpper(p.Algorithm)
+	return nil
+}
+
+// Round struct is a published beacon round: its number, the ledger entry of
+// its tick, the previous round's output and its own output and value
+type Round struct {
+	Round    int       `json:"round"`
+	Index    int       `json:"index"`
+	Tick     string    `json:"tick"`
+	WallTime time.Time `json:"wall_time"`
+	Previous string    `json:"previous,omitempty"`
+	Output   string    `json:"output"`
+	Value    string    `json:"value"`
+}
+
+// Extract function returns the (hex-encoded) output of the input round, from
+// the previous round's output (empty for round 1) and the round's tick hash
+func Extract(round int, previous, tick string) string {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(round))
+
+	h := sha256.New()
+	h.Write([]byte(extractLabel))
+	h.Write(n[:])
+	h.Write([]byte(previous))
+	h.Write([]byte(tick))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Delay function returns the input output hashed `delay` times with the input
+// algorithm, as in `RecHash`; or the output itself if the delay is zero. It
+// stops early if the input context is cancelled, returning its error
+func Delay(ctx context.Context, alg string, delay int, output string) (string, error) {
+	if delay == 0 {
+		return output, nil
+	}
+
+	e, err := clock.NewEngine(alg, clock.ModeHex)
+	if err != nil {
+		return "", err
+	}
+
+	// each step of the memory-hard function is thousands of hashes
+	interval := checkInterval
+	if strings.EqualFold(alg, clock.MemoryHardAlgorithm) {
+		interval = 1
+	}
+
+	hash := e.Hash([]byte(output))
+	for idx := 2; idx <= delay; idx++ {
+		if idx%interval == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		hash = e.Step(hash)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,314 @@
+package beacon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+const testSeed string = "genesis_string"
+
+// newTestLedger function creates a ledger in a temporary directory with a
+// checkpoint every 10 hashes, up to the input index; with an event mixed in at
+// index 40, instead of its checkpoint
+func newTestLedger(t *testing.T, last int) (*ledger.Ledger, *clock.Chain) {
+	t.Helper()
+
+	l, err := ledger.Create(&ledger.Config{
+		Dir:         t.TempDir(),
+		Seed:        testSeed,
+		Interval:    10,
+		SegmentSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] ledger.Create() failed: %s", err)
+	}
+
+	chain, _ := clock.NewChain("sha256", testSeed)
+	appendEntries(t, l, chain, last)
+	return l, chain
+}
+
+// appendEntries function calculates the chain up to the input index, appending
+// its checkpoints to the ledger
+func appendEntries(t *testing.T, l *ledger.Ledger, chain *clock.Chain, last int) {
+	t.Helper()
+
+	for chain.Index() < last {
+		e := ledger.Entry{WallTime: time.Date(2022, 6, 1, 0, 0, chain.Index(), 0, time.UTC)}
+
+		if chain.Index() == 39 {
+			digest := chain.Digest([]byte("event"))
+			chain.Mix(digest)
+			e.Event = string(digest)
+		} else {
+			chain.Next()
+			if chain.Index()%10 != 0 {
+				continue
+			}
+		}
+
+		e.Index, e.Hash = chain.Index(), chain.Hash()
+		if err := l.Append(e); err != nil {
+			t.Fatalf("FAILED -- [Beacon] Append() failed: %s", err)
+		}
+	}
+}
+
+func TestBeacon(t *testing.T) {
+	l, chain := newTestLedger(t, 100)
+	defer l.Close()
+
+	b, err := Create(l.Dir(), Params{Every: 20})
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] Create() failed: %s", err)
+	}
+
+	rounds, err := b.Catchup(context.Background())
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] Catchup() failed: %s", err)
+	}
+
+	// the checkpoint at index 40 is replaced by an event, so round 2's tick
+	// is at index 50
+	wantIndex := []int{20, 50, 60, 80, 100}
+	if len(rounds) != len(wantIndex) {
+		t.Fatalf("FAILED -- [Beacon] expected %d rounds ; got %d", len(wantIndex), len(rounds))
+	}
+
+	previous := ""
+	for i, r := range rounds {
+		entry, _ := ledger.Find(l.Dir(), wantIndex[i])
+		switch {
+		case r.Round != i+1 || r.Index != wantIndex[i]:
+			t.Errorf("FAILED -- [Beacon] round #%d mismatch: wanted round %d at index %d ; got round %d at index %d", i, i+1, wantIndex[i], r.Round, r.Index)
+		case r.Tick != entry.Hash || !r.WallTime.Equal(entry.WallTime):
+			t.Errorf("FAILED -- [Beacon] round %d's tick does not match its ledger entry", r.Round)
+		case r.Previous != previous || r.Output != Extract(r.Round, previous, r.Tick) || r.Value != r.Output:
+			t.Errorf("FAILED -- [Beacon] round %d's output mismatch: %+v", r.Round, r)
+		}
+		previous = r.Output
+	}
+
+	// rounds are published as the ledger grows
+	appendEntries(t, l, chain, 120)
+	last, _ := l.Last()
+	rounds, err = b.Observe(context.Background(), last)
+	if err != nil || len(rounds) != 1 || rounds[0].Round != 6 || rounds[0].Previous != previous {
+		t.Errorf("FAILED -- [Beacon] Observe() should publish round 6: %+v (%v)", rounds, err)
+	}
+	b.Close()
+
+	// the log is read back, by another reader
+	r, err := OpenReader(l.Dir())
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] OpenReader() failed: %s", err)
+	}
+	defer r.Close()
+
+	got, err := r.Get(3)
+	if err != nil || got.Index != 60 {
+		t.Errorf("FAILED -- [Beacon] Get(3) mismatch: %+v (%v)", got, err)
+	}
+	if got, err := r.Last(); err != nil || got.Round != 6 {
+		t.Errorf("FAILED -- [Beacon] Last() mismatch: %+v (%v)", got, err)
+	}
+	if _, err := r.Get(7); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FAILED -- [Beacon] Get() of an unpublished round should fail with ErrNotFound: %v", err)
+	}
+	if _, err := r.Observe(context.Background(), last); err == nil {
+		t.Errorf("FAILED -- [Beacon] Observe() on a read-only log should fail")
+	}
+
+	// resuming the beacon does not publish any round twice
+	b, err = Open(l.Dir())
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] Open() failed: %s", err)
+	}
+	defer b.Close()
+	if rounds, err := b.Catchup(context.Background()); err != nil || len(rounds) != 0 {
+		t.Errorf("FAILED -- [Beacon] Catchup() of an up-to-date beacon should not publish rounds: %+v (%v)", rounds, err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	l, _ := newTestLedger(t, 60)
+	defer l.Close()
+
+	b, err := Create(l.Dir(), Params{Every: 30, Delay: 50, Algorithm: "sha512"})
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] Create() failed: %s", err)
+	}
+	defer b.Close()
+
+	rounds, err := b.Catchup(context.Background())
+	if err != nil || len(rounds) != 2 {
+		t.Fatalf("FAILED -- [Beacon] Catchup() should publish 2 rounds: %+v (%v)", rounds, err)
+	}
+
+	s := clock.NewService()
+	s.SetWriter(nil)
+	s.SetHasher("sha512")
+
+	for _, r := range rounds {
+		want, _ := s.RecHash(r.Output, 50)
+		if r.Value != want.Hash {
+			t.Errorf("FAILED -- [Beacon] round %d's value mismatch: wanted %s ; got %s", r.Round, want.Hash, r.Value)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Delay(ctx, "sha256", 1<<20, rounds[0].Output); err != context.Canceled {
+		t.Errorf("FAILED -- [Beacon] Delay() with a cancelled context should fail: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	l, _ := newTestLedger(t, 100)
+	defer l.Close()
+
+	b, _ := Create(l.Dir(), Params{Every: 20, Delay: 10})
+	defer b.Close()
+	b.Catchup(context.Background())
+
+	for n := 1; n <= 5; n++ {
+		published, _ := b.Get(n)
+		if _, err := Verify(context.Background(), l.Dir(), published); err != nil {
+			t.Errorf("FAILED -- [Beacon] Verify() of round %d failed: %s", n, err)
+		}
+	}
+
+	published, _ := b.Get(3)
+	published.Value = published.Output
+	if _, err := Verify(context.Background(), l.Dir(), published); !errors.Is(err, ErrMismatch) {
+		t.Errorf("FAILED -- [Beacon] Verify() of a forged value should fail with ErrMismatch: %v", err)
+	}
+
+	published, _ = b.Get(2)
+	published.Index = 40
+	if _, err := Verify(context.Background(), l.Dir(), published); !errors.Is(err, ErrMismatch) {
+		t.Errorf("FAILED -- [Beacon] Verify() of a forged index should fail with ErrMismatch: %v", err)
+	}
+
+	if _, err := Derive(context.Background(), l.Dir(), 6); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FAILED -- [Beacon] Derive() beyond the ledger should fail with ErrNotFound: %v", err)
+	}
+}
+
+func TestVerifyForgedLedger(t *testing.T) {
+	l, err := ledger.Create(&ledger.Config{
+		Dir:         t.TempDir(),
+		Seed:        testSeed,
+		Interval:    10,
+		SegmentSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [Beacon] ledger.Create() failed: %s", err)
+	}
+	defer l.Close()
+
+	// the first round's tick is genuine, the ones after it are calculated
+	// from another seed
+	chain, _ := clock.NewChain("sha256", testSeed)
+	appendEntries(t, l, chain, 20)
+	forged, _ := clock.NewChain("sha256", "forged seed")
+	for forged.Index() < 20 {
+		forged.Next()
+	}
+	appendEntries(t, l, forged, 100)
+
+	b, _ := Create(l.Dir(), Params{Every: 20})
+	defer b.Close()
+	b.Catchup(context.Background())
+
+	if _, err := Derive(context.Background(), l.Dir(), 1); err != nil {
+		t.Errorf("FAILED -- [Beacon] Derive() of the genuine round failed: %s", err)
+	}
+
+	published, _ := b.Get(3)
+	if _, err := Derive(context.Background(), l.Dir(), 3); !errors.Is(err, ErrMismatch) {
+		t.Errorf("FAILED -- [Beacon] Derive() from a forged ledger should fail with ErrMismatch: %v", err)
+	}
+	if published == nil {
+		t.Fatalf("FAILED -- [Beacon] the round of a forged tick should be published")
+	}
+	if r, err := Verify(context.Background(), l.Dir(), published); !errors.Is(err, ErrMismatch) || r != nil {
+		t.Errorf("FAILED -- [Beacon] Verify() against a forged ledger should fail with ErrMismatch: %v", err)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	l, _ := newTestLedger(t, 60)
+	defer l.Close()
+
+	b, _ := Create(l.Dir(), Params{Every: 20})
+	defer b.Close()
+
+	mux := http.NewServeMux()
+	b.Register(mux)
+
+	get := func(path string) (*httptest.ResponseRecorder, *Round) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		r := &Round{}
+		json.Unmarshal(rec.Body.Bytes(), r)
+		return rec, r
+	}
+
+	if rec, _ := get("/v1/beacon"); rec.Code != http.StatusNotFound {
+		t.Errorf("FAILED -- [Beacon] GET /v1/beacon without rounds: wanted status %d ; got %d", http.StatusNotFound, rec.Code)
+	}
+
+	b.Catchup(context.Background())
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		round  int
+	}{
+		{"/v1/beacon", http.StatusOK, 3},
+		{"/v1/beacon/2", http.StatusOK, 2},
+		{"/v1/beacon/4", http.StatusNotFound, 0},
+		{"/v1/beacon/x", http.StatusBadRequest, 0},
+	} {
+		rec, r := get(tc.path)
+		if rec.Code != tc.status || r.Round != tc.round {
+			t.Errorf("FAILED -- [Beacon] GET %s: wanted status %d and round %d ; got %d and %d", tc.path, tc.status, tc.round, rec.Code, r.Round)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	l, _ := newTestLedger(t, 20)
+	defer l.Close()
+
+	for i, p := range []Params{
+		{Every: 0},
+		{Every: 15},
+		{Every: 20, Delay: -1},
+		{Every: 20, Algorithm: "sha3"},
+	} {
+		if _, err := Create(l.Dir(), p); err == nil {
+			t.Errorf("FAILED -- [Beacon] Create() with invalid params #%d should fail", i)
+		}
+	}
+
+	b, _ := Create(l.Dir(), Params{Every: 20})
+	b.Close()
+	if _, err := Create(l.Dir(), Params{Every: 20}); !errors.Is(err, ErrExists) {
+		t.Errorf("FAILED -- [Beacon] Create() of an existing beacon should fail with ErrExists: %v", err)
+	}
+
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Errorf("FAILED -- [Beacon] Open() without a ledger should fail")
+	}
+}
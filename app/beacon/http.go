@@ -0,0 +1,78 @@
+package beacon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Mux interface is implemented by the types which can register the beacon's
+// HTTP handlers, such as `*http.ServeMux` and `*server.Server`
+type Mux interface {
+	Handle(pattern string, h http.Handler)
+}
+
+// errorResponse struct is the JSON object returned on errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Register method registers the beacon's HTTP handlers in the input mux:
+//
+//   - `GET /v1/beacon` returns the last published round
+//   - `GET /v1/beacon/{round}` returns the round with that number
+//
+// Rounds which were not published yet return a `404 Not Found` status
+func (l *Log) Register(mux Mux) {
+	mux.Handle("/v1/beacon", http.HandlerFunc(l.handleLast))
+	mux.Handle("/v1/beacon/", http.HandlerFunc(l.handleRound))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (l *Log) handleLast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	last, err := l.Last()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &errorResponse{Error: err.Error()})
+		return
+	}
+	if last == nil {
+		writeJSON(w, http.StatusNotFound, &errorResponse{Error: ErrNotFound.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, last)
+}
+
+func (l *Log) handleRound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/v1/beacon/"))
+	if err != nil || n < 1 {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "invalid round number"})
+		return
+	}
+
+	round, err := l.Get(n)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeJSON(w, http.StatusNotFound, &errorResponse{Error: err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, &errorResponse{Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusOK, round)
+	}
+}
@@ -0,0 +1,342 @@
+package beacon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+const (
+	paramsFile string = "beacon.json"
+	roundsFile string = "beacon.log"
+)
+
+// Log struct is a beacon's log of published rounds, one JSON object per line;
+// kept in the directory of its ledger. A Log opened with `Create` or `Open`
+// publishes new rounds; one opened with `OpenReader` only reads them, picking
+// up the rounds appended by another process. A Log is safe for concurrent use
+type Log struct {
+	dir    string
+	params *Params
+
+	mu   sync.Mutex
+	file *os.File
+	w    *os.File // nil for a read-only log
+
+	// offsets holds the offset of each round's line; size the offset after
+	// the last complete line; and last the last round
+	offsets []int64
+	size    int64
+	last    *Round
+}
+
+// ReadParams function reads the parameters of the beacon of the ledger in the
+// input directory
+func ReadParams(dir string) (*Params, error) {
+	b, err := os.ReadFile(filepath.Join(dir, paramsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Params{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("invalid beacon parameters: %s", err)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported beacon version %d", p.Version)
+	}
+
+	m, err := ledger.ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(m.Interval); err != nil {
+		return nil, fmt.Errorf("invalid beacon parameters: %s", err)
+	}
+	return p, nil
+}
+
+// Create function creates a new beacon with the input parameters, for the
+// ledger in the input directory
+func Create(dir string, p Params) (*Log, error) {
+	m, err := ledger.ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Version = Version
+	if err := p.validate(m.Interval); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, paramsFile)); err == nil {
+		return nil, ErrExists
+	}
+
+	b, err := json.MarshalIndent(&p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, paramsFile), b, 0o644); err != nil {
+		return nil, err
+	}
+
+	return Open(dir)
+}
+
+// Open function opens the beacon of the ledger in the input directory, to
+// publish new rounds. An incomplete line at the end of the log (left by a
+// crash) is truncated
+func Open(dir string) (*Log, error) {
+	l, err := OpenReader(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, roundsFile)
+	if err := os.Truncate(path, l.size); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	if l.w, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// OpenReader function opens the beacon of the ledger in the input directory,
+// to read its rounds
+func OpenReader(dir string) (*Log, error) {
+	p, err := ReadParams(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, roundsFile), os.O_RDONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{
+		dir:    dir,
+		params: p,
+		file:   f,
+	}
+	if err := l.refresh(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Params method returns the beacon's parameters
+func (l *Log) Params() Params {
+	return *l.params
+}
+
+// refresh method reads the rounds appended to the log since the last read,
+// up to its last complete line. It must be called while holding the lock
+func (l *Log) refresh() error {
+	if _, err := l.file.Seek(l.size, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(l.file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// an incomplete line is still being written
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		round := &Round{}
+		if err := json.Unmarshal(bytes.TrimSpace(line), round); err != nil {
+			return fmt.Errorf("invalid beacon log: round at offset %d: %s", l.size, err)
+		}
+		if round.Round != len(l.offsets)+1 {
+			return fmt.Errorf("invalid beacon log: expected round %d at offset %d; got %d", len(l.offsets)+1, l.size, round.Round)
+		}
+
+		l.offsets = append(l.offsets, l.size)
+		l.size += int64(len(line))
+		l.last = round
+	}
+}
+
+// Last method returns the last published round; or nil if there is none
+func (l *Log) Last() (*Round, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.refresh(); err != nil {
+		return nil, err
+	}
+	return l.last, nil
+}
+
+// Get method returns the published round with the input number
+func (l *Log) Get(round int) (*Round, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.refresh(); err != nil {
+		return nil, err
+	}
+	if round < 1 || round > len(l.offsets) {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, round)
+	}
+
+	end := l.size
+	if round < len(l.offsets) {
+		end = l.offsets[round]
+	}
+
+	line := make([]byte, end-l.offsets[round-1])
+	if _, err := l.file.ReadAt(line, l.offsets[round-1]); err != nil {
+		return nil, err
+	}
+
+	r := &Round{}
+	if err := json.Unmarshal(line, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Observe method publishes the rounds whose tick is the input ledger entry,
+// if any: only checkpoint entries (without an event) are ticks, and the tick
+// of a round is the first one at or after its position. Entries must be
+// observed in the ledger's order. A round's value is only published once its
+// delay function is calculated, which stops if the input context is
+// cancelled
+func (l *Log) Observe(ctx context.Context, e ledger.Entry) ([]*Round, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w == nil {
+		return nil, errors.New("beacon log is read-only")
+	}
+
+	var rounds []*Round
+	for {
+		r := next(l.params, l.last, e)
+		if r == nil {
+			return rounds, nil
+		}
+
+		value, err := Delay(ctx, l.params.Algorithm, l.params.Delay, r.Output)
+		if err != nil {
+			return rounds, err
+		}
+		r.Value = value
+
+		if err := l.append(r); err != nil {
+			return rounds, err
+		}
+		rounds = append(rounds, r)
+	}
+}
+
+// next function returns the round following the input one (round 1, if nil)
+// whose tick is the input entry, without its value; or nil if the entry is not
+// its tick
+func next(p *Params, last *Round, e ledger.Entry) *Round {
+	n, previous := 1, ""
+	if last != nil {
+		if e.Index < last.Index {
+			return nil
+		}
+		n, previous = last.Round+1, last.Output
+	}
+
+	if e.Event != "" || e.Index < n*p.Every {
+		return nil
+	}
+
+	return &Round{
+		Round:    n,
+		Index:    e.Index,
+		Tick:     e.Hash,
+		WallTime: e.WallTime,
+		Previous: previous,
+		Output:   Extract(n, previous, e.Hash),
+	}
+}
+
+// append method appends the input round to the log. It must be called while
+// holding the lock
+func (l *Log) append(r *Round) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	if _, err := l.w.Write(b); err != nil {
+		return err
+	}
+
+	l.offsets = append(l.offsets, l.size)
+	l.size += int64(len(b))
+	l.last = r
+	return nil
+}
+
+// Catchup method publishes the rounds whose ticks are already in the ledger,
+// after the last published round; returning them. It stops if the input
+// context is cancelled
+func (l *Log) Catchup(ctx context.Context) ([]*Round, error) {
+	from := 0
+	if last, err := l.Last(); err != nil {
+		return nil, err
+	} else if last != nil {
+		// the last round's tick may also be the next round's
+		from = last.Index - 1
+	}
+
+	var rounds []*Round
+	err := ledger.ReadSegments(l.dir, from, func(entries []ledger.Entry) error {
+		for _, e := range entries {
+			r, err := l.Observe(ctx, e)
+			rounds = append(rounds, r...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return rounds, err
+}
+
+// Close method closes the log's files
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+	if l.w != nil {
+		err = l.w.Close()
+		l.w = nil
+	}
+	if cErr := l.file.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package beacon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// errDone error is returned by the `ledger.SegmentFunc` of `Derive` to stop
+// reading, once the round is found
+var errDone = errors.New("round derived")
+
+// Derive function derives the input round from the ledger in the input
+// directory and its beacon's parameters: the ledger's entries are replayed
+// from the seed of its manifest up to the round's tick, the outputs of all
+// rounds up to it are extracted from their ticks, and the delay function is
+// applied to its own output. An entry which does not follow from the previous
+// one results in an error wrapping `ErrMismatch`. It stops if the input
+// context is cancelled
+func Derive(ctx context.Context, dir string, round int) (*Round, error) {
+	p, err := ReadParams(dir)
+	if err != nil {
+		return nil, err
+	}
+	if round < 1 {
+		return nil, errors.New("round must be greater than zero")
+	}
+
+	m, err := ledger.ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := clock.NewChain(m.Algorithm, m.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		last *Round
+		prev *ledger.Entry
+	)
+	err = ledger.ReadSegments(dir, 0, func(entries []ledger.Entry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for idx := range entries {
+			e := entries[idx]
+			if err := ledger.CheckEntry(ctx, chain, prev, &e); err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				return fmt.Errorf("%w: the ledger entry at index %d does not follow from the seed -- %s", ErrMismatch, e.Index, err)
+			}
+			prev = &e
+
+			for r := next(p, last, e); r != nil; r = next(p, last, e) {
+				last = r
+				if last.Round == round {
+					return errDone
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errDone {
+		return nil, err
+	}
+
+	if last == nil || last.Round != round {
+		return nil, fmt.Errorf("%w: the tick of round %d (at index %d or after) is not in the ledger yet", ErrNotFound, round, round*p.Every)
+	}
+
+	if last.Value, err = Delay(ctx, p.Algorithm, p.Delay, last.Output); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+// Verify function checks the input published round against the one derived
+// from the ledger in the input directory (with `Derive`); returning the
+// derived round, and an error wrapping `ErrMismatch` if they differ (or if the
+// ledger's chain is broken, with no derived round)
+func Verify(ctx context.Context, dir string, published *Round) (*Round, error) {
+	r, err := Derive(ctx, dir, published.Round)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case published.Index != r.Index:
+		return r, fmt.Errorf("%w: round %d: expected the tick at index %d; got %d", ErrMismatch, r.Round, r.Index, published.Index)
+	case published.Tick != r.Tick:
+		return r, fmt.Errorf("%w: round %d: expected tick hash %s; got %s", ErrMismatch, r.Round, r.Tick, published.Tick)
+	case !published.WallTime.Equal(r.WallTime):
+		return r, fmt.Errorf("%w: round %d: expected the tick's wall time %s; got %s", ErrMismatch, r.Round, r.WallTime, published.WallTime)
+	case published.Previous != r.Previous:
+		return r, fmt.Errorf("%w: round %d: expected previous output %s; got %s", ErrMismatch, r.Round, r.Previous, published.Previous)
+	case published.Output != r.Output:
+		return r, fmt.Errorf("%w: round %d: expected output %s; got %s", ErrMismatch, r.Round, r.Output, published.Output)
+	case published.Value != r.Value:
+		return r, fmt.Errorf("%w: round %d: expected value %s; got %s", ErrMismatch, r.Round, r.Value, published.Value)
+	}
+	return r, nil
+}
@@ -0,0 +1,401 @@
+// Package cache implements a `clock.CheckpointCache`: checkpoints of one or
+// more chains, kept in memory and (optionally) persisted to a directory -- so
+// that repeated verifications of the same seed resume from the nearest cached
+// checkpoint, instead of from the seed.
+//
+// The cache is bounded both in the number of chains (evicting the least
+// recently used one) and in the number of checkpoints per chain (dropping
+// every other checkpoint once full, which doubles their spacing).
+//
+// Persisted chains are written out of the hashing loops: the chains changed
+// since the last flush are written every `FlushInterval`, and on `Close`
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// DefaultMaxChains is the default maximum number of chains in a cache
+	DefaultMaxChains int = 64
+
+	// DefaultMaxCheckpoints is the default maximum number of checkpoints per
+	// chain
+	DefaultMaxCheckpoints int = 1024
+
+	// DefaultFlushInterval is the default interval between writes of the
+	// changed chains, with a directory set
+	DefaultFlushInterval time.Duration = 10 * time.Second
+
+	fileExt string = ".json"
+)
+
+// Config struct defines the configuration of a `Cache`
+type Config struct {
+	// Dir is the directory where each chain's checkpoints are persisted, in
+	// their own file; empty keeps them in memory only
+	Dir string
+
+	// MaxChains is the maximum number of chains in the cache; the least
+	// recently used chain is evicted once exceeded. `DefaultMaxChains` by
+	// default
+	MaxChains int
+
+	// MaxCheckpoints is the maximum number of checkpoints per chain; every
+	// other checkpoint is dropped once exceeded. `DefaultMaxCheckpoints` by
+	// default
+	MaxCheckpoints int
+
+	// FlushInterval is the interval between writes of the chains changed
+	// since the last one, with a directory set. `DefaultFlushInterval` by
+	// default
+	FlushInterval time.Duration
+}
+
+// chain struct holds the checkpoints of a single chain, sorted by index
+type chain struct {
+	Key         clock.CacheKey     `json:"key"`
+	Checkpoints []clock.Checkpoint `json:"checkpoints"`
+
+	used   uint64
+	loaded bool
+	dirty  bool
+}
+
+// Cache struct is a `clock.CheckpointCache` which is safe for concurrent use
+type Cache struct {
+	mu     sync.Mutex
+	cfg    Config
+	chains map[string]*chain
+	uses   uint64
+	err    error
+
+	// done stops the background flusher, if running
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New function creates a `Cache` with the input configuration. If a
+// directory is set, it is created if it does not exist; the chains persisted
+// in it are loaded as they are used; and the changed chains are written in
+// the background, until the cache is closed
+func New(cfg *Config) (*Cache, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.MaxChains <= 0 {
+		c.MaxChains = DefaultMaxChains
+	}
+	if c.MaxCheckpoints <= 0 {
+		c.MaxCheckpoints = DefaultMaxCheckpoints
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = DefaultFlushInterval
+	}
+	if c.MaxCheckpoints < 2 {
+		return nil, errors.New("a chain must keep at least 2 checkpoints")
+	}
+
+	cache := &Cache{
+		cfg:    c,
+		chains: map[string]*chain{},
+	}
+
+	if c.Dir == "" {
+		return cache, nil
+	}
+
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// persisted chains are registered by their last use (their file's
+	// modification time), without loading their checkpoints
+	type persisted struct {
+		id    string
+		mtime int64
+	}
+	var files []persisted
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, persisted{
+			id:    strings.TrimSuffix(e.Name(), fileExt),
+			mtime: info.ModTime().UnixNano(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].mtime < files[j].mtime
+	})
+
+	for _, f := range files {
+		cache.uses++
+		cache.chains[f.id] = &chain{used: cache.uses}
+	}
+	cache.evict("")
+
+	cache.done = make(chan struct{})
+	cache.wg.Add(1)
+	go cache.flushEvery(c.FlushInterval, cache.done)
+
+	return cache, nil
+}
+
+// flushEvery method writes the changed chains every input interval, until
+// the input channel is closed
+func (c *Cache) flushEvery(interval time.Duration, done <-chan struct{}) {
+	defer c.wg.Done()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			c.Flush()
+		case <-done:
+			return
+		}
+	}
+}
+
+// chainID function returns the identifier of the input key's chain, which
+// names its file
+func chainID(key clock.CacheKey) string {
+	sum := sha256.Sum256([]byte(key.Algorithm + "\n" + key.Mode + "\n" + key.Seed))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Nearest method returns the cached checkpoint of the input key's chain with
+// the highest index below the input index, if any
+func (c *Cache) Nearest(key clock.CacheKey, index int) (clock.Checkpoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := chainID(key)
+	ch := c.get(id, key)
+	if ch == nil {
+		return clock.Checkpoint{}, false
+	}
+	c.touch(ch)
+
+	n := sort.Search(len(ch.Checkpoints), func(i int) bool {
+		return ch.Checkpoints[i].Index >= index
+	})
+	if n == 0 {
+		return clock.Checkpoint{}, false
+	}
+	return ch.Checkpoints[n-1], true
+}
+
+// Put method stores the input checkpoint of the input key's chain; evicting
+// the least recently used chain, or thinning the chain's checkpoints, if a
+// limit is exceeded. With a directory set, the chain is persisted on the
+// next flush
+func (c *Cache) Put(key clock.CacheKey, cp clock.Checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := chainID(key)
+	ch := c.get(id, key)
+	if ch == nil {
+		ch = &chain{Key: key, loaded: true}
+		c.chains[id] = ch
+	}
+	c.touch(ch)
+
+	n := sort.Search(len(ch.Checkpoints), func(i int) bool {
+		return ch.Checkpoints[i].Index >= cp.Index
+	})
+	if n < len(ch.Checkpoints) && ch.Checkpoints[n].Index == cp.Index {
+		return
+	}
+
+	ch.Checkpoints = append(ch.Checkpoints, clock.Checkpoint{})
+	copy(ch.Checkpoints[n+1:], ch.Checkpoints[n:])
+	ch.Checkpoints[n] = cp
+
+	if len(ch.Checkpoints) > c.cfg.MaxCheckpoints {
+		ch.Checkpoints = thin(ch.Checkpoints)
+	}
+
+	ch.dirty = true
+	c.evict(id)
+}
+
+// thin function drops every other checkpoint, always keeping the last one
+func thin(points []clock.Checkpoint) []clock.Checkpoint {
+	out := make([]clock.Checkpoint, 0, len(points)/2+1)
+	for idx := (len(points) - 1) % 2; idx < len(points); idx += 2 {
+		out = append(out, points[idx])
+	}
+	return out
+}
+
+// get method returns the chain with the input identifier, loading it from
+// its file if needed; or nil if it is not cached. A file which cannot be
+// loaded (or which belongs to another key) is dropped from the cache
+func (c *Cache) get(id string, key clock.CacheKey) *chain {
+	ch, ok := c.chains[id]
+	if !ok {
+		return nil
+	}
+	if ch.loaded {
+		return ch
+	}
+
+	b, err := os.ReadFile(c.path(id))
+	if err == nil {
+		loaded := &chain{}
+		if err = json.Unmarshal(b, loaded); err == nil && loaded.Key != key {
+			err = fmt.Errorf("cache file %s belongs to another chain", c.path(id))
+		}
+		if err == nil {
+			loaded.used = ch.used
+			loaded.loaded = true
+			c.chains[id] = loaded
+			return loaded
+		}
+	}
+
+	delete(c.chains, id)
+	return nil
+}
+
+// touch method marks the input chain as the most recently used
+func (c *Cache) touch(ch *chain) {
+	c.uses++
+	ch.used = c.uses
+}
+
+// evict method removes the least recently used chains (other than the one
+// with the input identifier) until the number of chains is within the limit
+func (c *Cache) evict(keep string) {
+	for len(c.chains) > c.cfg.MaxChains {
+		var (
+			oldest string
+			used   uint64
+		)
+		for id, ch := range c.chains {
+			if id != keep && (oldest == "" || ch.used < used) {
+				oldest, used = id, ch.used
+			}
+		}
+		if oldest == "" {
+			return
+		}
+
+		delete(c.chains, oldest)
+		if c.cfg.Dir != "" {
+			os.Remove(c.path(oldest))
+		}
+	}
+}
+
+// persist method writes the input chain to its file (if a directory is
+// set), replacing it atomically
+func (c *Cache) persist(id string, ch *chain) error {
+	if c.cfg.Dir == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(id))
+}
+
+// path method returns the path of the file of the chain with the input
+// identifier
+func (c *Cache) path(id string) string {
+	return filepath.Join(c.cfg.Dir, id+fileExt)
+}
+
+// Flush method writes the chains changed since the last flush to their
+// files, if a directory is set; returning the first error, which is also
+// reported by `Err`
+func (c *Cache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var first error
+	for id, ch := range c.chains {
+		if !ch.dirty {
+			continue
+		}
+		if err := c.persist(id, ch); err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		ch.dirty = false
+	}
+
+	if first != nil && c.err == nil {
+		c.err = first
+	}
+	return first
+}
+
+// Close method stops the background flusher and writes the changed chains,
+// as in `Flush`. The cache can still be used in memory after it is closed
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	done := c.done
+	c.done = nil
+	c.mu.Unlock()
+
+	if done != nil {
+		close(done)
+		c.wg.Wait()
+	}
+	return c.Flush()
+}
+
+// Len method returns the number of chains in the cache
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.chains)
+}
+
+// Err method returns the first error while flushing a chain, if any
+func (c *Cache) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	testSeed  string = "Hello World!"
+	testHash  string = "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705" // index 10
+	testIndex int    = 10
+)
+
+func TestCache(t *testing.T) {
+	c, err := New(&Config{MaxChains: 2, MaxCheckpoints: 4})
+	if err != nil {
+		t.Fatalf("FAILED -- [Cache] New() failed: %s", err)
+	}
+
+	key := clock.NewCacheKey("SHA256", clock.ModeHex, testSeed)
+	for idx := 1; idx <= 8; idx++ {
+		c.Put(key, clock.Checkpoint{Index: idx * 100, Hash: "00"})
+	}
+
+	// thinned to every other checkpoint, keeping the last one
+	if cp, ok := c.Nearest(key, 1000); !ok || cp.Index != 800 {
+		t.Errorf("FAILED -- [Cache] Nearest(1000) = %+v, %v ; expected index 800", cp, ok)
+	}
+	if cp, ok := c.Nearest(key, 700); !ok || cp.Index != 500 {
+		t.Errorf("FAILED -- [Cache] Nearest(700) = %+v, %v ; expected index 500", cp, ok)
+	}
+	if _, ok := c.Nearest(key, 100); ok {
+		t.Errorf("FAILED -- [Cache] Nearest(100) should not find a checkpoint")
+	}
+
+	// the least recently used chain is evicted
+	other := clock.NewCacheKey("SHA256", clock.ModeHex, "other")
+	third := clock.NewCacheKey("SHA512", clock.ModeHex, testSeed)
+	c.Put(other, clock.Checkpoint{Index: 5, Hash: "00"})
+	c.Nearest(key, 1000)
+	c.Put(third, clock.Checkpoint{Index: 5, Hash: "00"})
+
+	if _, ok := c.Nearest(other, 10); ok || c.Len() != 2 {
+		t.Errorf("FAILED -- [Cache] the least recently used chain was not evicted: %v chains", c.Len())
+	}
+	if _, ok := c.Nearest(key, 1000); !ok {
+		t.Errorf("FAILED -- [Cache] a recently used chain was evicted")
+	}
+}
+
+func TestCachePersisted(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := New(&Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("FAILED -- [Cache] New() failed: %s", err)
+	}
+
+	// a verification fills the cache...
+	svc := clock.NewService()
+	svc.SetCheckpointCache(c, 4)
+	if res, err := svc.VerifyIndex(testSeed, testHash, testIndex); err != nil || !res.Match {
+		t.Fatalf("FAILED -- [Cache] VerifyIndex() = %+v, %v", res, err)
+	}
+
+	// the chain is only written on a flush, out of the hashing loop
+	key := clock.NewCacheKey("SHA256", clock.ModeHex, testSeed)
+	path := filepath.Join(dir, chainID(key)+fileExt)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("FAILED -- [Cache] the chain should not be written before a flush: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("FAILED -- [Cache] Close() failed: %s", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("FAILED -- [Cache] the chain should be written on Close(): %s", err)
+	}
+
+	// ...and the next one resumes from it, from another process
+	c, err = New(&Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("FAILED -- [Cache] New() failed: %s", err)
+	}
+	defer c.Close()
+	if cp, ok := c.Nearest(key, testIndex); !ok || cp.Index != 8 {
+		t.Fatalf("FAILED -- [Cache] Nearest(%v) = %+v, %v ; expected index 8", testIndex, cp, ok)
+	}
+
+	svc = clock.NewService()
+	svc.SetCheckpointCache(c, 4)
+
+	if res, err := svc.VerifyIndex(testSeed, testHash, testIndex); err != nil || !res.Match {
+		t.Errorf("FAILED -- [Cache] VerifyIndex() from the cache = %+v, %v", res, err)
+	}
+	if res, err := svc.RecHash(testSeed, testIndex); err != nil || res.Hash != testHash {
+		t.Errorf("FAILED -- [Cache] RecHash() from the cache = %+v, %v", res, err)
+	}
+
+	// a forged checkpoint results in a mismatch, rather than a false match
+	c.Put(key, clock.Checkpoint{Index: 9, Hash: testHash})
+	if res, err := svc.VerifyIndex(testSeed, testHash, testIndex); err != nil || res.Match {
+		t.Errorf("FAILED -- [Cache] VerifyIndex() from a forged checkpoint = %+v, %v", res, err)
+	}
+}
+
+func TestCacheModes(t *testing.T) {
+	c, _ := New(&Config{})
+
+	hex := clock.NewService()
+	hex.SetCheckpointCache(c, 4)
+	if _, err := hex.RecHash(testSeed, testIndex); err != nil {
+		t.Fatalf("FAILED -- [Cache] RecHash() failed: %s", err)
+	}
+
+	// chains of other chaining modes (or domains) do not share checkpoints
+	indexed := clock.NewService()
+	indexed.SetMode(clock.ModeIndexed, "a")
+	want, _ := indexed.RecHash(testSeed, testIndex)
+
+	indexed.SetCheckpointCache(c, 4)
+	if res, err := indexed.RecHash(testSeed, testIndex); err != nil || res.Hash != want.Hash {
+		t.Errorf("FAILED -- [Cache] indexed RecHash() with a shared cache = %+v, %v ; expected %s", res, err, want.Hash)
+	}
+
+	indexed.SetMode(clock.ModeIndexed, "b")
+	if res, err := indexed.VerifyIndex(testSeed, want.Hash, testIndex); err != nil || res.Match {
+		t.Errorf("FAILED -- [Cache] VerifyIndex() matched a chain of another domain from the cache: %+v, %v", res, err)
+	}
+
+	if res, err := hex.VerifyIndex(testSeed, testHash, testIndex); err != nil || !res.Match {
+		t.Errorf("FAILED -- [Cache] hex VerifyIndex() with a shared cache = %+v, %v", res, err)
+	}
+}
+
+func TestCacheFlusher(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := New(&Config{Dir: dir, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("FAILED -- [Cache] New() failed: %s", err)
+	}
+	defer c.Close()
+
+	key := clock.NewCacheKey("SHA256", clock.ModeHex, testSeed)
+	c.Put(key, clock.Checkpoint{Index: 100, Hash: "00"})
+
+	// the background flusher writes the changed chain
+	path := filepath.Join(dir, chainID(key)+fileExt)
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+	}
+	t.Errorf("FAILED -- [Cache] the background flusher did not write the chain")
+}
@@ -0,0 +1,128 @@
+package clock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	// ModeHex is the chaining mode of the `HashClockService` methods: each
+	// step hashes the hex-encoded previous hash
+	ModeHex string = "hex"
+
+	// ModeRaw is the chaining mode of Proof-of-History chains (as in the `poh`
+	// package): each step hashes the raw bytes of the previous hash
+	ModeRaw string = "raw"
+
+	// ModeIndexed is the domain-separated chaining mode: each step hashes the
+	// hex-encoded previous hash (or the seed, at index 1), followed by the
+	// step's index (as a big-endian, 8-byte integer) and the chain's domain
+	// tag, if any. A hash is only valid at its own index, in its own domain
+	ModeIndexed string = "indexed"
+)
+
+// CacheKey struct identifies a chain in a `CheckpointCache`: its hash
+// function, its chaining mode and the (hex-encoded SHA256) digest of its
+// seed -- so that seeds are not stored in the cache
+type CacheKey struct {
+	Algorithm string `json:"algorithm"`
+	Mode      string `json:"mode"`
+	Seed      string `json:"seed_digest"`
+}
+
+// NewCacheKey function creates the `CacheKey` of the chain of the input
+// algorithm, chaining mode and seed
+func NewCacheKey(alg, mode, seed string) CacheKey {
+	digest := sha256.Sum256([]byte(seed))
+
+	return CacheKey{
+		Algorithm: alg,
+		Mode:      mode,
+		Seed:      hex.EncodeToString(digest[:]),
+	}
+}
+
+// CheckpointCache interface describes a store of checkpoints of one or more
+// chains, which a `HashClockService` resumes from instead of the seed. Its
+// methods are called from the hashing loops, so they should return quickly;
+// and must be safe for concurrent use, if the cache is shared by several
+// services
+type CheckpointCache interface {
+	// Nearest returns the cached checkpoint of the chain with the highest
+	// index below the input index, if any
+	Nearest(key CacheKey, index int) (Checkpoint, bool)
+
+	// Put stores a checkpoint of the chain
+	Put(key CacheKey, cp Checkpoint)
+}
+
+// SetCheckpointCache method sets a cache of checkpoints for the service: the
+// methods which calculate the hash at a known index (`RecHash`, `VerifyIndex`
+// and `VerifyIndexTimeout`) resume from the nearest cached checkpoint below
+// it, and every method which walks the chain stores a checkpoint every
+// `interval` indices. A nil cache unsets it.
+//
+// The cache is not used while continuing from a checkpoint set with
+// `SetCheckpoint`, as the chain's seed is not known. Cached hashes are
+// trusted, so the cache should only be filled by trusted services
+func (s *HashClockService) SetCheckpointCache(cache CheckpointCache, interval int) error {
+	if cache == nil {
+		s.cache = nil
+		s.cacheInterval = 0
+		return nil
+	}
+
+	if interval <= 0 {
+		return errors.New("checkpoint interval must be greater than zero")
+	}
+
+	s.cache = cache
+	s.cacheInterval = interval
+	return nil
+}
+
+// cacheKey method returns the `CacheKey` of the current request's chain
+func (c *HashClockService) cacheKey() CacheKey {
+	alg := c.request.algorithm
+	if alg == "" {
+		// the default hasher, if `SetHasher` was not called
+		alg = HasherMapVals[3]
+	}
+	if c.memoryHard != nil {
+		// chains with different parameters are different chains
+		alg = c.memoryHard.String()
+	}
+
+	mode := ModeHex
+	if c.mode == ModeIndexed {
+		// as are chains in different domains
+		mode = ModeIndexed + ":" + c.tag
+	}
+	return NewCacheKey(alg, mode, string(c.request.seed))
+}
+
+// resume method returns where to start calculating the hash at the input
+// index, and its index: the nearest cached checkpoint below it; or the
+// origin, if there is none
+func (c *HashClockService) resume(target int) ([]byte, int) {
+	hash, start := c.origin()
+	if c.cache == nil || c.checkpoint != nil {
+		return hash, start
+	}
+
+	cp, ok := c.cache.Nearest(c.cacheKey(), target)
+	if !ok || cp.Index <= start || cp.Index >= target {
+		return hash, start
+	}
+	return []byte(cp.Hash), cp.Index
+}
+
+// store method stores the hash at the input index in the cache, if it falls
+// on the cache's interval
+func (c *HashClockService) store(index int, hash []byte) {
+	if c.checkpoint != nil || index%c.cacheInterval != 0 {
+		return
+	}
+	c.cache.Put(c.cacheKey(), Checkpoint{Index: index, Hash: string(hash)})
+}
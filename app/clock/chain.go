@@ -0,0 +1,131 @@
+package clock
+
+import (
+	"errors"
+)
+
+// Chain struct is a hash chain which is calculated one step at a time, keeping
+// its current index and hash. It follows the same rules as the
+// `HashClockService` methods:
+//
+// - index 0 is the seed
+// - index 1 is the hash of the seed
+// - index i is the hash of the (hex-encoded) hash at index i-1
+//
+// Events can be mixed into the chain: a mixed step hashes the previous hash
+// concatenated with the event's (hex-encoded) digest, instead of the previous
+// hash alone.
+//
+// Its steps are calculated with an `Engine`, so they do not allocate: the
+// slices returned by `Next` and `Mix` are only valid until the next step, and
+// must be copied to be kept. A Chain is not safe for concurrent use
+type Chain struct {
+	engine    *Engine
+	algorithm string
+	seed      []byte
+	index     int
+	hash      []byte
+
+	// buf is the input of a mixed step: the previous hash and the event's
+	// digest
+	buf []byte
+}
+
+// NewChain function creates a `Chain` for the input algorithm and seed, at
+// index 0
+func NewChain(alg, seed string) (*Chain, error) {
+	if err := ValidateSeed(seed); err != nil {
+		return nil, err
+	}
+
+	s := NewService()
+	if err := s.SetHasher(alg); err != nil {
+		return nil, err
+	}
+
+	return &Chain{
+		engine:    s.engine,
+		algorithm: s.request.algorithm,
+		seed:      []byte(seed),
+	}, nil
+}
+
+// Next method calculates the next hash in the chain, returning it
+func (c *Chain) Next() []byte {
+	prev := c.hash
+	if c.index == 0 {
+		prev = c.seed
+	}
+
+	c.hash = append(c.hash[:0], c.engine.Hash(prev)...)
+	c.index++
+
+	return c.hash
+}
+
+// Mix method calculates the next hash in the chain, mixing in the input
+// (hex-encoded) event digest; returning it
+func (c *Chain) Mix(digest []byte) []byte {
+	prev := c.hash
+	if c.index == 0 {
+		prev = c.seed
+	}
+
+	c.buf = append(c.buf[:0], prev...)
+	c.buf = append(c.buf, digest...)
+
+	c.hash = append(c.hash[:0], c.engine.Hash(c.buf)...)
+	c.index++
+
+	return c.hash
+}
+
+// Digest method returns the (hex-encoded) digest of the input event data,
+// with the chain's hash function -- as expected by `Mix`. Unlike the steps,
+// the returned slice is not reused
+func (c *Chain) Digest(data []byte) []byte {
+	return append([]byte(nil), c.engine.Hash(data)...)
+}
+
+// Reset method moves the chain to the input index and (hex-encoded) hash,
+// so that it continues from there. An index of 0 moves it back to the seed
+func (c *Chain) Reset(index int, hash string) error {
+	if index < 0 {
+		return errors.New("index cannot be negative")
+	}
+
+	if index == 0 {
+		c.index = 0
+		c.hash = nil
+		return nil
+	}
+
+	if err := ValidateHash(string(c.seed), hash); err != nil {
+		return err
+	}
+
+	c.index = index
+	c.hash = []byte(hash)
+	return nil
+}
+
+// Index method returns the index of the chain's current hash
+func (c *Chain) Index() int {
+	return c.index
+}
+
+// Hash method returns the chain's current (hex-encoded) hash; or an empty
+// string at index 0
+func (c *Chain) Hash() string {
+	return string(c.hash)
+}
+
+// Algorithm method returns the name of the chain's hash function
+func (c *Chain) Algorithm() string {
+	return c.algorithm
+}
+
+// Seed method returns the chain's seed
+func (c *Chain) Seed() string {
+	return string(c.seed)
+}
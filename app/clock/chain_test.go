@@ -0,0 +1,88 @@
+package clock
+
+import (
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	for id, test := range testCases {
+		chain, err := NewChain("sha256", test.seed)
+		if err != nil {
+			t.Fatalf("#%v -- FAILED -- [Chain] NewChain(%q) failed: %s", id, test.seed, err)
+		}
+
+		for i := 0; i < test.iterations; i++ {
+			chain.Next()
+		}
+
+		if chain.Index() != test.iterations {
+			t.Errorf("#%v -- FAILED -- [Chain] index mismatch: wanted %v ; got %v", id, test.iterations, chain.Index())
+		}
+		if chain.Hash() != test.hash {
+			t.Errorf("#%v -- FAILED -- [Chain] hash mismatch: wanted %s ; got %s", id, test.hash, chain.Hash())
+		}
+	}
+}
+
+func TestChainMix(t *testing.T) {
+	chain, err := NewChain("sha256", "Hello World!")
+	if err != nil {
+		t.Fatalf("FAILED -- [Chain] NewChain() failed: %s", err)
+	}
+
+	chain.Next()
+	prev := chain.Hash()
+
+	digest := chain.Digest([]byte("event"))
+	mixed := string(chain.Mix(digest))
+
+	if want := string(HasherMap[3].Hash([]byte(prev + string(digest)))); mixed != want {
+		t.Errorf("FAILED -- [Chain] mixed hash mismatch: wanted %s ; got %s", want, mixed)
+	}
+	if chain.Index() != 2 {
+		t.Errorf("FAILED -- [Chain] index mismatch: wanted 2 ; got %v", chain.Index())
+	}
+
+	// resuming from index 1 continues the plain chain
+	if err := chain.Reset(1, prev); err != nil {
+		t.Fatalf("FAILED -- [Chain] Reset() failed: %s", err)
+	}
+	if want := testCases[2].hash; string(chain.Next()) != want {
+		t.Errorf("FAILED -- [Chain] hash mismatch after reset: wanted %s ; got %s", want, chain.Hash())
+	}
+
+	if err := chain.Reset(1, "zzz"); err == nil {
+		t.Errorf("FAILED -- [Chain] Reset() with an invalid hash should fail")
+	}
+
+	if err := chain.Reset(0, ""); err != nil || chain.Index() != 0 {
+		t.Errorf("FAILED -- [Chain] Reset() to the seed failed: %v", err)
+	}
+	if want := testCases[0].hash; string(chain.Next()) != want {
+		t.Errorf("FAILED -- [Chain] hash mismatch after reset: wanted %s ; got %s", want, chain.Hash())
+	}
+
+	if _, err := NewChain("sha3", "Hello World!"); err == nil {
+		t.Errorf("FAILED -- [Chain] NewChain() with an invalid algorithm should fail")
+	}
+}
+
+func TestChainAllocs(t *testing.T) {
+	for _, alg := range []string{"sha256", "memhard"} {
+		chain, err := NewChain(alg, "Hello World!")
+		if err != nil {
+			t.Fatalf("FAILED -- [Chain] NewChain(%s) failed: %s", alg, err)
+		}
+		chain.Next()
+		digest := chain.Digest([]byte("event"))
+		chain.Mix(digest)
+
+		allocs := testing.AllocsPerRun(10, func() {
+			chain.Next()
+			chain.Mix(digest)
+		})
+		if allocs != 0 {
+			t.Errorf("FAILED -- [Chain] %s steps allocated %v times; expected none", alg, allocs)
+		}
+	}
+}
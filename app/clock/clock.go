@@ -6,7 +6,11 @@
 package clock
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -22,6 +26,7 @@ var HasherMap = map[int]rhash.Hasher{
 	5: rhash.SHA512{},
 	6: rhash.SHA512_224{},
 	7: rhash.SHA512_256{},
+	8: MemoryHard{Memory: DefaultMemory, Cost: DefaultCost},
 }
 
 var HasherMapVals = map[int]string{
@@ -33,6 +38,7 @@ var HasherMapVals = map[int]string{
 	5: "SHA512",
 	6: "SHA512_224",
 	7: "SHA512_256",
+	8: MemoryHardAlgorithm,
 }
 
 // HashClockRequest struct defines the input configuration for
@@ -44,30 +50,74 @@ type HashClockRequest struct {
 	timeout    int
 	hash       string
 	algorithm  string
+	prefix     Prefix
 }
 
 // HashClockResponse struct defines the input configuration for
 // a `HashClockService` response
 type HashClockResponse struct {
-	Seed       string `json:"seed,omitempty"`
-	Algorithm  string `json:"algorithm,omitempty"`
-	Timeout    int    `json:"timeout,omitempty"`
-	Iterations int    `json:"iterations,omitempty"`
-	Hash       string `json:"hash,omitempty"`
-	Target     string `json:"target,omitempty"`
-	Match      bool   `json:"match,omitempty"`
-	Duration   time.Duration
+	Seed       string        `json:"seed,omitempty"`
+	Algorithm  string        `json:"algorithm,omitempty"`
+	Timeout    int           `json:"timeout,omitempty"`
+	Iterations int           `json:"iterations,omitempty"`
+	Hash       string        `json:"hash,omitempty"`
+	Target     string        `json:"target,omitempty"`
+	Match      bool          `json:"match,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty"`
+
+	// Checkpoint is the trusted point the chain continued from, if set
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+
+	// MemoryHard are the parameters of the memory-hard step function, if
+	// it is the chain's algorithm
+	MemoryHard *MemoryHard `json:"memory_hard,omitempty"`
+
+	// Mode is the chain's chaining mode, if not the default (`ModeHex`); and
+	// Tag its domain tag, in the `ModeIndexed` mode
+	Mode string `json:"mode,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+
+	// Prefix is the target of a prefix search, and ExpectedWork the expected
+	// number of hashes to find it
+	Prefix       *Prefix `json:"prefix,omitempty"`
+	ExpectedWork float64 `json:"expected_work,omitempty"`
+}
+
+// Checkpoint struct is a trusted point of a chain: the index of a hash and the
+// (hex-encoded) hash itself. A service with a checkpoint continues the chain
+// from it, instead of from the seed
+type Checkpoint struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
 }
 
 // HashClockService struct is a placeholder for this service,
 // containing a pointer to both the request and response objects,
 // and being the container for all methods in this package
 type HashClockService struct {
-	request  *HashClockRequest
-	response *HashClockResponse
-	hasher   rhash.Hasher
+	request       *HashClockRequest
+	response      *HashClockResponse
+	hasher        rhash.Hasher
+	engine        *Engine
+	memoryHard    *MemoryHard
+	interval      int
+	mode          string
+	tag           string
+	checkpoint    *Checkpoint
+	cache         CheckpointCache
+	cacheInterval int
+	ctx           context.Context
+	writer        io.Writer
+	tickFunc      TickFunc
+	progressFunc  TickFunc
 }
 
+// checkInterval is the number of hashes calculated between checks for a
+// cancelled context or an expired timer, to keep the overhead in the
+// hashing loops low (for all algorithms but the memory-hard one, whose steps
+// are checked one by one)
+const checkInterval int = 1024
+
 // NewService function is a generic public function to spawn a
 // pointer to a new HashClockService, with set default values
 func NewService() *HashClockService {
@@ -83,14 +133,51 @@ func NewService() *HashClockService {
 
 	// initialize default hasher
 	c.hasher = HasherMap[3]
+	c.engine = newEngine(3, ModeHex)
+	c.interval = checkInterval
+
+	// initialize default context and output
+	c.ctx = context.Background()
+	c.writer = os.Stdout
 
 	return c
 }
 
+// SetContext method sets the context for the service's methods. Once the
+// context is cancelled (or its deadline is exceeded), any running method
+// returns the context's error -- which allows halting the infinite loops
+// in `RecHashLoop` and `Verify`
+func (s *HashClockService) SetContext(ctx context.Context) error {
+	if ctx == nil {
+		return errors.New("context cannot be nil")
+	}
+	s.ctx = ctx
+	return nil
+}
+
+// SetWriter method sets the output for the hashes logged at each breakpoint,
+// in `RecHashPrint` and `RecHashLoop`. By default, these are written to
+// std-out; a nil writer discards them
+func (s *HashClockService) SetWriter(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	s.writer = w
+}
+
 func (s *HashClockService) setHasher(input int) error {
 	switch {
 	case input >= 0 && input < len(HasherMap):
+		if m, ok := HasherMap[input].(MemoryHard); ok {
+			s.setMemoryHard(m)
+			return nil
+		}
+
 		s.hasher = HasherMap[input]
+		s.engine = newEngine(input, ModeHex)
+		s.setEngineMode()
+		s.memoryHard = nil
+		s.interval = checkInterval
 		s.request.algorithm = HasherMapVals[input]
 		return nil
 	default:
@@ -110,3 +197,112 @@ func (s *HashClockService) SetHasher(input string) error {
 	}
 	return errors.New("invalid hasher reference")
 }
+
+// SetMode method sets the chaining mode of the service's chain: `ModeHex` (the
+// default, if empty), where each step hashes the previous hash; or
+// `ModeIndexed`, where each step hashes the previous hash, its index and the
+// input domain tag (which may be empty). The tag is only used in the indexed
+// mode.
+//
+// The mode is kept when the hash function changes, and is reported in the
+// responses (with the tag) unless it is the default
+func (s *HashClockService) SetMode(mode, tag string) error {
+	if err := ValidateMode(mode, tag); err != nil {
+		return err
+	}
+
+	s.mode = ""
+	if mode == ModeIndexed {
+		s.mode = ModeIndexed
+	}
+	s.tag = tag
+	s.setEngineMode()
+	return nil
+}
+
+// setEngineMode method sets the service's chaining mode (and domain tag) on
+// its engine
+func (s *HashClockService) setEngineMode() {
+	s.engine.mode = ModeHex
+	if s.mode != "" {
+		s.engine.mode = s.mode
+	}
+	s.engine.SetTag([]byte(s.tag))
+}
+
+// SetCheckpoint method sets a trusted point of the chain, to continue from
+// instead of the seed: all methods (except for `Hash`) calculate the hashes
+// after the checkpoint's index, starting from its hash. Indices (the number of
+// iterations, in requests and responses) remain absolute, and verifications
+// are anchored on the checkpoint -- the seed is only reported, and may be
+// empty.
+//
+// An index of 0 unsets the checkpoint, so the chain starts from the seed
+func (s *HashClockService) SetCheckpoint(index int, hash string) error {
+	if index == 0 {
+		s.checkpoint = nil
+		return nil
+	}
+
+	if index < 0 {
+		return errors.New("checkpoint index cannot be negative")
+	}
+	if err := ValidateHash("", hash); err != nil {
+		return err
+	}
+	if len(hash) != len(s.hasher.Hash(nil)) {
+		return errors.New("checkpoint hash length does not match the hash function's digest size")
+	}
+
+	s.checkpoint = &Checkpoint{Index: index, Hash: hash}
+	return nil
+}
+
+// origin method returns the start of the service's chain, and its index: the
+// seed at index 0; or the checkpoint's hash, at its index
+func (c *HashClockService) origin() ([]byte, int) {
+	if c.checkpoint != nil {
+		return []byte(c.checkpoint.Hash), c.checkpoint.Index
+	}
+	return c.request.seed, 0
+}
+
+// first method calculates the first hash of the service's chain, following
+// the input origin at the input index (the seed, at index 0)
+func (c *HashClockService) first(origin []byte, index int) []byte {
+	if c.mode == ModeIndexed {
+		return c.engine.StepAt(origin, index+1)
+	}
+	if index == 0 {
+		return c.engine.Hash(origin)
+	}
+	return c.engine.Step(origin)
+}
+
+// validateSeed method checks the input seed string, which can only be empty
+// when continuing from a checkpoint
+func (c *HashClockService) validateSeed(seed string) error {
+	if c.checkpoint != nil {
+		return nil
+	}
+	return ValidateSeed(seed)
+}
+
+// validateHashLength method checks that the input target hash has the length
+// of a digest of the service's hash function
+func (c *HashClockService) validateHashLength(hash string) error {
+	alg := c.request.algorithm
+	if alg == "" {
+		alg = HasherMapVals[3]
+	}
+	return hashLength(alg, hash, c.engine.Size())
+}
+
+// validateTarget method checks that the input (absolute) index comes after
+// the checkpoint, if set
+func (c *HashClockService) validateTarget(index int) error {
+	if c.checkpoint != nil && index <= c.checkpoint.Index {
+		return fmt.Errorf("index %d does not come after the checkpoint's index %d", index, c.checkpoint.Index)
+	}
+	return nil
+}
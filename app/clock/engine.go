@@ -0,0 +1,170 @@
+package clock
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// hashFuncs maps each of the `HasherMap` keys to the function creating its
+// (reusable) hash state
+var hashFuncs = map[int]func() hash.Hash{
+	0: md5.New,
+	1: sha1.New,
+	2: sha256.New224,
+	3: sha256.New,
+	4: sha512.New384,
+	5: sha512.New,
+	6: sha512.New512_224,
+	7: sha512.New512_256,
+	8: MemoryHard{Memory: DefaultMemory, Cost: DefaultCost}.New,
+}
+
+// hexSize function returns the length of a hex-encoded digest of the input
+// `HasherMap` key's hash function
+func hexSize(input int) int {
+	if _, ok := HasherMap[input].(MemoryHard); ok {
+		// without allocating a scratchpad
+		return hex.EncodedLen(sha256.Size)
+	}
+	return hex.EncodedLen(hashFuncs[input]().Size())
+}
+
+// Engine struct calculates the steps of a hash chain without allocating: it
+// reuses a single hash state, and writes each digest (and its hex encoding)
+// to fixed buffers. Its output is the same as the `HasherMap` hashers'.
+//
+// The slices returned by `Hash` and `Step` are only valid until the next call,
+// so they must be copied to be kept. An Engine is not safe for concurrent use
+type Engine struct {
+	h    hash.Hash
+	mode string
+
+	// tag is the domain tag appended to each step, in the indexed chaining
+	// mode
+	tag []byte
+
+	// sum is the raw digest; out its hex encoding; in the decoded previous
+	// hash, in the raw chaining mode; and ctr the encoded index, in the
+	// indexed chaining mode
+	sum []byte
+	out []byte
+	in  []byte
+	ctr [8]byte
+}
+
+// NewEngine function creates an `Engine` for the input algorithm (as named in
+// `HasherMapVals`, lower-case or upper-case) and chaining mode (`ModeHex`,
+// `ModeRaw` or `ModeIndexed`; hex if empty)
+func NewEngine(alg, mode string) (*Engine, error) {
+	switch mode {
+	case "":
+		mode = ModeHex
+	case ModeHex, ModeRaw, ModeIndexed:
+	default:
+		return nil, fmt.Errorf("invalid chaining mode %q", mode)
+	}
+
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		if alg == HasherMapVals[idx] || alg == strings.ToLower(HasherMapVals[idx]) {
+			return newEngine(idx, mode), nil
+		}
+	}
+	return nil, errors.New("invalid hasher reference")
+}
+
+// newEngine function creates an `Engine` for the input `HasherMap` key and
+// chaining mode
+func newEngine(input int, mode string) *Engine {
+	return engineFor(hashFuncs[input](), mode)
+}
+
+// engineFor function creates an `Engine` which reuses the input hash state,
+// with the input chaining mode
+func engineFor(h hash.Hash, mode string) *Engine {
+	size := h.Size()
+
+	return &Engine{
+		h:    h,
+		mode: mode,
+		sum:  make([]byte, 0, size),
+		out:  make([]byte, hex.EncodedLen(size)),
+		in:   make([]byte, size),
+	}
+}
+
+// Size method returns the size of a (hex-encoded) hash
+func (e *Engine) Size() int {
+	return len(e.out)
+}
+
+// Hash method returns the (hex-encoded) digest of the input data -- the first
+// step of a chain, from its seed
+func (e *Engine) Hash(data []byte) []byte {
+	e.h.Reset()
+	e.h.Write(data)
+	e.sum = e.h.Sum(e.sum[:0])
+
+	hex.Encode(e.out, e.sum)
+	return e.out
+}
+
+// SetTag method sets the domain tag which is appended to each step, in the
+// `ModeIndexed` chaining mode; none if empty
+func (e *Engine) SetTag(tag []byte) {
+	e.tag = append(e.tag[:0], tag...)
+}
+
+// StepAt method returns the hash at the input index, following the input
+// (hex-encoded) hash -- or the seed, at index 1 in the `ModeIndexed` chaining
+// mode. Only that mode hashes the index, so in the others it is the same as
+// `Step`. The input can be the output of the previous call
+func (e *Engine) StepAt(prev []byte, index int) []byte {
+	if e.mode != ModeIndexed {
+		return e.Step(prev)
+	}
+
+	binary.BigEndian.PutUint64(e.ctr[:], uint64(index))
+
+	e.h.Reset()
+	e.h.Write(prev)
+	e.h.Write(e.ctr[:])
+	e.h.Write(e.tag)
+	e.sum = e.h.Sum(e.sum[:0])
+
+	hex.Encode(e.out, e.sum)
+	return e.out
+}
+
+// Step method returns the hash following the input (hex-encoded) hash, by the
+// engine's chaining mode. The input can be the output of the previous call.
+//
+// The `ModeIndexed` chaining mode needs the index of the hash, so its steps
+// are calculated with `StepAt` instead; `Step` hashes them at index 0
+func (e *Engine) Step(prev []byte) []byte {
+	switch e.mode {
+	case ModeHex:
+		return e.Hash(prev)
+	case ModeIndexed:
+		return e.StepAt(prev, 0)
+	}
+
+	// the previous hash is decoded before the output buffer is overwritten,
+	// as it is usually the same buffer
+	in := e.in
+	if len(prev) != len(e.out) {
+		// not a hash of the engine's algorithm
+		in = make([]byte, hex.DecodedLen(len(prev)))
+	}
+
+	// invalid input is hashed up to its first invalid byte
+	n, _ := hex.Decode(in, prev)
+	return e.Hash(in[:n])
+}
@@ -0,0 +1,180 @@
+package clock
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEngine(t *testing.T) {
+	seed := []byte("hashclock")
+
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		alg := HasherMapVals[idx]
+		hasher := HasherMap[idx]
+
+		e, err := NewEngine(alg, "")
+		if err != nil {
+			t.Fatalf("#%v -- FAILED -- [Engine] NewEngine(%s) failed: %s", idx, alg, err)
+		}
+		if e.Size() != len(hasher.Hash(nil)) {
+			t.Errorf("#%v -- FAILED -- [Engine] %s size mismatch: wanted %v ; got %v", idx, alg, len(hasher.Hash(nil)), e.Size())
+		}
+
+		want := hasher.Hash(seed)
+		hash := e.Hash(seed)
+		for i := 1; i <= 1000; i++ {
+			if string(hash) != string(want) {
+				t.Fatalf("#%v -- FAILED -- [Engine] %s hash mismatch at #%v: wanted %s ; got %s", idx, alg, i, want, hash)
+			}
+			want = hasher.Hash(want)
+			hash = e.Step(hash)
+		}
+	}
+}
+
+func TestEngineRaw(t *testing.T) {
+	seed := []byte("hashclock")
+
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		alg := HasherMapVals[idx]
+		hasher := HasherMap[idx]
+
+		e, err := NewEngine(alg, ModeRaw)
+		if err != nil {
+			t.Fatalf("#%v -- FAILED -- [Engine] NewEngine(%s, %s) failed: %s", idx, alg, ModeRaw, err)
+		}
+
+		want := hasher.Hash(seed)
+		hash := e.Hash(seed)
+		for i := 1; i <= 1000; i++ {
+			if string(hash) != string(want) {
+				t.Fatalf("#%v -- FAILED -- [Engine] %s raw hash mismatch at #%v: wanted %s ; got %s", idx, alg, i, want, hash)
+			}
+
+			raw, _ := hex.DecodeString(string(want))
+			want = hasher.Hash(raw)
+			hash = e.Step(hash)
+		}
+	}
+
+	// input which is not a hash of the engine's algorithm
+	e, _ := NewEngine("sha256", ModeRaw)
+	if want, got := string(HasherMap[3].Hash([]byte{0xab})), string(e.Step([]byte("abz"))); got != want {
+		t.Errorf("FAILED -- [Engine] invalid input hash mismatch: wanted %s ; got %s", want, got)
+	}
+}
+
+func TestEngineIndexed(t *testing.T) {
+	seed := []byte("hashclock")
+
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		alg := HasherMapVals[idx]
+		hasher := HasherMap[idx]
+
+		e, err := NewEngine(alg, ModeIndexed)
+		if err != nil {
+			t.Fatalf("#%v -- FAILED -- [Engine] NewEngine(%s, %s) failed: %s", idx, alg, ModeIndexed, err)
+		}
+
+		for _, tag := range []string{"", "domain"} {
+			e.SetTag([]byte(tag))
+
+			want := seed
+			hash := seed
+			for i := 1; i <= 100; i++ {
+				buf := make([]byte, 8)
+				binary.BigEndian.PutUint64(buf, uint64(i))
+				want = hasher.Hash(append(append(append([]byte{}, want...), buf...), tag...))
+
+				hash = e.StepAt(hash, i)
+				if string(hash) != string(want) {
+					t.Fatalf("#%v -- FAILED -- [Engine] %s indexed hash mismatch at #%v (tag %q): wanted %s ; got %s", idx, alg, i, tag, want, hash)
+				}
+			}
+		}
+	}
+
+	// the other modes do not hash the index
+	e, _ := NewEngine("sha256", ModeHex)
+	if want, got := string(HasherMap[3].Hash(seed)), string(e.StepAt(seed, 5)); got != want {
+		t.Errorf("FAILED -- [Engine] hex StepAt() mismatch: wanted %s ; got %s", want, got)
+	}
+}
+
+func TestEngineInvalid(t *testing.T) {
+	if _, err := NewEngine("sha3", ""); err == nil {
+		t.Errorf("FAILED -- [Engine] NewEngine() with an invalid algorithm should fail")
+	}
+	if _, err := NewEngine("sha256", "base64"); err == nil {
+		t.Errorf("FAILED -- [Engine] NewEngine() with an invalid chaining mode should fail")
+	}
+}
+
+func TestEngineAllocs(t *testing.T) {
+	for _, mode := range []string{ModeHex, ModeRaw, ModeIndexed} {
+		for idx := 0; idx < len(HasherMapVals); idx++ {
+			e, _ := NewEngine(HasherMapVals[idx], mode)
+			e.SetTag([]byte("domain"))
+			hash := e.Hash([]byte("hashclock"))
+
+			i := 1
+			allocs := testing.AllocsPerRun(100, func() {
+				i++
+				hash = e.StepAt(hash, i)
+			})
+			if allocs != 0 {
+				t.Errorf("#%v -- FAILED -- [Engine] %s (%s) step allocated %v times; expected none", idx, HasherMapVals[idx], mode, allocs)
+			}
+		}
+	}
+
+	// the service's hashing loops do not allocate per step either
+	s := NewService()
+	s.SetWriter(nil)
+	short := testing.AllocsPerRun(10, func() {
+		s.RecHash("hashclock", 10)
+	})
+	long := testing.AllocsPerRun(10, func() {
+		s.RecHash("hashclock", 1000)
+	})
+	if long != short {
+		t.Errorf("FAILED -- [Engine] RecHash allocations grow with the iterations: %v for 10 ; %v for 1000", short, long)
+	}
+}
+
+func benchmarkSteps(b *testing.B, step func([]byte) []byte, first []byte) {
+	hash := first
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hash = step(hash)
+	}
+}
+
+func BenchmarkHasher(b *testing.B) {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		hasher := HasherMap[idx]
+		b.Run(HasherMapVals[idx], func(b *testing.B) {
+			benchmarkSteps(b, hasher.Hash, hasher.Hash([]byte("hashclock")))
+		})
+	}
+}
+
+func BenchmarkEngine(b *testing.B) {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		e, _ := NewEngine(HasherMapVals[idx], ModeHex)
+		b.Run(HasherMapVals[idx], func(b *testing.B) {
+			benchmarkSteps(b, e.Step, e.Hash([]byte("hashclock")))
+		})
+	}
+}
+
+func BenchmarkEngineRaw(b *testing.B) {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		e, _ := NewEngine(HasherMapVals[idx], ModeRaw)
+		b.Run(HasherMapVals[idx], func(b *testing.B) {
+			benchmarkSteps(b, e.Step, e.Hash([]byte("hashclock")))
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package clock
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LogLine struct is a hash logged by `RecHashPrint` or `RecHashLoop`, as read
+// back from their text output (`#<index>:\t<hash>`)
+type LogLine struct {
+	// Line is the line's number in the output, starting at 1
+	Line  int    `json:"line"`
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+// LogLineError struct is returned for the first line of a log which does not
+// belong to the chain
+type LogLineError struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Index  int    `json:"index,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *LogLineError) Error() string {
+	return fmt.Sprintf("invalid line %d (%q): %s", e.Line, e.Text, e.Reason)
+}
+
+// LogReport struct is the result of verifying a log of hashes
+type LogReport struct {
+	Algorithm string        `json:"algorithm"`
+	Lines     int           `json:"lines"`
+	LastIndex int           `json:"last_index"`
+	LastHash  string        `json:"last_hash,omitempty"`
+	Valid     bool          `json:"valid"`
+	Bad       *LogLineError `json:"bad,omitempty"`
+}
+
+// ParseLogLine function parses a line logged by `RecHashPrint` or
+// `RecHashLoop` (`#<index>:\t<hash>`), returning its index and hash
+func ParseLogLine(text string) (int, string, error) {
+	if !strings.HasPrefix(text, "#") {
+		return 0, "", errors.New("expected a '#<index>:\\t<hash>' line")
+	}
+
+	sep := strings.Index(text, ":\t")
+	if sep < 0 {
+		return 0, "", errors.New("expected a '#<index>:\\t<hash>' line")
+	}
+
+	index, err := strconv.Atoi(text[1:sep])
+	if err != nil || index <= 0 {
+		return 0, "", fmt.Errorf("invalid index %q", text[1:sep])
+	}
+
+	hash := text[sep+2:]
+	if err := ValidateHash("", hash); err != nil {
+		return 0, "", err
+	}
+
+	return index, hash, nil
+}
+
+// LogVerifier struct verifies the text output of `RecHashPrint` or
+// `RecHashLoop` against the chain of a seed. The gap between two logged
+// indices is a segment of the chain, starting from the previous logged hash;
+// so segments are verified in parallel, which allows keeping up with a
+// running loop
+type LogVerifier struct {
+	algorithm string
+	seed      string
+	workers   int
+
+	// mode is the chain's chaining mode, and tag its domain tag
+	mode string
+	tag  string
+
+	// OnLine is called with each verified line, in order; if set
+	OnLine func(l LogLine)
+}
+
+// NewLogVerifier function creates a `LogVerifier` for the chain of the input
+// algorithm and seed, with up to `workers` segments verified at the same time
+// (the number of CPUs, if zero or below)
+func NewLogVerifier(alg, seed string, workers int) (*LogVerifier, error) {
+	chain, err := NewChain(alg, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &LogVerifier{
+		algorithm: chain.Algorithm(),
+		seed:      seed,
+		workers:   workers,
+	}, nil
+}
+
+// SetMode method sets the chaining mode (and domain tag) of the logged chain,
+// as in `HashClockService.SetMode`
+func (v *LogVerifier) SetMode(mode, tag string) error {
+	if err := ValidateMode(mode, tag); err != nil {
+		return err
+	}
+	v.mode, v.tag = mode, tag
+	return nil
+}
+
+// logSegment struct is a line to verify, from the previous logged hash; or
+// the line's error, if it cannot be verified
+type logSegment struct {
+	seq  int
+	text string
+	from LogLine
+	to   LogLine
+	err  error
+}
+
+// Verify method reads the log from the input reader, until it is exhausted or
+// the first line which does not belong to the chain. Lines which do not start
+// with a '#' (such as the final response of `RecHashPrint`) are skipped.
+//
+// The returned report covers the lines verified so far, even if the context
+// is cancelled (in which case its error is returned too). The reader is not
+// read any further once `Verify` returns -- though a blocked read (e.g. from a
+// pipe) is not interrupted
+func (v *LogVerifier) Verify(ctx context.Context, r io.Reader) (*LogReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		segments = make(chan *logSegment, v.workers)
+		results  = make(chan *logSegment, v.workers)
+		readErr  error
+		wg       sync.WaitGroup
+	)
+
+	go func() {
+		defer close(segments)
+		readErr = v.read(ctx, r, segments)
+	}()
+
+	for w := 0; w < v.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.walk(ctx, segments, results)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &LogReport{Algorithm: v.algorithm, Valid: true}
+
+	// results are committed in order, as each segment only proves its line
+	// once all the previous lines are verified
+	pending := map[int]*logSegment{}
+	next := 0
+
+	for {
+		var res *logSegment
+
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case r, ok := <-results:
+			if !ok {
+				return report, readErr
+			}
+			res = r
+		}
+
+		pending[res.seq] = res
+
+		for seg, ok := pending[next]; ok; seg, ok = pending[next] {
+			delete(pending, next)
+			next++
+
+			if seg.err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return report, ctxErr
+				}
+
+				report.Valid = false
+				report.Bad = &LogLineError{
+					Line:   seg.to.Line,
+					Text:   seg.text,
+					Index:  seg.to.Index,
+					Reason: seg.err.Error(),
+				}
+				return report, nil
+			}
+
+			report.Lines++
+			report.LastIndex = seg.to.Index
+			report.LastHash = seg.to.Hash
+
+			if v.OnLine != nil {
+				v.OnLine(seg.to)
+			}
+		}
+	}
+}
+
+// read method scans the log from the input reader, sending each line to
+// verify as a segment from the previous logged hash. It stops after the first
+// line which cannot be verified
+func (v *LogVerifier) read(ctx context.Context, r io.Reader, segments chan<- *logSegment) error {
+	scanner := bufio.NewScanner(r)
+
+	prev := LogLine{}
+	seq := 0
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if !strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		seg := &logSegment{seq: seq, text: text, from: prev, to: LogLine{Line: line}}
+		seq++
+
+		index, hash, err := ParseLogLine(text)
+		switch {
+		case err != nil:
+			seg.err = err
+		case index <= prev.Index:
+			seg.to.Index = index
+			seg.err = fmt.Errorf("index %d does not follow the previous index %d", index, prev.Index)
+		default:
+			seg.to.Index = index
+			seg.to.Hash = hash
+			prev = seg.to
+		}
+
+		// the segment belongs to the workers once sent
+		invalid := seg.err != nil
+
+		select {
+		case segments <- seg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if invalid {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// walk method verifies the segments received from the input channel, sending
+// them to the results channel. The segments are verified as walks of a
+// `Walker`, so several of them are hashed at once: while the walker has free
+// lanes, it takes in the segments which are already waiting
+func (v *LogVerifier) walk(ctx context.Context, segments <-chan *logSegment, results chan<- *logSegment) {
+	walker, _ := NewWalker(v.algorithm)
+	walker.SetMode(v.mode, v.tag)
+	walks := map[*Walk]*logSegment{}
+
+	send := func(seg *logSegment) bool {
+		select {
+		case results <- seg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for open := true; open || walker.Len() > 0; {
+		var seg *logSegment
+
+		switch {
+		case open && walker.Len() == 0:
+			seg, open = <-segments
+		case open && walker.Len() < walker.Width():
+			select {
+			case seg, open = <-segments:
+			default:
+			}
+		}
+
+		if seg != nil {
+			if seg.err == nil {
+				walk := &Walk{Seed: v.seed, Iterations: seg.to.Index}
+				if seg.from.Index > 0 {
+					walk.Checkpoint = &Checkpoint{Index: seg.from.Index, Hash: seg.from.Hash}
+				}
+
+				if seg.err = walker.Add(walk); seg.err == nil {
+					walks[walk] = seg
+					continue
+				}
+			}
+
+			if !send(seg) {
+				return
+			}
+			continue
+		}
+
+		if walker.Len() == 0 {
+			continue
+		}
+
+		done, err := walker.Step(ctx)
+		for _, walk := range done {
+			seg := walks[walk]
+			delete(walks, walk)
+
+			switch {
+			case err != nil:
+				seg.err = err
+			case walk.Response.Hash != seg.to.Hash:
+				seg.err = fmt.Errorf("hash does not match the chain at index %d", seg.to.Index)
+			}
+
+			if !send(seg) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package clock
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLog function returns the text output of `RecHashPrint` for the input
+// seed, number of iterations and breakpoint
+func testLog(t *testing.T, seed string, iter, breakpoint int) string {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	s := NewService()
+	s.SetWriter(buf)
+	if _, err := s.RecHashPrint(seed, iter, breakpoint); err != nil {
+		t.Fatalf("FAILED -- [LogVerifier] RecHashPrint() failed: %s", err)
+	}
+	return buf.String()
+}
+
+func TestLogVerifier(t *testing.T) {
+	log := testLog(t, "Hello World!", 500, 7)
+	lines := strings.SplitAfter(log, "\n")
+
+	tests := []struct {
+		name  string
+		log   string
+		valid bool
+		lines int
+		line  int
+	}{
+		{name: "valid", log: log + "\n----\nfinal response\n", valid: true, lines: 71},
+		{name: "forged", log: strings.Join(lines[:40], "") + strings.Replace(lines[40], "#287:", "#288:", 1), lines: 40, line: 41},
+		{name: "reordered", log: lines[1] + lines[0], lines: 1, line: 2},
+		{name: "malformed", log: lines[0] + "#x:\tzz\n", lines: 1, line: 2},
+	}
+
+	for _, test := range tests {
+		v, err := NewLogVerifier("sha256", "Hello World!", 4)
+		if err != nil {
+			t.Fatalf("FAILED -- [LogVerifier] NewLogVerifier() failed: %s", err)
+		}
+
+		var seen int
+		v.OnLine = func(l LogLine) {
+			seen++
+		}
+
+		report, err := v.Verify(context.Background(), strings.NewReader(test.log))
+		if err != nil {
+			t.Fatalf("FAILED -- [LogVerifier] %s: Verify() failed: %s", test.name, err)
+		}
+
+		if report.Valid != test.valid || report.Lines != test.lines || seen != test.lines {
+			t.Errorf("FAILED -- [LogVerifier] %s: unexpected report: %+v ; %v lines seen", test.name, report, seen)
+		}
+		if !test.valid && (report.Bad == nil || report.Bad.Line != test.line) {
+			t.Errorf("FAILED -- [LogVerifier] %s: expected line %v to be invalid: %+v", test.name, test.line, report.Bad)
+		}
+	}
+}
+
+func TestLogVerifierIndexed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := NewService()
+	s.SetWriter(buf)
+	s.SetMode(ModeIndexed, "tag")
+	if _, err := s.RecHashPrint("Hello World!", 500, 7); err != nil {
+		t.Fatalf("FAILED -- [LogVerifier] RecHashPrint() failed: %s", err)
+	}
+
+	tests := []struct {
+		mode  string
+		tag   string
+		valid bool
+	}{
+		{mode: ModeIndexed, tag: "tag", valid: true},
+		{mode: ModeIndexed, tag: "other tag"},
+		{mode: ModeHex},
+	}
+
+	for _, test := range tests {
+		v, _ := NewLogVerifier("sha256", "Hello World!", 4)
+		if err := v.SetMode(test.mode, test.tag); err != nil {
+			t.Fatalf("FAILED -- [LogVerifier] SetMode() failed: %s", err)
+		}
+
+		report, err := v.Verify(context.Background(), bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("FAILED -- [LogVerifier] Verify() failed: %s", err)
+		}
+		if report.Valid != test.valid {
+			t.Errorf("FAILED -- [LogVerifier] %s %q: valid is %v ; expected %v", test.mode, test.tag, report.Valid, test.valid)
+		}
+	}
+}
+
+func TestLogVerifierLive(t *testing.T) {
+	r, w := io.Pipe()
+
+	go func() {
+		s := NewService()
+		s.SetWriter(w)
+		s.RecHashPrint("Hello World!", 3000, 100)
+	}()
+
+	v, _ := NewLogVerifier("sha256", "Hello World!", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	v.OnLine = func(l LogLine) {
+		if l.Index == 3000 {
+			cancel()
+		}
+	}
+
+	report, err := v.Verify(ctx, r)
+	if err != context.Canceled {
+		t.Errorf("FAILED -- [LogVerifier] Verify() should stop with the context: %v", err)
+	}
+	if !report.Valid || report.Lines != 30 || report.LastIndex != 3000 {
+		t.Errorf("FAILED -- [LogVerifier] unexpected report: %+v", report)
+	}
+}
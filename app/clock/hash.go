@@ -1,17 +1,16 @@
 package clock
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"time"
 )
 
 // Hash method takes in a string to hash, returning an execution of the
 // `newHashResponse` method
 func (c *HashClockService) Hash(seed string) (*HashClockResponse, error) {
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
+	if err := ValidateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	c.request.seed = []byte(seed)
@@ -26,7 +25,7 @@ func (c *HashClockService) Hash(seed string) (*HashClockResponse, error) {
 // and build its `HashClockResponse.response` with the hash for the seed string
 func (c *HashClockService) newHashResponse() (*HashClockResponse, error) {
 
-	hash := c.hasher.Hash(c.request.seed)
+	hash := c.first(c.request.seed, 0)
 
 	c.response = &HashClockResponse{
 		Seed:       string(c.request.seed),
@@ -34,6 +33,9 @@ func (c *HashClockService) newHashResponse() (*HashClockResponse, error) {
 		Iterations: c.request.iterations,
 		Hash:       string(hash),
 		Algorithm:  c.request.algorithm,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
 	}
 
 	return c.response, nil
@@ -42,14 +44,16 @@ func (c *HashClockService) newHashResponse() (*HashClockResponse, error) {
 // RecHash method takes in a string to hash and the number of desired iterations,
 // returning an execution of the `newRecHashResponse` method
 func (c *HashClockService) RecHash(seed string, iter int) (*HashClockResponse, error) {
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// zero iterations exception
-	if iter <= 0 {
-		return &HashClockResponse{}, errors.New("number of iterations has to be greater than zero")
+	if err := ValidateIterations(iter); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := c.validateTarget(iter); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	c.request.seed = []byte(seed)
@@ -64,14 +68,26 @@ func (c *HashClockService) RecHash(seed string, iter int) (*HashClockResponse, e
 // and build its `HashClockResponse.response`; by hashing the seed for the number
 // of times defined in the iterations value, and setting them in the response object
 func (c *HashClockService) newRecHashResponse() (*HashClockResponse, error) {
-	var hash []byte
-
-	// recursive SHA256 hash
-	for i := 1; i <= c.request.iterations; i++ {
-		if i == 1 {
-			hash = c.hasher.Hash(c.request.seed)
-		} else {
-			hash = c.hasher.Hash(hash)
+	// recursive SHA256 hash, from the seed, the checkpoint or the nearest
+	// cached checkpoint
+	origin, start := c.resume(c.request.iterations)
+	hash := c.first(origin, start)
+	if c.cache != nil {
+		c.store(start+1, hash)
+	}
+
+	for i := start + 2; i <= c.request.iterations; i++ {
+		if i%c.interval == 0 {
+			c.progress(i-1, hash)
+
+			if err := c.ctx.Err(); err != nil {
+				return &HashClockResponse{}, err
+			}
+		}
+
+		hash = c.engine.StepAt(hash, i)
+		if c.cache != nil {
+			c.store(i, hash)
 		}
 	}
 
@@ -81,6 +97,10 @@ func (c *HashClockService) newRecHashResponse() (*HashClockResponse, error) {
 		Iterations: c.request.iterations,
 		Hash:       string(hash),
 		Algorithm:  c.request.algorithm,
+		Checkpoint: c.checkpoint,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
 	}
 
 	return c.response, nil
@@ -89,14 +109,16 @@ func (c *HashClockService) newRecHashResponse() (*HashClockResponse, error) {
 // RecHashPrint method takes in a string to hash, the number of desired iterations,
 // and a breakpoint value; returning an execution of the `newRecHashResponse` method
 func (c *HashClockService) RecHashPrint(seed string, iter int, breakpoint int) (*HashClockResponse, error) {
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// zero iterations exception
-	if iter <= 0 {
-		return &HashClockResponse{}, errors.New("number of iterations has to be greater than zero")
+	if err := ValidateIterations(iter); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := c.validateTarget(iter); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	// negative breakpoint exception
@@ -122,21 +144,36 @@ func (c *HashClockService) RecHashPrint(seed string, iter int, breakpoint int) (
 // of times defined in the iterations value, and setting them in the response object.
 //
 // During execution, if the counter modulo breakpoint is zero (counter % breakpoint == 0),
-// the hash is printed to std-out.
+// the hash is printed to the service's writer (std-out, by default), and passed to
+// the service's tick function (if set).
 func (c *HashClockService) newRecHashPrintResponse() (*HashClockResponse, error) {
-	var hash []byte
-
-	// recursive SHA256 hash
-	for i := 1; i <= c.request.iterations; i++ {
-		if i == 1 {
-			hash = c.hasher.Hash(c.request.seed)
-		} else {
-			hash = c.hasher.Hash(hash)
+	// recursive SHA256 hash, from the seed or the checkpoint
+	origin, start := c.origin()
+	hash := c.first(origin, start)
+	if c.cache != nil {
+		c.store(start+1, hash)
+	}
+	if (start+1)%c.request.breakpoint == 0 {
+		c.logBreakpoint(start+1, hash)
+	}
+
+	for i := start + 2; i <= c.request.iterations; i++ {
+		if i%c.interval == 0 {
+			c.progress(i-1, hash)
+
+			if err := c.ctx.Err(); err != nil {
+				return &HashClockResponse{}, err
+			}
+		}
+
+		hash = c.engine.StepAt(hash, i)
+		if c.cache != nil {
+			c.store(i, hash)
 		}
 
 		// breakpoint logging
 		if i%c.request.breakpoint == 0 {
-			fmt.Printf("#%v:\t%s\n", i, string(hash))
+			c.logBreakpoint(i, hash)
 		}
 	}
 
@@ -146,6 +183,10 @@ func (c *HashClockService) newRecHashPrintResponse() (*HashClockResponse, error)
 		Iterations: c.request.iterations,
 		Hash:       string(hash),
 		Algorithm:  c.request.algorithm,
+		Checkpoint: c.checkpoint,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
 	}
 
 	return c.response, nil
@@ -155,17 +196,18 @@ func (c *HashClockService) newRecHashPrintResponse() (*HashClockResponse, error)
 // (or until the program is halted) while printing out its hashes.
 //
 // During execution, if the counter modulo breakpoint is zero (counter % breakpoint == 0),
-// the hash is printed to std-out.
+// the hash is printed to the service's writer (std-out, by default), and passed to
+// the service's tick function (if set).
 //
 // This means that a breakpoint of 1 prints every hash, while a breakpoint of 5 prints
 // every 5th hash.
 //
 // Does not return a response object since it will be an infinite loop until the program
-// is interrupted and/or killed; only an error in case the input values are invalid
+// is interrupted and/or killed, or the service's context is cancelled; only an error in
+// case the input values are invalid, or the context's error
 func (c *HashClockService) RecHashLoop(seed string, breakpoint int) error {
-	// empty string exception
-	if seed == "" {
-		return errors.New("seed cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return err
 	}
 
 	// negative breakpoint exception
@@ -178,17 +220,28 @@ func (c *HashClockService) RecHashLoop(seed string, breakpoint int) error {
 	c.request.breakpoint = breakpoint
 	c.request.timeout = 0
 
-	hash := c.hasher.Hash([]byte(seed))
-
-	var counter int = 1
+	origin, counter := c.origin()
+	hash := c.first(origin, counter)
+	counter++
 
 	for {
-		hash = c.hasher.Hash(hash)
 		counter++
+		hash = c.engine.StepAt(hash, counter)
+		if c.cache != nil {
+			c.store(counter, hash)
+		}
+
+		if counter%c.interval == 0 {
+			c.progress(counter, hash)
+
+			if err := c.ctx.Err(); err != nil {
+				return err
+			}
+		}
 
 		// breakpoint logging
 		if counter%breakpoint == 0 {
-			fmt.Printf("#%v:\t%s\n", counter, string(hash))
+			c.logBreakpoint(counter, hash)
 		}
 	}
 }
@@ -196,14 +249,12 @@ func (c *HashClockService) RecHashLoop(seed string, breakpoint int) error {
 // RecHashTimeout method will take in a seed string and a timeout value (in seconds),
 // returning an execution of the `newRecHashTimeResponse` method
 func (c *HashClockService) RecHashTimeout(seed string, timeout int) (*HashClockResponse, error) {
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// empty timeout exception
-	if timeout <= 0 {
-		return &HashClockResponse{}, errors.New("timeout cannot be zero or below")
+	if err := ValidateTimeout(timeout); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	c.request.seed = []byte(seed)
@@ -216,54 +267,55 @@ func (c *HashClockService) RecHashTimeout(seed string, timeout int) (*HashClockR
 
 // newRecHashTimeResponse method will parse the `HashClockService.request` object
 // and build its `HashClockResponse.response`; by continuously hashing the seed string
-// in a goroutine limited by a timer (in seconds).
+// until the timer (in seconds) runs out.
 //
-// Once the timer runs out, a created `done` channel interrupts the goroutine. The
-// calculated hash and number of iterations are parsed into the `HashClockResponse.response`
-// object
+// The timer is checked every `checkInterval` hashes. Once it runs out, the calculated
+// hash and number of iterations are parsed into the `HashClockResponse.response`
+// object. If the service's context is cancelled before that, its error is returned
 func (c *HashClockService) newRecHashTimeoutResponse() (*HashClockResponse, error) {
 	r := &HashClockResponse{
-		Seed:      string(c.request.seed),
-		Timeout:   c.request.timeout,
-		Algorithm: c.request.algorithm,
-	}
-
-	// recursive conversions are done with byte arrays
-	// to preserve performance, instead of constantly
-	// converting to string
-	type timestamp struct {
-		hash []byte
-		id   int
+		Seed:       string(c.request.seed),
+		Timeout:    c.request.timeout,
+		Algorithm:  c.request.algorithm,
+		Checkpoint: c.checkpoint,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
 	}
 
-	ts := timestamp{}
-	done := make(chan bool)
+	ctx, cancel := context.WithTimeout(c.ctx, time.Second*time.Duration(c.request.timeout))
+	defer cancel()
 
 	// recursively calculate hashes until timer is up
-	go func() {
-
-		ts.hash = c.hasher.Hash(c.request.seed)
-		ts.id = 1
-
-		for {
-			select {
-			case <-done:
-				return
-			default:
-				ts.hash = c.hasher.Hash(ts.hash)
-				ts.id++
+	origin, id := c.origin()
+	hash := c.first(origin, id)
+	id++
+
+	for {
+		if id%c.interval == 0 {
+			c.progress(id, hash)
+
+			if ctx.Err() != nil {
+				break
 			}
 		}
+		id++
+		hash = c.engine.StepAt(hash, id)
 
-	}()
+		if c.cache != nil {
+			c.store(id, hash)
+		}
+	}
 
-	// kick off timer; then send done signal to goroutine
-	time.Sleep(time.Second * time.Duration(c.request.timeout))
-	done <- true
+	// the parent context was cancelled, not the timer
+	if err := c.ctx.Err(); err != nil {
+		return &HashClockResponse{}, err
+	}
 
 	// get calculated hash and number of iterations
-	r.Hash = string(ts.hash)
-	r.Iterations = ts.id
+	r.Hash = string(hash)
+	r.Iterations = id
 
+	c.response = r
 	return r, nil
 }
@@ -1,7 +1,14 @@
 package clock
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
 	"testing"
+	"time"
 )
 
 type testCase struct {
@@ -395,6 +402,223 @@ func TestRecHashLoop(t *testing.T) {
 	}
 }
 
+func TestRecHashLoopContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	out := &bytes.Buffer{}
+
+	clock := NewService()
+	if err := clock.SetContext(ctx); err != nil {
+		t.Fatalf("[HashClockService] SetContext() resulted in an unexpected error: %s", err)
+	}
+	clock.SetWriter(out)
+
+	err := clock.RecHashLoop(testCases[4].seed, testCases[4].iterations)
+	if err != context.DeadlineExceeded {
+		t.Errorf(
+			"[HashClockService] RecHashLoop(%s, %v) = %v ; expected %v",
+			testCases[4].seed,
+			testCases[4].iterations,
+			err,
+			context.DeadlineExceeded,
+		)
+	}
+
+	first := "#10:\t" + testCases[4].hash + "\n"
+	if !strings.HasPrefix(out.String(), first) {
+		t.Errorf(
+			"[HashClockService] RecHashLoop(%s, %v) wrote %q ; expected it to start with %q",
+			testCases[4].seed,
+			testCases[4].iterations,
+			out.String()[:len(first)],
+			first,
+		)
+	}
+
+	if err := clock.SetContext(nil); err == nil {
+		t.Errorf("[HashClockService] SetContext(nil) was expected to fail")
+	}
+}
+
+func TestSetProgressFunc(t *testing.T) {
+	var ticks []Tick
+
+	clock := NewService()
+	clock.SetProgressFunc(func(tick Tick) {
+		ticks = append(ticks, tick)
+	})
+
+	res, err := clock.RecHash(testCases[4].seed, checkInterval*3)
+	if err != nil {
+		t.Fatalf("[HashClockService] RecHash() resulted in an unexpected error: %s", err)
+	}
+
+	if len(ticks) != 3 {
+		t.Fatalf("[HashClockService] RecHash(%s, %v) reported progress %v times ; expected %v", testCases[4].seed, checkInterval*3, len(ticks), 3)
+	}
+
+	for _, tick := range ticks {
+		v, err := NewService().VerifyIndex(testCases[4].seed, tick.Hash, tick.Index)
+		if err != nil || !v.Match {
+			t.Errorf("[HashClockService] progress #%v: %s is not the hash at its index", tick.Index, tick.Hash)
+		}
+	}
+
+	if last := ticks[len(ticks)-1]; last.Index != res.Iterations-1 {
+		t.Errorf("[HashClockService] last progress index = %v ; expected %v", last.Index, res.Iterations-1)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	// continuing "Hello World!" from index 2
+	clock := NewService()
+	if err := clock.SetCheckpoint(testCases[2].iterations, testCases[2].hash); err != nil {
+		t.Fatalf("[HashClockService] SetCheckpoint() resulted in an unexpected error: %s", err)
+	}
+
+	res, err := clock.RecHash("", testCases[4].iterations)
+	if err != nil || res.Hash != testCases[4].hash || res.Iterations != testCases[4].iterations {
+		t.Errorf("[HashClockService] RecHash() from a checkpoint = %+v, %v ; expected the hash at index %v", res, err, testCases[4].iterations)
+	}
+	if res != nil && (res.Checkpoint == nil || res.Checkpoint.Index != testCases[2].iterations) {
+		t.Errorf("[HashClockService] RecHash() response does not report the checkpoint: %+v", res)
+	}
+
+	out := &bytes.Buffer{}
+	clock.SetWriter(out)
+	if _, err := clock.RecHashPrint("", 4, 1); err != nil || !strings.HasPrefix(out.String(), "#3:\t") {
+		t.Errorf("[HashClockService] RecHashPrint() from a checkpoint logged %q, %v ; expected absolute indices from #3", out.String(), err)
+	}
+
+	v, err := clock.Verify("", testCases[4].hash)
+	if err != nil || !v.Match || v.Iterations != testCases[4].iterations {
+		t.Errorf("[HashClockService] Verify() from a checkpoint = %+v, %v ; expected a match at index %v", v, err, testCases[4].iterations)
+	}
+
+	v, err = clock.VerifyIndexTimeout("", testCases[4].hash, testCases[4].iterations, 1)
+	if err != nil || !v.Match {
+		t.Errorf("[HashClockService] VerifyIndexTimeout() from a checkpoint = %+v, %v ; expected a match", v, err)
+	}
+
+	// the checkpoint is the anchor: earlier indices cannot be verified
+	if _, err := clock.VerifyIndex("", testCases[0].hash, 1); err == nil {
+		t.Errorf("[HashClockService] VerifyIndex() before the checkpoint was expected to fail")
+	}
+	if _, err := clock.RecHash("", 2); err == nil {
+		t.Errorf("[HashClockService] RecHash() up to the checkpoint was expected to fail")
+	}
+
+	if err := clock.SetCheckpoint(5, "abcd"); err == nil {
+		t.Errorf("[HashClockService] SetCheckpoint() with a short hash was expected to fail")
+	}
+
+	// unsetting the checkpoint requires a seed again
+	clock.SetCheckpoint(0, "")
+	if _, err := clock.RecHash("", 10); err == nil {
+		t.Errorf("[HashClockService] RecHash() without a seed or checkpoint was expected to fail")
+	}
+}
+
+// indexedChain function returns the first n hashes of the input seed's chain
+// in the indexed chaining mode, calculated with SHA256 from its definition
+func indexedChain(seed, tag string, n int) []string {
+	hashes := make([]string, n+1)
+	prev := []byte(seed)
+	for i := 1; i <= n; i++ {
+		ctr := make([]byte, 8)
+		binary.BigEndian.PutUint64(ctr, uint64(i))
+
+		data := append([]byte{}, prev...)
+		data = append(data, ctr...)
+		data = append(data, tag...)
+
+		sum := sha256.Sum256(data)
+		hashes[i] = hex.EncodeToString(sum[:])
+		prev = []byte(hashes[i])
+	}
+	return hashes
+}
+
+func TestIndexedMode(t *testing.T) {
+	const seed, tag = "Hello World!", "hashclock/test"
+	hashes := indexedChain(seed, tag, 2100)
+
+	clock := NewService()
+	clock.SetWriter(nil)
+	if err := clock.SetMode(ModeIndexed, tag); err != nil {
+		t.Fatalf("[HashClockService] SetMode() resulted in an unexpected error: %s", err)
+	}
+
+	res, err := clock.Hash(seed)
+	if err != nil || res.Hash != hashes[1] {
+		t.Errorf("[HashClockService] Hash() in the indexed mode = %+v, %v ; expected %s", res, err, hashes[1])
+	}
+
+	res, err = clock.RecHash(seed, 2100)
+	if err != nil || res.Hash != hashes[2100] {
+		t.Fatalf("[HashClockService] RecHash() in the indexed mode = %+v, %v ; expected %s", res, err, hashes[2100])
+	}
+	if res.Mode != ModeIndexed || res.Tag != tag {
+		t.Errorf("[HashClockService] RecHash() response does not report the mode: %+v", res)
+	}
+
+	out := &bytes.Buffer{}
+	clock.SetWriter(out)
+	if _, err := clock.RecHashPrint(seed, 3, 3); err != nil || out.String() != "#3:\t"+hashes[3]+"\n" {
+		t.Errorf("[HashClockService] RecHashPrint() in the indexed mode logged %q, %v ; expected %s", out.String(), err, hashes[3])
+	}
+	clock.SetWriter(nil)
+
+	// every verification method walks the same chain
+	for _, verify := range []func() (*HashClockResponse, error){
+		func() (*HashClockResponse, error) { return clock.Verify(seed, hashes[2000]) },
+		func() (*HashClockResponse, error) { return clock.VerifyTimeout(seed, hashes[2000], 5) },
+		func() (*HashClockResponse, error) { return clock.VerifyIndex(seed, hashes[2000], 2000) },
+		func() (*HashClockResponse, error) { return clock.VerifyIndexTimeout(seed, hashes[2000], 2000, 5) },
+	} {
+		v, err := verify()
+		if err != nil || !v.Match || v.Iterations != 2000 || v.Mode != ModeIndexed {
+			t.Errorf("[HashClockService] verification in the indexed mode = %+v, %v ; expected a match at index 2000", v, err)
+		}
+	}
+
+	// a hash is only valid at its own index, and in its own domain
+	if v, _ := clock.VerifyIndex(seed, hashes[1999], 2000); v.Match {
+		t.Errorf("[HashClockService] VerifyIndex() matched a hash at another index")
+	}
+	clock.SetMode(ModeIndexed, "other")
+	if v, _ := clock.VerifyIndex(seed, hashes[2000], 2000); v.Match {
+		t.Errorf("[HashClockService] VerifyIndex() matched a hash of another domain")
+	}
+	clock.SetMode(ModeIndexed, tag)
+
+	// the mode is kept when the hash function changes
+	clock.SetHasher("sha512")
+	clock.SetHasher("sha256")
+	if err := clock.SetCheckpoint(1000, hashes[1000]); err != nil {
+		t.Fatalf("[HashClockService] SetCheckpoint() resulted in an unexpected error: %s", err)
+	}
+	if res, err := clock.RecHash("", 1002); err != nil || res.Hash != hashes[1002] {
+		t.Errorf("[HashClockService] RecHash() from a checkpoint in the indexed mode = %+v, %v ; expected %s", res, err, hashes[1002])
+	}
+	clock.SetCheckpoint(0, "")
+
+	// back to the default mode, which is not reported
+	if err := clock.SetMode("", ""); err != nil {
+		t.Fatalf("[HashClockService] SetMode() resulted in an unexpected error: %s", err)
+	}
+	if res, _ := clock.RecHash(seed, testCases[2].iterations); res.Hash != testCases[2].hash || res.Mode != "" || res.Tag != "" {
+		t.Errorf("[HashClockService] RecHash() in the default mode = %+v ; expected %s", res, testCases[2].hash)
+	}
+
+	for _, m := range [][2]string{{ModeHex, tag}, {ModeRaw, ""}, {"base64", ""}} {
+		if err := clock.SetMode(m[0], m[1]); err == nil {
+			t.Errorf("[HashClockService] SetMode(%q, %q) was expected to fail", m[0], m[1])
+		}
+	}
+}
+
 func TestRecHashTimeout(t *testing.T) {
 
 	tests := []struct {
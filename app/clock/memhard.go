@@ -0,0 +1,155 @@
+package clock
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+const (
+	// MemoryHardAlgorithm is the name of the memory-hard step function, in
+	// `HasherMapVals`
+	MemoryHardAlgorithm string = "MEMHARD"
+
+	// DefaultMemory and DefaultCost are the parameters of the memory-hard step
+	// function selected with `SetHasher`: a scratchpad of 64 KiB, with as many
+	// reads as it has entries (as in scrypt's ROMix)
+	DefaultMemory int = 64
+	DefaultCost   int = DefaultMemory * 1024 / sha256.Size
+
+	// maxMemory is the largest scratchpad, in KiB (1 GiB); and maxCost the
+	// largest number of reads, one per entry of the largest scratchpad
+	maxMemory int = 1 << 20
+	maxCost   int = maxMemory * 1024 / sha256.Size
+)
+
+// MemoryHard struct is the configuration of the memory-hard step function,
+// for ASIC-resistance experiments. Each step hashes its input with SHA256, and
+// then runs scrypt's ROMix on that digest:
+//
+// - the scratchpad is filled with a SHA256 chain of the digest, one 32-byte
+// entry per hash, until it is `Memory` KiB long
+//
+// - `Cost` times, the current value is XOR'ed with the entry which its first
+// 8 bytes (little-endian, modulo the number of entries) point to, and hashed
+//
+// The output is the last value, a 32-byte digest; so every step needs the
+// whole scratchpad in memory, and costs `Memory*32 + Cost` SHA256 hashes.
+//
+// MemoryHard implements `rhash.Hasher`, returning the hex-encoded digest
+type MemoryHard struct {
+	Memory int `json:"memory"`
+	Cost   int `json:"cost"`
+}
+
+// Validate method checks the memory-hard function's parameters
+func (m MemoryHard) Validate() error {
+	if m.Memory <= 0 || m.Memory > maxMemory {
+		return fmt.Errorf("memory must be between 1 and %d KiB", maxMemory)
+	}
+	if m.Cost <= 0 || m.Cost > maxCost {
+		return fmt.Errorf("cost must be between 1 and %d", maxCost)
+	}
+	return nil
+}
+
+// String method returns the name of the memory-hard function and its
+// parameters, e.g. `MEMHARD(memory=64,cost=2048)`
+func (m MemoryHard) String() string {
+	return fmt.Sprintf("%s(memory=%d,cost=%d)", MemoryHardAlgorithm, m.Memory, m.Cost)
+}
+
+// Hash method returns the (hex-encoded) digest of the input data
+func (m MemoryHard) Hash(data []byte) []byte {
+	h := m.New()
+	h.Write(data)
+
+	out := make([]byte, hex.EncodedLen(sha256.Size))
+	hex.Encode(out, h.Sum(nil))
+	return out
+}
+
+// New method returns a `hash.Hash` calculating the memory-hard function, with
+// its own scratchpad; for an `Engine`
+func (m MemoryHard) New() hash.Hash {
+	return &memoryHard{
+		h:    sha256.New(),
+		pad:  make([][sha256.Size]byte, m.Memory*1024/sha256.Size),
+		cost: m.Cost,
+	}
+}
+
+// memoryHard struct is the `hash.Hash` of the memory-hard function
+type memoryHard struct {
+	h    hash.Hash
+	pad  [][sha256.Size]byte
+	cost int
+	buf  [sha256.Size]byte
+}
+
+func (m *memoryHard) Write(p []byte) (int, error) {
+	return m.h.Write(p)
+}
+
+func (m *memoryHard) Sum(b []byte) []byte {
+	m.h.Sum(m.buf[:0])
+	x := sha256.Sum256(m.buf[:])
+
+	n := uint64(len(m.pad))
+	for i := range m.pad {
+		m.pad[i] = x
+		x = sha256.Sum256(x[:])
+	}
+
+	for k := 0; k < m.cost; k++ {
+		v := &m.pad[binary.LittleEndian.Uint64(x[:8])%n]
+		for i := range x {
+			m.buf[i] = x[i] ^ v[i]
+		}
+		x = sha256.Sum256(m.buf[:])
+	}
+
+	return append(b, x[:]...)
+}
+
+func (m *memoryHard) Reset() {
+	m.h.Reset()
+}
+
+func (m *memoryHard) Size() int {
+	return sha256.Size
+}
+
+func (m *memoryHard) BlockSize() int {
+	return m.h.BlockSize()
+}
+
+// SetMemoryHard method selects the memory-hard step function (see
+// `MemoryHard`) with the input scratchpad size (in KiB) and cost, as
+// `SetHasher` does for the other algorithms. `SetHasher(MemoryHardAlgorithm)`
+// selects it with the default parameters
+func (s *HashClockService) SetMemoryHard(memory, cost int) error {
+	m := MemoryHard{Memory: memory, Cost: cost}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	s.setMemoryHard(m)
+	return nil
+}
+
+// setMemoryHard method sets the memory-hard step function with the input
+// parameters as the service's hasher
+func (s *HashClockService) setMemoryHard(m MemoryHard) {
+	s.hasher = m
+	s.engine = engineFor(m.New(), ModeHex)
+	s.setEngineMode()
+	s.memoryHard = &m
+	s.request.algorithm = MemoryHardAlgorithm
+
+	// each step is thousands of hashes, so the context is checked on every
+	// step
+	s.interval = 1
+}
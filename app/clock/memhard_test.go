@@ -0,0 +1,143 @@
+package clock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+// romix function is a plain reference of the memory-hard function, for the
+// input data and parameters
+func romix(data []byte, memory, cost int) string {
+	x := sha256.Sum256(data)
+	x = sha256.Sum256(x[:])
+
+	pad := make([][32]byte, memory*1024/32)
+	for i := range pad {
+		pad[i] = x
+		x = sha256.Sum256(x[:])
+	}
+
+	for k := 0; k < cost; k++ {
+		j := binary.LittleEndian.Uint64(x[:8]) % uint64(len(pad))
+		var buf [32]byte
+		for i := range buf {
+			buf[i] = x[i] ^ pad[j][i]
+		}
+		x = sha256.Sum256(buf[:])
+	}
+	return hex.EncodeToString(x[:])
+}
+
+func TestMemoryHard(t *testing.T) {
+	seed := "hashclock"
+
+	for _, m := range []MemoryHard{{Memory: 1, Cost: 1}, {Memory: 4, Cost: 300}, {Memory: DefaultMemory, Cost: DefaultCost}} {
+		want := romix([]byte(seed), m.Memory, m.Cost)
+		want = romix([]byte(want), m.Memory, m.Cost)
+
+		s := NewService()
+		if err := s.SetMemoryHard(m.Memory, m.Cost); err != nil {
+			t.Fatalf("FAILED -- [MemoryHard] SetMemoryHard(%d, %d) failed: %s", m.Memory, m.Cost, err)
+		}
+
+		r, err := s.RecHash(seed, 2)
+		if err != nil {
+			t.Fatalf("FAILED -- [MemoryHard] RecHash() failed: %s", err)
+		}
+		if r.Hash != want || r.Algorithm != MemoryHardAlgorithm || r.MemoryHard == nil || *r.MemoryHard != m {
+			t.Errorf("FAILED -- [MemoryHard] %s: unexpected response: %+v ; wanted hash %s", m, r, want)
+		}
+		if got := string(m.Hash(m.Hash([]byte(seed)))); got != want {
+			t.Errorf("FAILED -- [MemoryHard] %s: Hash() mismatch: wanted %s ; got %s", m, want, got)
+		}
+	}
+
+	// SetHasher selects the default parameters
+	s := NewService()
+	if err := s.SetHasher("memhard"); err != nil {
+		t.Fatalf("FAILED -- [MemoryHard] SetHasher() failed: %s", err)
+	}
+	r, _ := s.Hash(seed)
+	if want := romix([]byte(seed), DefaultMemory, DefaultCost); r.Hash != want {
+		t.Errorf("FAILED -- [MemoryHard] default parameters mismatch: wanted %s ; got %s", want, r.Hash)
+	}
+
+	// other hashers unset it
+	s.SetHasher("sha256")
+	if r, _ := s.Hash(seed); r.MemoryHard != nil {
+		t.Errorf("FAILED -- [MemoryHard] SetHasher() should unset the memory-hard function: %+v", r)
+	}
+}
+
+func TestMemoryHardVerify(t *testing.T) {
+	s := NewService()
+	s.SetMemoryHard(2, 100)
+
+	r, _ := s.RecHash("hashclock", 20)
+	cp, _ := s.RecHash("hashclock", 5)
+
+	checks := map[string]func() (*HashClockResponse, error){
+		"Verify":             func() (*HashClockResponse, error) { return s.Verify("hashclock", r.Hash) },
+		"VerifyIndex":        func() (*HashClockResponse, error) { return s.VerifyIndex("hashclock", r.Hash, 20) },
+		"VerifyTimeout":      func() (*HashClockResponse, error) { return s.VerifyTimeout("hashclock", r.Hash, 10) },
+		"VerifyIndexTimeout": func() (*HashClockResponse, error) { return s.VerifyIndexTimeout("hashclock", r.Hash, 20, 10) },
+	}
+	for name, fn := range checks {
+		res, err := fn()
+		if err != nil || !res.Match || res.Iterations != 20 || res.MemoryHard == nil {
+			t.Errorf("FAILED -- [MemoryHard] %s: unexpected response: %+v ; %v", name, res, err)
+		}
+	}
+
+	// from a checkpoint
+	if err := s.SetCheckpoint(5, cp.Hash); err != nil {
+		t.Fatalf("FAILED -- [MemoryHard] SetCheckpoint() failed: %s", err)
+	}
+	if res, err := s.VerifyIndex("", r.Hash, 20); err != nil || !res.Match {
+		t.Errorf("FAILED -- [MemoryHard] VerifyIndex() from a checkpoint: %+v ; %v", res, err)
+	}
+
+	// other parameters are another chain
+	s = NewService()
+	s.SetMemoryHard(2, 101)
+	if res, _ := s.VerifyIndex("hashclock", r.Hash, 20); res.Match {
+		t.Errorf("FAILED -- [MemoryHard] VerifyIndex() should not match with other parameters")
+	}
+}
+
+func TestMemoryHardInvalid(t *testing.T) {
+	s := NewService()
+	for _, m := range []MemoryHard{{Memory: 0, Cost: 1}, {Memory: maxMemory + 1, Cost: 1}, {Memory: 1, Cost: 0}, {Memory: 1, Cost: maxCost + 1}} {
+		if err := s.SetMemoryHard(m.Memory, m.Cost); err == nil {
+			t.Errorf("FAILED -- [MemoryHard] SetMemoryHard(%d, %d) should fail", m.Memory, m.Cost)
+		}
+	}
+}
+
+func TestMemoryHardCancel(t *testing.T) {
+	// a step takes long enough to check the context on every one
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	s := NewService()
+	s.SetContext(ctx)
+	s.SetMemoryHard(1024, 1<<15)
+
+	start := time.Now()
+	if _, err := s.Verify("hashclock", strings.Repeat("ab", 32)); err != context.DeadlineExceeded {
+		t.Errorf("FAILED -- [MemoryHard] Verify() should return the context's error; got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("FAILED -- [MemoryHard] Verify() took %v to return after its context expired", elapsed)
+	}
+}
+
+func BenchmarkMemoryHard(b *testing.B) {
+	e := engineFor(MemoryHard{Memory: DefaultMemory, Cost: DefaultCost}.New(), ModeHex)
+	benchmarkSteps(b, e.Step, e.Hash([]byte("hashclock")))
+}
@@ -0,0 +1,226 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Prefix struct is the target of a prefix search (see `SearchPrefix`): either
+// a hex-encoded prefix of the hash, of any number of characters; or a number
+// of leading zero bits of its digest
+type Prefix struct {
+	Hex  string `json:"hex,omitempty"`
+	Bits int    `json:"bits,omitempty"`
+}
+
+// ValidatePrefix function checks the input prefix search target, for a chain
+// of the input algorithm (SHA256, if empty): exactly one of its hex prefix or
+// number of bits must be set, and it cannot be longer than the digest
+func ValidatePrefix(alg string, p Prefix) error {
+	if alg == "" {
+		alg = HasherMapVals[3]
+	}
+
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		if alg == HasherMapVals[idx] || alg == strings.ToLower(HasherMapVals[idx]) {
+			return p.validate(hexSize(idx))
+		}
+	}
+	return errors.New("invalid hasher reference")
+}
+
+// validate method checks the prefix, for a (hex-encoded) digest of the input
+// length
+func (p Prefix) validate(size int) error {
+	switch {
+	case p.Hex == "" && p.Bits == 0:
+		return errors.New("either a hex prefix or a number of leading zero bits is required")
+	case p.Hex != "" && p.Bits != 0:
+		return errors.New("only one of a hex prefix or a number of leading zero bits can be set")
+	case p.Bits < 0 || p.Bits > size*4:
+		return fmt.Errorf("number of leading zero bits must be between 1 and %d", size*4)
+	case len(p.Hex) > size:
+		return fmt.Errorf("hex prefix length %d is longer than the digest length %d", len(p.Hex), size)
+	}
+
+	for idx := 0; idx < len(p.Hex); idx++ {
+		if nibble(p.Hex[idx]) < 0 {
+			return fmt.Errorf("hex prefix has an invalid character %q at position %d", p.Hex[idx], idx)
+		}
+	}
+	return nil
+}
+
+// ExpectedWork method returns the expected number of hashes to calculate
+// until one matches the prefix: 16 for each hex character, or 2 for each bit
+func (p Prefix) ExpectedWork() float64 {
+	if p.Hex != "" {
+		return math.Pow(16, float64(len(p.Hex)))
+	}
+	return math.Ldexp(1, p.Bits)
+}
+
+// match method returns whether the input (hex-encoded) hash starts with the
+// prefix. Hashes are lower-case, so the hex prefix must be too
+func (p Prefix) match(hash []byte) bool {
+	if p.Hex != "" {
+		return len(hash) >= len(p.Hex) && string(hash[:len(p.Hex)]) == p.Hex
+	}
+
+	// each leading '0' character is 4 zero bits; and the next one must be
+	// low enough for the remainder
+	full, rem := p.Bits/4, p.Bits%4
+	for idx := 0; idx < full; idx++ {
+		if hash[idx] != '0' {
+			return false
+		}
+	}
+	return rem == 0 || nibble(hash[full]) < 1<<(4-rem)
+}
+
+// nibble function returns the value of the input hex character; or -1 if it
+// is not one
+func nibble(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// SearchPrefix method will take in a seed string and a prefix search target,
+// returning an execution of the `newSearchPrefixResponse` method
+func (c *HashClockService) SearchPrefix(seed string, p Prefix) (*HashClockResponse, error) {
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := p.validate(c.engine.Size()); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	c.request.seed = []byte(seed)
+	c.request.iterations = 0
+	c.request.breakpoint = 0
+	c.request.timeout = 0
+	c.request.prefix = Prefix{Hex: strings.ToLower(p.Hex), Bits: p.Bits}
+
+	return c.newSearchPrefixResponse()
+}
+
+// newSearchPrefixResponse method will parse the `HashClockService.request`
+// object and build its `HashClockResponse.response`; by recursively hashing
+// the seed until a hash starts with the prefix.
+//
+// Like `Verify`, this operation only stops with a match -- or when the
+// service's context is cancelled
+func (c *HashClockService) newSearchPrefixResponse() (*HashClockResponse, error) {
+	return c.searchPrefix(c.ctx)
+}
+
+// SearchPrefixTimeout method will take in a seed string, a prefix search
+// target and a timeout value, returning an execution of the
+// `newSearchPrefixTimeoutResponse` method
+func (c *HashClockService) SearchPrefixTimeout(seed string, p Prefix, timeout int) (*HashClockResponse, error) {
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := p.validate(c.engine.Size()); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := ValidateTimeout(timeout); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	c.request.seed = []byte(seed)
+	c.request.iterations = 0
+	c.request.breakpoint = 0
+	c.request.timeout = timeout
+	c.request.prefix = Prefix{Hex: strings.ToLower(p.Hex), Bits: p.Bits}
+
+	return c.newSearchPrefixTimeoutResponse()
+}
+
+// newSearchPrefixTimeoutResponse method will parse the
+// `HashClockService.request` object and build its
+// `HashClockResponse.response`; by recursively hashing the seed until a hash
+// starts with the prefix, or the timer is up.
+//
+// The timer is checked every `checkInterval` hashes. If it runs out before a
+// match, the response contains the last calculated hash and its index, with
+// no match. If the service's context is cancelled before that, its error is
+// returned
+func (c *HashClockService) newSearchPrefixTimeoutResponse() (*HashClockResponse, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, time.Second*time.Duration(c.request.timeout))
+	defer cancel()
+
+	return c.searchPrefix(ctx)
+}
+
+// searchPrefix method walks the service's chain until a hash starts with the
+// requested prefix, or the input context is done
+func (c *HashClockService) searchPrefix(ctx context.Context) (*HashClockResponse, error) {
+	// timestamp is recorded when function is first called
+	timestamp := time.Now()
+
+	prefix := c.request.prefix
+	c.response = &HashClockResponse{
+		Seed:         string(c.request.seed),
+		Timeout:      c.request.timeout,
+		Algorithm:    c.request.algorithm,
+		Checkpoint:   c.checkpoint,
+		MemoryHard:   c.memoryHard,
+		Mode:         c.mode,
+		Tag:          c.tag,
+		Prefix:       &prefix,
+		ExpectedWork: prefix.ExpectedWork(),
+	}
+
+	origin, id := c.origin()
+	hash := c.first(origin, id)
+	id++
+
+	for !prefix.match(hash) {
+		if id%c.interval == 0 {
+			c.progress(id, hash)
+
+			if ctx.Err() != nil {
+				// the parent context was cancelled, not the timer
+				if err := c.ctx.Err(); err != nil {
+					return &HashClockResponse{}, err
+				}
+
+				c.response.Iterations = id
+				c.response.Hash = string(hash)
+				c.response.Match = false
+				c.response.Duration = time.Since(timestamp)
+
+				return c.response, nil
+			}
+		}
+
+		id++
+		hash = c.engine.StepAt(hash, id)
+
+		if c.cache != nil {
+			c.store(id, hash)
+		}
+	}
+
+	c.response.Iterations = id
+	c.response.Hash = string(hash)
+	c.response.Match = true
+	c.response.Duration = time.Since(timestamp)
+
+	return c.response, nil
+}
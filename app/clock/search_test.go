@@ -0,0 +1,124 @@
+package clock
+
+import (
+	"strings"
+	"testing"
+)
+
+// firstMatch function returns the index and hash of the first hash of the
+// input seed's SHA256 chain which matches the input function
+func firstMatch(seed string, match func(hash string) bool) (int, string) {
+	hash := HasherMap[3].Hash([]byte(seed))
+	for i := 1; ; i++ {
+		if match(string(hash)) {
+			return i, string(hash)
+		}
+		hash = HasherMap[3].Hash(hash)
+	}
+}
+
+func TestSearchPrefix(t *testing.T) {
+	const seed = "Hello World!"
+
+	tests := []struct {
+		prefix Prefix
+		match  func(hash string) bool
+		work   float64
+	}{
+		{
+			prefix: Prefix{Hex: "abc"},
+			match:  func(hash string) bool { return strings.HasPrefix(hash, "abc") },
+			work:   4096,
+		}, {
+			prefix: Prefix{Hex: "00"},
+			match:  func(hash string) bool { return strings.HasPrefix(hash, "00") },
+			work:   256,
+		}, {
+			// upper-case prefixes match the (lower-case) hashes
+			prefix: Prefix{Hex: "F0F"},
+			match:  func(hash string) bool { return strings.HasPrefix(hash, "f0f") },
+			work:   4096,
+		}, {
+			prefix: Prefix{Bits: 12},
+			match:  func(hash string) bool { return strings.HasPrefix(hash, "000") },
+			work:   4096,
+		}, {
+			// 10 bits: two '0' characters, and one below 4
+			prefix: Prefix{Bits: 10},
+			match:  func(hash string) bool { return strings.HasPrefix(hash, "00") && hash[2] < '4' },
+			work:   1024,
+		},
+	}
+
+	s := NewService()
+	for id, test := range tests {
+		index, hash := firstMatch(seed, test.match)
+
+		res, err := s.SearchPrefix(seed, test.prefix)
+		switch {
+		case err != nil:
+			t.Fatalf("#%v -- FAILED -- [HashClockService] SearchPrefix(%+v) failed: %s", id, test.prefix, err)
+		case !res.Match || res.Iterations != index || res.Hash != hash:
+			t.Errorf("#%v -- FAILED -- [HashClockService] SearchPrefix(%+v) = #%v %s ; expected #%v %s", id, test.prefix, res.Iterations, res.Hash, index, hash)
+		case res.ExpectedWork != test.work || res.Prefix == nil:
+			t.Errorf("#%v -- FAILED -- [HashClockService] SearchPrefix(%+v) reported %v expected work ; expected %v", id, test.prefix, res.ExpectedWork, test.work)
+		}
+
+		// the hash found is verified like any other
+		v, err := s.VerifyIndex(seed, res.Hash, res.Iterations)
+		if err != nil || !v.Match {
+			t.Errorf("#%v -- FAILED -- [HashClockService] VerifyIndex() of the found hash = %+v, %v", id, v, err)
+		}
+	}
+}
+
+func TestSearchPrefixTimeout(t *testing.T) {
+	s := NewService()
+
+	res, err := s.SearchPrefixTimeout("Hello World!", Prefix{Bits: 200}, 1)
+	if err != nil || res.Match || res.Iterations == 0 || res.ExpectedWork == 0 {
+		t.Errorf("FAILED -- [HashClockService] SearchPrefixTimeout() = %+v, %v ; expected no match", res, err)
+	}
+
+	res, err = s.SearchPrefixTimeout("Hello World!", Prefix{Hex: "a"}, 1)
+	if err != nil || !res.Match || !strings.HasPrefix(res.Hash, "a") {
+		t.Errorf("FAILED -- [HashClockService] SearchPrefixTimeout() = %+v, %v ; expected a match", res, err)
+	}
+
+	// from a checkpoint, the search starts after it
+	cp, _ := s.RecHash("Hello World!", 100)
+	s.SetCheckpoint(100, cp.Hash)
+	res, err = s.SearchPrefixTimeout("", Prefix{Hex: "a"}, 1)
+	if err != nil || !res.Match || res.Iterations <= 100 {
+		t.Errorf("FAILED -- [HashClockService] SearchPrefixTimeout() from a checkpoint = %+v, %v", res, err)
+	}
+}
+
+func TestSearchPrefixInvalid(t *testing.T) {
+	tests := []Prefix{
+		{},
+		{Hex: "ab", Bits: 8},
+		{Hex: "xyz"},
+		{Hex: strings.Repeat("a", 65)},
+		{Bits: -1},
+		{Bits: 257},
+	}
+
+	s := NewService()
+	for id, p := range tests {
+		if _, err := s.SearchPrefix("seed", p); err == nil {
+			t.Errorf("#%v -- FAILED -- [HashClockService] SearchPrefix(%+v) was expected to fail", id, p)
+		}
+		if err := ValidatePrefix("sha256", p); err == nil {
+			t.Errorf("#%v -- FAILED -- [HashClockService] ValidatePrefix(%+v) was expected to fail", id, p)
+		}
+	}
+
+	// the limits depend on the algorithm
+	if err := ValidatePrefix("sha512", Prefix{Bits: 257}); err != nil {
+		t.Errorf("FAILED -- [HashClockService] ValidatePrefix() for SHA512 failed: %s", err)
+	}
+	if err := ValidatePrefix("md5", Prefix{Hex: strings.Repeat("a", 33)}); err == nil {
+		t.Errorf("FAILED -- [HashClockService] ValidatePrefix() for MD5 was expected to fail")
+	}
+}
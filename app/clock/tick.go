@@ -0,0 +1,63 @@
+package clock
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tick struct describes a breakpoint in a running hash chain: the index of
+// the hash, the (hex-encoded) hash itself and the wall time when it was
+// calculated
+type Tick struct {
+	Index    int       `json:"index"`
+	Hash     string    `json:"hash"`
+	WallTime time.Time `json:"wall_time"`
+}
+
+// TickFunc type is a function which is called on each breakpoint of a running
+// hash chain, in `RecHashPrint` and `RecHashLoop`.
+//
+// It is called from the hashing loop, so it should return quickly; as any
+// delay slows down the chain
+type TickFunc func(t Tick)
+
+// SetTickFunc method sets a function to be called on each breakpoint, in
+// `RecHashPrint` and `RecHashLoop`, along with the hash being printed to the
+// service's writer. A nil function unsets it
+func (s *HashClockService) SetTickFunc(fn TickFunc) {
+	s.tickFunc = fn
+}
+
+// logBreakpoint method prints the hash at the input index to the service's
+// writer, and passes it to the service's tick function (if set)
+func (c *HashClockService) logBreakpoint(index int, hash []byte) {
+	fmt.Fprintf(c.writer, "#%v:\t%s\n", index, string(hash))
+
+	if c.tickFunc != nil {
+		c.tickFunc(Tick{
+			Index:    index,
+			Hash:     string(hash),
+			WallTime: time.Now(),
+		})
+	}
+}
+
+// SetProgressFunc method sets a function to be called every `checkInterval`
+// hashes (or every step of the memory-hard function), in all methods which
+// hash recursively -- reporting the progress of long-running calls. A nil
+// function unsets it
+func (s *HashClockService) SetProgressFunc(fn TickFunc) {
+	s.progressFunc = fn
+}
+
+// progress method passes the hash at the input index to the service's
+// progress function (if set)
+func (c *HashClockService) progress(index int, hash []byte) {
+	if c.progressFunc != nil {
+		c.progressFunc(Tick{
+			Index:    index,
+			Hash:     string(hash),
+			WallTime: time.Now(),
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package clock
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidateSeed function checks the input seed string, which cannot be empty
+func ValidateSeed(seed string) error {
+	// empty string exception
+	if seed == "" {
+		return errors.New("seed cannot be empty")
+	}
+	return nil
+}
+
+// ValidateIterations function checks the input number of iterations to
+// calculate, which has to be greater than zero
+func ValidateIterations(iter int) error {
+	// zero iterations exception
+	if iter <= 0 {
+		return errors.New("number of iterations has to be greater than zero")
+	}
+	return nil
+}
+
+// ValidateHash function checks the input target hash for a verification
+// of the input seed: it cannot be empty, it must be hex-encoded and it cannot
+// be the seed itself
+func ValidateHash(seed, hash string) error {
+	// empty hash exception
+	if hash == "" {
+		return errors.New("hash cannot be empty")
+	}
+
+	// hash is not hex-encoded exception
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("hex encoder: invalid string -- %s", err)
+	}
+
+	// seed is hash exception
+	if seed == hash {
+		return errors.New("seed cannot be the same as the hash (no verification involved)")
+	}
+	return nil
+}
+
+// ValidateHashLength function checks that the input (hex-encoded) hash has
+// the length of a digest of the input algorithm (SHA256, if empty), as a hash
+// of any other length is not part of its chains
+func ValidateHashLength(alg, hash string) error {
+	if alg == "" {
+		alg = HasherMapVals[3]
+	}
+
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		if alg == HasherMapVals[idx] || alg == strings.ToLower(HasherMapVals[idx]) {
+			return hashLength(HasherMapVals[idx], hash, hexSize(idx))
+		}
+	}
+	return errors.New("invalid hasher reference")
+}
+
+// hashLength function checks that the input hash is of the input length, of
+// a digest of the input algorithm
+func hashLength(alg, hash string, size int) error {
+	if len(hash) != size {
+		return fmt.Errorf("hash length %d does not match the %s digest length %d", len(hash), alg, size)
+	}
+	return nil
+}
+
+// ValidateIndex function checks the input target index for a verification,
+// which cannot be zero or below
+func ValidateIndex(iterations int) error {
+	// iterations is zero or below exception
+	if iterations <= 0 {
+		return errors.New("number of target iterations cannot be zero or below")
+	}
+	return nil
+}
+
+// ValidateTimeout function checks the input timeout value (in seconds),
+// which cannot be zero or below
+func ValidateTimeout(timeout int) error {
+	// empty timeout exception
+	if timeout <= 0 {
+		return errors.New("timeout cannot be zero or below")
+	}
+	return nil
+}
+
+// ValidateAlgorithm function checks that the input algorithm is a reference
+// to one of the supported hashers (in `HasherMapVals`); lower-case or uppercase
+func ValidateAlgorithm(alg string) error {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		if alg == HasherMapVals[idx] || alg == strings.ToLower(HasherMapVals[idx]) {
+			return nil
+		}
+	}
+	return errors.New("invalid hasher reference")
+}
+
+// ValidateMode function checks the input chaining mode of a service's chain,
+// `ModeHex` or `ModeIndexed` (hex if empty); and that a domain tag is only set
+// in the indexed mode
+func ValidateMode(mode, tag string) error {
+	switch mode {
+	case "", ModeHex:
+		if tag != "" {
+			return fmt.Errorf("a domain tag is only used in the %s chaining mode", ModeIndexed)
+		}
+	case ModeIndexed:
+	default:
+		return fmt.Errorf("invalid chaining mode %q", mode)
+	}
+	return nil
+}
@@ -1,9 +1,8 @@
 package clock
 
 import (
-	"encoding/hex"
-	"errors"
-	"fmt"
+	"context"
+	"crypto/subtle"
 	"time"
 	// rhash "github.com/ZalgoNoise/meta/crypto/hash"
 )
@@ -11,24 +10,16 @@ import (
 // Verify method will take in a seed string and a target hash,
 // returning an execution of the `newVerifyResponse` method
 func (c *HashClockService) Verify(seed string, hash string) (*HashClockResponse, error) {
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
-	}
-
-	// empty hash exception
-	if hash == "" {
-		return &HashClockResponse{}, errors.New("hash cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// hash is not hex-encoded exception
-	if _, err := hex.DecodeString(hash); err != nil {
-		return &HashClockResponse{}, fmt.Errorf("hex encoder: invalid string -- %s", err)
+	if err := ValidateHash(seed, hash); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// seed is hash exception
-	if seed == hash {
-		return &HashClockResponse{}, errors.New("seed cannot be the same as the hash (no verification involved)")
+	if err := c.validateHashLength(hash); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	c.request.seed = []byte(seed)
@@ -45,20 +36,29 @@ func (c *HashClockService) Verify(seed string, hash string) (*HashClockResponse,
 // until it finds the target hash.
 //
 // This operation is infinitely recursive and will not be terminated unless
-// halted by the user -- or, when the hash matches.
+// halted by the user (or by cancelling the service's context) -- or, when the
+// hash matches.
 func (c *HashClockService) newVerifyResponse() (*HashClockResponse, error) {
 	// timestamp is recorded when function is first called
 	timestamp := time.Now()
 
-	iterations := 0
-	hash := c.hasher.Hash(c.request.seed)
+	origin, iterations := c.origin()
+	hash := c.first(origin, iterations)
 	target := []byte(c.request.hash)
+	iterations++
 
 	for {
-		if iterations > 0 {
-			hash = c.hasher.Hash(hash)
+		if c.cache != nil {
+			c.store(iterations, hash)
+		}
+
+		if iterations%c.interval == 0 {
+			c.progress(iterations, hash)
+
+			if err := c.ctx.Err(); err != nil {
+				return &HashClockResponse{}, err
+			}
 		}
-		iterations++
 
 		if matchHash(hash, target) {
 			c.response = &HashClockResponse{
@@ -70,40 +70,37 @@ func (c *HashClockService) newVerifyResponse() (*HashClockResponse, error) {
 				Match:      true,
 				Duration:   time.Since(timestamp),
 				Algorithm:  c.request.algorithm,
+				Checkpoint: c.checkpoint,
+				MemoryHard: c.memoryHard,
+				Mode:       c.mode,
+				Tag:        c.tag,
 			}
 
 			return c.response, nil
 		}
 
+		iterations++
+		hash = c.engine.StepAt(hash, iterations)
 	}
 }
 
-// VerifyTimeout method will take in a seed string, a target hash and a timeout 
+// VerifyTimeout method will take in a seed string, a target hash and a timeout
 // value returning an execution of the `newVerifyTimeoutResponse` method
 func (c *HashClockService) VerifyTimeout(seed, hash string, timeout int) (*HashClockResponse, error) {
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
-	}
-
-	// empty hash exception
-	if hash == "" {
-		return &HashClockResponse{}, errors.New("hash cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// hash is not hex-encoded exception
-	if _, err := hex.DecodeString(hash); err != nil {
+	if err := ValidateHash(seed, hash); err != nil {
 		return &HashClockResponse{}, err
 	}
 
-	// seed is hash exception
-	if seed == hash {
-		return &HashClockResponse{}, errors.New("seed cannot be the same as the hash (no verification involved)")
+	if err := c.validateHashLength(hash); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// empty timeout exception
-	if timeout <= 0 {
-		return &HashClockResponse{}, errors.New("timeout cannot be zero or below")
+	if err := ValidateTimeout(timeout); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	c.request.seed = []byte(seed)
@@ -119,75 +116,63 @@ func (c *HashClockService) VerifyTimeout(seed, hash string, timeout int) (*HashC
 // and build its `HashClockResponse.response`; by recursively hashing the seed
 // until it finds the target hash within a specific timeframe.
 //
-// This operation will stop with a match or when the timer is up. As the hashing is
-// done in a goroutine, the ticker in the parent process will check for a match every
-// 10ms. This does not affect performance when compared to 100ms, for instance.
+// This operation will stop with a match or when the timer is up; which is checked
+// every `checkInterval` hashes. If the service's context is cancelled before that,
+// its error is returned
 func (c *HashClockService) newVerifyTimeoutResponse() (*HashClockResponse, error) {
+	// timestamp is recorded when function is first called
+	timestamp := time.Now()
+
+	ctx, cancel := context.WithTimeout(c.ctx, time.Second*time.Duration(c.request.timeout))
+	defer cancel()
+
 	c.response = &HashClockResponse{
-		Seed:    string(c.request.seed),
-		Timeout: c.request.timeout,
-		Target:  c.request.hash,
+		Seed:       string(c.request.seed),
+		Timeout:    c.request.timeout,
+		Target:     c.request.hash,
 		Algorithm:  c.request.algorithm,
+		Checkpoint: c.checkpoint,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
 	}
 	target := []byte(c.request.hash)
 
-	// recursive conversions are done with byte arrays
-	// to preserve performance, instead of constantly
-	// converting to string
-	type timestamp struct {
-		hash []byte
-		id   int
-	}
+	origin, id := c.origin()
+	hash := c.first(origin, id)
+	id++
 
-	ts := timestamp{
-		hash: c.hasher.Hash(c.request.seed),
-		id:   0,
-	}
-	done := make(chan bool)
-
-	go func() {
-		for {
-			select {
-			case <-done:
-				return
-			default:
-				if ts.id > 0 {
-					ts.hash = c.hasher.Hash(ts.hash)
+	for !matchHash(hash, target) {
+		if id%c.interval == 0 {
+			c.progress(id, hash)
+
+			if ctx.Err() != nil {
+				// the parent context was cancelled, not the timer
+				if err := c.ctx.Err(); err != nil {
+					return &HashClockResponse{}, err
 				}
-				ts.id++
 
-				if matchHash(ts.hash, target) {
-					c.response.Iterations = ts.id
-					c.response.Hash = string(ts.hash)
-					c.response.Match = true
+				c.response.Iterations = id
+				c.response.Hash = string(hash)
+				c.response.Match = false
+				c.response.Duration = time.Since(timestamp)
 
-					return
-				}
+				return c.response, nil
 			}
 		}
-	}()
-
-	// TODO: refactor; needs something more solid than this
-	// kick off timer; then send done signal to goroutine.
-	// Performs regular checks on the response object to avoid
-	// waiting for the total length of the timeout
-	//
-	// Checking every 10ms seems not to be impactful (yet)
-	for i := 0; i <= c.request.timeout*100; i++ {
-		time.Sleep(time.Millisecond * 10)
-		if c.response.Match {
-			c.response.Duration = time.Millisecond * 10 * time.Duration(i)
-			return c.response, nil
+
+		id++
+		hash = c.engine.StepAt(hash, id)
+
+		if c.cache != nil {
+			c.store(id, hash)
 		}
 	}
-	done <- true
 
-	c.response = &HashClockResponse{
-		Iterations: ts.id,
-		Hash:       string(ts.hash),
-		Match:      false,
-		Algorithm:  c.request.algorithm,
-	}
+	c.response.Iterations = id
+	c.response.Hash = string(hash)
+	c.response.Match = true
+	c.response.Duration = time.Since(timestamp)
 
 	return c.response, nil
 }
@@ -196,29 +181,24 @@ func (c *HashClockService) newVerifyTimeoutResponse() (*HashClockResponse, error
 // iterations returning an execution of the `newVerifyIndexResponse` method
 func (c *HashClockService) VerifyIndex(seed string, hash string, iterations int) (*HashClockResponse, error) {
 
-	// empty string exception
-	if seed == "" {
-		return &HashClockResponse{}, errors.New("seed cannot be empty")
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// empty hash exception
-	if hash == "" {
-		return &HashClockResponse{}, errors.New("hash cannot be empty")
+	if err := ValidateHash(seed, hash); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// hash is not hex-encoded exception
-	if _, err := hex.DecodeString(hash); err != nil {
+	if err := c.validateHashLength(hash); err != nil {
 		return &HashClockResponse{}, err
 	}
 
-	// seed is hash exception
-	if seed == hash {
-		return &HashClockResponse{}, errors.New("seed cannot be the same as the hash (no verification involved)")
+	if err := ValidateIndex(iterations); err != nil {
+		return &HashClockResponse{}, err
 	}
 
-	// iterations is zero or below exception
-	if iterations <= 0 {
-		return &HashClockResponse{}, errors.New("number of target iterations cannot be zero or below")
+	if err := c.validateTarget(iterations); err != nil {
+		return &HashClockResponse{}, err
 	}
 
 	c.request.seed = []byte(seed)
@@ -240,14 +220,26 @@ func (c *HashClockService) newVerifyIndexResponse() (*HashClockResponse, error)
 	// timestamp is recorded when function is first called
 	timestamp := time.Now()
 
-	hash := c.hasher.Hash(c.request.seed)
+	origin, start := c.resume(c.request.iterations)
+	hash := c.first(origin, start)
 	target := []byte(c.request.hash)
 
-	// index starts at 2 since:
-	// - index 0 is the seed
+	// index starts 2 after the origin since:
+	// - index 0 is the seed (or the checkpoint's index)
 	// - index 1 is the first hash calculated (above)
-	for i := 2; i <= c.request.iterations; i++ {
-		hash = c.hasher.Hash(hash)
+	for i := start + 2; i <= c.request.iterations; i++ {
+		if i%c.interval == 0 {
+			c.progress(i-1, hash)
+
+			if err := c.ctx.Err(); err != nil {
+				return &HashClockResponse{}, err
+			}
+		}
+
+		hash = c.engine.StepAt(hash, i)
+		if c.cache != nil {
+			c.store(i, hash)
+		}
 	}
 
 	c.response = &HashClockResponse{
@@ -258,6 +250,10 @@ func (c *HashClockService) newVerifyIndexResponse() (*HashClockResponse, error)
 		Target:     c.request.hash,
 		Duration:   time.Since(timestamp),
 		Algorithm:  c.request.algorithm,
+		Checkpoint: c.checkpoint,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
 	}
 
 	if matchHash(hash, target) {
@@ -268,13 +264,103 @@ func (c *HashClockService) newVerifyIndexResponse() (*HashClockResponse, error)
 	return c.response, nil
 }
 
-// matchHash function is a helper to read and compare each byte from both
-// the input hash and the target hash
-func matchHash(hash, target []byte) bool {
-	for idx, t := range target {
-		if t != hash[idx] {
-			return false
+// VerifyIndexTimeout method will take in a seed string, a target hash, a target number
+// of iterations and a timeout value, returning an execution of the
+// `newVerifyIndexTimeoutResponse` method
+func (c *HashClockService) VerifyIndexTimeout(seed, hash string, iterations, timeout int) (*HashClockResponse, error) {
+	if err := c.validateSeed(seed); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := ValidateHash(seed, hash); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := c.validateHashLength(hash); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := ValidateIndex(iterations); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := c.validateTarget(iterations); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	if err := ValidateTimeout(timeout); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	c.request.seed = []byte(seed)
+	c.request.iterations = iterations
+	c.request.breakpoint = 0
+	c.request.timeout = timeout
+	c.request.hash = hash
+
+	return c.newVerifyIndexTimeoutResponse()
+}
+
+// newVerifyIndexTimeoutResponse method will parse the `HashClockService.request` object
+// and build its `HashClockResponse.response`; by recursively hashing the seed
+// a specific number of times, or until the timer is up.
+//
+// The timer is checked every `checkInterval` hashes. If it runs out before reaching the
+// target index, the response contains the last calculated hash and its index, with no
+// match. If the service's context is cancelled before that, its error is returned
+func (c *HashClockService) newVerifyIndexTimeoutResponse() (*HashClockResponse, error) {
+	// timestamp is recorded when function is first called
+	timestamp := time.Now()
+
+	ctx, cancel := context.WithTimeout(c.ctx, time.Second*time.Duration(c.request.timeout))
+	defer cancel()
+
+	origin, i := c.resume(c.request.iterations)
+	hash := c.first(origin, i)
+	target := []byte(c.request.hash)
+
+	i++
+	for ; i < c.request.iterations; i++ {
+		if i%c.interval == 0 {
+			c.progress(i, hash)
+
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		hash = c.engine.StepAt(hash, i+1)
+
+		if c.cache != nil {
+			c.store(i+1, hash)
 		}
 	}
-	return true
+
+	// the parent context was cancelled, not the timer
+	if err := c.ctx.Err(); err != nil {
+		return &HashClockResponse{}, err
+	}
+
+	c.response = &HashClockResponse{
+		Seed:       string(c.request.seed),
+		Timeout:    c.request.timeout,
+		Iterations: i,
+		Hash:       string(hash),
+		Target:     c.request.hash,
+		Duration:   time.Since(timestamp),
+		Algorithm:  c.request.algorithm,
+		Checkpoint: c.checkpoint,
+		MemoryHard: c.memoryHard,
+		Mode:       c.mode,
+		Tag:        c.tag,
+	}
+
+	c.response.Match = i == c.request.iterations && matchHash(hash, target)
+	return c.response, nil
+}
+
+// matchHash function is a helper to compare the input hash and the target
+// hash, in constant time: they only match if they have the same length and
+// the same bytes. A shorter target is not a prefix match -- see `SearchPrefix`
+func matchHash(hash, target []byte) bool {
+	return subtle.ConstantTimeCompare(hash, target) == 1
 }
@@ -396,6 +396,126 @@ func TestVerifyIndex(t *testing.T) {
 
 }
 
+func TestVerifyIndexTimeout(t *testing.T) {
+	tests := []struct {
+		input      string
+		iterations int
+		timeout    int
+		ok         string
+		match      bool
+		pass       bool
+	}{
+		{
+			input:      testCases[6].seed,
+			iterations: testCases[6].iterations,
+			timeout:    1,
+			ok:         testCases[6].hash,
+			match:      true,
+			pass:       true,
+		}, {
+			input:      testCases[7].seed,
+			iterations: testCases[7].iterations,
+			timeout:    1,
+			ok:         testCases[7].hash,
+			match:      true,
+			pass:       true,
+		}, {
+			input:      testCases[6].seed,
+			iterations: testCases[4].iterations,
+			timeout:    1,
+			ok:         testCases[6].hash,
+			match:      false,
+			pass:       true,
+		}, {
+			input:      testBreakages[0].seed,
+			iterations: testCases[6].iterations,
+			timeout:    1,
+			ok:         testCases[6].hash,
+			pass:       false,
+		}, {
+			input:      testCases[6].seed,
+			iterations: testBreakages[1].iterations,
+			timeout:    1,
+			ok:         testCases[6].hash,
+			pass:       false,
+		}, {
+			input:      testCases[6].seed,
+			iterations: testCases[6].iterations,
+			timeout:    testBreakages[4].timeout,
+			ok:         testCases[6].hash,
+			pass:       false,
+		},
+	}
+
+	clock := NewService()
+
+	for id, test := range tests {
+		result, err := clock.VerifyIndexTimeout(test.input, test.ok, test.iterations, test.timeout)
+		if !test.pass {
+			if err == nil {
+				t.Errorf(
+					"#%v [HashClockService] VerifyIndexTimeout(%s, %s, %v, %v) was expected to fail",
+					id,
+					test.input,
+					test.ok,
+					test.iterations,
+					test.timeout,
+				)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf(
+				"#%v [HashClockService] VerifyIndexTimeout(%s, %s, %v, %v) resulted in error: %s",
+				id,
+				test.input,
+				test.ok,
+				test.iterations,
+				test.timeout,
+				err,
+			)
+			continue
+		}
+
+		if result.Match != test.match {
+			t.Errorf(
+				"#%v [HashClockService] VerifyIndexTimeout(%s, %s, %v, %v) = %v ; expected %v",
+				id,
+				test.input,
+				test.ok,
+				test.iterations,
+				test.timeout,
+				result.Match,
+				test.match,
+			)
+		}
+
+		if result.Iterations != test.iterations {
+			t.Errorf(
+				"#%v [HashClockService] VerifyIndexTimeout(%s, %s, %v, %v) = %v ; iteration count comparison failed: %v != %v",
+				id,
+				test.input,
+				test.ok,
+				test.iterations,
+				test.timeout,
+				result.Match,
+				result.Iterations,
+				test.iterations,
+			)
+		}
+
+		t.Logf(
+			"#%v -- TESTED -- [HashClockService] VerifyIndexTimeout(%s, %s, %v, %v)",
+			id,
+			test.input,
+			test.ok,
+			test.iterations,
+			test.timeout,
+		)
+	}
+}
+
 func TestMatchHash(t *testing.T) {
 	tests := []struct {
 		input      string
@@ -469,3 +589,33 @@ func TestMatchHash(t *testing.T) {
 
 	}
 }
+
+func TestMatchHashLength(t *testing.T) {
+	hash := []byte(testCases[2].hash)
+
+	// prefixes, and longer targets, do not match
+	for _, target := range []string{"", testCases[2].hash[:10], testCases[2].hash + "00"} {
+		if matchHash(hash, []byte(target)) {
+			t.Errorf("[HashClockService] matchHash(%s, %s) = true ; expected false", hash, target)
+		}
+	}
+
+	// and are rejected before any hash is calculated
+	clock := NewService()
+	for _, target := range []string{testCases[2].hash[:10], testCases[2].hash + "00"} {
+		if _, err := clock.Verify(testCases[2].seed, target); err == nil {
+			t.Errorf("[HashClockService] Verify() with a %v-character hash was expected to fail", len(target))
+		}
+		if _, err := clock.VerifyIndex(testCases[2].seed, target, testCases[2].iterations); err == nil {
+			t.Errorf("[HashClockService] VerifyIndex() with a %v-character hash was expected to fail", len(target))
+		}
+	}
+
+	clock.SetHasher("sha512")
+	if _, err := clock.VerifyIndexTimeout(testCases[2].seed, testCases[2].hash, testCases[2].iterations, 1); err == nil {
+		t.Errorf("[HashClockService] VerifyIndexTimeout() with a SHA256 hash in a SHA512 chain was expected to fail")
+	}
+	if err := ValidateHashLength("sha512", testCases[2].hash); err == nil {
+		t.Errorf("[HashClockService] ValidateHashLength() with a SHA256 hash for SHA512 was expected to fail")
+	}
+}
@@ -0,0 +1,293 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/lanes"
+)
+
+// Walk struct is a chain to calculate up to a known index, as in `RecHash`
+// (or `VerifyIndex`, if it has a target hash): from its seed, or from its
+// checkpoint if set
+type Walk struct {
+	Seed       string
+	Checkpoint *Checkpoint
+	Iterations int
+
+	// Target is the hash to match at the walk's last index; if empty, the walk
+	// is a `RecHash`
+	Target string
+
+	// Response is the walk's response, set once it completes: the same as the
+	// `HashClockService` method's
+	Response *HashClockResponse
+
+	index int
+	hash  []byte
+	start time.Time
+}
+
+// Walker struct advances several walks of the same algorithm (and chaining
+// mode) at once: with the multi-lane hash functions of the `lanes` package if
+// the CPU supports them, or one walk after the other otherwise. A Walker is
+// not safe for concurrent use
+type Walker struct {
+	algorithm  string
+	engine     *Engine
+	chains     *lanes.Chains
+	memoryHard *MemoryHard
+	interval   int
+	walks      []*Walk
+
+	// mode is the chaining mode, empty for the default (`ModeHex`); and tag
+	// the domain tag, in the `ModeIndexed` mode
+	mode string
+	tag  string
+}
+
+// LaneWidth function returns the largest number of walks that a `Walker`
+// advances at once, on this CPU
+func LaneWidth() int {
+	if !lanes.Accelerated() {
+		return 1
+	}
+
+	c, _ := lanes.New(HasherMapVals[3], ModeHex)
+	return c.Width()
+}
+
+// NewWalker function creates a `Walker` for the input algorithm (as named in
+// `HasherMapVals`, lower-case or upper-case)
+func NewWalker(alg string) (*Walker, error) {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		if alg != HasherMapVals[idx] && alg != strings.ToLower(HasherMapVals[idx]) {
+			continue
+		}
+
+		w := &Walker{
+			algorithm: HasherMapVals[idx],
+			engine:    newEngine(idx, ModeHex),
+			interval:  checkInterval,
+		}
+		if m, ok := HasherMap[idx].(MemoryHard); ok {
+			w.memoryHard = &m
+			w.interval = 1
+		}
+
+		// the algorithms without a multi-lane implementation (MD5 and SHA1)
+		// are always walked one after the other
+		if lanes.Accelerated() && lanes.Supported(w.algorithm) {
+			w.chains, _ = lanes.New(w.algorithm, ModeHex)
+		}
+		return w, nil
+	}
+	return nil, errors.New("invalid hasher reference")
+}
+
+// SetMode method sets the chaining mode (and domain tag) of the walker's
+// chains, as in `HashClockService.SetMode`. The multi-lane hash functions do
+// not hash the index, so the `ModeIndexed` walks are advanced one after the
+// other. It fails if the walker has walks in progress
+func (w *Walker) SetMode(mode, tag string) error {
+	if err := ValidateMode(mode, tag); err != nil {
+		return err
+	}
+	if len(w.walks) > 0 {
+		return errors.New("walker has walks in progress")
+	}
+
+	w.mode, w.tag = "", tag
+	w.engine.mode = ModeHex
+	if mode == ModeIndexed {
+		w.mode = ModeIndexed
+		w.engine.mode = ModeIndexed
+		w.chains = nil
+	} else if w.chains == nil && lanes.Accelerated() && lanes.Supported(w.algorithm) {
+		w.chains, _ = lanes.New(w.algorithm, ModeHex)
+	}
+	w.engine.SetTag([]byte(tag))
+	return nil
+}
+
+// Algorithm method returns the name of the walker's hash function
+func (w *Walker) Algorithm() string {
+	return w.algorithm
+}
+
+// Width method returns the number of walks which the walker advances at once
+func (w *Walker) Width() int {
+	if w.chains == nil {
+		return 1
+	}
+	return w.chains.Width()
+}
+
+// Len method returns the number of walks in progress
+func (w *Walker) Len() int {
+	return len(w.walks)
+}
+
+// Add method validates the input walk (as its `HashClockService` method would)
+// and starts it, calculating its first hash. It fails if the walker already
+// has `Width` walks in progress
+func (w *Walker) Add(walk *Walk) error {
+	if len(w.walks) >= w.Width() {
+		return errors.New("walker is full")
+	}
+
+	origin, index := []byte(walk.Seed), 0
+	if cp := walk.Checkpoint; cp != nil && cp.Index != 0 {
+		if cp.Index < 0 {
+			return errors.New("checkpoint index cannot be negative")
+		}
+		if err := ValidateHash("", cp.Hash); err != nil {
+			return err
+		}
+		if len(cp.Hash) != w.engine.Size() {
+			return errors.New("checkpoint hash length does not match the hash function's digest size")
+		}
+		origin, index = []byte(cp.Hash), cp.Index
+	} else if err := ValidateSeed(walk.Seed); err != nil {
+		return err
+	}
+
+	if walk.Target == "" {
+		if err := ValidateIterations(walk.Iterations); err != nil {
+			return err
+		}
+	} else {
+		if err := ValidateHash(walk.Seed, walk.Target); err != nil {
+			return err
+		}
+		if err := hashLength(w.algorithm, walk.Target, w.engine.Size()); err != nil {
+			return err
+		}
+		if err := ValidateIndex(walk.Iterations); err != nil {
+			return err
+		}
+	}
+	if walk.Iterations <= index {
+		return fmt.Errorf("index %d does not come after the checkpoint's index %d", walk.Iterations, index)
+	}
+
+	walk.start = time.Now()
+	walk.Response = nil
+
+	switch {
+	case w.mode == ModeIndexed:
+		walk.hash = append(walk.hash[:0], w.engine.StepAt(origin, index+1)...)
+	case index == 0:
+		walk.hash = append(walk.hash[:0], w.engine.Hash(origin)...)
+	default:
+		walk.hash = append(walk.hash[:0], w.engine.Step(origin)...)
+	}
+	walk.index = index + 1
+
+	w.walks = append(w.walks, walk)
+	return nil
+}
+
+// Step method advances the walks in progress by up to `checkInterval` hashes
+// (or a single step, of the memory-hard function), returning the walks which
+// complete -- with their `Response` set. If the input context is cancelled,
+// all the walks in progress are dropped and returned (without a response),
+// with the context's error
+func (w *Walker) Step(ctx context.Context) ([]*Walk, error) {
+	if err := ctx.Err(); err != nil {
+		walks := w.walks
+		w.walks = nil
+		return walks, err
+	}
+
+	// all walks advance by the same number of steps, so that none of them
+	// goes past its index
+	n := w.interval
+	for _, walk := range w.walks {
+		if left := walk.Iterations - walk.index; left < n {
+			n = left
+		}
+	}
+
+	if n > 0 {
+		// the lanes only pay off when most of them are in use
+		if w.chains != nil && len(w.walks) >= w.chains.Width()/2 {
+			w.stepLanes(n)
+		} else {
+			w.stepEngine(n)
+		}
+	}
+
+	var done []*Walk
+	active := w.walks[:0]
+	for _, walk := range w.walks {
+		if walk.index < walk.Iterations {
+			active = append(active, walk)
+			continue
+		}
+
+		walk.Response = w.response(walk)
+		done = append(done, walk)
+	}
+	for idx := len(active); idx < len(w.walks); idx++ {
+		w.walks[idx] = nil
+	}
+	w.walks = active
+
+	return done, nil
+}
+
+// stepLanes method advances all walks by the input number of steps, one walk
+// per lane
+func (w *Walker) stepLanes(n int) {
+	for l, walk := range w.walks {
+		// the hashes were calculated by the walker, so they are valid
+		_ = w.chains.Set(l, walk.hash)
+	}
+
+	w.chains.Step(n)
+
+	for l, walk := range w.walks {
+		walk.hash = w.chains.Hash(l)
+		walk.index += n
+	}
+}
+
+// stepEngine method advances all walks by the input number of steps, one
+// after the other
+func (w *Walker) stepEngine(n int) {
+	for _, walk := range w.walks {
+		hash := walk.hash
+		for i := 1; i <= n; i++ {
+			hash = w.engine.StepAt(hash, walk.index+i)
+		}
+		walk.hash = append(walk.hash[:0], hash...)
+		walk.index += n
+	}
+}
+
+// response method builds the response of the input (completed) walk
+func (w *Walker) response(walk *Walk) *HashClockResponse {
+	r := &HashClockResponse{
+		Seed:       walk.Seed,
+		Iterations: walk.Iterations,
+		Hash:       string(walk.hash),
+		Algorithm:  w.algorithm,
+		MemoryHard: w.memoryHard,
+		Mode:       w.mode,
+		Tag:        w.tag,
+	}
+	if cp := walk.Checkpoint; cp != nil && cp.Index != 0 {
+		r.Checkpoint = cp
+	}
+
+	if walk.Target != "" {
+		r.Target = walk.Target
+		r.Duration = time.Since(walk.start)
+		r.Match = matchHash(walk.hash, []byte(walk.Target))
+	}
+	return r
+}
@@ -0,0 +1,195 @@
+package clock
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// runWalks function adds the input walks to the walker as it has room for
+// them, and steps it until all of them complete
+func runWalks(t testing.TB, w *Walker, walks []*Walk) {
+	queue := walks
+	for len(queue) > 0 || w.Len() > 0 {
+		for len(queue) > 0 && w.Len() < w.Width() {
+			if err := w.Add(queue[0]); err != nil {
+				t.Fatalf("FAILED -- [Walker] Add() failed: %s", err)
+			}
+			queue = queue[1:]
+		}
+
+		if _, err := w.Step(context.Background()); err != nil {
+			t.Fatalf("FAILED -- [Walker] Step() failed: %s", err)
+		}
+	}
+}
+
+func TestWalker(t *testing.T) {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		alg := HasherMapVals[idx]
+
+		w, err := NewWalker(alg)
+		if err != nil {
+			t.Fatalf("#%v -- FAILED -- [Walker] NewWalker(%s) failed: %s", idx, alg, err)
+		}
+
+		s := NewService()
+		s.SetWriter(nil)
+		s.SetHasher(alg)
+
+		// a full walker, and a single walk
+		for _, n := range []int{w.Width() + 3, 1} {
+			walks := make([]*Walk, n)
+			wants := make([]*HashClockResponse, n)
+
+			for i := range walks {
+				seed := fmt.Sprintf("walk %d", i)
+				iter := 1 + i*700
+				if alg == MemoryHardAlgorithm {
+					iter = 1 + i*7
+				}
+
+				want, err := s.RecHash(seed, iter)
+				if err != nil {
+					t.Fatalf("#%v -- FAILED -- [Walker] RecHash() failed: %s", idx, err)
+				}
+				wants[i] = want
+
+				walks[i] = &Walk{Seed: seed, Iterations: iter}
+				if i%2 == 1 {
+					walks[i].Target = want.Hash
+				}
+			}
+
+			runWalks(t, w, walks)
+
+			for i, walk := range walks {
+				r := walk.Response
+				switch {
+				case r == nil:
+					t.Fatalf("#%v -- FAILED -- [Walker] %s walk %d has no response", idx, alg, i)
+				case r.Hash != wants[i].Hash || r.Iterations != wants[i].Iterations || r.Algorithm != wants[i].Algorithm:
+					t.Errorf("#%v -- FAILED -- [Walker] %s walk %d mismatch: wanted %+v ; got %+v", idx, alg, i, wants[i], r)
+				case walk.Target != "" && !r.Match:
+					t.Errorf("#%v -- FAILED -- [Walker] %s walk %d should match its target", idx, alg, i)
+				}
+			}
+		}
+	}
+}
+
+func TestWalkerCheckpoint(t *testing.T) {
+	s := NewService()
+	s.SetWriter(nil)
+
+	cp, _ := s.RecHash("Hello World!", 500)
+	want, _ := s.RecHash("Hello World!", 2000)
+
+	w, _ := NewWalker("sha256")
+	walk := &Walk{
+		Checkpoint: &Checkpoint{Index: 500, Hash: cp.Hash},
+		Iterations: 2000,
+		Target:     want.Hash,
+	}
+	runWalks(t, w, []*Walk{walk})
+
+	if r := walk.Response; !r.Match || r.Hash != want.Hash || r.Checkpoint == nil {
+		t.Errorf("FAILED -- [Walker] unexpected response from a checkpoint: %+v", r)
+	}
+}
+
+func TestWalkerIndexed(t *testing.T) {
+	s := NewService()
+	s.SetWriter(nil)
+	if err := s.SetMode(ModeIndexed, "tag"); err != nil {
+		t.Fatalf("FAILED -- [Walker] SetMode() failed: %s", err)
+	}
+
+	cp, _ := s.RecHash("Hello World!", 500)
+	want, _ := s.RecHash("Hello World!", 2000)
+
+	w, _ := NewWalker("sha256")
+	if err := w.SetMode(ModeIndexed, "tag"); err != nil {
+		t.Fatalf("FAILED -- [Walker] SetMode() failed: %s", err)
+	}
+
+	walks := []*Walk{
+		{Seed: "Hello World!", Iterations: 2000, Target: want.Hash},
+		{Checkpoint: &Checkpoint{Index: 500, Hash: cp.Hash}, Iterations: 2000, Target: want.Hash},
+		{Seed: "Hello World!", Iterations: 1},
+	}
+	runWalks(t, w, walks)
+
+	for i, walk := range walks[:2] {
+		if r := walk.Response; !r.Match || r.Hash != want.Hash || r.Mode != ModeIndexed || r.Tag != "tag" {
+			t.Errorf("FAILED -- [Walker] unexpected response of indexed walk %d: %+v", i, r)
+		}
+	}
+	if first, _ := s.RecHash("Hello World!", 1); walks[2].Response.Hash != first.Hash {
+		t.Errorf("FAILED -- [Walker] indexed walk to #1: wanted %s ; got %s", first.Hash, walks[2].Response.Hash)
+	}
+
+	if err := w.SetMode(ModeHex, "tag"); err == nil {
+		t.Errorf("FAILED -- [Walker] SetMode() with a tag in the hex mode should fail")
+	}
+	w.Add(&Walk{Seed: "seed", Iterations: 10})
+	if err := w.SetMode(ModeHex, ""); err == nil {
+		t.Errorf("FAILED -- [Walker] SetMode() with walks in progress should fail")
+	}
+}
+
+func TestWalkerInvalid(t *testing.T) {
+	if _, err := NewWalker("sha3"); err == nil {
+		t.Errorf("FAILED -- [Walker] NewWalker() with an invalid algorithm should fail")
+	}
+
+	w, _ := NewWalker("sha256")
+	tests := []*Walk{
+		{Seed: "", Iterations: 10},
+		{Seed: "seed", Iterations: 0},
+		{Seed: "seed", Iterations: 10, Target: "zz"},
+		{Checkpoint: &Checkpoint{Index: 10, Hash: "abcd"}, Iterations: 20},
+		{Checkpoint: &Checkpoint{Index: 10, Hash: fmt.Sprintf("%064d", 0)}, Iterations: 10},
+	}
+	for i, walk := range tests {
+		if err := w.Add(walk); err == nil {
+			t.Errorf("FAILED -- [Walker] Add() with invalid walk #%d should fail", i)
+		}
+	}
+
+	for w.Len() < w.Width() {
+		if err := w.Add(&Walk{Seed: "seed", Iterations: 1 << 30}); err != nil {
+			t.Fatalf("FAILED -- [Walker] Add() failed: %s", err)
+		}
+	}
+	if err := w.Add(&Walk{Seed: "seed", Iterations: 10}); err == nil {
+		t.Errorf("FAILED -- [Walker] Add() to a full walker should fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dropped, err := w.Step(ctx)
+	if err != context.Canceled || len(dropped) != w.Width() || w.Len() != 0 {
+		t.Errorf("FAILED -- [Walker] Step() with a cancelled context should drop all walks: %v walks ; %v", len(dropped), err)
+	}
+}
+
+// BenchmarkWalker reports the time per hash of a full walker; compared to
+// BenchmarkHasher, for a single chain
+func BenchmarkWalker(b *testing.B) {
+	for idx := 0; idx < len(HasherMapVals); idx++ {
+		b.Run(HasherMapVals[idx], func(b *testing.B) {
+			w, _ := NewWalker(HasherMapVals[idx])
+
+			walks := make([]*Walk, w.Width())
+			iter := b.N/len(walks) + 1
+			for i := range walks {
+				walks[i] = &Walk{Seed: fmt.Sprintf("walk %d", i), Iterations: iter}
+			}
+
+			b.ResetTimer()
+			runWalks(b, w, walks)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ZalgoNoise/hashclock/batch"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+)
+
+// runBatch function runs the jobs read from the set file or std-in, writing
+// one response per line to stdout and the summary of failures to stderr. A
+// job which fails results in an error, and a verification without a match
+// in a `mismatchError`
+func runBatch(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	var r io.Reader = s.stdin
+	if cfg.Input != "-" {
+		f, err := os.Open(cfg.Input)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if r == nil {
+		return nil, errors.New("cannot read jobs: std-in is undefined")
+	}
+
+	cache, err := newCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCache(cache)
+
+	sum, err := batch.Run(ctx, r, s.stdout, &batch.Config{
+		Workers:       cfg.Workers,
+		Format:        cfg.Format,
+		Ordered:       cfg.Order == "input",
+		Cache:         cache,
+		CacheInterval: cfg.CacheInterval,
+	})
+	if sum == nil {
+		return nil, &usageError{err}
+	}
+
+	if cfg.SetJSON {
+		out, jErr := json.Marshal(sum)
+		if jErr != nil {
+			return nil, jErr
+		}
+		fmt.Fprintln(s.stderr, string(out))
+	} else {
+		fmt.Fprintf(s.stderr, "jobs: %d; ok: %d; mismatches: %d; errors: %d\n", sum.Jobs, sum.OK, sum.Mismatches, sum.Errors)
+		for _, f := range sum.Failures {
+			fmt.Fprintf(s.stderr, "line %d: %s\n", f.Line, f.Reason)
+		}
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, context.Canceled):
+		return nil, err
+	case sum.Errors > 0:
+		return nil, fmt.Errorf("%d of %d jobs failed", sum.Errors, sum.Jobs)
+	case sum.Mismatches > 0:
+		return nil, &mismatchError{fmt.Errorf("%d of %d verifications did not match", sum.Mismatches, sum.Jobs)}
+	}
+	return nil, nil
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ZalgoNoise/hashclock/beacon"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// openBeacon function opens the beacon of the input ledger; or creates it with
+// the configured parameters, if it does not exist
+func openBeacon(cfg *flags.CLIConfig, l *ledger.Ledger) (*beacon.Log, error) {
+	b, err := beacon.Open(l.Dir())
+	if !errors.Is(err, beacon.ErrNotExist) {
+		return b, err
+	}
+
+	every := cfg.Every
+	if every == 0 {
+		every = l.Manifest().Interval
+	}
+
+	b, err = beacon.Create(l.Dir(), beacon.Params{
+		Every:     every,
+		Delay:     cfg.Delay,
+		Algorithm: cfg.DelayAlgorithm,
+	})
+	if err != nil {
+		return nil, &usageError{err}
+	}
+	return b, nil
+}
+
+// printRound function writes the input round to the input writer, as a JSON
+// line or as text
+func printRound(w io.Writer, r *beacon.Round, toJSON bool) error {
+	if toJSON {
+		out, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "round #%d:\t%s\tindex: %d\n", r.Round, r.Value, r.Index)
+	return err
+}
+
+// runBeacon function runs a clock recorded in an on-disk ledger (as in
+// `runLedger`), publishing its beacon's rounds and logging each of them; until
+// the context is cancelled (e.g. with Ctrl+C). The rounds whose ticks are
+// already in the ledger are published first
+func runBeacon(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	l, err := openLedger(cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	l.Sync = cfg.Sync
+
+	b, err := openBeacon(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	rounds, err := b.Catchup(ctx)
+	for _, r := range rounds {
+		printRound(s.stdout, r, cfg.SetJSON)
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := ledger.NewRecorder(l)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// rounds are published apart from the recorder, so that their delay
+	// function does not hold back the chain
+	ticks := make(chan ledger.Entry, 1024)
+	rec.OnEntry = func(e ledger.Entry) {
+		if e.Event != "" {
+			return
+		}
+		select {
+		case ticks <- e:
+		case <-ctx.Done():
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case e := <-ticks:
+				rounds, err := b.Observe(ctx, e)
+				for _, r := range rounds {
+					printRound(s.stdout, r, cfg.SetJSON)
+				}
+				if err != nil {
+					done <- err
+					cancel()
+					return
+				}
+			case <-ctx.Done():
+				done <- nil
+				return
+			}
+		}
+	}()
+
+	if cfg.Events {
+		go readEvents(ctx, s, rec)
+	}
+
+	// the recorder only halts once the context is cancelled, which is its
+	// expected ending
+	err = rec.Run(ctx)
+	cancel()
+
+	if pErr := <-done; pErr != nil && !errors.Is(pErr, context.Canceled) {
+		return nil, pErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// runBeaconGet function prints the set round of the beacon in the set
+// directory; or its last round
+func runBeaconGet(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	b, err := beacon.OpenReader(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	var r *beacon.Round
+	if cfg.Round == 0 {
+		if r, err = b.Last(); err == nil && r == nil {
+			err = fmt.Errorf("%w: the beacon has no rounds yet", beacon.ErrNotFound)
+		}
+	} else {
+		r, err = b.Get(cfg.Round)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.SetJSON {
+		return nil, printRound(s.stdout, r, true)
+	}
+
+	_, err = fmt.Fprintf(s.stdout, "round: %d; index: %d; tick: %s; previous: %s; output: %s; value: %s\n",
+		r.Round, r.Index, r.Tick, r.Previous, r.Output, r.Value)
+	return nil, err
+}
+
+// beaconReport struct is the result of verifying a beacon round
+type beaconReport struct {
+	*beacon.Round
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// runBeaconVerify function derives the set round from the beacon's ledger,
+// and checks it against the set value -- or against the published round, if
+// no value is set; writing the report to stdout. A mismatch -- or a ledger
+// whose chain does not follow from its seed -- results in a `mismatchError`
+func runBeaconVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	var (
+		r         *beacon.Round
+		err       error
+		verifyErr error
+	)
+
+	if cfg.Value != "" {
+		r, err = beacon.Derive(ctx, cfg.Dir, cfg.Round)
+		if err == nil && r.Value != cfg.Value {
+			verifyErr = fmt.Errorf("%w: round %d: expected value %s; got %s", beacon.ErrMismatch, r.Round, r.Value, cfg.Value)
+		}
+	} else {
+		b, oErr := beacon.OpenReader(cfg.Dir)
+		if oErr != nil {
+			return nil, oErr
+		}
+		defer b.Close()
+
+		published, gErr := b.Get(cfg.Round)
+		if gErr != nil {
+			return nil, gErr
+		}
+
+		r, err = beacon.Verify(ctx, cfg.Dir, published)
+	}
+	if errors.Is(err, beacon.ErrMismatch) {
+		verifyErr, err = err, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		// the ledger's chain is broken before the round's tick
+		r = &beacon.Round{Round: cfg.Round}
+	}
+
+	report := &beaconReport{Round: r, Valid: verifyErr == nil}
+	if verifyErr != nil {
+		report.Error = verifyErr.Error()
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		fmt.Fprintf(s.stdout, "round: %d; index: %d; value: %s; valid: %t\n", r.Round, r.Index, r.Value, report.Valid)
+	}
+
+	if verifyErr != nil {
+		return nil, &mismatchError{verifyErr}
+	}
+	return nil, nil
+}
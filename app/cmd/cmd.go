@@ -3,27 +3,59 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
+	"strings"
 
 	"github.com/ZalgoNoise/hashclock/clock"
 	"github.com/ZalgoNoise/hashclock/flags"
 )
 
+// Exit codes returned by `Run`, so that scripts can branch on the result of
+// a command without parsing its output
+const (
+	// ExitOK is returned when the command succeeds; including a verification
+	// which found a match
+	ExitOK int = 0
+
+	// ExitError is returned when the command fails while running
+	ExitError int = 1
+
+	// ExitUsage is returned when the command-line input is invalid
+	ExitUsage int = 2
+
+	// ExitMismatch is returned when a verification completes without a match
+	ExitMismatch int = 3
+
+	// ExitTimeout is returned when a verification's timer runs out without
+	// a match
+	ExitTimeout int = 4
+)
+
+// streams struct holds the input and outputs of a single `Run` call
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
 // printResponse function is a generic fork based on the input `toJSON` value
 // to either run `printJSON` or `printText`. This is to avoid repetition in `Run`
-func printResponse(res *clock.HashClockResponse, toJSON bool) {
+func printResponse(w io.Writer, res *clock.HashClockResponse, toJSON bool) error {
 	if toJSON {
-		printJSON(res)
+		return printJSON(w, res)
 	}
-	printText(res)
+	return printText(w, res)
 }
 
 // printJSON function will parse the set values in `clock.HashClockResponse`
 // and build a new JSON object only containing the set values
-func printJSON(res *clock.HashClockResponse) {
+func printJSON(w io.Writer, res *clock.HashClockResponse) error {
 	type output struct {
 		Seed       string `json:"seed,omitempty"`
 		Iterations int    `json:"iterations,omitempty"`
@@ -33,9 +65,23 @@ func printJSON(res *clock.HashClockResponse) {
 		Match      bool   `json:"match,omitempty"`
 		Duration   string `json:"duration,omitempty"`
 		Algorithm  string `json:"algorithm,omitempty"`
+
+		Checkpoint *clock.Checkpoint `json:"checkpoint,omitempty"`
+		MemoryHard *clock.MemoryHard `json:"memory_hard,omitempty"`
+		Mode       string            `json:"mode,omitempty"`
+		Tag        string            `json:"tag,omitempty"`
+
+		Prefix       *clock.Prefix `json:"prefix,omitempty"`
+		ExpectedWork float64       `json:"expected_work,omitempty"`
 	}
 
 	o := &output{}
+	o.Checkpoint = res.Checkpoint
+	o.MemoryHard = res.MemoryHard
+	o.Mode = res.Mode
+	o.Tag = res.Tag
+	o.Prefix = res.Prefix
+	o.ExpectedWork = res.ExpectedWork
 
 	o.Seed = res.Seed
 	o.Hash = res.Hash
@@ -49,24 +95,24 @@ func printJSON(res *clock.HashClockResponse) {
 		o.Timeout = res.Timeout
 	}
 
-	if res.Target != "" {
+	if res.Target != "" || res.Prefix != nil {
 		o.Target = res.Target
 		o.Match = res.Match
 		o.Duration = res.Duration.String()
 	}
 
 	out, err := json.Marshal(o)
-
 	if err != nil {
-		panic(err)
+		return err
 	}
-	fmt.Println(string(out))
-	os.Exit(0)
+
+	_, err = fmt.Fprintln(w, string(out))
+	return err
 }
 
 // printText function will parse the set values in `clock.HashClockResponse`
 // and build a std-out message only containing the set values
-func printText(res *clock.HashClockResponse) {
+func printText(w io.Writer, res *clock.HashClockResponse) error {
 	const (
 		pad string = "----"
 		tb  string = "timeout: "
@@ -78,6 +124,12 @@ func printText(res *clock.HashClockResponse) {
 		m   string = "match: "
 		d   string = "duration: "
 		a   string = "algo: "
+		c   string = "checkpoint: #"
+		md  string = "mode: "
+		tg  string = "tag: "
+		px  string = "prefix: "
+		zb  string = "zero bits: "
+		ew  string = "expected work: "
 		sp  string = "; "
 		nl  string = "\n"
 	)
@@ -94,146 +146,161 @@ func printText(res *clock.HashClockResponse) {
 	if res.Iterations > 0 {
 		out += i + strconv.Itoa(res.Iterations) + sp
 	}
-	out += s + res.Seed + sp
+	if res.Seed != "" || res.Checkpoint == nil {
+		out += s + res.Seed + sp
+	}
+	if res.Checkpoint != nil {
+		out += c + strconv.Itoa(res.Checkpoint.Index) + sp
+	}
 
 	if res.Target != "" {
 		out += t + res.Target + sp + m + strconv.FormatBool(res.Match) + sp
 	}
 
+	if p := res.Prefix; p != nil {
+		if p.Hex != "" {
+			out += px + p.Hex + sp
+		} else {
+			out += zb + strconv.Itoa(p.Bits) + sp
+		}
+		out += m + strconv.FormatBool(res.Match) + sp + ew + strconv.FormatFloat(res.ExpectedWork, 'g', -1, 64) + sp
+	}
+
 	if res.Duration > 0 {
 		out += d + res.Duration.String() + sp
 	}
 
-	out += a + res.Algorithm + sp
+	if res.MemoryHard != nil {
+		out += a + res.MemoryHard.String() + sp
+	} else {
+		out += a + res.Algorithm + sp
+	}
+
+	if res.Mode != "" {
+		out += md + res.Mode + sp
+		if res.Tag != "" {
+			out += tg + res.Tag + sp
+		}
+	}
 
 	out += nl + pad + nl + res.Hash + nl + pad + nl
 
-	fmt.Println(out)
-	os.Exit(0)
+	_, err := fmt.Fprintln(w, out)
+	return err
 }
 
-// Run function is the entrypoint for a CLI deployment of hashclock
-//
-// The configuration is defined from the parsed flags, and a new
-// `clock.HashClockService` is created. From this point, depending on the
-// defined values, different methods are called.
-//
-// All `clock.HashClockService` methods (except for `RecHashLoop`) return
-// a `clock.HashClockResponse` object, which is parsed in the
-// `printResponse` function
-func Run() {
-	cfg := flags.NewConfig()
+// readSeed function replaces a seed set as '-' with the contents of the
+// input reader (std-in), without its trailing newline
+func readSeed(cfg *flags.CLIConfig, stdin io.Reader) error {
+	if cfg.Seed != "-" {
+		return nil
+	}
 
-	// configure service with input algorithm
-	cService := clock.NewService()
-	err := cService.SetHasher(cfg.Algorithm)
+	if stdin == nil {
+		return errors.New("cannot read seed: std-in is undefined")
+	}
+
+	seed, err := io.ReadAll(stdin)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		return fmt.Errorf("cannot read seed from std-in: %s", err)
 	}
 
+	cfg.Seed = strings.TrimRight(string(seed), "\r\n")
 	if cfg.Seed == "" {
-		fmt.Println("input seed string is undefined")
-		os.Exit(1)
-	}
-
-	// hash is set
-	// verify hashes from seed
-	if cfg.Hash != "" {
-		// timeout is also set
-		// verify hash within # of seconds
-		if cfg.Timeout > 0 {
-			res, err := cService.VerifyTimeout(cfg.Seed, cfg.Hash, cfg.Timeout)
-			if err != nil {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
-
-			printResponse(res, cfg.SetJSON)
-		}
-
-		// iterations is set
-		// verify hashes with a certain index; default value
-		// for iterations in CLI is 1, so detector starts at 2
-		if cfg.Iterations > 1 {
-			res, err := cService.VerifyIndex(cfg.Seed, cfg.Hash, cfg.Iterations)
-			if err != nil {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
-
-			printResponse(res, cfg.SetJSON)
-		}
-
-		// simple verifier (runs indefinitely)
-		res, err := cService.Verify(cfg.Seed, cfg.Hash)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
-		}
-
-		printResponse(res, cfg.SetJSON)
+		return errors.New("input seed string read from std-in is empty")
 	}
+	return nil
+}
 
-	// timeout is set
-	// calculate hashes for # seconds
-	if cfg.Timeout > 0 {
-		res, err := cService.RecHashTimeout(cfg.Seed, cfg.Timeout)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
-		}
-
-		printResponse(res, cfg.SetJSON)
+// exitCode function returns the exit code for a command's response. Only
+// verifications (including index lookups) and prefix searches can result in a
+// different code than `ExitOK`: a verification without a match returns
+// `ExitTimeout` when the timer ran out before reaching the target, or
+// `ExitMismatch` otherwise
+func exitCode(cfg *flags.CLIConfig, res *clock.HashClockResponse) int {
+	if res == nil || (cfg.Command != "verify" && cfg.Command != "index lookup" && cfg.Command != "search") || res.Match {
+		return ExitOK
 	}
 
-	// 0 iterations set
-	// infinite recursive hashing
-	if cfg.Iterations == 0 {
-		err := cService.RecHashLoop(cfg.Seed, cfg.Breakpoint)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
-		}
+	switch {
+	case cfg.Timeout > 0 && cfg.Iterations == 0:
+		return ExitTimeout
+	case cfg.Timeout > 0 && res.Iterations < cfg.Iterations:
+		return ExitTimeout
+	default:
+		return ExitMismatch
+	}
+}
 
+// Run function is the entrypoint for a CLI deployment of hashclock
+//
+// The configuration is defined from the input arguments (without the program
+// name): a subcommand followed by its flags. Its handler creates a new
+// `clock.HashClockService` bound to the input context, and calls the
+// appropriate methods. Cancelling the context halts any running command.
+//
+// All `clock.HashClockService` methods (except for `RecHashLoop`) return
+// a `clock.HashClockResponse` object, which is parsed in the
+// `printResponse` function and written to stdout. Errors are written
+// to stderr.
+//
+// The returned value is the command's exit code; one of `ExitOK`, `ExitError`,
+// `ExitUsage`, `ExitMismatch` or `ExitTimeout`
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s := &streams{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
 	}
 
-	// 1 iteration set
-	// clac only 1 hash of a seed string
-	if cfg.Iterations == 1 {
-		res, err := cService.Hash(cfg.Seed)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
-		}
+	cfg, err := flags.ParseArgs(args, stderr)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return ExitUsage
+	}
 
-		printResponse(res, cfg.SetJSON)
+	if cfg.Deprecated {
+		fmt.Fprintf(stderr, "warning: flag-only usage is deprecated; use 'hashclock %s' instead\n", cfg.Command)
 	}
 
-	// 2+ iterations set
-	// recursive hashing
-	if cfg.Iterations >= 2 {
+	if err := readSeed(cfg, stdin); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return ExitUsage
+	}
 
-		// breakpoint is 0
-		// don't print calculated hashes
-		if cfg.Breakpoint == 0 {
-			res, err := cService.RecHash(cfg.Seed, cfg.Iterations)
-			if err != nil {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
+	run, ok := commands[cfg.Command]
+	if !ok {
+		fmt.Fprintf(stderr, "command %q is not implemented\n", cfg.Command)
+		return ExitUsage
+	}
 
-			printResponse(res, cfg.SetJSON)
+	res, err := run(ctx, cfg, s)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+
+		var (
+			uErr *usageError
+			mErr *mismatchError
+		)
+		switch {
+		case errors.As(err, &uErr):
+			return ExitUsage
+		case errors.As(err, &mErr):
+			return ExitMismatch
+		default:
+			return ExitError
 		}
+	}
 
-		// breakpoint is 1+
-		// log every X hashes
-		res, err := cService.RecHashPrint(cfg.Seed, cfg.Iterations, cfg.Breakpoint)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
+	if res != nil {
+		if err := printResponse(stdout, res, cfg.SetJSON); err != nil {
+			fmt.Fprintln(stderr, err.Error())
+			return ExitError
 		}
-
-		printResponse(res, cfg.SetJSON)
 	}
+
+	return exitCode(cfg, res)
 }
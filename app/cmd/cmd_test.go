@@ -0,0 +1,565 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSeed string = "genesis_string"
+	testHash string = "d971baf34116ecb1bd23d9375baecae5d87a48ba3f09f76145ebed04986fb686" // index 3
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			args:   []string{"hash", "-seed", "Hello World!"},
+			code:   ExitOK,
+			stdout: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069",
+		}, {
+			args:   []string{"chain", "-seed", testSeed, "-iter", "3", "-log", "1"},
+			code:   ExitOK,
+			stdout: "#3:\t" + testHash,
+		}, {
+			args:   []string{"chain", "-seed", "-", "-iter", "3", "-json"},
+			stdin:  testSeed + "\n",
+			code:   ExitOK,
+			stdout: `"hash":"` + testHash + `"`,
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", testHash},
+			code:   ExitOK,
+			stdout: "match: true",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", testHash, "-iter", "3", "-time", "1"},
+			code:   ExitOK,
+			stdout: "match: true",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", testHash, "-iter", "4"},
+			code:   ExitMismatch,
+			stdout: "match: false",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", strings.Repeat("0", 64), "-time", "1"},
+			code:   ExitTimeout,
+			stdout: "match: false",
+		}, {
+			args:   []string{"chain", "-from-index", "2", "-from-hash", "05d64f8e6ccd3810fbee93f6ee4120fd5a7061dddc2797dd1997a765a00e5006", "-iter", "3", "-log", "1"},
+			code:   ExitOK,
+			stdout: "#3:\t" + testHash,
+		}, {
+			args:   []string{"verify", "-from-index", "2", "-from-hash", "05d64f8e6ccd3810fbee93f6ee4120fd5a7061dddc2797dd1997a765a00e5006", "-hash", testHash, "-json"},
+			code:   ExitOK,
+			stdout: `"checkpoint":{"index":2`,
+		}, {
+			args:   []string{"verify", "-from-index", "3", "-from-hash", testHash, "-hash", testHash, "-iter", "2"},
+			code:   ExitUsage,
+			stderr: "does not come after -from-index",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", testHash, "-iter", "3", "-cache-interval", "1"},
+			code:   ExitOK,
+			stdout: "match: true",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", testHash, "-cache-interval", "-1"},
+			code:   ExitUsage,
+			stderr: "-cache-interval cannot be negative",
+		}, {
+			args:   []string{"chain", "-seed", "x", "-iter", "3", "-alg", "memhard", "-mem", "8", "-cost", "10", "-json"},
+			code:   ExitOK,
+			stdout: `"hash":"6951489f13b383795def8061ff8798a7d2c1bd705665e8fb0b7f0225001aa41d","algorithm":"MEMHARD","memory_hard":{"memory":8,"cost":10}`,
+		}, {
+			args:   []string{"verify", "-seed", "x", "-iter", "3", "-alg", "memhard", "-mem", "8", "-cost", "10", "-hash", "6951489f13b383795def8061ff8798a7d2c1bd705665e8fb0b7f0225001aa41d"},
+			code:   ExitOK,
+			stdout: "match: true",
+		}, {
+			args:   []string{"hash", "-seed", "x", "-alg", "memhard", "-cost", "0"},
+			code:   ExitUsage,
+			stderr: "-cost must be greater than zero",
+		}, {
+			args:   []string{"bench", "-alg", "memhard", "-mem", "8", "-cost", "10", "-json"},
+			code:   ExitOK,
+			stdout: `"algorithm":"MEMHARD"`,
+		}, {
+			args:   []string{"chain", "-seed", "x", "-iter", "3", "-mode", "indexed", "-tag", "demo", "-json"},
+			code:   ExitOK,
+			stdout: `"hash":"4b3cb0094c5b485f7a9aa4160d56f582e3bfbbc101b29ff67326e50888126bca","algorithm":"SHA256","mode":"indexed","tag":"demo"`,
+		}, {
+			args:   []string{"verify", "-seed", "x", "-mode", "indexed", "-tag", "demo", "-hash", "4b3cb0094c5b485f7a9aa4160d56f582e3bfbbc101b29ff67326e50888126bca"},
+			code:   ExitOK,
+			stdout: "hashes: 3;",
+		}, {
+			args:   []string{"verify", "-seed", "x", "-iter", "3", "-mode", "indexed", "-tag", "other", "-hash", "4b3cb0094c5b485f7a9aa4160d56f582e3bfbbc101b29ff67326e50888126bca"},
+			code:   ExitMismatch,
+			stdout: "match: false",
+		}, {
+			args:   []string{"chain", "-seed", "x", "-iter", "3", "-tag", "demo"},
+			code:   ExitUsage,
+			stderr: "-tag is only used with -mode indexed",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", testHash[:10]},
+			code:   ExitUsage,
+			stderr: "hash length 10 does not match the SHA256 digest length 64",
+		}, {
+			args:   []string{"search", "-seed", "Hello World!", "-prefix", "abc"},
+			code:   ExitOK,
+			stdout: "hashes: 1994; seed: Hello World!; prefix: abc; match: true; expected work: 4096;",
+		}, {
+			args:   []string{"search", "-seed", "Hello World!", "-bits", "16", "-json"},
+			code:   ExitOK,
+			stdout: `"iterations":75307,"hash":"000064eda69cfd6c2f8d6aa6823ab6c24edbeabac2299be454344fa11cc4f4c5","match":true`,
+		}, {
+			args:   []string{"search", "-seed", testSeed, "-bits", "200", "-time", "1"},
+			code:   ExitTimeout,
+			stdout: "match: false",
+		}, {
+			args:   []string{"search", "-seed", testSeed, "-prefix", "abc", "-bits", "8"},
+			code:   ExitUsage,
+			stderr: "either -prefix or -bits is required",
+		}, {
+			args:   []string{"search", "-seed", testSeed, "-alg", "md5", "-bits", "129"},
+			code:   ExitUsage,
+			stderr: "number of leading zero bits must be between 1 and 128",
+		}, {
+			args:   []string{"batch"},
+			stdin:  "seed,iter\n" + testSeed + ",3\n",
+			code:   ExitOK,
+			stdout: `"line":2,"method":"RecHash","seed":"` + testSeed + `"`,
+			stderr: "jobs: 1; ok: 1",
+		}, {
+			args:   []string{"batch", "-order", "completion"},
+			stdin:  `{"seed":"` + testSeed + `","iter":3,"hash":"` + testHash + `"}` + "\n" + `{"seed":"` + testSeed + `","iter":4,"hash":"` + testHash + `"}`,
+			code:   ExitMismatch,
+			stderr: "line 2: hash does not match",
+		}, {
+			args:   []string{"-seed", testSeed, "-iter", "3", "-log", "0"},
+			code:   ExitOK,
+			stdout: testHash,
+			stderr: "deprecated",
+		}, {
+			args:   []string{"follow-verify", "-seed", testSeed},
+			stdin:  "#1:\tcb6ebc0ee2c4bbd1a4b5d8cb3e0ac6a6e8d1a8e3b9e0e5a7c2b3e4d5f6a7b8c9\n",
+			code:   ExitMismatch,
+			stdout: "lines: 0",
+			stderr: "invalid line 1",
+		}, {
+			args:   []string{"follow-verify", "-seed", testSeed},
+			stdin:  "#3:\t" + testHash + "\n",
+			code:   ExitOK,
+			stdout: "lines: 1; last index: 3",
+		}, {
+			args:   []string{"rpc"},
+			stdin:  `{"jsonrpc":"2.0","id":1,"method":"RecHash","params":{"seed":"` + testSeed + `","iterations":3}}` + "\n",
+			code:   ExitOK,
+			stdout: `"hash":"` + testHash + `"`,
+		}, {
+			args:   []string{"rpc", "-net", "tcp"},
+			code:   ExitUsage,
+			stderr: "-addr is required",
+		}, {
+			args:   []string{"chain", "-seed", testSeed},
+			code:   ExitUsage,
+			stderr: "-iter must be greater than zero",
+		}, {
+			args:   []string{"verify", "-seed", testSeed, "-hash", "zzz"},
+			code:   ExitUsage,
+			stderr: "not hex-encoded",
+		}, {
+			args:   []string{"hash", "-seed", testSeed, "-alg", "sha3"},
+			code:   ExitUsage,
+			stderr: "invalid hasher reference",
+		}, {
+			args:   []string{"rewind", "-seed", testSeed},
+			code:   ExitUsage,
+			stderr: "unknown command",
+		}, {
+			args:   []string{"help", "chain"},
+			code:   ExitOK,
+			stderr: "Usage of hashclock chain",
+		}, {
+			args:   []string{"help", "ledger", "verify"},
+			code:   ExitOK,
+			stderr: "Usage of hashclock ledger verify",
+		}, {
+			args:   []string{"ledger", "-dir", "x"},
+			code:   ExitUsage,
+			stderr: "requires a subcommand",
+		}, {
+			args:   []string{"ledger", "run", "-dir", "x", "-seed", "-", "-events"},
+			code:   ExitUsage,
+			stderr: "-events cannot be used",
+		}, {
+			args:   []string{"ledger", "follow", "-dir", "x"},
+			code:   ExitUsage,
+			stderr: "-addr is required",
+		}, {
+			args:   []string{"config", "print", "chain", "-seed", testSeed},
+			code:   ExitOK,
+			stdout: testSeed + "\"         flag",
+		}, {
+			args:   []string{"config", "print"},
+			code:   ExitUsage,
+			stderr: "a command is required",
+		},
+	}
+
+	for id, test := range tests {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		code := Run(context.Background(), test.args, strings.NewReader(test.stdin), stdout, stderr)
+
+		if code != test.code {
+			t.Errorf(
+				"#%v Run(%s) = %v ; expected %v -- stderr: %s",
+				id,
+				strings.Join(test.args, " "),
+				code,
+				test.code,
+				stderr.String(),
+			)
+		}
+
+		if !strings.Contains(stdout.String(), test.stdout) {
+			t.Errorf(
+				"#%v Run(%s) wrote %q to stdout ; expected it to contain %q",
+				id,
+				strings.Join(test.args, " "),
+				stdout.String(),
+				test.stdout,
+			)
+		}
+
+		if !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf(
+				"#%v Run(%s) wrote %q to stderr ; expected it to contain %q",
+				id,
+				strings.Join(test.args, " "),
+				stderr.String(),
+				test.stderr,
+			)
+		}
+
+		t.Logf(
+			"#%v -- TESTED -- Run(%s) = %v",
+			id,
+			strings.Join(test.args, " "),
+			code,
+		)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	code := Run(ctx, []string{"loop", "-seed", testSeed, "-log", "1000"}, nil, stdout, stderr)
+
+	// a deadline is not the expected ending of a loop (as opposed to an interrupt)
+	if code != ExitError {
+		t.Errorf("Run(loop) = %v ; expected %v -- stderr: %s", code, ExitError, stderr.String())
+	}
+
+	if !strings.HasPrefix(stdout.String(), "#1000:\t") {
+		t.Errorf("Run(loop) wrote %q to stdout ; expected logged hashes", stdout.String())
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	code = Run(ctx, []string{"loop", "-seed", testSeed}, nil, stdout, stderr)
+	if code != ExitOK {
+		t.Errorf("Run(loop) = %v ; expected %v after an interrupt -- stderr: %s", code, ExitOK, stderr.String())
+	}
+}
+
+func TestRunLedger(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*200, cancel)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	args := []string{"ledger", "run", "-dir", dir, "-seed", testSeed, "-log", "1000", "-segment-size", "5", "-events"}
+	code := Run(ctx, args, strings.NewReader("first\nsecond\n"), stdout, stderr)
+	if code != ExitOK {
+		t.Fatalf("Run(ledger run) = %v ; expected %v -- stderr: %s", code, ExitOK, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "event: ") || !strings.Contains(stdout.String(), "000:\t") {
+		t.Errorf("Run(ledger run) wrote %q to stdout ; expected checkpoint and event entries", stdout.String())
+	}
+
+	stdout.Reset()
+	code = Run(context.Background(), []string{"ledger", "verify", "-dir", dir}, nil, stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "valid: true") {
+		t.Errorf("Run(ledger verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	// corrupt the last byte of the first segment
+	path := filepath.Join(dir, "00000001.seg")
+	b, _ := os.ReadFile(path)
+	b[len(b)-1] ^= 0xff
+	os.WriteFile(path, b, 0o644)
+
+	stdout.Reset()
+	stderr.Reset()
+	code = Run(context.Background(), []string{"ledger", "verify", "-dir", dir, "-json"}, nil, stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stderr.String(), "segment 00000001.seg") {
+		t.Errorf("Run(ledger verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunPoH(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	args := []string{"poh", "run", "-seed", testSeed, "-hashes-per-tick", "100", "-ticks-per-slot", "2", "-slots", "2", "-events"}
+	code := Run(context.Background(), args, strings.NewReader("first\n"), stdout, stderr)
+	if code != ExitOK {
+		t.Fatalf("Run(poh run) = %v ; expected %v -- stderr: %s", code, ExitOK, stderr.String())
+	}
+
+	entries := stdout.String()
+	if n := strings.Count(entries, "\n"); n < 4 {
+		t.Errorf("Run(poh run) wrote %v entries ; expected at least 4 ticks", n)
+	}
+
+	verify := []string{"poh", "verify", "-seed", testSeed, "-hashes-per-tick", "100"}
+
+	stdout.Reset()
+	code = Run(context.Background(), verify, strings.NewReader(entries), stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "ticks: 4; hashes: 400") {
+		t.Errorf("Run(poh verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	// a tick with one hash less breaks both the chain and the tick spacing
+	lines := strings.SplitAfter(entries, "\n")
+	lines[len(lines)-2] = strings.Replace(lines[len(lines)-2], `"num_hashes":100`, `"num_hashes":99`, 1)
+
+	stdout.Reset()
+	stderr.Reset()
+	code = Run(context.Background(), verify, strings.NewReader(strings.Join(lines, "")), stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stderr.String(), "tick after 99 hashes") {
+		t.Errorf("Run(poh verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.idx")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	code := Run(context.Background(), []string{"index", "build", "-seed", testSeed, "-iter", "2", "-file", path}, nil, stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "indexed: 2 hashes") {
+		t.Fatalf("Run(index build) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	// the hash at index 3 is beyond the index, and verified from its last hash
+	stdout.Reset()
+	code = Run(context.Background(), []string{"index", "lookup", "-file", path, "-hash", testHash, "-json"}, nil, stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), `"iterations":3`) {
+		t.Errorf("Run(index lookup) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = Run(context.Background(), []string{"index", "lookup", "-file", path, "-hash", strings.Repeat("0", 64), "-time", "1"}, nil, stdout, stderr)
+	if code != ExitTimeout {
+		t.Errorf("Run(index lookup) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitTimeout, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*200, cancel)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	// a ledger with an event, against the plain chain of its seed
+	args := []string{"ledger", "run", "-dir", dir, "-seed", testSeed, "-log", "1000", "-events"}
+	if code := Run(ctx, args, strings.NewReader("first\n"), stdout, stderr); code != ExitOK {
+		t.Fatalf("Run(ledger run) = %v ; expected %v -- stderr: %s", code, ExitOK, stderr.String())
+	}
+
+	stdout.Reset()
+	code := Run(context.Background(), []string{"diff", "-a", dir, "-b-seed", testSeed}, nil, stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stdout.String(), "cause: an event is mixed in at #") {
+		t.Errorf("Run(diff) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+
+	// a log of the same chain
+	stdout.Reset()
+	code = Run(context.Background(), []string{"diff", "-a", "-", "-b-seed", testSeed}, strings.NewReader("#3:\t"+testHash+"\n"), stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "diverged: false; last common index: 3") {
+		t.Errorf("Run(diff) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunPoW(t *testing.T) {
+	store := filepath.Join(t.TempDir(), "spent")
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	code := Run(context.Background(), []string{"pow", "mint", "-resource", "user@example.com", "-bits", "8", "-alg", "sha256"}, nil, stdout, stderr)
+	if code != ExitOK || !strings.HasPrefix(stdout.String(), "1:8:") {
+		t.Fatalf("Run(pow mint) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+	stamp := strings.TrimSpace(stdout.String())
+
+	verify := []string{"pow", "verify", "-stamp", "-", "-resource", "user@example.com", "-bits", "8", "-alg", "sha1,sha256", "-store", store}
+
+	stdout.Reset()
+	code = Run(context.Background(), verify, strings.NewReader(stamp+"\n"), stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "bits: 8; algo: SHA256; valid: true") {
+		t.Errorf("Run(pow verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	// the stamp was recorded as spent in the store
+	stdout.Reset()
+	stderr.Reset()
+	code = Run(context.Background(), verify, strings.NewReader(stamp), stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stderr.String(), "already spent") {
+		t.Errorf("Run(pow verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+
+	// a stricter difficulty
+	stdout.Reset()
+	stderr.Reset()
+	code = Run(context.Background(), []string{"pow", "verify", "-stamp", stamp, "-resource", "user@example.com", "-bits", "16", "-alg", "sha256", "-json"}, nil, stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stdout.String(), `"valid":false`) {
+		t.Errorf("Run(pow verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+
+	code = Run(context.Background(), []string{"pow", "mint", "-resource", "a:b"}, nil, stdout, stderr)
+	if code != ExitUsage {
+		t.Errorf("Run(pow mint) with an invalid resource = %v ; expected %v", code, ExitUsage)
+	}
+}
+
+func TestRunBeacon(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*300, cancel)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	args := []string{"beacon", "run", "-dir", dir, "-seed", testSeed, "-log", "1000", "-every", "2000", "-delay", "100", "-json"}
+	if code := Run(ctx, args, nil, stdout, stderr); code != ExitOK {
+		t.Fatalf("Run(beacon run) = %v ; expected %v -- stderr: %s", code, ExitOK, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"round":2,"index":4000`) {
+		t.Fatalf("Run(beacon run) should publish round 2 at index 4000 -- stdout: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	code := Run(context.Background(), []string{"beacon", "get", "-dir", dir, "-round", "2"}, nil, stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "round: 2; index: 4000;") {
+		t.Fatalf("Run(beacon get) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+	value := stdout.String()[strings.LastIndex(stdout.String(), "value: ")+7:]
+	value = strings.TrimSpace(value)
+
+	stdout.Reset()
+	code = Run(context.Background(), []string{"beacon", "verify", "-dir", dir, "-round", "2"}, nil, stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "valid: true") {
+		t.Errorf("Run(beacon verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = Run(context.Background(), []string{"beacon", "verify", "-dir", dir, "-round", "2", "-value", value}, nil, stdout, stderr)
+	if code != ExitOK || !strings.Contains(stdout.String(), "valid: true") {
+		t.Errorf("Run(beacon verify -value) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitOK, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = Run(context.Background(), []string{"beacon", "verify", "-dir", dir, "-round", "1", "-value", value}, nil, stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stdout.String(), "valid: false") {
+		t.Errorf("Run(beacon verify -value) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+
+	// the ledger's entries do not follow from another seed
+	manifest := filepath.Join(dir, "ledger.json")
+	data, _ := os.ReadFile(manifest)
+	os.WriteFile(manifest, bytes.Replace(data, []byte(`"`+testSeed+`"`), []byte(`"forged seed"`), 1), 0o644)
+
+	stdout.Reset()
+	code = Run(context.Background(), []string{"beacon", "verify", "-dir", dir, "-round", "2"}, nil, stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stdout.String(), "valid: false") {
+		t.Errorf("Run(beacon verify) of a forged ledger = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunStamp(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(t.TempDir(), "file.txt")
+	path := filepath.Join(t.TempDir(), "receipt.json")
+	os.WriteFile(file, []byte("stamped file"), 0o644)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	args := []string{"stamp", "-dir", dir, "-seed", testSeed, "-log", "100", "-file", file, "-out", path}
+	if code := Run(context.Background(), args, nil, stdout, stderr); code != ExitOK {
+		t.Fatalf("Run(stamp) = %v ; expected %v -- stderr: %s", code, ExitOK, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "event #1 anchored at #100") {
+		t.Errorf("Run(stamp) should anchor the event #1 at #100 -- stderr: %s", stderr.String())
+	}
+
+	// the receipt is checked offline, with an unconfirmed anchor; and
+	// verified against the ledger
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"stamp", "verify", "-file", file, "-receipt", path}, "unconfirmed); consistent: true; valid: false"},
+		{[]string{"stamp", "verify", "-file", file, "-receipt", path, "-dir", dir}, "confirmed); consistent: true; valid: true"},
+	} {
+		stdout.Reset()
+		code := Run(context.Background(), test.args, nil, stdout, stderr)
+		if code != ExitOK || !strings.Contains(stdout.String(), "index: 1; anchor: #100") || !strings.Contains(stdout.String(), test.want) {
+			t.Errorf("Run(%v) = %v ; expected %v -- stdout: %s ; stderr: %s", test.args, code, ExitOK, stdout.String(), stderr.String())
+		}
+	}
+
+	// the same receipt is found in the ledger
+	stdout.Reset()
+	args = []string{"stamp", "-dir", dir, "-file", file, "-find"}
+	if code := Run(context.Background(), args, nil, stdout, stderr); code != ExitOK {
+		t.Fatalf("Run(stamp -find) = %v ; expected %v -- stderr: %s", code, ExitOK, stderr.String())
+	}
+	if data, _ := os.ReadFile(path); stdout.String() != string(data) {
+		t.Errorf("Run(stamp -find) wrote %s ; expected %s", stdout.String(), data)
+	}
+
+	os.WriteFile(file, []byte("another file"), 0o644)
+
+	stdout.Reset()
+	code := Run(context.Background(), []string{"stamp", "verify", "-file", file, "-receipt", path, "-json"}, nil, stdout, stderr)
+	if code != ExitMismatch || !strings.Contains(stdout.String(), `"valid":false`) {
+		t.Errorf("Run(stamp verify) = %v ; expected %v -- stdout: %s ; stderr: %s", code, ExitMismatch, stdout.String(), stderr.String())
+	}
+
+	if code := Run(context.Background(), []string{"stamp", "verify", "-file", file}, nil, stdout, stderr); code != ExitUsage {
+		t.Errorf("Run(stamp verify) without a receipt = %v ; expected %v", code, ExitUsage)
+	}
+}
@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/cache"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/metrics"
+)
+
+// commandFunc type describes a subcommand's handler, which runs the
+// appropriate `clock.HashClockService` method(s) for the input configuration
+type commandFunc func(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error)
+
+// commands maps each subcommand name (as registered in `flags.Commands`)
+// to its handler
+var commands = map[string]commandFunc{
+	"hash":   runHash,
+	"chain":  runChain,
+	"loop":   runLoop,
+	"verify": runVerify,
+	"search": runSearch,
+	"proof":  runProof,
+	"bench":  runBench,
+	"serve":  runServe,
+	"rpc":    runRPC,
+
+	"follow-verify": runFollowVerify,
+	"batch":         runBatch,
+	"diff":          runDiff,
+	"ledger run":    runLedger,
+	"ledger verify": runLedgerVerify,
+	"ledger lead":   runLedgerLead,
+	"ledger follow": runLedgerFollow,
+	"index build":   runIndexBuild,
+	"index lookup":  runIndexLookup,
+	"poh run":       runPoH,
+	"poh verify":    runPoHVerify,
+	"beacon run":    runBeacon,
+	"beacon get":    runBeaconGet,
+	"beacon verify": runBeaconVerify,
+	"pow mint":      runPoWMint,
+	"pow verify":    runPoWVerify,
+	"stamp":         runStamp,
+	"stamp verify":  runStampVerify,
+	"config print":  runConfigPrint,
+}
+
+// usageError struct marks errors caused by invalid input, as opposed to
+// failures while running a command
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+// mismatchError struct marks a failed verification, for commands which do
+// not return a `clock.HashClockResponse`
+type mismatchError struct {
+	err error
+}
+
+func (e *mismatchError) Error() string {
+	return e.err.Error()
+}
+
+// newService function creates a `clock.HashClockService` configured with
+// the input algorithm (and the configured memory-hard parameters, for the
+// memory-hard step function) and the configured chaining mode, bound to the
+// input context and writing any logged hashes to the stdout stream
+func newService(ctx context.Context, alg string, cfg *flags.CLIConfig, s *streams) (*clock.HashClockService, error) {
+	cService := clock.NewService()
+	if err := cService.SetHasher(alg); err != nil {
+		return nil, &usageError{err}
+	}
+	if strings.EqualFold(alg, clock.MemoryHardAlgorithm) && (cfg.Memory != 0 || cfg.Cost != 0) {
+		if err := cService.SetMemoryHard(cfg.Memory, cfg.Cost); err != nil {
+			return nil, &usageError{err}
+		}
+	}
+	if err := cService.SetMode(cfg.Mode, cfg.Tag); err != nil {
+		return nil, &usageError{err}
+	}
+	if err := cService.SetContext(ctx); err != nil {
+		return nil, err
+	}
+	cService.SetWriter(s.stdout)
+
+	return cService, nil
+}
+
+// setCheckpoint function sets the configured checkpoint (if any) on the input
+// service, so that its chain continues from it instead of the seed
+func setCheckpoint(c *clock.HashClockService, cfg *flags.CLIConfig) error {
+	if cfg.FromIndex == 0 {
+		return nil
+	}
+	if err := c.SetCheckpoint(cfg.FromIndex, cfg.FromHash); err != nil {
+		return &usageError{err}
+	}
+	return nil
+}
+
+// newCache function creates the configured checkpoint cache; or nil, if the
+// cache interval is not set
+func newCache(cfg *flags.CLIConfig) (clock.CheckpointCache, error) {
+	if cfg.CacheInterval == 0 {
+		return nil, nil
+	}
+
+	c, err := cache.New(&cache.Config{
+		Dir:            cfg.CacheDir,
+		MaxChains:      cfg.CacheMaxChains,
+		MaxCheckpoints: cfg.CacheMaxCheckpoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// closeCache function closes the input checkpoint cache (if any), writing
+// its persisted chains
+func closeCache(cc clock.CheckpointCache) error {
+	if c, ok := cc.(*cache.Cache); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// setCache function sets the configured checkpoint cache (if any) on the
+// input service, returning it to be closed once the service is done
+func setCache(c *clock.HashClockService, cfg *flags.CLIConfig) (clock.CheckpointCache, error) {
+	cc, err := newCache(cfg)
+	if err != nil || cc == nil {
+		return nil, err
+	}
+	if err := c.SetCheckpointCache(cc, cfg.CacheInterval); err != nil {
+		closeCache(cc)
+		return nil, err
+	}
+	return cc, nil
+}
+
+// runHash function calculates only 1 hash of a seed string
+func runHash(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cService, err := newService(ctx, cfg.Algorithm, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return cService.Hash(cfg.Seed)
+}
+
+// runChain function recursively hashes the seed string for the set number
+// of iterations; logging every # of steps if a breakpoint is set
+func runChain(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cService, err := newService(ctx, cfg.Algorithm, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := setCheckpoint(cService, cfg); err != nil {
+		return nil, err
+	}
+	cc, err := setCache(cService, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCache(cc)
+
+	// breakpoint is 0
+	// don't print calculated hashes
+	if cfg.Breakpoint == 0 {
+		return cService.RecHash(cfg.Seed, cfg.Iterations)
+	}
+
+	// breakpoint is 1+
+	// log every X hashes
+	return cService.RecHashPrint(cfg.Seed, cfg.Iterations, cfg.Breakpoint)
+}
+
+// runLoop function recursively hashes the seed string indefinitely,
+// logging every # of steps; and serving its metrics, if an address is set
+func runLoop(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cService, err := newService(ctx, cfg.Algorithm, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := setCheckpoint(cService, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.MetricsAddr != "" {
+		m := metrics.New()
+		cService.SetProgressFunc(m.ProgressFunc(cfg.Algorithm))
+		cService.SetTickFunc(m.CheckpointFunc())
+
+		if err := serveMetrics(ctx, cfg.MetricsAddr, m); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(s.stderr, "serving metrics on %s/metrics\n", cfg.MetricsAddr)
+	}
+
+	// the loop only halts once the context is cancelled (e.g. with Ctrl+C),
+	// which is its expected ending
+	err = cService.RecHashLoop(cfg.Seed, cfg.Breakpoint)
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// runVerify function verifies the input hash against the seed's chain,
+// picking the verification method from the set index and timeout:
+//
+// - index and timeout: `VerifyIndexTimeout`
+// - timeout only: `VerifyTimeout`
+// - index only: `VerifyIndex`
+// - neither: `Verify` (runs until the hash is found)
+func runVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cService, err := newService(ctx, cfg.Algorithm, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := clock.ValidateHashLength(cfg.Algorithm, cfg.Hash); err != nil {
+		return nil, &usageError{err}
+	}
+	if err := setCheckpoint(cService, cfg); err != nil {
+		return nil, err
+	}
+	cc, err := setCache(cService, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCache(cc)
+
+	switch {
+	case cfg.Iterations > 0 && cfg.Timeout > 0:
+		return cService.VerifyIndexTimeout(cfg.Seed, cfg.Hash, cfg.Iterations, cfg.Timeout)
+	case cfg.Timeout > 0:
+		return cService.VerifyTimeout(cfg.Seed, cfg.Hash, cfg.Timeout)
+	case cfg.Iterations > 0:
+		return cService.VerifyIndex(cfg.Seed, cfg.Hash, cfg.Iterations)
+	default:
+		return cService.Verify(cfg.Seed, cfg.Hash)
+	}
+}
+
+// runSearch function walks the seed's chain until a hash starts with the set
+// hex prefix (or number of leading zero bits), with `SearchPrefixTimeout` if
+// a timeout is set, or `SearchPrefix` otherwise
+func runSearch(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cService, err := newService(ctx, cfg.Algorithm, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	prefix := clock.Prefix{Hex: cfg.Prefix, Bits: cfg.Bits}
+	if err := clock.ValidatePrefix(cfg.Algorithm, prefix); err != nil {
+		return nil, &usageError{err}
+	}
+	if err := setCheckpoint(cService, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Timeout > 0 {
+		return cService.SearchPrefixTimeout(cfg.Seed, prefix, cfg.Timeout)
+	}
+	return cService.SearchPrefix(cfg.Seed, prefix)
+}
+
+// runProof function recursively hashes the seed string for the set number
+// of seconds
+func runProof(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cService, err := newService(ctx, cfg.Algorithm, cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := setCheckpoint(cService, cfg); err != nil {
+		return nil, err
+	}
+
+	return cService.RecHashTimeout(cfg.Seed, cfg.Timeout)
+}
+
+// benchResult struct holds the hashing rate measured for one algorithm
+type benchResult struct {
+	Algorithm  string  `json:"algorithm"`
+	Iterations int     `json:"iterations"`
+	Timeout    int     `json:"timeout"`
+	Rate       float64 `json:"hashes_per_second"`
+
+	// MemoryHard are the parameters of the memory-hard step function, if
+	// benchmarked
+	MemoryHard *clock.MemoryHard `json:"memory_hard,omitempty"`
+}
+
+// runBench function measures the hashing rate of the set algorithm (or of
+// all algorithms), by recursively hashing the seed for the set number of
+// seconds with each of them. The results are written to stdout directly, as
+// there is no single `clock.HashClockResponse` to return
+func runBench(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	var algs []string
+
+	if cfg.Algorithm == "all" {
+		for idx := 0; idx < len(clock.HasherMapVals); idx++ {
+			algs = append(algs, clock.HasherMapVals[idx])
+		}
+	} else {
+		algs = append(algs, cfg.Algorithm)
+	}
+
+	var results []benchResult
+
+	for _, alg := range algs {
+		cService, err := newService(ctx, alg, cfg, s)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := cService.RecHashTimeout(cfg.Seed, cfg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, benchResult{
+			Algorithm:  res.Algorithm,
+			Iterations: res.Iterations,
+			Timeout:    res.Timeout,
+			Rate:       float64(res.Iterations) / float64(res.Timeout),
+			MemoryHard: res.MemoryHard,
+		})
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(results)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fmt.Fprintln(s.stdout, string(out))
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-12s %14s %16s\n", "algo", "hashes", "hashes/sec")
+	for _, r := range results {
+		fmt.Fprintf(&sb, "%-12s %14d %16.0f\n", r.Algorithm, r.Iterations, r.Rate)
+	}
+	_, err := fmt.Fprint(s.stdout, sb.String())
+	return nil, err
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+)
+
+// runConfigPrint function writes the effective configuration of the target
+// command to stdout: each flag's value, and where it came from (a flag, an
+// environment variable, the configuration file or its default)
+func runConfigPrint(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# hashclock %s\n", cfg.Target)
+	fmt.Fprintf(&sb, "%-18s %-24s %s\n", "flag", "value", "source")
+
+	for _, setting := range cfg.Settings {
+		source := setting.Source
+		if setting.Origin != "" {
+			source += ": " + setting.Origin
+		}
+
+		fmt.Fprintf(&sb, "%-18s %-24q %s\n", "-"+setting.Name, setting.Value, source)
+	}
+
+	_, err := fmt.Fprint(s.stdout, sb.String())
+	return nil, err
+}
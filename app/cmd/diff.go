@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/diff"
+	"github.com/ZalgoNoise/hashclock/flags"
+)
+
+// diffSource function loads a chain to compare: the ledger or log at the input
+// path ('-' reads a log from std-in); or, without a path, the chain of the
+// input seed, algorithm and chaining mode (and domain tag)
+func diffSource(name, path, seed, alg, mode, tag string, s *streams) (*diff.Source, error) {
+	if path == "" {
+		src, err := diff.Spec(name, seed, alg, mode, tag)
+		if err != nil {
+			return nil, &usageError{err}
+		}
+		return src, nil
+	}
+
+	if path == "-" {
+		if s.stdin == nil {
+			return nil, errors.New("cannot read logged hashes: std-in is undefined")
+		}
+		return readLog(name, s.stdin, seed, alg, tag)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return diff.ReadLedger(name, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readLog(name, f, seed, alg, tag)
+}
+
+// readLog function loads a log to compare, with the input domain tag to try
+// in the indexed chaining mode
+func readLog(name string, r io.Reader, seed, alg, tag string) (*diff.Source, error) {
+	src, err := diff.ReadLog(name, r, seed, alg)
+	if err != nil {
+		return nil, err
+	}
+	src.Tag = tag
+	return src, nil
+}
+
+// runDiff function compares chains A and B, writing where they diverge (if
+// they do) to stdout. Diverging chains result in a `mismatchError`
+func runDiff(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	a, err := diffSource("a", cfg.SourceA, cfg.SeedA, cfg.AlgorithmA, cfg.ModeA, cfg.TagA, s)
+	if err != nil {
+		return nil, err
+	}
+	b, err := diffSource("b", cfg.SourceB, cfg.SeedB, cfg.AlgorithmB, cfg.ModeB, cfg.TagB, s)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := diff.Diff(ctx, a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(res)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		printDiff(s, res)
+	}
+
+	if res.Diverged {
+		return nil, &mismatchError{fmt.Errorf("the chains diverge after #%d", res.LastCommon)}
+	}
+	return nil, nil
+}
+
+// printDiff function writes the input diff result as text
+func printDiff(s *streams, res *diff.Result) {
+	switch {
+	case !res.Diverged:
+		fmt.Fprintf(s.stdout, "diverged: false; last common index: %d; shared checkpoints: %d\n", res.LastCommon, res.Shared)
+		return
+	case res.Exact:
+		fmt.Fprintf(s.stdout, "diverged: true; last common index: %d; first differing index: %d; shared checkpoints: %d\n",
+			res.LastCommon, res.Index, res.Shared)
+	default:
+		fmt.Fprintf(s.stdout, "diverged: true; last common index: %d; first differing checkpoint: %d; shared checkpoints: %d\n",
+			res.LastCommon, res.Index, res.Shared)
+	}
+
+	for _, side := range []diff.Side{res.A, res.B} {
+		mode := side.Mode
+		if side.Tag != "" {
+			mode += fmt.Sprintf(" %q", side.Tag)
+		}
+		fmt.Fprintf(s.stdout, "%s: %s (%s) #%d:\t%s\n", side.Name, side.Algorithm, mode, res.Index, side.Hash)
+	}
+	if res.Cause != "" {
+		fmt.Fprintf(s.stdout, "cause: %s\n", res.Cause)
+	}
+}
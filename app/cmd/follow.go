@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+)
+
+// runFollowVerify function verifies the hashes logged by `chain` or `loop`
+// (`#N:\t<hash>` lines) from the set file or std-in, writing the report to
+// stdout once the input is exhausted or interrupted (e.g. with Ctrl+C). A
+// line which does not belong to the chain results in a `mismatchError`
+func runFollowVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	v, err := clock.NewLogVerifier(cfg.Algorithm, cfg.Seed, cfg.Workers)
+	if err != nil {
+		return nil, &usageError{err}
+	}
+	if err := v.SetMode(cfg.Mode, cfg.Tag); err != nil {
+		return nil, &usageError{err}
+	}
+
+	var r io.Reader = s.stdin
+	if cfg.Input != "-" {
+		f, err := os.Open(cfg.Input)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if r == nil {
+		return nil, errors.New("cannot read logged hashes: std-in is undefined")
+	}
+
+	// an interrupt is the expected ending when following a running loop, so
+	// the lines verified so far are still reported
+	report, err := v.Verify(ctx, r)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		fmt.Fprintf(s.stdout, "lines: %d; last index: %d; last hash: %s; algo: %s; valid: %t\n",
+			report.Lines, report.LastIndex, report.LastHash, report.Algorithm, report.Valid)
+	}
+
+	if !report.Valid {
+		return nil, &mismatchError{report.Bad}
+	}
+	return nil, nil
+}
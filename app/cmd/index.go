@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/index"
+)
+
+// runIndexBuild function hashes the seed up to the set index, writing the
+// chain's lookup index to the set file; and prints the index's header
+func runIndexBuild(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	if _, err := clock.NewChain(cfg.Algorithm, cfg.Seed); err != nil {
+		return nil, &usageError{err}
+	}
+
+	h, err := index.Build(ctx, cfg.Index, &index.Config{
+		Seed:       cfg.Seed,
+		Algorithm:  cfg.Algorithm,
+		Length:     cfg.Iterations,
+		PrefixSize: cfg.PrefixSize,
+		BloomBits:  cfg.BloomBits,
+		Mode:       cfg.Mode,
+		Tag:        cfg.Tag,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(h)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+		return nil, nil
+	}
+
+	fmt.Fprintf(s.stdout, "indexed: %d hashes; prefix: %d bytes; bloom filter: %d bits; algo: %s; mode: %s; file: %s\n#%d:\t%s\n",
+		h.Length, h.PrefixSize, h.BloomBits, h.Algorithm, h.Mode, cfg.Index, h.Last.Index, h.Last.Hash)
+	return nil, nil
+}
+
+// runIndexLookup function finds the index of the input hash in the set index
+// file, with `index.Find`: hashes beyond the index are verified from its last
+// hash, until found or until the set timeout runs out
+func runIndexLookup(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	x, err := index.Open(cfg.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer x.Close()
+
+	return x.Find(ctx, cfg.Seed, cfg.Hash, cfg.Timeout)
+}
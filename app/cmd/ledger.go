@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// openLedger function opens the ledger in the configured directory; or
+// creates it from the configured seed and algorithm, if it does not exist
+func openLedger(cfg *flags.CLIConfig, s *streams) (*ledger.Ledger, error) {
+	l, err := ledger.Open(cfg.Dir)
+	if err == nil {
+		m := l.Manifest()
+		if cfg.Seed != "" && cfg.Seed != m.Seed {
+			l.Close()
+			return nil, &usageError{errors.New("input seed does not match the ledger's seed")}
+		}
+
+		if last, ok := l.Last(); ok {
+			fmt.Fprintf(s.stderr, "resuming ledger in %s from index %d\n", cfg.Dir, last.Index)
+		}
+		return l, nil
+	}
+
+	if !errors.Is(err, ledger.ErrNotExist) {
+		return nil, err
+	}
+
+	if cfg.Seed == "" {
+		return nil, &usageError{errors.New("-seed is required to create a new ledger")}
+	}
+	if _, err := clock.NewChain(cfg.Algorithm, cfg.Seed); err != nil {
+		return nil, &usageError{err}
+	}
+
+	return ledger.Create(&ledger.Config{
+		Dir:         cfg.Dir,
+		Algorithm:   cfg.Algorithm,
+		Seed:        cfg.Seed,
+		Interval:    cfg.Breakpoint,
+		SegmentSize: cfg.SegmentSize,
+	})
+}
+
+// printEntry function returns a `ledger.EntryFunc` which logs each entry to
+// the stdout stream
+func printEntry(s *streams) ledger.EntryFunc {
+	return func(e ledger.Entry) {
+		if e.Event != "" {
+			fmt.Fprintf(s.stdout, "#%v:\t%s\tevent: %s\n", e.Index, e.Hash, e.Event)
+			return
+		}
+		fmt.Fprintf(s.stdout, "#%v:\t%s\n", e.Index, e.Hash)
+	}
+}
+
+// readEvents function records each (non-empty) line read from the stdin
+// stream as an event, until it is exhausted or the context is cancelled
+func readEvents(ctx context.Context, s *streams, rec *ledger.Recorder) {
+	if s.stdin == nil {
+		return
+	}
+
+	scanner := bufio.NewScanner(s.stdin)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		// the scanner reuses its buffer
+		event := append([]byte(nil), scanner.Bytes()...)
+		if err := rec.Record(ctx, event); err != nil {
+			return
+		}
+	}
+}
+
+// runLedger function runs a clock recorded in an on-disk ledger, logging each
+// of its entries; until the context is cancelled (e.g. with Ctrl+C). With
+// events enabled, each line read from std-in is mixed into the chain
+func runLedger(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	l, err := openLedger(cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	l.Sync = cfg.Sync
+
+	rec, err := ledger.NewRecorder(l)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.OnEntry = printEntry(s)
+
+	if cfg.Events {
+		go readEvents(ctx, s, rec)
+	}
+
+	// the recorder only halts once the context is cancelled, which is its
+	// expected ending
+	err = rec.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// runLedgerVerify function verifies every entry of an on-disk ledger, writing
+// the report to stdout. An invalid ledger results in a `mismatchError`
+func runLedgerVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	report, err := ledger.Verify(ctx, cfg.Dir, cfg.Workers)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		fmt.Fprintf(s.stdout, "segments: %d; entries: %d; last index: %d; algo: %s; valid: %t\n",
+			report.Segments, report.Entries, report.LastIndex, report.Algorithm, report.Valid)
+	}
+
+	if !report.Valid {
+		bad := report.Bad
+		return nil, &mismatchError{fmt.Errorf(
+			"invalid record #%d in segment %s (offset %d, index %d): %s",
+			bad.Record, bad.Segment, bad.Offset, bad.Index, bad.Reason,
+		)}
+	}
+	return nil, nil
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/metrics"
+)
+
+// serveMetrics function serves the input metrics on `/metrics`, on the input
+// TCP address, until the context is cancelled
+func serveMetrics(ctx context.Context, addr string, m *metrics.Metrics) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go srv.Serve(l)
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/poh"
+)
+
+// pohBatchSize is the number of entries read before verifying them, in
+// parallel
+const pohBatchSize int = 4096
+
+// pohStart function returns the start hash of a PoH chain: the configured
+// hex-encoded hash, or the digest of the configured seed
+func pohStart(cfg *flags.CLIConfig) ([]byte, error) {
+	if _, err := poh.NewHasher(cfg.Algorithm); err != nil {
+		return nil, &usageError{err}
+	}
+
+	if cfg.Start != "" {
+		return hex.DecodeString(cfg.Start)
+	}
+	return poh.StartHash(cfg.Algorithm, cfg.Seed)
+}
+
+// runPoH function runs a Solana-style PoH chain, writing each entry to stdout
+// as a JSON line; until the set number of slots is reached or the context is
+// cancelled (e.g. with Ctrl+C). With events enabled, the digest of each line
+// read from std-in is mixed into the chain
+func runPoH(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	start, err := pohStart(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := poh.New(start, &poh.Config{
+		Algorithm:     cfg.Algorithm,
+		HashesPerTick: cfg.HashesPerTick,
+		TicksPerSlot:  cfg.TicksPerSlot,
+	})
+	if err != nil {
+		return nil, &usageError{err}
+	}
+
+	mixins := make(chan [][]byte, 1024)
+	if cfg.Events && s.stdin != nil {
+		go func() {
+			scanner := bufio.NewScanner(s.stdin)
+			for scanner.Scan() {
+				if len(scanner.Bytes()) == 0 {
+					continue
+				}
+
+				select {
+				case mixins <- [][]byte{p.Digest(scanner.Bytes())}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	w := bufio.NewWriter(s.stdout)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+
+	err = p.Run(ctx, mixins, cfg.Slots, func(e *poh.Entry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+
+		// flush on every slot, and on every mixin
+		if !e.IsTick() || p.TickHeight()%cfg.TicksPerSlot == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+
+	// the chain only halts once the context is cancelled, which is its
+	// expected ending (when no number of slots is set)
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// pohReport struct is the result of verifying a stream of PoH entries
+type pohReport struct {
+	Algorithm string `json:"algorithm"`
+	Entries   uint64 `json:"entries"`
+	Ticks     uint64 `json:"ticks"`
+	Hashes    uint64 `json:"hashes"`
+	LastHash  string `json:"last_hash"`
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runPoHVerify function verifies a stream of PoH entries (JSON lines) from
+// the set file or std-in, in batches, writing the report to stdout. An
+// invalid entry results in a `mismatchError`
+func runPoHVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	start, err := pohStart(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := poh.NewVerifier(cfg.Algorithm, start, cfg.HashesPerTick, cfg.Workers)
+	if err != nil {
+		return nil, &usageError{err}
+	}
+
+	var r io.Reader = s.stdin
+	if cfg.Input != "-" {
+		f, err := os.Open(cfg.Input)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if r == nil {
+		return nil, errors.New("cannot read entries: std-in is undefined")
+	}
+
+	verifyErr := verifyEntries(ctx, v, r)
+	if verifyErr != nil && !errors.As(verifyErr, new(*poh.EntryError)) {
+		return nil, verifyErr
+	}
+
+	report := &pohReport{
+		Algorithm: v.Algorithm(),
+		Entries:   v.Entries(),
+		Ticks:     v.Ticks(),
+		Hashes:    v.Hashes(),
+		LastHash:  v.LastHash(),
+		Valid:     verifyErr == nil,
+	}
+	if verifyErr != nil {
+		report.Error = verifyErr.Error()
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		fmt.Fprintf(s.stdout, "entries: %d; ticks: %d; hashes: %d; last hash: %s; valid: %t\n",
+			report.Entries, report.Ticks, report.Hashes, report.LastHash, report.Valid)
+	}
+
+	if verifyErr != nil {
+		return nil, &mismatchError{verifyErr}
+	}
+	return nil, nil
+}
+
+// verifyEntries function reads the entries (JSON lines) from the input reader
+// and verifies them with the input verifier, in batches. An entry which
+// cannot be decoded is reported as a `poh.EntryError`
+func verifyEntries(ctx context.Context, v *poh.Verifier, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+
+	batch := make([]poh.Entry, 0, pohBatchSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		e := poh.Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			if bErr := v.Verify(ctx, batch); bErr != nil {
+				return bErr
+			}
+			return &poh.EntryError{
+				Position: v.Entries(),
+				Reason:   fmt.Sprintf("invalid JSON: %s", err),
+			}
+		}
+
+		batch = append(batch, e)
+		if len(batch) == pohBatchSize {
+			if err := v.Verify(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return v.Verify(ctx, batch)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/pow"
+)
+
+// powMintReport struct is the result of minting a proof-of-work stamp
+type powMintReport struct {
+	Stamp     string        `json:"stamp"`
+	Algorithm string        `json:"algorithm"`
+	Bits      int           `json:"bits"`
+	Hash      string        `json:"hash"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// runPoWMint function mints a hashcash stamp for the configured resource,
+// writing it to stdout; until one is found or the context is cancelled
+func runPoWMint(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	m, err := pow.NewMinter(cfg.Algorithm, cfg.Bits, cfg.Workers)
+	if err != nil {
+		return nil, &usageError{err}
+	}
+
+	start := time.Now()
+	stamp, err := m.Mint(ctx, cfg.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.SetJSON {
+		fmt.Fprintln(s.stdout, stamp.String())
+		return nil, nil
+	}
+
+	hash, err := stamp.Hash()
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(&powMintReport{
+		Stamp:     stamp.String(),
+		Algorithm: stamp.Algorithm(),
+		Bits:      stamp.Bits,
+		Hash:      hash,
+		Duration:  time.Since(start),
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(s.stdout, string(out))
+	return nil, nil
+}
+
+// powVerifyReport struct is the result of verifying a proof-of-work stamp
+type powVerifyReport struct {
+	Stamp     string `json:"stamp"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Bits      int    `json:"bits,omitempty"`
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runPoWVerify function verifies the configured stamp for the configured
+// resource, spending it in the configured store (if set); and writes the
+// report to stdout. An invalid stamp results in a `mismatchError`
+func runPoWVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	text := cfg.Stamp
+	if text == "-" {
+		if s.stdin == nil {
+			return nil, &usageError{errors.New("cannot read stamp: std-in is undefined")}
+		}
+		b, err := io.ReadAll(s.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read stamp from std-in: %s", err)
+		}
+		text = strings.TrimSpace(string(b))
+	}
+
+	store, err := pow.OpenStore(cfg.Store)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	v, err := pow.NewVerifier(pow.Config{
+		Bits:       cfg.Bits,
+		Window:     cfg.Window,
+		Algorithms: strings.Split(cfg.Algorithm, ","),
+		Store:      store,
+	})
+	if err != nil {
+		return nil, &usageError{err}
+	}
+
+	stamp, verifyErr := v.Verify(text, cfg.Resource)
+	if verifyErr != nil && !pow.IsInvalid(verifyErr) {
+		return nil, verifyErr
+	}
+
+	report := &powVerifyReport{
+		Stamp: text,
+		Valid: verifyErr == nil,
+	}
+	if stamp != nil {
+		report.Algorithm = stamp.Algorithm()
+		report.Bits = stamp.Bits
+	}
+	if verifyErr != nil {
+		report.Error = verifyErr.Error()
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		fmt.Fprintf(s.stdout, "stamp: %s; bits: %d; algo: %s; valid: %t\n",
+			report.Stamp, report.Bits, report.Algorithm, report.Valid)
+	}
+
+	if verifyErr != nil {
+		return nil, &mismatchError{verifyErr}
+	}
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/replica"
+)
+
+// runLedgerLead function runs a clock recorded in an on-disk ledger (like
+// `runLedger`), serving its entries to followers on a TCP address; until the
+// context is cancelled (e.g. with Ctrl+C)
+func runLedgerLead(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	l, err := openLedger(cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	l.Sync = cfg.Sync
+
+	leader, err := replica.NewLeader(l)
+	if err != nil {
+		return nil, err
+	}
+	leader.OnEntry = printEntry(s)
+
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(s.stderr, "serving the ledger in %s to followers on %s\n", cfg.Dir, lis.Addr())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- leader.Serve(ctx, lis)
+		cancel()
+	}()
+
+	if cfg.Events {
+		go readEvents(ctx, s, leader.Recorder())
+	}
+
+	err = leader.Run(ctx)
+	cancel()
+
+	if sErr := <-serveErr; sErr != nil {
+		return nil, sErr
+	}
+
+	// the leader only halts once the context is cancelled, which is its
+	// expected ending
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// runLedgerFollow function replicates a leader's ledger into a local copy,
+// logging each verified entry; until the context is cancelled (e.g. with
+// Ctrl+C). A fork or an invalid segment results in a `mismatchError`
+func runLedgerFollow(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	f := replica.NewFollower(cfg.Addr, cfg.Dir)
+	f.Retry = time.Duration(cfg.Timeout) * time.Second
+	f.Sync = cfg.Sync
+	f.OnEntry = printEntry(s)
+	f.OnDisconnect = func(err error) {
+		fmt.Fprintf(s.stderr, "disconnected from %s: %s; reconnecting in %v\n", cfg.Addr, err, f.Retry)
+	}
+
+	err := f.Run(ctx)
+
+	var (
+		fErr *replica.ForkError
+		sErr *replica.SegmentError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return nil, nil
+	case errors.As(err, &fErr), errors.As(err, &sErr):
+		return nil, &mismatchError{err}
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/rpc"
+)
+
+// runRPC function serves the `clock.HashClockService` methods over JSON-RPC,
+// on std-in / std-out or on a TCP or Unix socket; until the input is
+// exhausted or the context is cancelled (e.g. with Ctrl+C)
+func runRPC(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cache, err := newCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCache(cache)
+
+	srv := rpc.New(&rpc.Config{
+		MaxJobs:          cfg.MaxJobs,
+		ProgressInterval: time.Duration(cfg.Progress) * time.Second,
+		Cache:            cache,
+		CacheInterval:    cfg.CacheInterval,
+	})
+
+	if cfg.Network == "stdio" {
+		err = srv.ServeConn(ctx, s.stdin, s.stdout)
+	} else {
+		l, lErr := net.Listen(cfg.Network, cfg.Addr)
+		if lErr != nil {
+			return nil, lErr
+		}
+
+		fmt.Fprintf(s.stderr, "serving the hashclock JSON-RPC API on %s %s\n", cfg.Network, l.Addr())
+		err = srv.Serve(ctx, l)
+	}
+
+	// an interrupt is the expected way to stop the server
+	if errors.Is(err, context.Canceled) {
+		return nil, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/beacon"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/index"
+	"github.com/ZalgoNoise/hashclock/server"
+	"github.com/ZalgoNoise/hashclock/stream"
+)
+
+// runServe function serves the `clock.HashClockService` methods as a HTTP/JSON
+// API, until the context is cancelled (e.g. with Ctrl+C). If a stream seed is
+// set, a continuous clock also runs and its ticks are streamed to subscribers
+func runServe(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	cache, err := newCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCache(cache)
+
+	srv := server.New(&server.Config{
+		Addr:            cfg.Addr,
+		MaxJobs:         cfg.MaxJobs,
+		MaxQueue:        cfg.MaxQueue,
+		JobTTL:          time.Duration(cfg.JobTTL) * time.Second,
+		ShutdownTimeout: time.Duration(cfg.ShutdownTimeout) * time.Second,
+		Cache:           cache,
+		CacheInterval:   cfg.CacheInterval,
+	})
+
+	if cfg.StreamSeed != "" {
+		ticks, err := stream.New(&stream.Config{
+			Seed:       cfg.StreamSeed,
+			Algorithm:  cfg.StreamAlgorithm,
+			Breakpoint: cfg.StreamLog,
+			BufferSize: cfg.StreamBuffer,
+			OnTick:     srv.Metrics().CheckpointFunc(),
+			OnProgress: srv.Metrics().ProgressFunc(cfg.StreamAlgorithm),
+		})
+		if err != nil {
+			return nil, &usageError{err}
+		}
+		ticks.Register(srv)
+
+		go ticks.Run(ctx)
+
+		fmt.Fprintf(s.stderr, "streaming clock ticks every %v hashes\n", cfg.StreamLog)
+	}
+
+	if cfg.Index != "" {
+		x, err := index.Open(cfg.Index)
+		if err != nil {
+			return nil, err
+		}
+		defer x.Close()
+		x.Register(srv)
+
+		h := x.Header()
+		fmt.Fprintf(s.stderr, "serving lookups in an index of %v %s hashes\n", h.Length, h.Algorithm)
+	}
+
+	if cfg.Beacon != "" {
+		b, err := beacon.OpenReader(cfg.Beacon)
+		if err != nil {
+			return nil, err
+		}
+		defer b.Close()
+		b.Register(srv)
+
+		fmt.Fprintf(s.stderr, "serving the rounds of a beacon every %v hashes\n", b.Params().Every)
+	}
+
+	fmt.Fprintf(s.stderr, "serving the hashclock API on %s\n", cfg.Addr)
+
+	return nil, srv.ListenAndServe(ctx)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/flags"
+	"github.com/ZalgoNoise/hashclock/receipt"
+)
+
+// fileDigest function returns the digest of the file in the input path, as
+// stamped in a receipt
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return receipt.Digest(f)
+}
+
+// runStamp function stamps the set file in the ledger in the set directory:
+// it records the file's digest as an event, running the chain (as in
+// `runLedger`) until a checkpoint anchors it -- or finds the event in the
+// ledger, if set. The receipt is written to the set path, or to stdout
+func runStamp(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	digest, err := fileDigest(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var r *receipt.Receipt
+	if cfg.Find {
+		r, err = receipt.Build(cfg.Dir, digest)
+	} else {
+		l, oErr := openLedger(cfg, s)
+		if oErr != nil {
+			return nil, oErr
+		}
+		defer l.Close()
+		l.Sync = cfg.Sync
+
+		fmt.Fprintf(s.stderr, "stamping %s (sha256: %s)\n", cfg.File, digest)
+		r, err = receipt.Issue(ctx, l, digest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Receipt == "" {
+		_, err = fmt.Fprintln(s.stdout, string(out))
+		return nil, err
+	}
+	if err := os.WriteFile(cfg.Receipt, append(out, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(s.stderr, "event #%d anchored at #%d (%s); receipt written to %s\n",
+		r.Index, r.Anchor.Index, r.Anchor.WallTime.Format(time.RFC3339), cfg.Receipt)
+	return nil, nil
+}
+
+// stampReport struct is the result of verifying a file against its receipt
+type stampReport struct {
+	File   string    `json:"file"`
+	Digest string    `json:"digest"`
+	Index  int       `json:"index"`
+	Anchor int       `json:"anchor"`
+	Time   time.Time `json:"anchor_time"`
+
+	// Consistent is set when the file matches the receipt's digest, and its
+	// chain segment replays up to the anchor
+	Consistent bool `json:"consistent"`
+
+	// Anchored is set when the anchor (and its time) was confirmed against a
+	// copy of the ledger
+	Anchored bool `json:"anchored"`
+
+	// Valid is set when the receipt is both consistent and anchored
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// runStampVerify function verifies the set file against its receipt, by
+// replaying the receipt's chain segment; and checks the receipt's anchor
+// against the ledger in the set directory, if any. The report is written to
+// stdout, and a mismatch results in a `mismatchError`. Without a ledger the
+// anchor and its time are unconfirmed, so the receipt is not reported as
+// valid -- but there is no mismatch either
+func runStampVerify(ctx context.Context, cfg *flags.CLIConfig, s *streams) (*clock.HashClockResponse, error) {
+	data, err := os.ReadFile(cfg.Receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &receipt.Receipt{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, &usageError{fmt.Errorf("invalid receipt %s: %s", cfg.Receipt, err)}
+	}
+
+	f, err := os.Open(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	report := &stampReport{
+		File:   cfg.File,
+		Digest: r.Digest,
+		Index:  r.Index,
+		Anchor: r.Anchor.Index,
+		Time:   r.Anchor.WallTime,
+	}
+
+	verifyErr := r.Verify(ctx, f)
+	if verifyErr == nil {
+		report.Consistent = true
+		if cfg.Dir != "" {
+			verifyErr = r.CheckAnchor(cfg.Dir)
+			report.Anchored = verifyErr == nil
+		}
+	}
+	if verifyErr != nil && !receipt.IsInvalid(verifyErr) {
+		return nil, verifyErr
+	}
+
+	report.Valid = report.Consistent && report.Anchored
+	if verifyErr != nil {
+		report.Error = verifyErr.Error()
+	}
+
+	if cfg.SetJSON {
+		out, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(s.stdout, string(out))
+	} else {
+		anchored := "unconfirmed"
+		if report.Anchored {
+			anchored = "confirmed"
+		}
+		fmt.Fprintf(s.stdout, "file: %s; digest: %s; index: %d; anchor: #%d (%s, %s); consistent: %t; valid: %t\n",
+			report.File, report.Digest, report.Index, report.Anchor, report.Time.Format(time.RFC3339), anchored, report.Consistent, report.Valid)
+	}
+	if report.Consistent && cfg.Dir == "" {
+		fmt.Fprintln(s.stderr, "the anchor and its time are unconfirmed: check them against a copy of the ledger with -dir")
+	}
+
+	if verifyErr != nil {
+		return nil, &mismatchError{verifyErr}
+	}
+	return nil, nil
+}
@@ -0,0 +1,419 @@
+package diff
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	rhash "github.com/ZalgoNoise/meta/crypto/hash"
+)
+
+// checkInterval is the number of hashes between two checks of the context
+const checkInterval int = 1 << 16
+
+// Side struct describes one of the compared chains in a `Result`: its name,
+// its hash function and chaining mode (as detected, for a log), and its hash
+// at the first differing index
+type Side struct {
+	Name      string `json:"name"`
+	Algorithm string `json:"algorithm"`
+	Mode      string `json:"mode"`
+	Tag       string `json:"tag,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+}
+
+// Result struct is the outcome of comparing two chains
+type Result struct {
+	A Side `json:"a"`
+	B Side `json:"b"`
+
+	// Shared is the number of checkpoints (indices) known by both chains
+	Shared int `json:"shared"`
+
+	// Diverged is set if the chains differ at any shared checkpoint
+	Diverged bool `json:"diverged"`
+
+	// LastCommon is the last index where both chains have the same hash (0
+	// for the seed), and CommonHash is that hash
+	LastCommon int    `json:"last_common"`
+	CommonHash string `json:"common_hash,omitempty"`
+
+	// Index is the first index where the chains differ. If Exact is not set,
+	// the segment could not be re-hashed, and the chains diverge somewhere
+	// after LastCommon, up to Index
+	Index int  `json:"index,omitempty"`
+	Exact bool `json:"exact,omitempty"`
+
+	// Cause is the reason why the chains diverge, if one can be found
+	Cause string `json:"cause,omitempty"`
+}
+
+// rules struct describes how a chain is calculated: its hash function and
+// chaining mode (and domain tag, in the indexed mode)
+type rules struct {
+	algorithm string
+	mode      string
+	tag       string
+	hasher    rhash.Hasher
+}
+
+// newRules function returns the rules of the input algorithm and chaining
+// mode; the domain tag is only used in the `clock.ModeIndexed` mode
+func newRules(alg, mode, tag string) (*rules, error) {
+	switch mode {
+	case clock.ModeHex, clock.ModeRaw:
+		tag = ""
+	case clock.ModeIndexed:
+	default:
+		return nil, fmt.Errorf("invalid chaining mode %q", mode)
+	}
+
+	for idx := 0; idx < len(clock.HasherMapVals); idx++ {
+		name := clock.HasherMapVals[idx]
+		if alg == name || alg == strings.ToLower(name) {
+			return &rules{algorithm: name, mode: mode, tag: tag, hasher: clock.HasherMap[idx]}, nil
+		}
+	}
+	return nil, errors.New("invalid hasher reference")
+}
+
+// step method calculates the hash at the input index from the input previous
+// hash (or from the seed, for index 1), mixing in the input event digest if
+// set
+func (r *rules) step(prev []byte, index int, event string) []byte {
+	input := prev
+	switch {
+	case r.mode == clock.ModeRaw && index > 1:
+		input = make([]byte, hex.DecodedLen(len(prev)))
+		hex.Decode(input, prev)
+	case r.mode == clock.ModeIndexed:
+		input = make([]byte, len(prev)+8, len(prev)+8+len(r.tag))
+		copy(input, prev)
+		binary.BigEndian.PutUint64(input[len(prev):], uint64(index))
+		input = append(input, r.tag...)
+	}
+
+	if event != "" {
+		buf := make([]byte, 0, len(input)+len(event))
+		buf = append(buf, input...)
+		input = append(buf, event...)
+	}
+	return r.hasher.Hash(input)
+}
+
+// side struct holds the state of a compared chain while diffing
+type side struct {
+	src   *Source
+	rules *rules
+	seed  []byte
+
+	// computed are the hashes calculated so far for a spec, sorted by index
+	computed []Point
+}
+
+// newSide function creates the `side` of the input source, assuming the other
+// source's seed if its own is not known
+func newSide(src, other *Source) (*side, error) {
+	r, err := newRules(src.Algorithm, src.Mode, src.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", src.Name, err)
+	}
+
+	s := &side{src: src, rules: r}
+	switch {
+	case src.Seed != "":
+		s.seed = []byte(src.Seed)
+	case other.Seed != "":
+		s.seed = []byte(other.Seed)
+	}
+	return s, nil
+}
+
+// hashAt method returns the side's hash at the input index, which must be
+// known: one of its points, or any index for a spec
+func (s *side) hashAt(ctx context.Context, index int) (string, error) {
+	if s.src.Points != nil {
+		p, _ := s.src.point(index)
+		return p.Hash, nil
+	}
+
+	n := sort.Search(len(s.computed), func(i int) bool {
+		return s.computed[i].Index >= index
+	})
+	if n < len(s.computed) && s.computed[n].Index == index {
+		return s.computed[n].Hash, nil
+	}
+
+	// continue from the nearest hash calculated so far
+	prev, from := s.seed, 0
+	if n > 0 {
+		prev, from = []byte(s.computed[n-1].Hash), s.computed[n-1].Index
+	}
+
+	for i := from + 1; i <= index; i++ {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		prev = s.rules.step(prev, i, "")
+	}
+
+	s.computed = append(s.computed, Point{})
+	copy(s.computed[n+1:], s.computed[n:])
+	s.computed[n] = Point{Index: index, Hash: string(prev)}
+
+	return string(prev), nil
+}
+
+// candidates method returns the rules to try when re-hashing the side: its
+// own; and, if they were guessed, every other algorithm with the same hash
+// size in every chaining mode (with the side's domain tag, in the indexed
+// mode) -- but the memory-hard function, whose parameters cannot be guessed
+// (and which is too slow to try)
+func (s *side) candidates() []*rules {
+	out := []*rules{s.rules}
+	if !s.src.guessed {
+		return out
+	}
+
+	size := len(s.src.Points[0].Hash)
+	for idx := 0; idx < len(clock.HasherMapVals); idx++ {
+		if clock.HasherMapVals[idx] == clock.MemoryHardAlgorithm {
+			continue
+		}
+
+		for _, mode := range []string{clock.ModeHex, clock.ModeRaw, clock.ModeIndexed} {
+			r, _ := newRules(clock.HasherMapVals[idx], mode, s.src.Tag)
+			if len(r.hasher.Hash(nil)) != size || (r.algorithm == s.rules.algorithm && r.mode == s.rules.mode) {
+				continue
+			}
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// resolve method sets the side's rules to the first candidate which
+// reproduces its points after the input start (index and hash; a nil hash
+// starts from the seed), up to the input end index. It returns false if none
+// of them do
+func (s *side) resolve(ctx context.Context, from int, start []byte, to int) (bool, error) {
+	if s.src.Points == nil {
+		return true, nil
+	}
+
+	for _, r := range s.candidates() {
+		ok, err := s.reproduces(ctx, r, from, start, to)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			s.rules = r
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reproduces method returns true if the input rules reproduce the side's
+// points in the input segment
+func (s *side) reproduces(ctx context.Context, r *rules, from int, start []byte, to int) (bool, error) {
+	prev := start
+	for i := from + 1; i <= to; i++ {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+
+		p, known := s.src.point(i)
+		prev = r.step(prev, i, p.Event)
+		if known && string(prev) != p.Hash {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Diff function compares the input chains, returning where they diverge (if
+// they do). It binary-searches over the checkpoints both chains share for the
+// first one where they differ; then re-hashes the segment since the last
+// common checkpoint on both sides, to find the first differing step and its
+// cause.
+//
+// Hashes are compared at shared checkpoints only: chains which agree on all of
+// them do not diverge, even if one of them continues further
+func Diff(ctx context.Context, a, b *Source) (*Result, error) {
+	sa, err := newSide(a, b)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := newSide(b, a)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := shared(a, b)
+	if len(idx) == 0 {
+		return nil, errors.New("the chains do not share any checkpoint indices")
+	}
+
+	res := &Result{
+		A:      Side{Name: a.Name, Algorithm: sa.rules.algorithm, Mode: sa.rules.mode, Tag: sa.rules.tag},
+		B:      Side{Name: b.Name, Algorithm: sb.rules.algorithm, Mode: sb.rules.mode, Tag: sb.rules.tag},
+		Shared: len(idx),
+	}
+
+	var sErr error
+	differ := func(i int) bool {
+		if sErr != nil {
+			return true
+		}
+		ha, err := sa.hashAt(ctx, idx[i])
+		if err != nil {
+			sErr = err
+			return true
+		}
+		hb, err := sb.hashAt(ctx, idx[i])
+		if err != nil {
+			sErr = err
+			return true
+		}
+		return ha != hb
+	}
+
+	// once two chains diverge, they do not meet again
+	n := sort.Search(len(idx), differ)
+	if sErr != nil {
+		return nil, sErr
+	}
+
+	if n > 0 {
+		res.LastCommon = idx[n-1]
+		if res.CommonHash, err = sa.hashAt(ctx, res.LastCommon); err != nil {
+			return nil, err
+		}
+	}
+	if n == len(idx) {
+		return res, nil
+	}
+
+	res.Diverged = true
+	res.Index = idx[n]
+	if res.A.Hash, err = sa.hashAt(ctx, res.Index); err != nil {
+		return nil, err
+	}
+	if res.B.Hash, err = sb.hashAt(ctx, res.Index); err != nil {
+		return nil, err
+	}
+
+	if err := pinpoint(ctx, res, sa, sb); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// pinpoint function re-hashes the segment of both chains between the last
+// common index and the first differing checkpoint, setting the result's exact
+// first differing index and its cause
+func pinpoint(ctx context.Context, res *Result, a, b *side) error {
+	var start []byte
+	if res.LastCommon > 0 {
+		start = []byte(res.CommonHash)
+	} else if a.seed == nil {
+		res.Cause = "the chains diverge from the seed, which neither of them records"
+		return nil
+	}
+
+	startA, startB := start, start
+	if start == nil {
+		startA, startB = a.seed, b.seed
+	}
+
+	for _, s := range []*side{a, b} {
+		from := startA
+		if s == b {
+			from = startB
+		}
+
+		ok, err := s.resolve(ctx, res.LastCommon, from, res.Index)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			res.Cause = fmt.Sprintf("the hashes of %s after #%d do not follow from it with any algorithm or chaining mode: a forged or corrupt hash, or an unrecorded event",
+				s.src.Name, res.LastCommon)
+			return nil
+		}
+	}
+
+	res.A.Algorithm, res.A.Mode, res.A.Tag = a.rules.algorithm, a.rules.mode, a.rules.tag
+	res.B.Algorithm, res.B.Mode, res.B.Tag = b.rules.algorithm, b.rules.mode, b.rules.tag
+
+	prevA, prevB := startA, startB
+	for i := res.LastCommon + 1; i <= res.Index; i++ {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		prevA = a.rules.step(prevA, i, a.src.event(i))
+		prevB = b.rules.step(prevB, i, b.src.event(i))
+
+		if string(prevA) != string(prevB) {
+			res.Index = i
+			res.Exact = true
+			res.A.Hash, res.B.Hash = string(prevA), string(prevB)
+			res.Cause = cause(i, a, b)
+			return nil
+		}
+	}
+
+	// both segments were reproduced, so they must differ by their end
+	return errors.New("the re-hashed segments do not differ")
+}
+
+// cause function describes why the input sides differ at the input index
+func cause(index int, a, b *side) string {
+	var causes []string
+
+	ea, eb := a.src.event(index), b.src.event(index)
+	switch {
+	case ea != "" && eb != "" && ea != eb:
+		causes = append(causes, fmt.Sprintf("different events are mixed in at #%d", index))
+	case ea != "" && eb == "":
+		causes = append(causes, fmt.Sprintf("an event is mixed in at #%d in %s only", index, a.src.Name))
+	case eb != "" && ea == "":
+		causes = append(causes, fmt.Sprintf("an event is mixed in at #%d in %s only", index, b.src.Name))
+	}
+
+	if a.rules.algorithm != b.rules.algorithm {
+		causes = append(causes, fmt.Sprintf("different algorithms: %s in %s, %s in %s",
+			a.rules.algorithm, a.src.Name, b.rules.algorithm, b.src.Name))
+	}
+
+	// the chaining mode only matters after the first hash, but for the
+	// indexed mode, which also hashes the seed's index
+	indexed := a.rules.mode == clock.ModeIndexed || b.rules.mode == clock.ModeIndexed
+	if (index > 1 || indexed) && a.rules.mode != b.rules.mode {
+		causes = append(causes, fmt.Sprintf("different chaining modes: %s in %s, %s in %s",
+			a.rules.mode, a.src.Name, b.rules.mode, b.src.Name))
+	}
+	if a.rules.mode == b.rules.mode && a.rules.tag != b.rules.tag {
+		causes = append(causes, fmt.Sprintf("different domain tags: %q in %s, %q in %s",
+			a.rules.tag, a.src.Name, b.rules.tag, b.src.Name))
+	}
+
+	if index == 1 && string(a.seed) != string(b.seed) {
+		causes = append(causes, "different seeds")
+	}
+
+	return strings.Join(causes, "; ")
+}
@@ -0,0 +1,173 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const testSeed string = "Hello World!"
+
+// testLog function returns a log of the chain of the input seed, algorithm
+// and chaining mode (with the input domain tag, in the indexed mode) up to
+// index n: every `interval` hashes, and at the
+// indices of the input events (which are mixed into the chain)
+func testLog(t *testing.T, seed, alg, mode, tag string, n, interval int, events map[int]string) string {
+	t.Helper()
+
+	r, err := newRules(alg, mode, tag)
+	if err != nil {
+		t.Fatalf("FAILED -- [Diff] newRules() failed: %s", err)
+	}
+
+	sb := &strings.Builder{}
+	prev := []byte(seed)
+	for i := 1; i <= n; i++ {
+		event := events[i]
+		prev = r.step(prev, i, event)
+
+		switch {
+		case event != "":
+			fmt.Fprintf(sb, "#%d:\t%s\tevent: %s\n", i, prev, event)
+		case i%interval == 0:
+			fmt.Fprintf(sb, "#%d:\t%s\n", i, prev)
+		}
+	}
+	return sb.String()
+}
+
+func TestDiff(t *testing.T) {
+	spec := func(name, seed, alg, mode, tag string) *Source {
+		s, err := Spec(name, seed, alg, mode, tag)
+		if err != nil {
+			t.Fatalf("FAILED -- [Diff] Spec() failed: %s", err)
+		}
+		return s
+	}
+	log := func(name, seed, text string) *Source {
+		s, err := ReadLog(name, strings.NewReader(text), seed, "")
+		if err != nil {
+			t.Fatalf("FAILED -- [Diff] ReadLog() failed: %s", err)
+		}
+		return s
+	}
+
+	event := strings.Repeat("ab", 32)
+	plain := testLog(t, testSeed, "sha256", clock.ModeHex, "", 100, 10, nil)
+
+	// an event mixed in at #45 which is not logged
+	var unrecorded []string
+	for _, line := range strings.SplitAfter(testLog(t, testSeed, "sha256", clock.ModeHex, "", 100, 10, map[int]string{45: event}), "\n") {
+		if !strings.HasPrefix(line, "#45:") {
+			unrecorded = append(unrecorded, line)
+		}
+	}
+
+	// a log of the clock's chain in the indexed mode, with a domain tag
+	indexed := &strings.Builder{}
+	c := clock.NewService()
+	if err := c.SetMode(clock.ModeIndexed, "tag"); err != nil {
+		t.Fatalf("FAILED -- [Diff] SetMode() failed: %s", err)
+	}
+	c.SetWriter(indexed)
+	if _, err := c.RecHashPrint(testSeed, 100, 10); err != nil {
+		t.Fatalf("FAILED -- [Diff] RecHashPrint() failed: %s", err)
+	}
+	tagged := log("b", "", indexed.String())
+	tagged.Tag = "tag"
+
+	tests := []struct {
+		name     string
+		a, b     *Source
+		diverged bool
+		common   int
+		index    int
+		exact    bool
+		cause    string
+	}{
+		{
+			name:   "identical specs",
+			a:      spec("a", testSeed, "sha256", "", ""),
+			b:      spec("b", testSeed, "sha256", "", ""),
+			common: 2,
+		}, {
+			name:     "algorithm",
+			a:        spec("a", testSeed, "sha256", "", ""),
+			b:        spec("b", testSeed, "sha512_256", "", ""),
+			diverged: true, index: 1, exact: true,
+			cause: "different algorithms: SHA256 in a, SHA512_256 in b",
+		}, {
+			name:     "seed",
+			a:        spec("a", testSeed, "", "", ""),
+			b:        spec("b", "other seed", "", "", ""),
+			diverged: true, index: 1, exact: true,
+			cause: "different seeds",
+		}, {
+			name:     "chaining mode",
+			a:        spec("a", testSeed, "", clock.ModeHex, ""),
+			b:        log("b", "", testLog(t, testSeed, "sha256", clock.ModeRaw, "", 100, 10, nil)),
+			diverged: true, index: 2, exact: true,
+			cause: "different chaining modes: hex in a, raw in b",
+		}, {
+			name:   "indexed mode",
+			a:      spec("a", testSeed, "", clock.ModeIndexed, "tag"),
+			b:      tagged,
+			common: 100,
+		}, {
+			name:     "indexed log",
+			a:        spec("a", testSeed, "", clock.ModeHex, ""),
+			b:        log("b", "", testLog(t, testSeed, "sha256", clock.ModeIndexed, "", 100, 10, nil)),
+			diverged: true, index: 1, exact: true,
+			cause: "different chaining modes: hex in a, indexed in b",
+		}, {
+			name:     "domain tag",
+			a:        spec("a", testSeed, "", clock.ModeIndexed, "tag"),
+			b:        spec("b", testSeed, "", clock.ModeIndexed, "other tag"),
+			diverged: true, index: 1, exact: true,
+			cause: `different domain tags: "tag" in a, "other tag" in b`,
+		}, {
+			name:     "event",
+			a:        log("a", testSeed, plain),
+			b:        log("b", "", testLog(t, testSeed, "sha256", clock.ModeHex, "", 100, 10, map[int]string{37: event})),
+			diverged: true, common: 30, index: 37, exact: true,
+			cause: "an event is mixed in at #37 in b only",
+		}, {
+			name:   "same log",
+			a:      spec("a", testSeed, "", "", ""),
+			b:      log("b", "", plain),
+			common: 100,
+		}, {
+			name:     "unrecorded event",
+			a:        spec("a", testSeed, "", "", ""),
+			b:        log("b", "", strings.Join(unrecorded, "")),
+			diverged: true, common: 40, index: 50,
+			cause: "the hashes of b after #40 do not follow from it",
+		},
+	}
+
+	for _, test := range tests {
+		res, err := Diff(context.Background(), test.a, test.b)
+		if err != nil {
+			t.Fatalf("FAILED -- [Diff] %s: Diff() failed: %s", test.name, err)
+		}
+
+		if res.Diverged != test.diverged || res.LastCommon != test.common || res.Index != test.index || res.Exact != test.exact {
+			t.Errorf("FAILED -- [Diff] %s: unexpected result: %+v", test.name, res)
+		}
+		if !strings.Contains(res.Cause, test.cause) {
+			t.Errorf("FAILED -- [Diff] %s: cause %q ; expected %q", test.name, res.Cause, test.cause)
+		}
+	}
+}
+
+func TestDiffNoShared(t *testing.T) {
+	a, _ := ReadLog("a", strings.NewReader("#1:\t00\n"), "", "")
+	b, _ := ReadLog("b", strings.NewReader("#2:\t00\n"), "", "")
+
+	if _, err := Diff(context.Background(), a, b); err == nil {
+		t.Errorf("FAILED -- [Diff] Diff() of chains without shared indices should fail")
+	}
+}
@@ -0,0 +1,229 @@
+// Package diff finds the point where two hash chains diverge -- such as the
+// chains of two nodes which disagree about a clock.
+//
+// Each chain is a `Source`: a checkpoint log (the `#N:\t<hash>` lines logged
+// by `chain`, `loop` or `ledger run`), a ledger, or a `{seed, algorithm}` spec
+// which is calculated on demand. `Diff` binary-searches over the checkpoints
+// both chains share for the last common index, then re-hashes the segment
+// which follows it on both sides, to pinpoint the first differing step and
+// its cause: a different seed, algorithm, chaining mode or mixed-in event.
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// eventSep separates a logged hash from the digest of the event mixed into
+// it, in the lines logged by `ledger run`
+const eventSep string = "\tevent: "
+
+// Point struct is a known hash of a chain: its index, its (hex-encoded) hash
+// and the (hex-encoded) digest of the event mixed into it, if any
+type Point struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+	Event string `json:"event,omitempty"`
+}
+
+// Source struct is one of the chains to compare. A source with points only
+// knows the hashes at those indices; a source without points (a spec) knows
+// every hash, calculated from its seed
+type Source struct {
+	// Name identifies the source in a `Result`
+	Name string
+
+	// Algorithm is the chain's hash function
+	Algorithm string
+
+	// Mode is the chain's chaining mode: `clock.ModeHex`, `clock.ModeRaw` or
+	// `clock.ModeIndexed`; and Tag its domain tag, in the indexed mode (also
+	// tried for a log, when guessing its chaining mode)
+	Mode string
+	Tag  string
+
+	// Seed is the chain's seed; it may be empty for a log, in which case the
+	// other source's seed is assumed
+	Seed string
+
+	// Points are the known hashes, sorted by index; nil for a spec
+	Points []Point
+
+	// guessed is set when the algorithm and chaining mode were not recorded
+	// with the points (as in a log), so others are tried when they do not
+	// reproduce them
+	guessed bool
+}
+
+// Spec function creates a `Source` for the chain of the input seed and
+// algorithm (SHA256 if empty), in the input chaining mode (`clock.ModeHex` if
+// empty) and with the input domain tag (in the `clock.ModeIndexed` mode only)
+func Spec(name, seed, alg, mode, tag string) (*Source, error) {
+	if err := clock.ValidateSeed(seed); err != nil {
+		return nil, err
+	}
+
+	if mode == clock.ModeHex || mode == clock.ModeRaw || mode == "" {
+		if tag != "" {
+			return nil, fmt.Errorf("a domain tag is only used in the %s chaining mode", clock.ModeIndexed)
+		}
+	}
+
+	s := &Source{Name: name, Seed: seed, Tag: tag}
+	if err := s.setRules(alg, mode); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ReadLog function creates a `Source` from the hashes logged in the input
+// reader (`#N:\t<hash>` lines, optionally followed by `\tevent: <digest>`);
+// lines which do not start with a `#` are skipped. The seed is optional, and
+// the algorithm (SHA256 if empty) is only a first guess, as logs do not
+// record it
+func ReadLog(name string, r io.Reader, seed, alg string) (*Source, error) {
+	s := &Source{Name: name, Seed: seed, guessed: true}
+	if err := s.setRules(alg, ""); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if !strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var event string
+		if sep := strings.Index(text, eventSep); sep >= 0 {
+			text, event = text[:sep], text[sep+len(eventSep):]
+		}
+
+		index, hash, err := clock.ParseLogLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %s", name, n, err)
+		}
+		if last := len(s.Points) - 1; last >= 0 && index <= s.Points[last].Index {
+			return nil, fmt.Errorf("%s: line %d: index %d does not follow the previous index %d", name, n, index, s.Points[last].Index)
+		}
+
+		s.Points = append(s.Points, Point{Index: index, Hash: strings.ToLower(hash), Event: event})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(s.Points) == 0 {
+		return nil, fmt.Errorf("%s: no logged hashes", name)
+	}
+	return s, nil
+}
+
+// ReadLedger function creates a `Source` from the entries of the ledger in the
+// input directory, with the seed and algorithm of its manifest
+func ReadLedger(name, dir string) (*Source, error) {
+	m, err := ledger.ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Source{Name: name, Seed: m.Seed}
+	if err := s.setRules(m.Algorithm, ""); err != nil {
+		return nil, err
+	}
+
+	err = ledger.ReadSegments(dir, 0, func(entries []ledger.Entry) error {
+		for _, e := range entries {
+			s.Points = append(s.Points, Point{Index: e.Index, Hash: e.Hash, Event: e.Event})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.Points) == 0 {
+		return nil, fmt.Errorf("%s: the ledger has no entries", name)
+	}
+	return s, nil
+}
+
+// setRules method sets the source's algorithm and chaining mode, checking them
+func (s *Source) setRules(alg, mode string) error {
+	if alg == "" {
+		alg = clock.HasherMapVals[3]
+	}
+	if mode == "" {
+		mode = clock.ModeHex
+	}
+
+	r, err := newRules(alg, mode, s.Tag)
+	if err != nil {
+		return err
+	}
+
+	s.Algorithm = r.algorithm
+	s.Mode = r.mode
+	return nil
+}
+
+// point method returns the source's point at the input index, if any
+func (s *Source) point(index int) (Point, bool) {
+	n := sort.Search(len(s.Points), func(i int) bool {
+		return s.Points[i].Index >= index
+	})
+	if n < len(s.Points) && s.Points[n].Index == index {
+		return s.Points[n], true
+	}
+	return Point{}, false
+}
+
+// event method returns the digest of the event mixed in at the input index,
+// if any
+func (s *Source) event(index int) string {
+	p, _ := s.point(index)
+	return p.Event
+}
+
+// shared function returns the indices known by both input sources, sorted.
+// A spec knows every index, so two specs are compared at the second index --
+// the first one which depends on the seed, algorithm and chaining mode
+func shared(a, b *Source) []int {
+	switch {
+	case a.Points == nil && b.Points == nil:
+		return []int{2}
+	case a.Points == nil:
+		return indices(b.Points)
+	case b.Points == nil:
+		return indices(a.Points)
+	}
+
+	var out []int
+	for i, j := 0, 0; i < len(a.Points) && j < len(b.Points); {
+		switch {
+		case a.Points[i].Index < b.Points[j].Index:
+			i++
+		case a.Points[i].Index > b.Points[j].Index:
+			j++
+		default:
+			out = append(out, a.Points[i].Index)
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func indices(points []Point) []int {
+	out := make([]int, len(points))
+	for idx, p := range points {
+		out[idx] = p.Index
+	}
+	return out
+}
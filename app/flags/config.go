@@ -0,0 +1,385 @@
+package flags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envPrefix is the prefix of the environment variables which set
+	// hashclock's flags
+	envPrefix string = "HASHCLOCK_"
+
+	// envConfig is the environment variable with the configuration file's
+	// path, when the `-config` flag is not set
+	envConfig string = envPrefix + "CONFIG"
+
+	configFlag string = "config"
+)
+
+// Sources of a setting's value, from the highest to the lowest precedence
+const (
+	SourceFlag    string = "flag"
+	SourceEnv     string = "env"
+	SourceFile    string = "file"
+	SourceDefault string = "default"
+)
+
+// lookupEnv is the function used to read environment variables
+var lookupEnv = os.LookupEnv
+
+// Setting struct describes the effective value of one of a command's flags,
+// and where it came from
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+
+	// Origin is the environment variable or the configuration file (and key)
+	// which set the value, if any
+	Origin string `json:"origin,omitempty"`
+}
+
+// envNames function returns the environment variables which set the input
+// flag of the input command, by precedence: the command-specific variable
+// (e.g. `HASHCLOCK_SERVE_ADDR`) and the shared one (e.g. `HASHCLOCK_ADDR`)
+func envNames(command, name string) []string {
+	key := func(s string) string {
+		return strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(s))
+	}
+
+	return []string{
+		envPrefix + key(command) + "_" + key(name),
+		envPrefix + key(name),
+	}
+}
+
+// fileKeys function returns the configuration file keys which set the input
+// flag of the input command, by precedence: the key in the command's table
+// (e.g. `ledger.run.dir`) and the top-level key (e.g. `dir`)
+func fileKeys(command, name string) []string {
+	return []string{
+		strings.ReplaceAll(command, " ", ".") + "." + name,
+		name,
+	}
+}
+
+// loadFile function reads the configuration file at the input path, as JSON
+// or TOML (by its extension); returning its values by their (dotted) keys
+func loadFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration file: %s", err)
+	}
+
+	var values map[string]string
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSON(b)
+	case ".toml":
+		values, err = parseTOML(b)
+	default:
+		return nil, fmt.Errorf("unsupported configuration file %q; expected a .json or .toml file", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %s", path, err)
+	}
+	return values, nil
+}
+
+// parseJSON function flattens a JSON object into its values by their dotted
+// keys; nested objects are tables (e.g. `{"serve": {"addr": ":80"}}` sets
+// `serve.addr`)
+func parseJSON(b []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+
+	var flatten func(prefix string, m map[string]interface{}) error
+	flatten = func(prefix string, m map[string]interface{}) error {
+		for k, v := range m {
+			key := prefix + k
+
+			switch val := v.(type) {
+			case map[string]interface{}:
+				if err := flatten(key+".", val); err != nil {
+					return err
+				}
+			case string:
+				values[key] = val
+			case json.Number:
+				values[key] = val.String()
+			case bool:
+				values[key] = strconv.FormatBool(val)
+			default:
+				return fmt.Errorf("unsupported value for %q", key)
+			}
+		}
+		return nil
+	}
+
+	if err := flatten("", root); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseTOML function parses the subset of TOML used by configuration files
+// into its values by their dotted keys: comments, `[table]` and
+// `[dotted.table]` headers, and `key = value` pairs with string, integer,
+// float and boolean values
+func parseTOML(b []byte) (map[string]string, error) {
+	values := map[string]string{}
+	prefix := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			end := strings.Index(text, "]")
+			if end < 0 || strings.HasPrefix(text, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header", line)
+			}
+			if rest := strings.TrimSpace(text[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected %q after the table header", line, rest)
+			}
+
+			table := strings.TrimSpace(text[1:end])
+			parts := strings.Split(table, ".")
+			for idx := range parts {
+				parts[idx] = strings.TrimSpace(parts[idx])
+				if !isBareKey(parts[idx]) {
+					return nil, fmt.Errorf("line %d: invalid table name %q", line, table)
+				}
+			}
+			prefix = strings.Join(parts, ".") + "."
+			continue
+		}
+
+		eq := strings.Index(text, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected a key = value pair", line)
+		}
+
+		key := strings.TrimSpace(text[:eq])
+		if !isBareKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", line, key)
+		}
+
+		value, err := parseTOMLValue(strings.TrimSpace(text[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		if _, ok := values[prefix+key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line, prefix+key)
+		}
+		values[prefix+key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// isBareKey function returns true if the input is a valid TOML bare key
+func isBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseTOMLValue function parses a TOML value (followed by an optional
+// comment) as a string
+func parseTOMLValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		// basic strings have the same escapes as Go's (for the supported
+		// subset), so they are unquoted as such
+		end := 1
+		for ; end < len(s); end++ {
+			if s[end] == '\\' {
+				end++
+				continue
+			}
+			if s[end] == '"' {
+				break
+			}
+		}
+		if end >= len(s) {
+			return "", errors.New("unterminated string")
+		}
+		if err := trailing(s[end+1:]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(s[:end+1])
+
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		if err := trailing(s[end+2:]); err != nil {
+			return "", err
+		}
+		return s[1 : end+1], nil
+	}
+
+	if idx := strings.Index(s, "#"); idx >= 0 {
+		s = strings.TrimSpace(s[:idx])
+	}
+
+	switch {
+	case s == "true" || s == "false":
+		return s, nil
+	case s == "":
+		return "", errors.New("missing value")
+	}
+
+	num := strings.ReplaceAll(s, "_", "")
+	if _, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return num, nil
+	}
+	if _, err := strconv.ParseFloat(num, 64); err == nil {
+		return num, nil
+	}
+	return "", fmt.Errorf("unsupported value %q", s)
+}
+
+// trailing function checks that only a comment follows a value
+func trailing(s string) error {
+	s = strings.TrimSpace(s)
+	if s != "" && !strings.HasPrefix(s, "#") {
+		return fmt.Errorf("unexpected %q after the value", s)
+	}
+	return nil
+}
+
+// resolve function sets the flags which were not set in the command line from
+// the environment or the configuration file (in this order), returning the
+// effective settings of the command.
+//
+// The configuration file is read from the `-config` flag, or from the
+// `HASHCLOCK_CONFIG` environment variable
+func resolve(c *Command, fs *flag.FlagSet) ([]Setting, error) {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	path := fs.Lookup(configFlag).Value.String()
+	pathSetting := Setting{Name: configFlag, Value: path, Source: SourceFlag}
+	if path == "" {
+		path, _ = lookupEnv(envConfig)
+		pathSetting = Setting{Name: configFlag, Value: path, Source: SourceDefault}
+		if path != "" {
+			pathSetting.Source = SourceEnv
+			pathSetting.Origin = envConfig
+		}
+	}
+
+	var file map[string]string
+	if path != "" {
+		var err error
+		if file, err = loadFile(path); err != nil {
+			return nil, err
+		}
+
+		// keys in the command's own table must be its flags
+		table := strings.ReplaceAll(c.Name, " ", ".") + "."
+		for key := range file {
+			if strings.HasPrefix(key, table) && fs.Lookup(strings.TrimPrefix(key, table)) == nil {
+				return nil, fmt.Errorf("%s: unknown setting %q in %s", c.Name, key, path)
+			}
+		}
+	}
+
+	var (
+		settings []Setting
+		err      error
+	)
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		if f.Name == configFlag {
+			settings = append(settings, pathSetting)
+			return
+		}
+
+		s := Setting{Name: f.Name, Source: SourceDefault}
+
+		switch {
+		case set[f.Name]:
+			s.Source = SourceFlag
+		default:
+			if value, source, origin, ok := lookupSetting(c.Name, f.Name, file); ok {
+				if source == SourceFile {
+					origin = path + " (" + origin + ")"
+				}
+				s.Source = source
+				s.Origin = origin
+
+				if setErr := fs.Set(f.Name, value); setErr != nil {
+					err = fmt.Errorf("%s: invalid value %q for -%s from %s: %s", c.Name, value, f.Name, origin, setErr)
+					return
+				}
+			}
+		}
+
+		s.Value = f.Value.String()
+		settings = append(settings, s)
+	})
+
+	return settings, err
+}
+
+// lookupSetting function returns the value of the input flag from the
+// environment or the configuration file, along with its source and the
+// variable or key which set it
+func lookupSetting(command, name string, file map[string]string) (value, source, origin string, ok bool) {
+	for _, env := range envNames(command, name) {
+		if value, ok := lookupEnv(env); ok {
+			return value, SourceEnv, env, true
+		}
+	}
+
+	for _, key := range fileKeys(command, name) {
+		if value, ok := file[key]; ok {
+			return value, SourceFile, key, true
+		}
+	}
+
+	return "", "", "", false
+}
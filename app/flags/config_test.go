@@ -0,0 +1,116 @@
+package flags
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	input := `
+# shared settings
+alg = "sha512" # trailing comment
+time = 1_000
+json = true
+
+[serve]
+addr = ":9090"
+
+[ ledger.run ]
+dir = 'C:\hashclock'
+seed = "quoted \"seed\" # not a comment"
+`
+
+	values, err := parseTOML([]byte(input))
+	if err != nil {
+		t.Fatalf("FAILED -- [Config] parseTOML() failed: %s", err)
+	}
+
+	want := map[string]string{
+		"alg":             "sha512",
+		"time":            "1000",
+		"json":            "true",
+		"serve.addr":      ":9090",
+		"ledger.run.dir":  `C:\hashclock`,
+		"ledger.run.seed": `quoted "seed" # not a comment`,
+	}
+	if len(values) != len(want) {
+		t.Errorf("FAILED -- [Config] parseTOML() returned %v values ; expected %v: %v", len(values), len(want), values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("FAILED -- [Config] parseTOML() value mismatch for %q: wanted %q ; got %q", k, v, values[k])
+		}
+	}
+
+	for _, invalid := range []string{
+		"alg sha256",
+		"alg = sha256",
+		"[serve",
+		"seed = \"unterminated",
+		"alg = \"a\"\nalg = \"b\"",
+		"[[jobs]]",
+	} {
+		if _, err := parseTOML([]byte(invalid)); err == nil {
+			t.Errorf("FAILED -- [Config] parseTOML(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestParseArgsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hashclock.json")
+	os.WriteFile(path, []byte(`{"seed": "file", "alg": "sha1", "log": 7, "chain": {"iter": 10}}`), 0o644)
+
+	env := map[string]string{
+		envConfig:               path,
+		"HASHCLOCK_ALG":         "md5",
+		"HASHCLOCK_CHAIN_LOG":   "3",
+		"HASHCLOCK_VERIFY_SEED": "ignored",
+	}
+	lookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	defer func() { lookupEnv = os.LookupEnv }()
+
+	cfg, err := ParseArgs([]string{"chain", "-log", "2"}, io.Discard)
+	if err != nil {
+		t.Fatalf("FAILED -- [Config] ParseArgs() failed: %s", err)
+	}
+
+	// flags > env > file > defaults
+	if cfg.Breakpoint != 2 || cfg.Algorithm != "md5" || cfg.Seed != "file" || cfg.Iterations != 10 || cfg.SetJSON {
+		t.Errorf("FAILED -- [Config] unexpected configuration: %+v", cfg)
+	}
+
+	sources := map[string]string{}
+	for _, s := range cfg.Settings {
+		sources[s.Name] = s.Source
+	}
+	want := map[string]string{
+		"log":    SourceFlag,
+		"alg":    SourceEnv,
+		"seed":   SourceFile,
+		"iter":   SourceFile,
+		"json":   SourceDefault,
+		"config": SourceEnv,
+	}
+	for k, v := range want {
+		if sources[k] != v {
+			t.Errorf("FAILED -- [Config] source mismatch for -%s: wanted %q ; got %q", k, v, sources[k])
+		}
+	}
+
+	env["HASHCLOCK_CHAIN_ITER"] = "many"
+	if _, err := ParseArgs([]string{"chain"}, io.Discard); err == nil {
+		t.Errorf("FAILED -- [Config] ParseArgs() should fail with an invalid value from the environment")
+	}
+	delete(env, "HASHCLOCK_CHAIN_ITER")
+
+	os.WriteFile(path, []byte(`{"chain": {"iterations": 10}}`), 0o644)
+	if _, err := ParseArgs([]string{"chain", "-seed", "x", "-iter", "1"}, io.Discard); err == nil {
+		t.Errorf("FAILED -- [Config] ParseArgs() should fail with an unknown setting in the command's table")
+	}
+}
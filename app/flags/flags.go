@@ -2,11 +2,26 @@
 // as an object, which is parsed and used in `hashclock/cmd`
 package flags
 
-import "flag"
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const algUsage string = "Hash function to use; lower-case or uppercase. One of: 'md5', 'sha1', 'sha224', 'sha256', 'sha384', 'sha512', 'sha512_224', 'sha512_256'; or 'memhard' for the memory-hard step function"
 
 // CLIConfig struct defines the set configuration for hashclock
 // in an object which is parsed and used in `hashclock/cmd`
 type CLIConfig struct {
+	Command    string
 	Seed       string
 	Hash       string
 	Algorithm  string
@@ -14,28 +29,1251 @@ type CLIConfig struct {
 	Breakpoint int
 	Timeout    int
 	SetJSON    bool
+
+	// memory-hard step function settings, with the 'memhard' algorithm
+	Memory int
+	Cost   int
+
+	// chaining mode settings: 'hex' or 'indexed', and the domain tag of
+	// the indexed mode
+	Mode string
+	Tag  string
+
+	// prefix search settings: a hex prefix, or a number of leading zero
+	// bits
+	Prefix string
+	Bits   int
+
+	// checkpoint settings, to continue a chain from a trusted index and
+	// hash instead of the seed
+	FromIndex int
+	FromHash  string
+
+	// checkpoint cache settings, to resume repeated verifications of the
+	// same seed from a cached checkpoint
+	CacheInterval       int
+	CacheDir            string
+	CacheMaxChains      int
+	CacheMaxCheckpoints int
+
+	// server settings
+	Addr            string
+	MaxJobs         int
+	MaxQueue        int
+	JobTTL          int
+	ShutdownTimeout int
+
+	// MetricsAddr is the address to serve a loop's metrics on
+	MetricsAddr string
+
+	// JSON-RPC settings
+	Network  string
+	Progress int
+
+	// tick streaming settings
+	StreamSeed      string
+	StreamAlgorithm string
+	StreamLog       int
+	StreamBuffer    int
+
+	// ledger settings
+	Dir         string
+	SegmentSize int
+	Sync        bool
+	Events      bool
+	Workers     int
+
+	// reverse lookup index settings
+	Index      string
+	PrefixSize int
+	BloomBits  int
+
+	// batch settings
+	Format string
+	Order  string
+
+	// diff settings, for chains A and B
+	SourceA    string
+	SourceB    string
+	SeedA      string
+	SeedB      string
+	AlgorithmA string
+	AlgorithmB string
+	ModeA      string
+	ModeB      string
+	TagA       string
+	TagB       string
+
+	// PoH settings
+	HashesPerTick uint64
+	TicksPerSlot  uint64
+	Slots         uint64
+	Start         string
+	Input         string
+
+	// proof-of-work stamp settings: the stamp's resource, the date window
+	// and the spent-stamp store of the verifier
+	Stamp    string
+	Resource string
+	Window   time.Duration
+	Store    string
+
+	// randomness beacon settings: the hashes between rounds, the delay
+	// function's steps and hash function, and the round to read or verify
+	Beacon         string
+	Every          int
+	Delay          int
+	DelayAlgorithm string
+	Round          int
+	Value          string
+
+	// timestamping receipt settings: the stamped file, the receipt's path,
+	// and whether the file's event is already in the ledger
+	File    string
+	Receipt string
+	Find    bool
+
+	// Target is the command whose configuration is printed, for the
+	// `config print` command
+	Target string
+
+	// Settings are the effective values of the command's flags, and their
+	// sources
+	Settings []Setting
+
+	// Deprecated is set when the configuration was parsed from the
+	// legacy, flag-only invocation (e.g. `hashclock -seed x -iter 10`)
+	Deprecated bool
+}
+
+// Command struct describes a hashclock subcommand: its name, a short
+// summary for the help output, the flags it accepts and a validation
+// function which checks the parsed values before the command runs
+type Command struct {
+	Name     string
+	Summary  string
+	flags    func(fs *flag.FlagSet, cfg *CLIConfig)
+	validate func(cfg *CLIConfig) error
+}
+
+// Commands lists all supported hashclock subcommands, in the order
+// they are presented in the help output
+var Commands = []Command{
+	{
+		Name:    "hash",
+		Summary: "Hash the seed string once",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			memoryHardFlags(fs, cfg)
+			modeFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			return requireSeed(cfg)
+		},
+	},
+	{
+		Name:    "chain",
+		Summary: "Hash the seed recursively for a number of iterations",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			memoryHardFlags(fs, cfg)
+			modeFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			checkpointFlags(fs, cfg)
+			cacheFlags(fs, cfg)
+			fs.IntVar(&cfg.Iterations, "iter", 0, "Number of iterations (required); the absolute index of the last hash, when continuing from a checkpoint")
+			fs.IntVar(&cfg.Breakpoint, "log", 0, "Log hashes every # of steps; 0 does not log any hashes")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			if err := validateCheckpoint(cfg); err != nil {
+				return err
+			}
+			if err := validateCache(cfg); err != nil {
+				return err
+			}
+			if cfg.Iterations <= 0 {
+				return errors.New("-iter must be greater than zero")
+			}
+			if cfg.Breakpoint < 0 {
+				return errors.New("-log cannot be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "loop",
+		Summary: "Hash the seed recursively, indefinitely, logging every # of steps",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			memoryHardFlags(fs, cfg)
+			modeFlags(fs, cfg)
+			checkpointFlags(fs, cfg)
+			fs.IntVar(&cfg.Breakpoint, "log", 1, "Log hashes every # of steps")
+			fs.StringVar(&cfg.MetricsAddr, "metrics", "", "Serve Prometheus metrics on /metrics, on this TCP address (e.g. ':9100'); empty does not serve them")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			if err := validateCheckpoint(cfg); err != nil {
+				return err
+			}
+			if cfg.Breakpoint <= 0 {
+				return errors.New("-log must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "verify",
+		Summary: "Verify that a hash is part of the seed's chain; optionally at an index and / or within a timeout",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			memoryHardFlags(fs, cfg)
+			modeFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			checkpointFlags(fs, cfg)
+			cacheFlags(fs, cfg)
+			fs.StringVar(&cfg.Hash, "hash", "", "Input hash which will be verified, from hashing the seed (required)")
+			fs.IntVar(&cfg.Iterations, "iter", 0, "Index of the hash in the chain; 0 searches for the hash at any index")
+			fs.IntVar(&cfg.Timeout, "time", 0, "Stop verifying after # seconds; 0 does not set a timeout")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			if err := validateCheckpoint(cfg); err != nil {
+				return err
+			}
+			if err := validateCache(cfg); err != nil {
+				return err
+			}
+			if cfg.Hash == "" {
+				return errors.New("-hash is required")
+			}
+			if _, err := hex.DecodeString(cfg.Hash); err != nil {
+				return fmt.Errorf("-hash is not hex-encoded: %s", err)
+			}
+			if cfg.Iterations < 0 {
+				return errors.New("-iter cannot be negative")
+			}
+			if cfg.Timeout < 0 {
+				return errors.New("-time cannot be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "follow-verify",
+		Summary: "Verify the hashes logged by 'chain' or 'loop' ('#N:\t<hash>' lines), from a file or live from std-in; reporting the first invalid line",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			modeFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Input, "in", "-", "File with the logged hashes; '-' reads them from std-in (e.g. piped from 'hashclock loop')")
+			fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of segments (between logged hashes) verified at the same time")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := requireSeed(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			if cfg.Seed == "-" && cfg.Input == "-" {
+				return errors.New("cannot read both the seed and the logged hashes from std-in")
+			}
+			if cfg.Workers <= 0 {
+				return errors.New("-workers must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "search",
+		Summary: "Find the first hash in the seed's chain which starts with a hex prefix, or with a number of zero bits",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			memoryHardFlags(fs, cfg)
+			modeFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			checkpointFlags(fs, cfg)
+			fs.StringVar(&cfg.Prefix, "prefix", "", "Hex prefix of the hash to find (e.g. 'c0ffee')")
+			fs.IntVar(&cfg.Bits, "bits", 0, "Number of leading zero bits of the hash to find; instead of -prefix")
+			fs.IntVar(&cfg.Timeout, "time", 0, "Stop searching after # seconds; 0 does not set a timeout")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			if err := validateCheckpoint(cfg); err != nil {
+				return err
+			}
+			if (cfg.Prefix == "") == (cfg.Bits == 0) {
+				return errors.New("either -prefix or -bits is required")
+			}
+			if strings.Trim(cfg.Prefix, "0123456789abcdefABCDEF") != "" {
+				return fmt.Errorf("-prefix %q is not hex-encoded", cfg.Prefix)
+			}
+			if cfg.Bits < 0 {
+				return errors.New("-bits cannot be negative")
+			}
+			if cfg.Timeout < 0 {
+				return errors.New("-time cannot be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "proof",
+		Summary: "Hash the seed recursively for # seconds, producing a proof of elapsed time",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			memoryHardFlags(fs, cfg)
+			modeFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			checkpointFlags(fs, cfg)
+			fs.IntVar(&cfg.Timeout, "time", 0, "Calculate hashes for # seconds (required)")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := validateMode(cfg); err != nil {
+				return err
+			}
+			if err := validateCheckpoint(cfg); err != nil {
+				return err
+			}
+			if cfg.Timeout <= 0 {
+				return errors.New("-time must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "bench",
+		Summary: "Measure the hashing rate (hashes per second) of one or all algorithms",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Algorithm, "alg", "all", algUsage+"; or 'all' to benchmark every algorithm")
+			memoryHardFlags(fs, cfg)
+			fs.IntVar(&cfg.Timeout, "time", 1, "Benchmark each algorithm for # seconds")
+			fs.StringVar(&cfg.Seed, "seed", "hashclock", "Input seed which will be hashed")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateMemoryHard(cfg); err != nil {
+				return err
+			}
+			if err := requireSeed(cfg); err != nil {
+				return err
+			}
+			if cfg.Timeout <= 0 {
+				return errors.New("-time must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "serve",
+		Summary: "Serve the hashing and verification methods as a HTTP/JSON API",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			fs.StringVar(&cfg.Addr, "addr", ":8080", "TCP address to listen on")
+			fs.IntVar(&cfg.MaxJobs, "max-jobs", runtime.NumCPU(), "Maximum number of method calls running at the same time")
+			fs.IntVar(&cfg.MaxQueue, "max-queue", 100, "Maximum number of asynchronous jobs queued or running at the same time")
+			fs.IntVar(&cfg.JobTTL, "job-ttl", 600, "Keep finished asynchronous jobs for # seconds")
+			fs.IntVar(&cfg.ShutdownTimeout, "shutdown-timeout", 10, "Wait # seconds for open requests and jobs when shutting down")
+			fs.StringVar(&cfg.StreamSeed, "stream-seed", "", "Run a continuous clock from this seed, streaming its ticks; empty does not stream")
+			fs.StringVar(&cfg.StreamAlgorithm, "stream-alg", "sha256", "Hash function for the streamed clock")
+			fs.IntVar(&cfg.StreamLog, "stream-log", 100000, "Stream a tick every # of hashes")
+			fs.IntVar(&cfg.StreamBuffer, "stream-buffer", 1024, "Keep the # most recent ticks, for subscribers starting from an index")
+			fs.StringVar(&cfg.Index, "index", "", "Serve lookups in this index file (built with 'index build'); empty does not serve them")
+			fs.StringVar(&cfg.Beacon, "beacon", "", "Serve the rounds of the randomness beacon of the ledger in this directory (run with 'beacon run'); empty does not serve them")
+			cacheFlags(fs, cfg)
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Addr == "" {
+				return errors.New("-addr is required")
+			}
+			if cfg.MaxJobs <= 0 {
+				return errors.New("-max-jobs must be greater than zero")
+			}
+			if cfg.MaxQueue <= 0 {
+				return errors.New("-max-queue must be greater than zero")
+			}
+			if cfg.JobTTL <= 0 {
+				return errors.New("-job-ttl must be greater than zero")
+			}
+			if cfg.ShutdownTimeout <= 0 {
+				return errors.New("-shutdown-timeout must be greater than zero")
+			}
+			if err := validateCache(cfg); err != nil {
+				return err
+			}
+			if cfg.StreamSeed != "" {
+				if cfg.StreamLog <= 0 {
+					return errors.New("-stream-log must be greater than zero")
+				}
+				if cfg.StreamBuffer <= 0 {
+					return errors.New("-stream-buffer must be greater than zero")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "rpc",
+		Summary: "Serve the hashing and verification methods over JSON-RPC 2.0, on std-in / std-out or a socket",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			fs.StringVar(&cfg.Network, "net", "stdio", "Transport to serve on. One of: 'stdio', 'tcp', 'unix'")
+			fs.StringVar(&cfg.Addr, "addr", "", "TCP address or Unix socket path to listen on (required for 'tcp' and 'unix')")
+			fs.IntVar(&cfg.MaxJobs, "max-jobs", runtime.NumCPU(), "Maximum number of method calls running at the same time")
+			fs.IntVar(&cfg.Progress, "progress", 1, "Send progress notifications every # seconds, for calls which request them")
+			cacheFlags(fs, cfg)
+		},
+		validate: func(cfg *CLIConfig) error {
+			switch cfg.Network {
+			case "stdio":
+			case "tcp", "unix":
+				if cfg.Addr == "" {
+					return fmt.Errorf("-addr is required with -net %s", cfg.Network)
+				}
+			default:
+				return fmt.Errorf("invalid -net %q; expected 'stdio', 'tcp' or 'unix'", cfg.Network)
+			}
+			if cfg.MaxJobs <= 0 {
+				return errors.New("-max-jobs must be greater than zero")
+			}
+			if cfg.Progress <= 0 {
+				return errors.New("-progress must be greater than zero")
+			}
+			if err := validateCache(cfg); err != nil {
+				return err
+			}
+			return nil
+		},
+	},
+	{
+		Name:     "ledger run",
+		Summary:  "Run a clock recorded in an on-disk ledger; resuming it if the ledger exists",
+		flags:    ledgerRunFlags,
+		validate: validateLedgerRun,
+	},
+	{
+		Name:    "ledger verify",
+		Summary: "Verify every entry of an on-disk ledger, reporting the first corrupt or forged record",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Dir, "dir", "", "Directory of the ledger (required)")
+			fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of segments verified at the same time")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Dir == "" {
+				return errors.New("-dir is required")
+			}
+			if cfg.Workers <= 0 {
+				return errors.New("-workers must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "ledger lead",
+		Summary: "Run a clock recorded in an on-disk ledger (like 'ledger run'), replicating it to followers over TCP",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			ledgerRunFlags(fs, cfg)
+			fs.StringVar(&cfg.Addr, "addr", ":7070", "TCP address to serve followers on")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Addr == "" {
+				return errors.New("-addr is required")
+			}
+			return validateLedgerRun(cfg)
+		},
+	},
+	{
+		Name:    "ledger follow",
+		Summary: "Replicate a leader's ledger over TCP into a local copy, verifying each received segment",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			fs.StringVar(&cfg.Dir, "dir", "", "Directory of the local copy of the ledger (required)")
+			fs.StringVar(&cfg.Addr, "addr", "", "TCP address of the leader (required)")
+			fs.IntVar(&cfg.Timeout, "retry", 1, "Reconnect to the leader after # seconds, when disconnected")
+			fs.BoolVar(&cfg.Sync, "sync", false, "Flush each entry to stable storage before continuing")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Dir == "" {
+				return errors.New("-dir is required")
+			}
+			if cfg.Addr == "" {
+				return errors.New("-addr is required")
+			}
+			if cfg.Timeout <= 0 {
+				return errors.New("-retry must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "batch",
+		Summary: "Hash or verify many seeds, one job per NDJSON or CSV record, on a pool of workers; writing one response per line",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			cacheFlags(fs, cfg)
+			fs.StringVar(&cfg.Input, "in", "-", "File with the jobs; '-' reads them from std-in")
+			fs.StringVar(&cfg.Format, "format", "", "Format of the jobs: 'ndjson' or 'csv' (with a header row); empty detects it")
+			fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of jobs running at the same time")
+			fs.StringVar(&cfg.Order, "order", "input", "Order of the written responses: 'input' or 'completion'")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateCache(cfg); err != nil {
+				return err
+			}
+			if cfg.Format != "" && cfg.Format != "ndjson" && cfg.Format != "csv" {
+				return fmt.Errorf("invalid -format %q; expected 'ndjson' or 'csv'", cfg.Format)
+			}
+			if cfg.Order != "input" && cfg.Order != "completion" {
+				return fmt.Errorf("invalid -order %q; expected 'input' or 'completion'", cfg.Order)
+			}
+			if cfg.Workers <= 0 {
+				return errors.New("-workers must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "diff",
+		Summary: "Find where two chains diverge: checkpoint logs, ledgers or seeds; reporting the first differing index and its cause",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			diffFlags(fs, "a", &cfg.SourceA, &cfg.SeedA, &cfg.AlgorithmA, &cfg.ModeA, &cfg.TagA)
+			diffFlags(fs, "b", &cfg.SourceB, &cfg.SeedB, &cfg.AlgorithmB, &cfg.ModeB, &cfg.TagB)
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateDiff("a", cfg.SourceA, cfg.SeedA, cfg.ModeA, cfg.TagA); err != nil {
+				return err
+			}
+			if err := validateDiff("b", cfg.SourceB, cfg.SeedB, cfg.ModeB, cfg.TagB); err != nil {
+				return err
+			}
+			if cfg.SourceA == "-" && cfg.SourceB == "-" {
+				return errors.New("cannot read both logs from std-in")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "index build",
+		Summary: "Hash the seed up to an index, writing a sorted lookup index of the chain's hashes to a file",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			seedFlag(fs, cfg)
+			algFlag(fs, cfg)
+			jsonFlag(fs, cfg)
+			modeFlags(fs, cfg)
+			fs.StringVar(&cfg.Index, "file", "", "Path of the index file, which is replaced if it exists (required)")
+			fs.IntVar(&cfg.Iterations, "iter", 0, "Index the hashes from index 1 up to this index (required)")
+			fs.IntVar(&cfg.PrefixSize, "prefix", 8, "Number of bytes of each hash kept in the index")
+			fs.IntVar(&cfg.BloomBits, "bloom", 10, "Bloom filter bits per hash, to reject hashes outside of the index without searching; 0 does not build a filter")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := requireSeed(cfg); err != nil {
+				return err
+			}
+			if cfg.Index == "" {
+				return errors.New("-file is required")
+			}
+			if cfg.Iterations <= 0 {
+				return errors.New("-iter must be greater than zero")
+			}
+			if cfg.PrefixSize < 4 {
+				return errors.New("-prefix must be at least 4 bytes")
+			}
+			if cfg.BloomBits < 0 {
+				return errors.New("-bloom cannot be negative")
+			}
+			return validateMode(cfg)
+		},
+	},
+	{
+		Name:    "index lookup",
+		Summary: "Find the index of a hash in a lookup index; continuing the chain from the index's last hash if it is beyond it",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Index, "file", "", "Path of the index file (required)")
+			fs.StringVar(&cfg.Hash, "hash", "", "Input hash whose index is looked up (required)")
+			fs.StringVar(&cfg.Seed, "seed", "", "Input seed, checked against the index's seed; optional")
+			fs.IntVar(&cfg.Timeout, "time", 0, "Stop searching beyond the index after # seconds; 0 does not set a timeout")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Index == "" {
+				return errors.New("-file is required")
+			}
+			if cfg.Hash == "" {
+				return errors.New("-hash is required")
+			}
+			if _, err := hex.DecodeString(cfg.Hash); err != nil {
+				return fmt.Errorf("-hash is not hex-encoded: %s", err)
+			}
+			if cfg.Timeout < 0 {
+				return errors.New("-time cannot be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "poh run",
+		Summary: "Run a Solana-style Proof-of-History chain, writing its entries (ticks and mixins) as JSON lines",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			pohFlags(fs, cfg)
+			fs.Uint64Var(&cfg.TicksPerSlot, "ticks-per-slot", 64, "Number of ticks per slot")
+			fs.Uint64Var(&cfg.Slots, "slots", 0, "Stop after # slots; 0 runs indefinitely")
+			fs.BoolVar(&cfg.Events, "events", false, "Read events from std-in, one per line, and mix their digests into the chain")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validatePoH(cfg); err != nil {
+				return err
+			}
+			if cfg.HashesPerTick < 2 {
+				return errors.New("-hashes-per-tick must be greater than one")
+			}
+			if cfg.TicksPerSlot == 0 {
+				return errors.New("-ticks-per-slot must be greater than zero")
+			}
+			if cfg.Events && cfg.Seed == "-" {
+				return errors.New("-events cannot be used when reading the seed from std-in")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "poh verify",
+		Summary: "Verify a stream of Proof-of-History entries (JSON lines), reporting the first invalid entry",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			pohFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Input, "in", "-", "File with the entries; '-' reads them from std-in")
+			fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of entries verified at the same time")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validatePoH(cfg); err != nil {
+				return err
+			}
+			if cfg.Seed == "-" && cfg.Input == "-" {
+				return errors.New("cannot read both the seed and the entries from std-in")
+			}
+			if cfg.Workers <= 0 {
+				return errors.New("-workers must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "beacon run",
+		Summary: "Run a clock recorded in an on-disk ledger (like 'ledger run'), publishing a randomness beacon round every # hashes",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			ledgerRunFlags(fs, cfg)
+			jsonFlag(fs, cfg)
+			fs.IntVar(&cfg.Every, "every", 0, "Publish a round every # hashes, for a new beacon; a multiple of the ledger's -log interval, which is the default")
+			fs.IntVar(&cfg.Delay, "delay", 0, "Hash each round's output # more times (a delay function) for its value, for a new beacon; 0 does not apply it")
+			fs.StringVar(&cfg.DelayAlgorithm, "delay-alg", "sha256", "Hash function of the delay function, for a new beacon; as in -alg")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Every < 0 {
+				return errors.New("-every cannot be negative")
+			}
+			if cfg.Delay < 0 {
+				return errors.New("-delay cannot be negative")
+			}
+			return validateLedgerRun(cfg)
+		},
+	},
+	{
+		Name:    "beacon get",
+		Summary: "Print a published randomness beacon round; or the last one",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Dir, "dir", "", "Directory of the beacon's ledger (required)")
+			fs.IntVar(&cfg.Round, "round", 0, "Number of the round; 0 prints the last one")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Dir == "" {
+				return errors.New("-dir is required")
+			}
+			if cfg.Round < 0 {
+				return errors.New("-round cannot be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "beacon verify",
+		Summary: "Verify a randomness beacon round against its ledger: the published round, or a value",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Dir, "dir", "", "Directory of the beacon's ledger (required)")
+			fs.IntVar(&cfg.Round, "round", 0, "Number of the round (required)")
+			fs.StringVar(&cfg.Value, "value", "", "Value of the round to verify; empty verifies the published round")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Dir == "" {
+				return errors.New("-dir is required")
+			}
+			if cfg.Round <= 0 {
+				return errors.New("-round must be greater than zero")
+			}
+			if cfg.Value != "" {
+				if _, err := hex.DecodeString(cfg.Value); err != nil {
+					return fmt.Errorf("-value is not hex-encoded: %s", err)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "pow mint",
+		Summary: "Mint a hashcash proof-of-work stamp for a resource, trying counters on all CPU cores",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Resource, "resource", "", "Resource of the stamp, such as an e-mail address or a request path; cannot contain a colon (required)")
+			fs.IntVar(&cfg.Bits, "bits", 20, "Number of leading zero bits of the stamp's digest")
+			fs.StringVar(&cfg.Algorithm, "alg", "sha1", algUsage)
+			fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of counters tried at the same time")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if err := validateResource(cfg); err != nil {
+				return err
+			}
+			if cfg.Bits <= 0 {
+				return errors.New("-bits must be greater than zero")
+			}
+			if cfg.Workers <= 0 {
+				return errors.New("-workers must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "pow verify",
+		Summary: "Verify a hashcash proof-of-work stamp: its resource, difficulty and date; and that it was not spent before",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.Stamp, "stamp", "", "Stamp to verify; use '-' to read it from std-in (required)")
+			fs.StringVar(&cfg.Resource, "resource", "", "Expected resource of the stamp (required)")
+			fs.IntVar(&cfg.Bits, "bits", 20, "Minimum number of leading zero bits of the stamp's digest")
+			fs.StringVar(&cfg.Algorithm, "alg", "sha1", "Accepted hash functions of the stamp, comma-separated; as in -alg of the other commands")
+			fs.DurationVar(&cfg.Window, "window", 48*time.Hour, "Maximum difference between the stamp's date and the current time")
+			fs.StringVar(&cfg.Store, "store", "", "File of spent stamps, to reject a stamp used twice; empty does not record the stamp")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.Stamp == "" {
+				return errors.New("-stamp is required")
+			}
+			if err := validateResource(cfg); err != nil {
+				return err
+			}
+			if cfg.Bits <= 0 {
+				return errors.New("-bits must be greater than zero")
+			}
+			if cfg.Window <= 0 {
+				return errors.New("-window must be greater than zero")
+			}
+			return nil
+		},
+	},
+	{
+		Name:    "stamp",
+		Summary: "Stamp a file in an on-disk ledger: record its digest as an event, and write a receipt once a checkpoint anchors it",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			ledgerFlags(fs, cfg)
+			fs.StringVar(&cfg.File, "file", "", "File to stamp (required)")
+			fs.StringVar(&cfg.Receipt, "out", "", "Path of the receipt, which is replaced if it exists; empty writes it to std-out")
+			fs.BoolVar(&cfg.Find, "find", false, "Find the file's event in the ledger (e.g. piped to 'ledger run -events' as its digest) instead of recording it")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.File == "" {
+				return errors.New("-file is required")
+			}
+			return validateLedgerRun(cfg)
+		},
+	},
+	{
+		Name:    "stamp verify",
+		Summary: "Verify a file against its timestamping receipt: its digest and the chain segment up to the receipt's anchor, offline; and the anchor, against a ledger",
+		flags: func(fs *flag.FlagSet, cfg *CLIConfig) {
+			jsonFlag(fs, cfg)
+			fs.StringVar(&cfg.File, "file", "", "Stamped file (required)")
+			fs.StringVar(&cfg.Receipt, "receipt", "", "Path of the file's receipt (required)")
+			fs.StringVar(&cfg.Dir, "dir", "", "Directory of a copy of the ledger, to confirm the receipt's anchor (and its time) against; the receipt is not valid without it")
+		},
+		validate: func(cfg *CLIConfig) error {
+			if cfg.File == "" {
+				return errors.New("-file is required")
+			}
+			if cfg.Receipt == "" {
+				return errors.New("-receipt is required")
+			}
+			return nil
+		},
+	},
+	{
+		Name:     "config print",
+		Summary:  "Print the effective configuration of a command ('hashclock config print <command> [flags]'), and where each value came from",
+		flags:    func(fs *flag.FlagSet, cfg *CLIConfig) {},
+		validate: func(cfg *CLIConfig) error { return nil },
+	},
+}
+
+func ledgerRunFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	ledgerFlags(fs, cfg)
+	fs.BoolVar(&cfg.Events, "events", false, "Read events from std-in, one per line, and mix them into the chain")
+}
+
+func ledgerFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.StringVar(&cfg.Dir, "dir", "", "Directory of the ledger (required)")
+	fs.StringVar(&cfg.Seed, "seed", "", "Input seed which will be hashed; use '-' to read it from std-in (required for a new ledger)")
+	algFlag(fs, cfg)
+	fs.IntVar(&cfg.Breakpoint, "log", 1000000, "Record a checkpoint entry every # of steps, for a new ledger")
+	fs.IntVar(&cfg.SegmentSize, "segment-size", 10000, "Maximum number of entries per segment file, for a new ledger")
+	fs.BoolVar(&cfg.Sync, "sync", false, "Flush each entry to stable storage before continuing")
+}
+
+func validateLedgerRun(cfg *CLIConfig) error {
+	if cfg.Dir == "" {
+		return errors.New("-dir is required")
+	}
+	if cfg.Breakpoint <= 0 {
+		return errors.New("-log must be greater than zero")
+	}
+	if cfg.SegmentSize <= 0 {
+		return errors.New("-segment-size must be greater than zero")
+	}
+	if cfg.Events && cfg.Seed == "-" {
+		return errors.New("-events cannot be used when reading the seed from std-in")
+	}
+	return nil
+}
+
+func pohFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.StringVar(&cfg.Seed, "seed", "", "Input seed whose (raw) digest is the start hash; use '-' to read it from std-in")
+	fs.StringVar(&cfg.Start, "start", "", "Hex-encoded start hash, instead of a seed")
+	algFlag(fs, cfg)
+	fs.Uint64Var(&cfg.HashesPerTick, "hashes-per-tick", 12500, "Number of hashes per tick; when verifying, 0 does not check the ticks' spacing")
+}
+
+func validatePoH(cfg *CLIConfig) error {
+	if (cfg.Seed == "") == (cfg.Start == "") {
+		return errors.New("either -seed or -start is required")
+	}
+	if cfg.Start != "" {
+		if _, err := hex.DecodeString(cfg.Start); err != nil {
+			return fmt.Errorf("-start is not hex-encoded: %s", err)
+		}
+	}
+	return nil
+}
+
+func validateResource(cfg *CLIConfig) error {
+	if cfg.Resource == "" {
+		return errors.New("-resource is required")
+	}
+	if strings.Contains(cfg.Resource, ":") {
+		return fmt.Errorf("-resource %q cannot contain a colon", cfg.Resource)
+	}
+	return nil
+}
+
+func diffFlags(fs *flag.FlagSet, name string, source, seed, alg, mode, tag *string) {
+	fs.StringVar(source, name, "", fmt.Sprintf("Chain %s: a ledger directory, or a file with logged hashes ('-' reads them from std-in); empty calculates it from -%s-seed", strings.ToUpper(name), name))
+	fs.StringVar(seed, name+"-seed", "", fmt.Sprintf("Seed of chain %s; optional for a log", strings.ToUpper(name)))
+	fs.StringVar(alg, name+"-alg", "", fmt.Sprintf("Hash function of chain %s; SHA256 by default (a first guess, for a log); a ledger's is in its manifest", strings.ToUpper(name)))
+	fs.StringVar(mode, name+"-mode", "", fmt.Sprintf("Chaining mode of chain %s, when calculated from its seed: 'hex' (the default), 'raw' or 'indexed'", strings.ToUpper(name)))
+	fs.StringVar(tag, name+"-tag", "", fmt.Sprintf("Domain tag of chain %s, in the indexed mode (also tried for a log)", strings.ToUpper(name)))
+}
+
+func validateDiff(name, source, seed, mode, tag string) error {
+	if source == "" && seed == "" {
+		return fmt.Errorf("either -%s or -%s-seed is required", name, name)
+	}
+	switch mode {
+	case "", "hex", "raw":
+		if tag != "" && source == "" {
+			return fmt.Errorf("-%s-tag is only used with -%s-mode indexed", name, name)
+		}
+	case "indexed":
+	default:
+		return fmt.Errorf("invalid -%s-mode %q; expected 'hex', 'raw' or 'indexed'", name, mode)
+	}
+	return nil
+}
+
+func seedFlag(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.StringVar(&cfg.Seed, "seed", "", "Input seed which will be hashed; use '-' to read it from std-in (required)")
+}
+
+func algFlag(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.StringVar(&cfg.Algorithm, "alg", "sha256", algUsage)
+}
+
+func memoryHardFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.IntVar(&cfg.Memory, "mem", clock.DefaultMemory, "Scratchpad size of the memory-hard step function, in KiB (with -alg memhard)")
+	fs.IntVar(&cfg.Cost, "cost", clock.DefaultCost, "Number of scratchpad reads per step of the memory-hard function (with -alg memhard)")
+}
+
+func validateMemoryHard(cfg *CLIConfig) error {
+	if cfg.Memory <= 0 {
+		return errors.New("-mem must be greater than zero")
+	}
+	if cfg.Cost <= 0 {
+		return errors.New("-cost must be greater than zero")
+	}
+	return nil
+}
+
+func modeFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.StringVar(&cfg.Mode, "mode", "hex", "Chaining mode: 'hex' hashes the previous hash; 'indexed' hashes the previous hash, its index and the -tag")
+	fs.StringVar(&cfg.Tag, "tag", "", "Domain tag appended to each step, with -mode indexed")
+}
+
+func validateMode(cfg *CLIConfig) error {
+	switch cfg.Mode {
+	case "hex":
+		if cfg.Tag != "" {
+			return errors.New("-tag is only used with -mode indexed")
+		}
+	case "indexed":
+	default:
+		return fmt.Errorf("invalid -mode %q; expected 'hex' or 'indexed'", cfg.Mode)
+	}
+	return nil
+}
+
+func jsonFlag(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.BoolVar(&cfg.SetJSON, "json", false, "Returns the output in JSON format")
+}
+
+func checkpointFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.IntVar(&cfg.FromIndex, "from-index", 0, "Continue the chain from a trusted checkpoint at this index, instead of the seed (which is then optional); 0 starts from the seed")
+	fs.StringVar(&cfg.FromHash, "from-hash", "", "Hex-encoded hash at the checkpoint's index (required with -from-index)")
+}
+
+// validateCheckpoint function checks the checkpoint flags, if set; or
+// requires a seed otherwise. Indices are absolute, so a set -iter must come
+// after the checkpoint
+func validateCheckpoint(cfg *CLIConfig) error {
+	if cfg.FromIndex == 0 && cfg.FromHash == "" {
+		return requireSeed(cfg)
+	}
+
+	if cfg.FromIndex <= 0 {
+		return errors.New("-from-index must be greater than zero with -from-hash")
+	}
+	if cfg.FromHash == "" {
+		return errors.New("-from-hash is required with -from-index")
+	}
+	if _, err := hex.DecodeString(cfg.FromHash); err != nil {
+		return fmt.Errorf("-from-hash is not hex-encoded: %s", err)
+	}
+	if cfg.Iterations > 0 && cfg.Iterations <= cfg.FromIndex {
+		return fmt.Errorf("-iter %d does not come after -from-index %d", cfg.Iterations, cfg.FromIndex)
+	}
+	return nil
+}
+
+func cacheFlags(fs *flag.FlagSet, cfg *CLIConfig) {
+	fs.IntVar(&cfg.CacheInterval, "cache-interval", 0, "Cache a checkpoint every # hashes, resuming later verifications of the same seed from the nearest one; 0 does not cache checkpoints")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "Persist the cached checkpoints in this directory, to share them across runs; empty keeps them in memory")
+	fs.IntVar(&cfg.CacheMaxChains, "cache-max-chains", 64, "Maximum number of cached chains (seed, algorithm); the least recently used one is evicted")
+	fs.IntVar(&cfg.CacheMaxCheckpoints, "cache-max-checkpoints", 1024, "Maximum number of cached checkpoints per chain; every other one is dropped once exceeded")
+}
+
+func validateCache(cfg *CLIConfig) error {
+	if cfg.CacheInterval < 0 {
+		return errors.New("-cache-interval cannot be negative")
+	}
+	if cfg.CacheMaxChains <= 0 {
+		return errors.New("-cache-max-chains must be greater than zero")
+	}
+	if cfg.CacheMaxCheckpoints < 2 {
+		return errors.New("-cache-max-checkpoints must be at least 2")
+	}
+	return nil
+}
+
+func requireSeed(cfg *CLIConfig) error {
+	if cfg.Seed == "" {
+		return errors.New("-seed is required")
+	}
+	return nil
+}
+
+// Lookup function returns the subcommand registered with the input name
+func Lookup(name string) (*Command, bool) {
+	for idx := range Commands {
+		if Commands[idx].Name == name {
+			return &Commands[idx], true
+		}
+	}
+	return nil, false
 }
 
-// NewConfig function captures the set command-line flags and their
-// values, and stores them in a `CLIConfig` object
-func NewConfig() *CLIConfig {
-	inputSeed := flag.String("seed", "", "Input seed which will be hashed")
-	inputHash := flag.String("hash", "", "Input hash which will be verified, from hashing the seed")
-	inputAlg := flag.String("alg", "sha256", "Hash function to use; lower-case or uppercase. One of: 'md5', 'sha1', 'sha224', 'sha256', 'sha384', 'sha512', 'sha512_224', 'sha512_256'")
-	inputIterations := flag.Int("iter", 1, "Number of iterations")
-	inputBreakpoint := flag.Int("log", 1, "Log hashes every # of steps")
-	inputTimeout := flag.Int("time", 0, "Calculate hashes for # seconds")
-	inputSetJSON := flag.Bool("json", false, "Returns the output in JSON format")
-
-	flag.Parse()
-
-	return &CLIConfig{
-		Seed:       *inputSeed,
-		Hash:       *inputHash,
-		Algorithm:  *inputAlg,
-		Iterations: *inputIterations,
-		Breakpoint: *inputBreakpoint,
-		Timeout:    *inputTimeout,
-		SetJSON:    *inputSetJSON,
+// lookupArgs function returns the subcommand named by the input arguments,
+// which is either the first argument or -- for grouped subcommands such as
+// `ledger run` -- the first two; along with the number of arguments it takes
+func lookupArgs(args []string) (*Command, int, error) {
+	if len(args) > 1 {
+		if c, ok := Lookup(args[0] + " " + args[1]); ok {
+			return c, 2, nil
+		}
+	}
+
+	if c, ok := Lookup(args[0]); ok {
+		return c, 1, nil
+	}
+
+	var group []string
+	for _, c := range Commands {
+		if strings.HasPrefix(c.Name, args[0]+" ") {
+			group = append(group, "'"+c.Name+"'")
+		}
+	}
+	if len(group) > 0 {
+		return nil, 0, fmt.Errorf("command %q requires a subcommand; one of: %s", args[0], strings.Join(group, ", "))
+	}
+
+	return nil, 0, fmt.Errorf("unknown command %q; run 'hashclock help' for a list of commands", args[0])
+}
+
+// FlagSet method creates a new `flag.FlagSet` for the subcommand, binding
+// its flags to the input `CLIConfig`. Usage and parsing errors are written
+// to the input writer
+func (c *Command) FlagSet(cfg *CLIConfig, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("hashclock "+c.Name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	c.flags(fs, cfg)
+	fs.String(configFlag, "", "Configuration file (.json or .toml) with default values for the flags; or set with "+envConfig)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\n\nUsage of hashclock %s:\n", c.Summary, c.Name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Usage function prints the top-level help for hashclock to the input
+// writer, listing all subcommands
+func Usage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: hashclock <command> [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+
+	width := 0
+	for _, c := range Commands {
+		if len(c.Name) > width {
+			width = len(c.Name)
+		}
+	}
+	for _, c := range Commands {
+		fmt.Fprintf(out, "  %-*s %s\n", width, c.Name, c.Summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run 'hashclock help <command>' or 'hashclock <command> -h' for the command's flags.")
+}
+
+// NewConfig function captures the set command-line arguments and their
+// values (along with any set in the environment or the configuration file),
+// and stores them in a `CLIConfig` object
+func NewConfig() (*CLIConfig, error) {
+	return ParseArgs(os.Args[1:], os.Stderr)
+}
+
+// ParseArgs function parses the input arguments (without the program name)
+// into a `CLIConfig` object.
+//
+// The first argument is the subcommand name (or the first two, for grouped
+// subcommands such as `ledger run`), followed by its flags. Flags which are
+// not set fall back to `HASHCLOCK_*` environment variables, and then to the
+// configuration file (see `resolve`). If the
+// first argument is a flag (or if there are no arguments), the legacy
+// flag-only invocation is parsed instead, and the command is inferred from
+// the set flags.
+//
+// Asking for help (`help`, `-h`) returns a `flag.ErrHelp` error, once the
+// usage has been printed to the input writer. Any other error is a usage
+// error, with the invalid input
+func ParseArgs(args []string, w io.Writer) (*CLIConfig, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return parseLegacy(args, w)
+	}
+
+	if args[0] == "help" {
+		if len(args) > 1 {
+			if c, _, err := lookupArgs(args[1:]); err == nil {
+				c.FlagSet(&CLIConfig{}, w).Usage()
+				return nil, flag.ErrHelp
+			}
+		}
+		Usage(w)
+		return nil, flag.ErrHelp
+	}
+
+	c, n, err := lookupArgs(args)
+	if err != nil {
+		return nil, err
 	}
+
+	if c.Name == "config print" {
+		return parseConfigPrint(args[n:], w)
+	}
+
+	cfg, err := parseCommand(c, args[n:], w)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %s", c.Name, err)
+	}
+
+	return cfg, nil
+}
+
+// parseCommand function parses the input arguments as the input subcommand's
+// flags, into a new `CLIConfig` object. Flags which are not set are read from
+// the environment or the configuration file, before falling back to their
+// defaults
+func parseCommand(c *Command, args []string, w io.Writer) (*CLIConfig, error) {
+	cfg := &CLIConfig{Command: c.Name}
+	fs := c.FlagSet(cfg, w)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("%s: unexpected arguments: %s", c.Name, strings.Join(fs.Args(), " "))
+	}
+
+	settings, err := resolve(c, fs)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Settings = settings
+
+	return cfg, nil
+}
+
+// parseConfigPrint function parses the arguments of the `config print`
+// command: the target subcommand, followed by its flags. The target's
+// configuration is not validated, so that incomplete configurations can be
+// inspected too
+func parseConfigPrint(args []string, w io.Writer) (*CLIConfig, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return nil, errors.New("config print: a command is required, e.g. 'hashclock config print serve'")
+	}
+
+	c, n, err := lookupArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("config print: %s", err)
+	}
+	if c.Name == "config print" {
+		return nil, errors.New("config print: cannot print its own configuration")
+	}
+
+	cfg, err := parseCommand(c, args[n:], w)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Command = "config print"
+	cfg.Target = c.Name
+	return cfg, nil
+}
+
+// parseLegacy function parses the deprecated, flag-only invocation of
+// hashclock, and infers the subcommand from the set flags -- following
+// the same rules as before subcommands were introduced:
+//
+// - `-hash` set: `verify`; with `-time` taking precedence over `-iter`
+// - `-time` set: `proof`
+// - `-iter 0`: `loop`
+// - `-iter 1`: `hash`
+// - `-iter 2+`: `chain`
+func parseLegacy(args []string, w io.Writer) (*CLIConfig, error) {
+	cfg := &CLIConfig{Deprecated: true}
+
+	fs := flag.NewFlagSet("hashclock", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.StringVar(&cfg.Seed, "seed", "", "Input seed which will be hashed")
+	fs.StringVar(&cfg.Hash, "hash", "", "Input hash which will be verified, from hashing the seed")
+	fs.StringVar(&cfg.Algorithm, "alg", "sha256", algUsage)
+	fs.IntVar(&cfg.Iterations, "iter", 1, "Number of iterations")
+	fs.IntVar(&cfg.Breakpoint, "log", 1, "Log hashes every # of steps")
+	fs.IntVar(&cfg.Timeout, "time", 0, "Calculate hashes for # seconds")
+	fs.BoolVar(&cfg.SetJSON, "json", false, "Returns the output in JSON format")
+
+	fs.Usage = func() {
+		Usage(fs.Output())
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Deprecated flag-only usage of hashclock:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.Seed == "" {
+		return nil, errors.New("input seed string is undefined")
+	}
+
+	switch {
+	case cfg.Hash != "":
+		cfg.Command = "verify"
+
+		// a timeout verification ignores the index; and the default
+		// value for iterations (1) means no index is set
+		if cfg.Timeout > 0 || cfg.Iterations <= 1 {
+			cfg.Iterations = 0
+		}
+	case cfg.Timeout > 0:
+		cfg.Command = "proof"
+	case cfg.Iterations == 0:
+		cfg.Command = "loop"
+	case cfg.Iterations == 1:
+		cfg.Command = "hash"
+	case cfg.Iterations >= 2:
+		cfg.Command = "chain"
+	default:
+		return nil, errors.New("number of iterations has to be greater than zero")
+	}
+
+	return cfg, nil
 }
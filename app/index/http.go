@@ -0,0 +1,86 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// maxBodySize is the maximum size of a lookup request's body
+const maxBodySize int64 = 4096
+
+// Mux interface is implemented by the types which can register the index's
+// HTTP handlers, such as `*http.ServeMux` and `*server.Server`
+type Mux interface {
+	Handle(pattern string, h http.Handler)
+}
+
+// lookupRequest struct is the JSON body of a lookup request
+type lookupRequest struct {
+	Seed    string `json:"seed,omitempty"`
+	Hash    string `json:"hash"`
+	Timeout int    `json:"timeout,omitempty"`
+}
+
+// errorResponse struct is the JSON object returned on errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Register method registers the index's HTTP handlers in the input mux:
+//
+//   - `POST /v1/index/lookup` finds the index of the `hash` in the body, like
+//     `Find`; with an optional `seed` and `timeout` (in seconds)
+//   - `GET /v1/index` returns the index's header
+//
+// A lookup beyond the indexed range runs until the hash is found, the timeout
+// runs out or the client disconnects
+func (x *Index) Register(mux Mux) {
+	mux.Handle("/v1/index", http.HandlerFunc(x.handleHeader))
+	mux.Handle("/v1/index/lookup", http.HandlerFunc(x.handleLookup))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (x *Index) handleHeader(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, x.Header())
+}
+
+func (x *Index) handleLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	req := &lookupRequest{}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "invalid JSON body -- " + err.Error()})
+		return
+	}
+	if req.Timeout < 0 {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "timeout cannot be negative"})
+		return
+	}
+
+	res, err := x.Find(r.Context(), req.Seed, req.Hash, req.Timeout)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, context.Canceled) {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, &errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
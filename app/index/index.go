@@ -0,0 +1,385 @@
+// Package index implements a reverse lookup index of a hash chain: a file
+// which maps the hashes of a chain (from index 1 to N) to their index, so that
+// the index of a hash is found with a binary search instead of by re-hashing
+// the chain.
+//
+// An index file has a fixed-size header, an optional Bloom filter (which
+// rejects most hashes outside of the indexed range without searching) and a
+// table of fixed-size entries -- the first bytes (prefix) of each raw hash and
+// its index -- sorted by prefix. All values are big-endian and each section is
+// 8-byte aligned, so that the file can be read in place (e.g. memory-mapped):
+//
+//	offset  size        field
+//	0       4           magic ("HCIX")
+//	4       2           format version
+//	6       2           prefix size, in bytes
+//	8       8           number of indexed hashes (N)
+//	16      8           size of the Bloom filter, in bits (0 without a filter)
+//	24      4           number of Bloom filter hash functions
+//	28      4           hash size, in bytes
+//	32      16          algorithm name, zero-padded
+//	48      32          SHA256 digest of the seed
+//	80      1           chaining mode (0: hex, 1: indexed)
+//	81      3           reserved, zero
+//	84      4           domain tag size, in bytes (indexed mode only)
+//	88      hash size   raw hash at index N
+//	...     tag size    domain tag
+//	...     bits / 8    Bloom filter
+//	...     N * (prefix size + 8)  entries: prefix, index
+//
+// Matches are by prefix: with the default 8-byte prefix, a hash outside of the
+// chain is only mistaken for an indexed one on a 64-bit prefix collision.
+package index
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// Version is the index file format version; version 2 added the chaining
+	// mode and domain tag
+	Version int = 2
+
+	// DefaultPrefixSize is the default number of bytes of each hash which are
+	// kept in the index
+	DefaultPrefixSize int = 8
+
+	magic       string = "HCIX"
+	headerSize  int64  = 88
+	maxTagSize  int    = 1 << 10
+	algSize     int    = 16
+	indexSize   int    = 8
+	minPrefix   int    = 4
+	checkPeriod int    = 1 << 16
+)
+
+// ErrFormat error is returned when reading a file which is not a valid index
+var ErrFormat = errors.New("invalid index file")
+
+// Config struct defines the chain to index and the layout of the index
+type Config struct {
+	// Seed is the chain's seed
+	Seed string
+
+	// Algorithm is the chain's hash function; SHA256 by default
+	Algorithm string
+
+	// Length is the number of indexed hashes (N), from index 1
+	Length int
+
+	// PrefixSize is the number of bytes of each (raw) hash which are kept in
+	// the index; `DefaultPrefixSize` by default
+	PrefixSize int
+
+	// BloomBits is the number of Bloom filter bits per indexed hash; 0 does
+	// not build a Bloom filter. 10 bits reject ~99% of the hashes outside of
+	// the indexed range
+	BloomBits int
+
+	// Mode is the chain's chaining mode (`clock.ModeHex` if empty, or
+	// `clock.ModeIndexed`), and Tag its domain tag; see
+	// `clock.HashClockService.SetMode`
+	Mode string
+	Tag  string
+}
+
+// Header struct describes an index file
+type Header struct {
+	Version     int    `json:"version"`
+	Algorithm   string `json:"algorithm"`
+	Length      int    `json:"length"`
+	PrefixSize  int    `json:"prefix_size"`
+	BloomBits   int    `json:"bloom_bits,omitempty"`
+	BloomHashes int    `json:"bloom_hashes,omitempty"`
+	SeedDigest  string `json:"seed_digest"`
+	Mode        string `json:"mode"`
+	Tag         string `json:"tag,omitempty"`
+
+	// Last is the last indexed hash, which a lookup continues from when a
+	// hash is beyond the indexed range
+	Last clock.Checkpoint `json:"last"`
+}
+
+// Build function calculates the chain of the input configuration up to its
+// length and writes its index to the file in the input path, replacing it
+// atomically. The whole table is sorted in memory, which takes
+// N * (prefix size + 8) bytes
+func Build(ctx context.Context, path string, cfg *Config) (*Header, error) {
+	if cfg == nil {
+		return nil, errors.New("index configuration cannot be nil")
+	}
+	if cfg.Length <= 0 {
+		return nil, errors.New("index length must be greater than zero")
+	}
+	if cfg.BloomBits < 0 {
+		return nil, errors.New("bloom filter bits cannot be negative")
+	}
+
+	if err := clock.ValidateSeed(cfg.Seed); err != nil {
+		return nil, err
+	}
+	if err := clock.ValidateMode(cfg.Mode, cfg.Tag); err != nil {
+		return nil, err
+	}
+	if len(cfg.Tag) > maxTagSize {
+		return nil, fmt.Errorf("domain tag cannot be longer than %d bytes", maxTagSize)
+	}
+	mode := cfg.Mode
+	if mode == "" {
+		mode = clock.ModeHex
+	}
+
+	alg := cfg.Algorithm
+	if alg == "" {
+		alg = clock.HasherMapVals[3]
+	}
+	e, err := clock.NewEngine(alg, mode)
+	if err != nil {
+		return nil, err
+	}
+	e.SetTag([]byte(cfg.Tag))
+
+	hashSize := e.Size() / 2
+	prefix := cfg.PrefixSize
+	if prefix == 0 {
+		prefix = DefaultPrefixSize
+	}
+	if prefix < minPrefix || prefix > hashSize {
+		return nil, errors.New("prefix size must be between 4 bytes and the hash size")
+	}
+
+	digest := sha256.Sum256([]byte(cfg.Seed))
+	h := &Header{
+		Version:    Version,
+		Algorithm:  algorithmName(alg),
+		Length:     cfg.Length,
+		PrefixSize: prefix,
+		SeedDigest: hex.EncodeToString(digest[:]),
+		Mode:       mode,
+		Tag:        cfg.Tag,
+	}
+
+	var f *bloom
+	if cfg.BloomBits > 0 {
+		f = newBloom(cfg.Length, cfg.BloomBits)
+		h.BloomBits = len(f.bits) * 8
+		h.BloomHashes = f.k
+	}
+
+	t := &table{
+		size: prefix + indexSize,
+		buf:  make([]byte, cfg.Length*(prefix+indexSize)),
+		tmp:  make([]byte, prefix+indexSize),
+	}
+	raw := make([]byte, hashSize)
+	hash := []byte(cfg.Seed)
+
+	for idx := 1; idx <= cfg.Length; idx++ {
+		if idx%checkPeriod == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		hash = e.StepAt(hash, idx)
+		if _, err := hex.Decode(raw, hash); err != nil {
+			return nil, err
+		}
+
+		e := t.entry(idx - 1)
+		copy(e, raw[:prefix])
+		binary.BigEndian.PutUint64(e[prefix:], uint64(idx))
+
+		if f != nil {
+			f.add(raw)
+		}
+	}
+	h.Last = clock.Checkpoint{Index: cfg.Length, Hash: string(hash)}
+
+	sort.Sort(t)
+
+	if err := write(path, h, raw, f, t); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// write function writes the index file to a temporary file, renaming it to the
+// input path once complete
+func write(path string, h *Header, last []byte, f *bloom, t *table) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriter(file)
+
+	hdr := make([]byte, bloomOffset(len(last), len(h.Tag)))
+	copy(hdr[0:4], magic)
+	binary.BigEndian.PutUint16(hdr[4:6], uint16(h.Version))
+	binary.BigEndian.PutUint16(hdr[6:8], uint16(h.PrefixSize))
+	binary.BigEndian.PutUint64(hdr[8:16], uint64(h.Length))
+	binary.BigEndian.PutUint64(hdr[16:24], uint64(h.BloomBits))
+	binary.BigEndian.PutUint32(hdr[24:28], uint32(h.BloomHashes))
+	binary.BigEndian.PutUint32(hdr[28:32], uint32(len(last)))
+	copy(hdr[32:32+algSize], h.Algorithm)
+	digest, _ := hex.DecodeString(h.SeedDigest)
+	copy(hdr[48:80], digest)
+	if h.Mode == clock.ModeIndexed {
+		hdr[80] = 1
+	}
+	binary.BigEndian.PutUint32(hdr[84:88], uint32(len(h.Tag)))
+	copy(hdr[headerSize:], last)
+	copy(hdr[headerSize+int64(len(last)):], h.Tag)
+
+	if _, err := w.Write(hdr); err != nil {
+		file.Close()
+		return err
+	}
+	if f != nil {
+		if _, err := w.Write(f.bits); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if _, err := w.Write(t.buf); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// bloomOffset function returns the offset of the Bloom filter (the end of the
+// header) for the input hash and domain tag sizes, aligned to 8 bytes
+func bloomOffset(hashSize, tagSize int) int64 {
+	return align(headerSize + int64(hashSize) + int64(tagSize))
+}
+
+// algorithmName function returns the name of the input algorithm (lower-case
+// or upper-case), as in `clock.HasherMapVals`
+func algorithmName(alg string) string {
+	for idx := 0; idx < len(clock.HasherMapVals); idx++ {
+		if strings.EqualFold(alg, clock.HasherMapVals[idx]) {
+			return clock.HasherMapVals[idx]
+		}
+	}
+	return alg
+}
+
+func align(offset int64) int64 {
+	return (offset + 7) &^ 7
+}
+
+// table struct is the sortable table of index entries, each `size` bytes long
+type table struct {
+	size int
+	buf  []byte
+	tmp  []byte
+}
+
+func (t *table) entry(i int) []byte {
+	return t.buf[i*t.size : (i+1)*t.size]
+}
+
+func (t *table) Len() int {
+	return len(t.buf) / t.size
+}
+
+// Less method orders the entries by prefix and then by index, as the index is
+// stored after the prefix in big-endian order
+func (t *table) Less(i, j int) bool {
+	a, b := t.entry(i), t.entry(j)
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return a[idx] < b[idx]
+		}
+	}
+	return false
+}
+
+func (t *table) Swap(i, j int) {
+	a, b := t.entry(i), t.entry(j)
+	copy(t.tmp, a)
+	copy(a, b)
+	copy(b, t.tmp)
+}
+
+// bloom struct is a Bloom filter of raw hashes. As the hashes are uniformly
+// distributed, its bit positions are derived from the hashes themselves
+// (with double hashing) rather than by hashing them again
+type bloom struct {
+	bits []byte
+	k    int
+}
+
+// newBloom function creates a Bloom filter for the input number of hashes and
+// bits per hash, rounded up to a multiple of 64 bits
+func newBloom(n, bitsPerHash int) *bloom {
+	m := (uint64(n)*uint64(bitsPerHash) + 63) &^ 63
+
+	k := int(math.Round(float64(bitsPerHash) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	if k > 16 {
+		k = 16
+	}
+
+	return &bloom{bits: make([]byte, m/8), k: k}
+}
+
+func (b *bloom) positions(raw []byte, fn func(pos uint64) bool) {
+	m := uint64(len(b.bits)) * 8
+	h1 := binary.BigEndian.Uint64(raw[0:8])
+	h2 := binary.BigEndian.Uint64(raw[8:16]) | 1
+
+	for i := 0; i < b.k; i++ {
+		if !fn((h1 + uint64(i)*h2) % m) {
+			return
+		}
+	}
+}
+
+func (b *bloom) add(raw []byte) {
+	b.positions(raw, func(pos uint64) bool {
+		b.bits[pos/8] |= 1 << (pos % 8)
+		return true
+	})
+}
+
+// has method returns false if the input hash was definitely not added to the
+// filter
+func (b *bloom) has(raw []byte) bool {
+	found := true
+	b.positions(raw, func(pos uint64) bool {
+		found = b.bits[pos/8]&(1<<(pos%8)) != 0
+		return found
+	})
+	return found
+}
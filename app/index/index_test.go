@@ -0,0 +1,256 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const testSeed string = "Hello World!"
+
+// testHashes function returns the hashes of the test chain, from index 1 to n
+func testHashes(t *testing.T, n int) []string {
+	t.Helper()
+
+	c, err := clock.NewChain("sha256", testSeed)
+	if err != nil {
+		t.Fatalf("FAILED -- [Index] NewChain() failed: %s", err)
+	}
+
+	hashes := make([]string, n)
+	for idx := range hashes {
+		hashes[idx] = string(c.Next())
+	}
+	return hashes
+}
+
+func TestIndex(t *testing.T) {
+	hashes := testHashes(t, 1100)
+
+	for _, bloomBits := range []int{0, 10} {
+		path := filepath.Join(t.TempDir(), "chain.idx")
+
+		h, err := Build(context.Background(), path, &Config{
+			Seed:      testSeed,
+			Algorithm: "sha256",
+			Length:    1000,
+			BloomBits: bloomBits,
+		})
+		if err != nil {
+			t.Fatalf("FAILED -- [Index] Build() failed: %s", err)
+		}
+		if h.Last.Index != 1000 || h.Last.Hash != hashes[999] {
+			t.Errorf("FAILED -- [Index] unexpected last hash: %+v", h.Last)
+		}
+
+		x, err := Open(path)
+		if err != nil {
+			t.Fatalf("FAILED -- [Index] Open() failed: %s", err)
+		}
+		defer x.Close()
+
+		if got := x.Header(); got != *h {
+			t.Errorf("FAILED -- [Index] header %+v does not match the built header %+v", got, *h)
+		}
+
+		for idx := 1; idx <= 1000; idx++ {
+			if got, ok, err := x.Lookup(hashes[idx-1]); err != nil || !ok || got != idx {
+				t.Fatalf("FAILED -- [Index] Lookup(#%v) = %v, %v, %v", idx, got, ok, err)
+			}
+		}
+
+		// beyond the indexed range
+		if _, ok, err := x.Lookup(hashes[1049]); err != nil || ok {
+			t.Errorf("FAILED -- [Index] Lookup(#1050) should not find an indexed hash: %v, %v", ok, err)
+		}
+		res, err := x.Find(context.Background(), "", hashes[1049], 0)
+		if err != nil || !res.Match || res.Iterations != 1050 || res.Checkpoint == nil {
+			t.Errorf("FAILED -- [Index] Find(#1050) = %+v, %v", res, err)
+		}
+
+		res, err = x.Find(context.Background(), testSeed, strings.ToUpper(hashes[41]), 0)
+		if err != nil || !res.Match || res.Iterations != 42 || res.Hash != hashes[41] {
+			t.Errorf("FAILED -- [Index] Find(#42) = %+v, %v", res, err)
+		}
+
+		// confirmed from the hash, up to the last indexed hash
+		res, err = x.Find(context.Background(), "", hashes[899], 0)
+		if err != nil || !res.Match || res.Iterations != 900 {
+			t.Errorf("FAILED -- [Index] Find(#900) = %+v, %v", res, err)
+		}
+
+		// a forged hash which shares an indexed hash's prefix is not a match,
+		// either with or without the seed
+		forged := hashes[49][:2*DefaultPrefixSize] + strings.Repeat("0", len(hashes[49])-2*DefaultPrefixSize)
+		for _, seed := range []string{"", testSeed} {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			res, err = x.Find(ctx, seed, forged, 0)
+			cancel()
+			if err == nil && res.Match {
+				t.Errorf("FAILED -- [Index] Find() of a forged hash (seed %q) should not match: %+v", seed, res)
+			}
+		}
+
+		if _, err := x.Find(context.Background(), "other seed", hashes[41], 0); err == nil {
+			t.Errorf("FAILED -- [Index] Find() with another seed should fail")
+		}
+		if _, _, err := x.Lookup(hashes[0][:32]); err == nil {
+			t.Errorf("FAILED -- [Index] Lookup() of a short hash should fail")
+		}
+	}
+}
+
+func TestIndexMode(t *testing.T) {
+	const tag = "hashclock/test"
+
+	e, err := clock.NewEngine("sha256", clock.ModeIndexed)
+	if err != nil {
+		t.Fatalf("FAILED -- [Index] NewEngine() failed: %s", err)
+	}
+	e.SetTag([]byte(tag))
+
+	hashes := make([]string, 150)
+	next := []byte(testSeed)
+	for idx := range hashes {
+		next = e.StepAt(next, idx+1)
+		hashes[idx] = string(next)
+	}
+
+	path := filepath.Join(t.TempDir(), "chain.idx")
+	h, err := Build(context.Background(), path, &Config{
+		Seed:      testSeed,
+		Length:    100,
+		BloomBits: 10,
+		Mode:      clock.ModeIndexed,
+		Tag:       tag,
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [Index] Build() failed: %s", err)
+	}
+	if h.Last.Hash != hashes[99] {
+		t.Errorf("FAILED -- [Index] unexpected last hash: %+v", h.Last)
+	}
+
+	x, err := Open(path)
+	if err != nil {
+		t.Fatalf("FAILED -- [Index] Open() failed: %s", err)
+	}
+	defer x.Close()
+
+	if got := x.Header(); got != *h {
+		t.Errorf("FAILED -- [Index] header %+v does not match the built header %+v", got, *h)
+	}
+
+	// confirmed from the seed, from the hash, and beyond the indexed range
+	for _, test := range []struct {
+		seed string
+		idx  int
+	}{
+		{seed: testSeed, idx: 10},
+		{seed: "", idx: 90},
+		{seed: "", idx: 120},
+	} {
+		res, err := x.Find(context.Background(), test.seed, hashes[test.idx-1], 0)
+		if err != nil || !res.Match || res.Iterations != test.idx || res.Mode != clock.ModeIndexed || res.Tag != tag {
+			t.Errorf("FAILED -- [Index] Find(#%v) = %+v, %v", test.idx, res, err)
+		}
+	}
+
+	if _, err := Build(context.Background(), path, &Config{Seed: testSeed, Length: 10, Tag: tag}); err == nil {
+		t.Errorf("FAILED -- [Index] Build() with a tag in the hex mode should fail")
+	}
+}
+
+// patch function returns a copy of the input data with the byte at the input
+// offset replaced
+func patch(data []byte, offset int, b byte) []byte {
+	out := append([]byte{}, data...)
+	out[offset] = b
+	return out
+}
+
+func TestIndexInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.idx")
+	if _, err := Build(context.Background(), path, &Config{Seed: testSeed, Length: 10}); err != nil {
+		t.Fatalf("FAILED -- [Index] Build() failed: %s", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("FAILED -- [Index] ReadFile() failed: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated", data: b[:len(b)-1]},
+		{name: "magic", data: append([]byte("XXXX"), b[4:]...)},
+		{name: "empty", data: nil},
+		{name: "version", data: patch(b, 5, 1)},
+		{name: "mode", data: patch(b, 80, 2)},
+		{name: "tag", data: patch(b, 87, 4)},
+	}
+
+	for _, test := range tests {
+		if _, err := New(strings.NewReader(string(test.data))); !errors.Is(err, ErrFormat) {
+			t.Errorf("FAILED -- [Index] %s: New() = %v ; expected ErrFormat", test.name, err)
+		}
+	}
+
+	if _, err := Build(context.Background(), path, &Config{Seed: testSeed, Length: 10, PrefixSize: 2}); err == nil {
+		t.Errorf("FAILED -- [Index] Build() with a 2-byte prefix should fail")
+	}
+}
+
+func TestIndexHTTP(t *testing.T) {
+	hashes := testHashes(t, 100)
+	path := filepath.Join(t.TempDir(), "chain.idx")
+	if _, err := Build(context.Background(), path, &Config{Seed: testSeed, Length: 100, BloomBits: 10}); err != nil {
+		t.Fatalf("FAILED -- [Index] Build() failed: %s", err)
+	}
+	x, err := Open(path)
+	if err != nil {
+		t.Fatalf("FAILED -- [Index] Open() failed: %s", err)
+	}
+	defer x.Close()
+
+	mux := http.NewServeMux()
+	x.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		body   string
+		status int
+		index  int
+	}{
+		{body: `{"hash":"` + hashes[9] + `"}`, status: http.StatusOK, index: 10},
+		{body: `{"hash":"zz"}`, status: http.StatusBadRequest},
+		{body: `{"hash":"` + hashes[9] + `","timeout":-1}`, status: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Post(srv.URL+"/v1/index/lookup", "application/json", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatalf("FAILED -- [Index] POST failed: %s", err)
+		}
+
+		res := &clock.HashClockResponse{}
+		json.NewDecoder(resp.Body).Decode(res)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.status || res.Iterations != test.index {
+			t.Errorf("FAILED -- [Index] POST %s = %v %+v ; expected %v, index %v", test.body, resp.StatusCode, res, test.status, test.index)
+		}
+	}
+}
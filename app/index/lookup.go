@@ -0,0 +1,318 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Index struct is an open index file, which is safe for concurrent lookups
+type Index struct {
+	r      io.ReaderAt
+	closer io.Closer
+
+	header   Header
+	hashSize int
+	bloom    *bloom
+	entries  int64
+}
+
+// Open function opens the index file in the input path
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := New(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	x.closer = f
+	return x, nil
+}
+
+// New function reads an index from the input reader, such as an open file or
+// a memory-mapped one (wrapped in a `bytes.Reader`). Only the header and the
+// Bloom filter are loaded; entries are read on each lookup
+func New(r io.ReaderAt) (*Index, error) {
+	hdr := make([]byte, headerSize)
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFormat, err)
+	}
+	if string(hdr[0:4]) != magic {
+		return nil, fmt.Errorf("%w: unexpected magic %q", ErrFormat, hdr[0:4])
+	}
+
+	x := &Index{
+		r: r,
+		header: Header{
+			Version:     int(binary.BigEndian.Uint16(hdr[4:6])),
+			PrefixSize:  int(binary.BigEndian.Uint16(hdr[6:8])),
+			Length:      int(binary.BigEndian.Uint64(hdr[8:16])),
+			BloomBits:   int(binary.BigEndian.Uint64(hdr[16:24])),
+			BloomHashes: int(binary.BigEndian.Uint32(hdr[24:28])),
+			Algorithm:   string(bytes.TrimRight(hdr[32:32+algSize], "\x00")),
+			SeedDigest:  hex.EncodeToString(hdr[48:80]),
+		},
+		hashSize: int(binary.BigEndian.Uint32(hdr[28:32])),
+	}
+
+	tagSize := int(binary.BigEndian.Uint32(hdr[84:88]))
+
+	h := &x.header
+	switch {
+	case h.Version != Version:
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrFormat, h.Version)
+	case hdr[80] > 1 || (hdr[80] == 0 && tagSize != 0) || tagSize > maxTagSize:
+		return nil, fmt.Errorf("%w: invalid chaining mode", ErrFormat)
+	case x.hashSize < 16 || x.hashSize > 64:
+		return nil, fmt.Errorf("%w: invalid hash size %d", ErrFormat, x.hashSize)
+	case h.PrefixSize < minPrefix || h.PrefixSize > x.hashSize:
+		return nil, fmt.Errorf("%w: invalid prefix size %d", ErrFormat, h.PrefixSize)
+	case h.Length <= 0:
+		return nil, fmt.Errorf("%w: invalid length %d", ErrFormat, h.Length)
+	case h.BloomBits%64 != 0 || (h.BloomBits > 0 && h.BloomHashes <= 0):
+		return nil, fmt.Errorf("%w: invalid bloom filter", ErrFormat)
+	}
+
+	last := make([]byte, x.hashSize)
+	if _, err := r.ReadAt(last, headerSize); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFormat, err)
+	}
+	h.Last = clock.Checkpoint{Index: h.Length, Hash: hex.EncodeToString(last)}
+
+	h.Mode = clock.ModeHex
+	if hdr[80] == 1 {
+		h.Mode = clock.ModeIndexed
+
+		tag := make([]byte, tagSize)
+		if _, err := r.ReadAt(tag, headerSize+int64(x.hashSize)); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrFormat, err)
+		}
+		h.Tag = string(tag)
+	}
+
+	x.entries = bloomOffset(x.hashSize, tagSize)
+	if h.BloomBits > 0 {
+		x.bloom = &bloom{bits: make([]byte, h.BloomBits/8), k: h.BloomHashes}
+		if _, err := r.ReadAt(x.bloom.bits, x.entries); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrFormat, err)
+		}
+		x.entries += int64(len(x.bloom.bits))
+	}
+
+	// the last entry must be readable, for the file to be complete
+	entry := make([]byte, x.entrySize())
+	if _, err := r.ReadAt(entry, x.entries+int64(h.Length-1)*int64(len(entry))); err != nil {
+		return nil, fmt.Errorf("%w: truncated entries -- %s", ErrFormat, err)
+	}
+
+	return x, nil
+}
+
+// Close method closes the index file, if it was opened with `Open`
+func (x *Index) Close() error {
+	if x.closer == nil {
+		return nil
+	}
+	return x.closer.Close()
+}
+
+// Header method returns the index's header
+func (x *Index) Header() Header {
+	return x.header
+}
+
+func (x *Index) entrySize() int {
+	return x.header.PrefixSize + indexSize
+}
+
+// Lookup method returns the index of a candidate for the input (hex-encoded)
+// hash within the indexed range, with a binary search over the entries (after
+// checking the Bloom filter, if any). It returns false if the hash is not
+// indexed.
+//
+// Only the hashes' prefixes are stored and compared, so a hash which shares
+// its prefix with an indexed one is a candidate too: `Find` confirms the full
+// hash before reporting a match
+func (x *Index) Lookup(hash string) (int, bool, error) {
+	idx, err := x.candidates(hash)
+	if err != nil || len(idx) == 0 {
+		return 0, false, err
+	}
+	return idx[0], true, nil
+}
+
+// candidates method returns the indices of the entries whose prefix matches
+// the input (hex-encoded) hash's, in the order of the index's table
+func (x *Index) candidates(hash string) ([]int, error) {
+	raw, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("hex encoder: invalid string -- %s", err)
+	}
+	if len(raw) != x.hashSize {
+		return nil, errors.New("hash length does not match the index's hash function")
+	}
+
+	if x.bloom != nil && !x.bloom.has(raw) {
+		return nil, nil
+	}
+
+	var (
+		prefix = raw[:x.header.PrefixSize]
+		entry  = make([]byte, x.entrySize())
+		rErr   error
+	)
+	read := func(i int) []byte {
+		if _, err := x.r.ReadAt(entry, x.entries+int64(i)*int64(len(entry))); err != nil && rErr == nil {
+			rErr = err
+		}
+		return entry
+	}
+
+	n := sort.Search(x.header.Length, func(i int) bool {
+		return bytes.Compare(read(i)[:len(prefix)], prefix) >= 0
+	})
+
+	// the entries are sorted by prefix, so any others which share it follow
+	var idx []int
+	for ; n < x.header.Length && rErr == nil; n++ {
+		e := read(n)
+		if rErr != nil || !bytes.Equal(e[:len(prefix)], prefix) {
+			break
+		}
+		idx = append(idx, int(binary.BigEndian.Uint64(e[len(prefix):])))
+	}
+	if rErr != nil {
+		return nil, rErr
+	}
+	return idx, nil
+}
+
+// confirm method returns whether the input (hex-encoded, lower-case) hash is
+// the chain's hash at the input index, by calculating the chain (in the
+// index's chaining mode) from the nearest known point: the seed (if set), or
+// the hash itself up to the last indexed hash. If the input context is done
+// first, its error is returned
+func (x *Index) confirm(ctx context.Context, seed, hash string, idx int) (bool, error) {
+	e, err := clock.NewEngine(x.header.Algorithm, x.header.Mode)
+	if err != nil {
+		return false, err
+	}
+	e.SetTag([]byte(x.header.Tag))
+
+	next, from, to, want := []byte(hash), idx, x.header.Length, x.header.Last.Hash
+	if seed != "" && idx <= x.header.Length-idx {
+		next, from, to, want = []byte(seed), 0, idx, hash
+	}
+
+	for i := from + 1; i <= to; i++ {
+		if i%checkPeriod == 0 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+		next = e.StepAt(next, i)
+	}
+	return string(next) == want, nil
+}
+
+// Find method returns the index of the input (hex-encoded) hash in the chain,
+// as a `clock.HashClockResponse` (like `Verify`). A hash within the indexed
+// range is looked up in the index, and confirmed by calculating the chain from
+// the seed (if set) or from the hash up to the last indexed one -- whichever
+// is shorter; otherwise, the chain is calculated from the last indexed hash
+// until it is found. Both stop when the context is cancelled or the timeout
+// (in seconds; 0 does not set one) runs out: a candidate which could not be
+// confirmed by then is returned with its index, and no match. The chain is
+// calculated in the index's chaining mode and domain tag.
+//
+// The seed is optional, as the index holds the hashes; if set, it must be the
+// indexed chain's seed, and it is reported in the response
+func (x *Index) Find(ctx context.Context, seed, hash string, timeout int) (*clock.HashClockResponse, error) {
+	if seed != "" {
+		digest := sha256.Sum256([]byte(seed))
+		if hex.EncodeToString(digest[:]) != x.header.SeedDigest {
+			return &clock.HashClockResponse{}, errors.New("input seed does not match the index's seed")
+		}
+	}
+
+	timestamp := time.Now()
+
+	candidates, err := x.candidates(hash)
+	if err != nil {
+		return &clock.HashClockResponse{}, err
+	}
+
+	confirmCtx := ctx
+	if timeout > 0 && len(candidates) > 0 {
+		var cancel context.CancelFunc
+		confirmCtx, cancel = context.WithTimeout(ctx, time.Second*time.Duration(timeout))
+		defer cancel()
+	}
+
+	lower := string(bytes.ToLower([]byte(hash)))
+	for _, idx := range candidates {
+		ok, err := x.confirm(confirmCtx, seed, lower, idx)
+		if err != nil && ctx.Err() != nil {
+			return &clock.HashClockResponse{}, ctx.Err()
+		}
+
+		res := &clock.HashClockResponse{
+			Seed:       seed,
+			Algorithm:  x.header.Algorithm,
+			Timeout:    timeout,
+			Iterations: idx,
+			Target:     hash,
+		}
+		if x.header.Mode == clock.ModeIndexed {
+			res.Mode, res.Tag = x.header.Mode, x.header.Tag
+		}
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			// the timeout ran out: an unconfirmed candidate
+			res.Duration = time.Since(timestamp)
+			return res, nil
+		case err != nil:
+			return &clock.HashClockResponse{}, err
+		case ok:
+			res.Hash = lower
+			res.Match = true
+			res.Duration = time.Since(timestamp)
+			return res, nil
+		}
+	}
+
+	// beyond the indexed range (or a prefix shared with an indexed hash):
+	// continue the chain from the last indexed hash
+	svc := clock.NewService()
+	if err := svc.SetHasher(x.header.Algorithm); err != nil {
+		return &clock.HashClockResponse{}, err
+	}
+	if err := svc.SetMode(x.header.Mode, x.header.Tag); err != nil {
+		return &clock.HashClockResponse{}, err
+	}
+	if err := svc.SetContext(ctx); err != nil {
+		return &clock.HashClockResponse{}, err
+	}
+	if err := svc.SetCheckpoint(x.header.Last.Index, x.header.Last.Hash); err != nil {
+		return &clock.HashClockResponse{}, err
+	}
+
+	if timeout > 0 {
+		return svc.VerifyTimeout(seed, hash, timeout)
+	}
+	return svc.Verify(seed, hash)
+}
@@ -0,0 +1,142 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package lanes
+
+// level is the fastest implementation supported by the CPU
+var level = maxLevel()
+
+// maxLevel function checks the CPU's features (and that the OS saves the
+// registers they use) for the fastest supported implementation
+func maxLevel() int {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return levelGeneric
+	}
+
+	_, _, ecx1, _ := cpuid(1, 0)
+	const (
+		osxsave = 1 << 27
+		avx     = 1 << 28
+	)
+	if ecx1&osxsave == 0 || ecx1&avx == 0 {
+		return levelGeneric
+	}
+
+	// the XMM and YMM states; and the opmask and ZMM states, for AVX-512
+	xcr0, _ := xgetbv()
+	if xcr0&0x6 != 0x6 {
+		return levelGeneric
+	}
+
+	_, ebx7, _, _ := cpuid(7, 0)
+	const (
+		avx2     = 1 << 5
+		avx512f  = 1 << 16
+		avx512vl = 1 << 31
+	)
+	switch {
+	case ebx7&avx2 == 0:
+		return levelGeneric
+	case ebx7&avx512f != 0 && ebx7&avx512vl != 0 && xcr0&0xe0 == 0xe0:
+		return levelAVX512
+	default:
+		return levelAVX2
+	}
+}
+
+func hex8(h *state256, w *block256, words int) {
+	if level >= levelAVX2 {
+		hex8AVX2(h, w, words)
+		return
+	}
+	hex8Generic(h, w, words)
+}
+
+func expand8(w *block256, s *sched256) {
+	switch level {
+	case levelAVX512:
+		expand8AVX512(w, s)
+	case levelAVX2:
+		expand8AVX2(w, s)
+	default:
+		expand8Generic(w, s)
+	}
+}
+
+func rounds8(h *state256, s *sched256) {
+	switch level {
+	case levelAVX512:
+		rounds8AVX512(h, s)
+	case levelAVX2:
+		rounds8AVX2(h, s)
+	default:
+		rounds8Generic(h, s)
+	}
+}
+
+func hex4(h *state512, w *block512, data int) {
+	if level >= levelAVX2 {
+		hex4AVX2(h, w, data)
+		return
+	}
+	hex4Generic(h, w, data)
+}
+
+func expand4(w *block512, s *sched512) {
+	switch level {
+	case levelAVX512:
+		expand4AVX512(w, s)
+	case levelAVX2:
+		expand4AVX2(w, s)
+	default:
+		expand4Generic(w, s)
+	}
+}
+
+func rounds4(h *state512, s *sched512) {
+	switch level {
+	case levelAVX512:
+		rounds4AVX512(h, s)
+	case levelAVX2:
+		rounds4AVX2(h, s)
+	default:
+		rounds4Generic(h, s)
+	}
+}
+
+// implemented in block_amd64.s
+
+//go:noescape
+func hex8AVX2(h *state256, w *block256, words int)
+
+//go:noescape
+func expand8AVX2(w *block256, s *sched256)
+
+//go:noescape
+func rounds8AVX2(h *state256, s *sched256)
+
+//go:noescape
+func expand8AVX512(w *block256, s *sched256)
+
+//go:noescape
+func rounds8AVX512(h *state256, s *sched256)
+
+//go:noescape
+func hex4AVX2(h *state512, w *block512, data int)
+
+//go:noescape
+func expand4AVX2(w *block512, s *sched512)
+
+//go:noescape
+func rounds4AVX2(h *state512, s *sched512)
+
+//go:noescape
+func expand4AVX512(w *block512, s *sched512)
+
+//go:noescape
+func rounds4AVX512(h *state512, s *sched512)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
@@ -0,0 +1,1898 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// The lanes are hashed in the 8 32-bit (SHA-256) or 4 64-bit (SHA-512) words
+// of the YMM registers, one vector per word of the hash values or message
+// schedule. The schedule is expanded with the round constants already added
+// (by the expand functions), so that blocks with a constant message (such as
+// the padding block of a hex-encoded SHA-256 hash) are only expanded once.
+//
+// In the rounds functions, the hash values a to h are kept in Y0 to Y7 --
+// each round renames them, instead of moving them -- and Y8 to Y11 are
+// temporary. The AVX2 functions rotate with the XOR of both shifts (which do
+// not overlap); the AVX-512 functions (on YMM registers, with AVX512VL) have
+// rotations and three-input logic instructions.
+
+// ROTXOR_D sets dst to the XOR of dst and x rotated right by n (32-bit)
+#define ROTXOR_D(x, n, m, dst) \
+	VPSRLD $n, x, Y8; VPSLLD $m, x, Y9; VPXOR Y8, dst, dst; VPXOR Y9, dst, dst
+
+// ROTXOR_Q sets dst to the XOR of dst and x rotated right by n (64-bit)
+#define ROTXOR_Q(x, n, m, dst) \
+	VPSRLQ $n, x, Y8; VPSLLQ $m, x, Y9; VPXOR Y8, dst, dst; VPXOR Y9, dst, dst
+
+// ROUND_AVX2 runs a round with the scheduled word (plus constant) at woff(SI):
+// h becomes the new a, and d the new e. S is the width of the words (D or Q),
+// and the rotations of Σ1 and Σ0 are r1-r3 and r4-r6 (their complements, m)
+#define ROUND_AVX2(ROTXOR, ADD, a, b, c, d, e, f, g, h, woff, r1, m1, r2, m2, r3, m3, r4, m4, r5, m5, r6, m6) \
+	VPXOR Y10, Y10, Y10; \
+	ROTXOR(e, r1, m1, Y10); ROTXOR(e, r2, m2, Y10); ROTXOR(e, r3, m3, Y10); \
+	VPAND f, e, Y8; VPANDN g, e, Y9; VPXOR Y8, Y9, Y11; \
+	ADD Y10, h, h; ADD Y11, h, h; \
+	ADD woff(SI), h, h; \
+	ADD h, d, d; \
+	VPXOR Y10, Y10, Y10; \
+	ROTXOR(a, r4, m4, Y10); ROTXOR(a, r5, m5, Y10); ROTXOR(a, r6, m6, Y10); \
+	VPOR b, a, Y8; VPAND c, Y8, Y8; VPAND b, a, Y9; VPOR Y9, Y8, Y11; \
+	ADD Y10, h, h; ADD Y11, h, h
+
+// ROUND_AVX512 runs a round as ROUND_AVX2, with the rotations of the word
+// width (VPRORD or VPRORQ) and three-input logic (VPTERNLOGD or VPTERNLOGQ):
+// 0x96 is the XOR of the three inputs, 0xca is Ch and 0xe8 is Maj
+#define ROUND_AVX512(ROR, TERN, ADD, a, b, c, d, e, f, g, h, woff, r1, r2, r3, r4, r5, r6) \
+	ROR $r1, e, Y8; ROR $r2, e, Y9; ROR $r3, e, Y10; TERN $0x96, Y8, Y9, Y10; \
+	VMOVDQU e, Y11; TERN $0xca, g, f, Y11; \
+	ADD Y10, h, h; ADD Y11, h, h; \
+	ADD woff(SI), h, h; \
+	ADD h, d, d; \
+	ROR $r4, a, Y8; ROR $r5, a, Y9; ROR $r6, a, Y10; TERN $0x96, Y8, Y9, Y10; \
+	VMOVDQU a, Y11; TERN $0xe8, c, b, Y11; \
+	ADD Y10, h, h; ADD Y11, h, h
+
+// SCHED_AVX2 expands the word at ot(DI) from the words t-2, t-7, t-15 and
+// t-16: the rotations and shift of σ1 are r1, r2, s1, and of σ0 r3, r4, s2
+#define SCHED_AVX2(ROTXOR, SHR, ADD, o2, o7, o15, o16, ot, r1, m1, r2, m2, s1, r3, m3, r4, m4, s2) \
+	VMOVDQU o2(DI), Y12; \
+	SHR $s1, Y12, Y13; ROTXOR(Y12, r1, m1, Y13); ROTXOR(Y12, r2, m2, Y13); \
+	VMOVDQU o15(DI), Y12; \
+	SHR $s2, Y12, Y14; ROTXOR(Y12, r3, m3, Y14); ROTXOR(Y12, r4, m4, Y14); \
+	ADD Y14, Y13, Y13; ADD o7(DI), Y13, Y13; ADD o16(DI), Y13, Y13; \
+	VMOVDQU Y13, ot(DI)
+
+// SCHED_AVX512 expands a word as SCHED_AVX2, with rotations and three-input
+// logic
+#define SCHED_AVX512(ROR, SHR, TERN, ADD, o2, o7, o15, o16, ot, r1, r2, s1, r3, r4, s2) \
+	VMOVDQU o2(DI), Y12; \
+	ROR $r1, Y12, Y8; ROR $r2, Y12, Y9; SHR $s1, Y12, Y13; TERN $0x96, Y8, Y9, Y13; \
+	VMOVDQU o15(DI), Y12; \
+	ROR $r3, Y12, Y8; ROR $r4, Y12, Y9; SHR $s2, Y12, Y14; TERN $0x96, Y8, Y9, Y14; \
+	ADD Y14, Y13, Y13; ADD o7(DI), Y13, Y13; ADD o16(DI), Y13, Y13; \
+	VMOVDQU Y13, ot(DI)
+
+// func expand8AVX2(w *block256, s *sched256)
+TEXT ·expand8AVX2(SB), NOSPLIT, $0-16
+	MOVQ w+0(FP), SI
+	MOVQ s+8(FP), DI
+	LEAQ ·k256(SB), R8
+
+	// the message block, then the rest of the schedule
+	VMOVDQU 0(SI), Y8
+	VMOVDQU Y8, 0(DI)
+	VMOVDQU 32(SI), Y8
+	VMOVDQU Y8, 32(DI)
+	VMOVDQU 64(SI), Y8
+	VMOVDQU Y8, 64(DI)
+	VMOVDQU 96(SI), Y8
+	VMOVDQU Y8, 96(DI)
+	VMOVDQU 128(SI), Y8
+	VMOVDQU Y8, 128(DI)
+	VMOVDQU 160(SI), Y8
+	VMOVDQU Y8, 160(DI)
+	VMOVDQU 192(SI), Y8
+	VMOVDQU Y8, 192(DI)
+	VMOVDQU 224(SI), Y8
+	VMOVDQU Y8, 224(DI)
+	VMOVDQU 256(SI), Y8
+	VMOVDQU Y8, 256(DI)
+	VMOVDQU 288(SI), Y8
+	VMOVDQU Y8, 288(DI)
+	VMOVDQU 320(SI), Y8
+	VMOVDQU Y8, 320(DI)
+	VMOVDQU 352(SI), Y8
+	VMOVDQU Y8, 352(DI)
+	VMOVDQU 384(SI), Y8
+	VMOVDQU Y8, 384(DI)
+	VMOVDQU 416(SI), Y8
+	VMOVDQU Y8, 416(DI)
+	VMOVDQU 448(SI), Y8
+	VMOVDQU Y8, 448(DI)
+	VMOVDQU 480(SI), Y8
+	VMOVDQU Y8, 480(DI)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 448, 288, 32, 0, 512, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 480, 320, 64, 32, 544, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 512, 352, 96, 64, 576, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 544, 384, 128, 96, 608, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 576, 416, 160, 128, 640, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 608, 448, 192, 160, 672, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 640, 480, 224, 192, 704, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 672, 512, 256, 224, 736, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 704, 544, 288, 256, 768, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 736, 576, 320, 288, 800, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 768, 608, 352, 320, 832, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 800, 640, 384, 352, 864, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 832, 672, 416, 384, 896, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 864, 704, 448, 416, 928, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 896, 736, 480, 448, 960, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 928, 768, 512, 480, 992, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 960, 800, 544, 512, 1024, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 992, 832, 576, 544, 1056, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1024, 864, 608, 576, 1088, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1056, 896, 640, 608, 1120, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1088, 928, 672, 640, 1152, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1120, 960, 704, 672, 1184, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1152, 992, 736, 704, 1216, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1184, 1024, 768, 736, 1248, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1216, 1056, 800, 768, 1280, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1248, 1088, 832, 800, 1312, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1280, 1120, 864, 832, 1344, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1312, 1152, 896, 864, 1376, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1344, 1184, 928, 896, 1408, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1376, 1216, 960, 928, 1440, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1408, 1248, 992, 960, 1472, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1440, 1280, 1024, 992, 1504, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1472, 1312, 1056, 1024, 1536, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1504, 1344, 1088, 1056, 1568, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1536, 1376, 1120, 1088, 1600, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1568, 1408, 1152, 1120, 1632, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1600, 1440, 1184, 1152, 1664, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1632, 1472, 1216, 1184, 1696, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1664, 1504, 1248, 1216, 1728, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1696, 1536, 1280, 1248, 1760, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1728, 1568, 1312, 1280, 1792, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1760, 1600, 1344, 1312, 1824, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1792, 1632, 1376, 1344, 1856, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1824, 1664, 1408, 1376, 1888, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1856, 1696, 1440, 1408, 1920, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1888, 1728, 1472, 1440, 1952, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1920, 1760, 1504, 1472, 1984, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+	SCHED_AVX2(ROTXOR_D, VPSRLD, VPADDD, 1952, 1792, 1536, 1504, 2016, 17, 15, 19, 13, 10, 7, 25, 18, 14, 3)
+
+	// add the round constants
+	VPBROADCASTD 0(R8), Y8
+	VPADDD 0(DI), Y8, Y8
+	VMOVDQU Y8, 0(DI)
+	VPBROADCASTD 4(R8), Y8
+	VPADDD 32(DI), Y8, Y8
+	VMOVDQU Y8, 32(DI)
+	VPBROADCASTD 8(R8), Y8
+	VPADDD 64(DI), Y8, Y8
+	VMOVDQU Y8, 64(DI)
+	VPBROADCASTD 12(R8), Y8
+	VPADDD 96(DI), Y8, Y8
+	VMOVDQU Y8, 96(DI)
+	VPBROADCASTD 16(R8), Y8
+	VPADDD 128(DI), Y8, Y8
+	VMOVDQU Y8, 128(DI)
+	VPBROADCASTD 20(R8), Y8
+	VPADDD 160(DI), Y8, Y8
+	VMOVDQU Y8, 160(DI)
+	VPBROADCASTD 24(R8), Y8
+	VPADDD 192(DI), Y8, Y8
+	VMOVDQU Y8, 192(DI)
+	VPBROADCASTD 28(R8), Y8
+	VPADDD 224(DI), Y8, Y8
+	VMOVDQU Y8, 224(DI)
+	VPBROADCASTD 32(R8), Y8
+	VPADDD 256(DI), Y8, Y8
+	VMOVDQU Y8, 256(DI)
+	VPBROADCASTD 36(R8), Y8
+	VPADDD 288(DI), Y8, Y8
+	VMOVDQU Y8, 288(DI)
+	VPBROADCASTD 40(R8), Y8
+	VPADDD 320(DI), Y8, Y8
+	VMOVDQU Y8, 320(DI)
+	VPBROADCASTD 44(R8), Y8
+	VPADDD 352(DI), Y8, Y8
+	VMOVDQU Y8, 352(DI)
+	VPBROADCASTD 48(R8), Y8
+	VPADDD 384(DI), Y8, Y8
+	VMOVDQU Y8, 384(DI)
+	VPBROADCASTD 52(R8), Y8
+	VPADDD 416(DI), Y8, Y8
+	VMOVDQU Y8, 416(DI)
+	VPBROADCASTD 56(R8), Y8
+	VPADDD 448(DI), Y8, Y8
+	VMOVDQU Y8, 448(DI)
+	VPBROADCASTD 60(R8), Y8
+	VPADDD 480(DI), Y8, Y8
+	VMOVDQU Y8, 480(DI)
+	VPBROADCASTD 64(R8), Y8
+	VPADDD 512(DI), Y8, Y8
+	VMOVDQU Y8, 512(DI)
+	VPBROADCASTD 68(R8), Y8
+	VPADDD 544(DI), Y8, Y8
+	VMOVDQU Y8, 544(DI)
+	VPBROADCASTD 72(R8), Y8
+	VPADDD 576(DI), Y8, Y8
+	VMOVDQU Y8, 576(DI)
+	VPBROADCASTD 76(R8), Y8
+	VPADDD 608(DI), Y8, Y8
+	VMOVDQU Y8, 608(DI)
+	VPBROADCASTD 80(R8), Y8
+	VPADDD 640(DI), Y8, Y8
+	VMOVDQU Y8, 640(DI)
+	VPBROADCASTD 84(R8), Y8
+	VPADDD 672(DI), Y8, Y8
+	VMOVDQU Y8, 672(DI)
+	VPBROADCASTD 88(R8), Y8
+	VPADDD 704(DI), Y8, Y8
+	VMOVDQU Y8, 704(DI)
+	VPBROADCASTD 92(R8), Y8
+	VPADDD 736(DI), Y8, Y8
+	VMOVDQU Y8, 736(DI)
+	VPBROADCASTD 96(R8), Y8
+	VPADDD 768(DI), Y8, Y8
+	VMOVDQU Y8, 768(DI)
+	VPBROADCASTD 100(R8), Y8
+	VPADDD 800(DI), Y8, Y8
+	VMOVDQU Y8, 800(DI)
+	VPBROADCASTD 104(R8), Y8
+	VPADDD 832(DI), Y8, Y8
+	VMOVDQU Y8, 832(DI)
+	VPBROADCASTD 108(R8), Y8
+	VPADDD 864(DI), Y8, Y8
+	VMOVDQU Y8, 864(DI)
+	VPBROADCASTD 112(R8), Y8
+	VPADDD 896(DI), Y8, Y8
+	VMOVDQU Y8, 896(DI)
+	VPBROADCASTD 116(R8), Y8
+	VPADDD 928(DI), Y8, Y8
+	VMOVDQU Y8, 928(DI)
+	VPBROADCASTD 120(R8), Y8
+	VPADDD 960(DI), Y8, Y8
+	VMOVDQU Y8, 960(DI)
+	VPBROADCASTD 124(R8), Y8
+	VPADDD 992(DI), Y8, Y8
+	VMOVDQU Y8, 992(DI)
+	VPBROADCASTD 128(R8), Y8
+	VPADDD 1024(DI), Y8, Y8
+	VMOVDQU Y8, 1024(DI)
+	VPBROADCASTD 132(R8), Y8
+	VPADDD 1056(DI), Y8, Y8
+	VMOVDQU Y8, 1056(DI)
+	VPBROADCASTD 136(R8), Y8
+	VPADDD 1088(DI), Y8, Y8
+	VMOVDQU Y8, 1088(DI)
+	VPBROADCASTD 140(R8), Y8
+	VPADDD 1120(DI), Y8, Y8
+	VMOVDQU Y8, 1120(DI)
+	VPBROADCASTD 144(R8), Y8
+	VPADDD 1152(DI), Y8, Y8
+	VMOVDQU Y8, 1152(DI)
+	VPBROADCASTD 148(R8), Y8
+	VPADDD 1184(DI), Y8, Y8
+	VMOVDQU Y8, 1184(DI)
+	VPBROADCASTD 152(R8), Y8
+	VPADDD 1216(DI), Y8, Y8
+	VMOVDQU Y8, 1216(DI)
+	VPBROADCASTD 156(R8), Y8
+	VPADDD 1248(DI), Y8, Y8
+	VMOVDQU Y8, 1248(DI)
+	VPBROADCASTD 160(R8), Y8
+	VPADDD 1280(DI), Y8, Y8
+	VMOVDQU Y8, 1280(DI)
+	VPBROADCASTD 164(R8), Y8
+	VPADDD 1312(DI), Y8, Y8
+	VMOVDQU Y8, 1312(DI)
+	VPBROADCASTD 168(R8), Y8
+	VPADDD 1344(DI), Y8, Y8
+	VMOVDQU Y8, 1344(DI)
+	VPBROADCASTD 172(R8), Y8
+	VPADDD 1376(DI), Y8, Y8
+	VMOVDQU Y8, 1376(DI)
+	VPBROADCASTD 176(R8), Y8
+	VPADDD 1408(DI), Y8, Y8
+	VMOVDQU Y8, 1408(DI)
+	VPBROADCASTD 180(R8), Y8
+	VPADDD 1440(DI), Y8, Y8
+	VMOVDQU Y8, 1440(DI)
+	VPBROADCASTD 184(R8), Y8
+	VPADDD 1472(DI), Y8, Y8
+	VMOVDQU Y8, 1472(DI)
+	VPBROADCASTD 188(R8), Y8
+	VPADDD 1504(DI), Y8, Y8
+	VMOVDQU Y8, 1504(DI)
+	VPBROADCASTD 192(R8), Y8
+	VPADDD 1536(DI), Y8, Y8
+	VMOVDQU Y8, 1536(DI)
+	VPBROADCASTD 196(R8), Y8
+	VPADDD 1568(DI), Y8, Y8
+	VMOVDQU Y8, 1568(DI)
+	VPBROADCASTD 200(R8), Y8
+	VPADDD 1600(DI), Y8, Y8
+	VMOVDQU Y8, 1600(DI)
+	VPBROADCASTD 204(R8), Y8
+	VPADDD 1632(DI), Y8, Y8
+	VMOVDQU Y8, 1632(DI)
+	VPBROADCASTD 208(R8), Y8
+	VPADDD 1664(DI), Y8, Y8
+	VMOVDQU Y8, 1664(DI)
+	VPBROADCASTD 212(R8), Y8
+	VPADDD 1696(DI), Y8, Y8
+	VMOVDQU Y8, 1696(DI)
+	VPBROADCASTD 216(R8), Y8
+	VPADDD 1728(DI), Y8, Y8
+	VMOVDQU Y8, 1728(DI)
+	VPBROADCASTD 220(R8), Y8
+	VPADDD 1760(DI), Y8, Y8
+	VMOVDQU Y8, 1760(DI)
+	VPBROADCASTD 224(R8), Y8
+	VPADDD 1792(DI), Y8, Y8
+	VMOVDQU Y8, 1792(DI)
+	VPBROADCASTD 228(R8), Y8
+	VPADDD 1824(DI), Y8, Y8
+	VMOVDQU Y8, 1824(DI)
+	VPBROADCASTD 232(R8), Y8
+	VPADDD 1856(DI), Y8, Y8
+	VMOVDQU Y8, 1856(DI)
+	VPBROADCASTD 236(R8), Y8
+	VPADDD 1888(DI), Y8, Y8
+	VMOVDQU Y8, 1888(DI)
+	VPBROADCASTD 240(R8), Y8
+	VPADDD 1920(DI), Y8, Y8
+	VMOVDQU Y8, 1920(DI)
+	VPBROADCASTD 244(R8), Y8
+	VPADDD 1952(DI), Y8, Y8
+	VMOVDQU Y8, 1952(DI)
+	VPBROADCASTD 248(R8), Y8
+	VPADDD 1984(DI), Y8, Y8
+	VMOVDQU Y8, 1984(DI)
+	VPBROADCASTD 252(R8), Y8
+	VPADDD 2016(DI), Y8, Y8
+	VMOVDQU Y8, 2016(DI)
+
+	VZEROUPPER
+	RET
+
+// func rounds8AVX2(h *state256, s *sched256)
+TEXT ·rounds8AVX2(SB), NOSPLIT, $0-16
+	MOVQ h+0(FP), DI
+	MOVQ s+8(FP), SI
+
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 128(DI), Y4
+	VMOVDQU 160(DI), Y5
+	VMOVDQU 192(DI), Y6
+	VMOVDQU 224(DI), Y7
+
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 0, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 32, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 64, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 96, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 128, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 160, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 192, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 224, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 256, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 288, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 320, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 352, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 384, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 416, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 448, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 480, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 512, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 544, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 576, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 608, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 640, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 672, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 704, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 736, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 768, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 800, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 832, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 864, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 896, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 928, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 960, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 992, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1024, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1056, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1088, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1120, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1152, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1184, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1216, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1248, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1280, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1312, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1344, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1376, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1408, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1440, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1472, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1504, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1536, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1568, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1600, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1632, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1664, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1696, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1728, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1760, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1792, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1824, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1856, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1888, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1920, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1952, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1984, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+	ROUND_AVX2(ROTXOR_D, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2016, 6, 26, 11, 21, 25, 7, 2, 30, 13, 19, 22, 10)
+
+	// add the compressed block to the hash values
+	VPADDD 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VPADDD 32(DI), Y1, Y1
+	VMOVDQU Y1, 32(DI)
+	VPADDD 64(DI), Y2, Y2
+	VMOVDQU Y2, 64(DI)
+	VPADDD 96(DI), Y3, Y3
+	VMOVDQU Y3, 96(DI)
+	VPADDD 128(DI), Y4, Y4
+	VMOVDQU Y4, 128(DI)
+	VPADDD 160(DI), Y5, Y5
+	VMOVDQU Y5, 160(DI)
+	VPADDD 192(DI), Y6, Y6
+	VMOVDQU Y6, 192(DI)
+	VPADDD 224(DI), Y7, Y7
+	VMOVDQU Y7, 224(DI)
+
+	VZEROUPPER
+	RET
+
+// func expand8AVX512(w *block256, s *sched256)
+TEXT ·expand8AVX512(SB), NOSPLIT, $0-16
+	MOVQ w+0(FP), SI
+	MOVQ s+8(FP), DI
+	LEAQ ·k256(SB), R8
+
+	// the message block, then the rest of the schedule
+	VMOVDQU 0(SI), Y8
+	VMOVDQU Y8, 0(DI)
+	VMOVDQU 32(SI), Y8
+	VMOVDQU Y8, 32(DI)
+	VMOVDQU 64(SI), Y8
+	VMOVDQU Y8, 64(DI)
+	VMOVDQU 96(SI), Y8
+	VMOVDQU Y8, 96(DI)
+	VMOVDQU 128(SI), Y8
+	VMOVDQU Y8, 128(DI)
+	VMOVDQU 160(SI), Y8
+	VMOVDQU Y8, 160(DI)
+	VMOVDQU 192(SI), Y8
+	VMOVDQU Y8, 192(DI)
+	VMOVDQU 224(SI), Y8
+	VMOVDQU Y8, 224(DI)
+	VMOVDQU 256(SI), Y8
+	VMOVDQU Y8, 256(DI)
+	VMOVDQU 288(SI), Y8
+	VMOVDQU Y8, 288(DI)
+	VMOVDQU 320(SI), Y8
+	VMOVDQU Y8, 320(DI)
+	VMOVDQU 352(SI), Y8
+	VMOVDQU Y8, 352(DI)
+	VMOVDQU 384(SI), Y8
+	VMOVDQU Y8, 384(DI)
+	VMOVDQU 416(SI), Y8
+	VMOVDQU Y8, 416(DI)
+	VMOVDQU 448(SI), Y8
+	VMOVDQU Y8, 448(DI)
+	VMOVDQU 480(SI), Y8
+	VMOVDQU Y8, 480(DI)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 448, 288, 32, 0, 512, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 480, 320, 64, 32, 544, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 512, 352, 96, 64, 576, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 544, 384, 128, 96, 608, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 576, 416, 160, 128, 640, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 608, 448, 192, 160, 672, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 640, 480, 224, 192, 704, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 672, 512, 256, 224, 736, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 704, 544, 288, 256, 768, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 736, 576, 320, 288, 800, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 768, 608, 352, 320, 832, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 800, 640, 384, 352, 864, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 832, 672, 416, 384, 896, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 864, 704, 448, 416, 928, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 896, 736, 480, 448, 960, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 928, 768, 512, 480, 992, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 960, 800, 544, 512, 1024, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 992, 832, 576, 544, 1056, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1024, 864, 608, 576, 1088, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1056, 896, 640, 608, 1120, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1088, 928, 672, 640, 1152, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1120, 960, 704, 672, 1184, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1152, 992, 736, 704, 1216, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1184, 1024, 768, 736, 1248, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1216, 1056, 800, 768, 1280, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1248, 1088, 832, 800, 1312, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1280, 1120, 864, 832, 1344, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1312, 1152, 896, 864, 1376, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1344, 1184, 928, 896, 1408, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1376, 1216, 960, 928, 1440, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1408, 1248, 992, 960, 1472, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1440, 1280, 1024, 992, 1504, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1472, 1312, 1056, 1024, 1536, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1504, 1344, 1088, 1056, 1568, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1536, 1376, 1120, 1088, 1600, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1568, 1408, 1152, 1120, 1632, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1600, 1440, 1184, 1152, 1664, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1632, 1472, 1216, 1184, 1696, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1664, 1504, 1248, 1216, 1728, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1696, 1536, 1280, 1248, 1760, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1728, 1568, 1312, 1280, 1792, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1760, 1600, 1344, 1312, 1824, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1792, 1632, 1376, 1344, 1856, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1824, 1664, 1408, 1376, 1888, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1856, 1696, 1440, 1408, 1920, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1888, 1728, 1472, 1440, 1952, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1920, 1760, 1504, 1472, 1984, 17, 19, 10, 7, 18, 3)
+	SCHED_AVX512(VPRORD, VPSRLD, VPTERNLOGD, VPADDD, 1952, 1792, 1536, 1504, 2016, 17, 19, 10, 7, 18, 3)
+
+	// add the round constants
+	VPBROADCASTD 0(R8), Y8
+	VPADDD 0(DI), Y8, Y8
+	VMOVDQU Y8, 0(DI)
+	VPBROADCASTD 4(R8), Y8
+	VPADDD 32(DI), Y8, Y8
+	VMOVDQU Y8, 32(DI)
+	VPBROADCASTD 8(R8), Y8
+	VPADDD 64(DI), Y8, Y8
+	VMOVDQU Y8, 64(DI)
+	VPBROADCASTD 12(R8), Y8
+	VPADDD 96(DI), Y8, Y8
+	VMOVDQU Y8, 96(DI)
+	VPBROADCASTD 16(R8), Y8
+	VPADDD 128(DI), Y8, Y8
+	VMOVDQU Y8, 128(DI)
+	VPBROADCASTD 20(R8), Y8
+	VPADDD 160(DI), Y8, Y8
+	VMOVDQU Y8, 160(DI)
+	VPBROADCASTD 24(R8), Y8
+	VPADDD 192(DI), Y8, Y8
+	VMOVDQU Y8, 192(DI)
+	VPBROADCASTD 28(R8), Y8
+	VPADDD 224(DI), Y8, Y8
+	VMOVDQU Y8, 224(DI)
+	VPBROADCASTD 32(R8), Y8
+	VPADDD 256(DI), Y8, Y8
+	VMOVDQU Y8, 256(DI)
+	VPBROADCASTD 36(R8), Y8
+	VPADDD 288(DI), Y8, Y8
+	VMOVDQU Y8, 288(DI)
+	VPBROADCASTD 40(R8), Y8
+	VPADDD 320(DI), Y8, Y8
+	VMOVDQU Y8, 320(DI)
+	VPBROADCASTD 44(R8), Y8
+	VPADDD 352(DI), Y8, Y8
+	VMOVDQU Y8, 352(DI)
+	VPBROADCASTD 48(R8), Y8
+	VPADDD 384(DI), Y8, Y8
+	VMOVDQU Y8, 384(DI)
+	VPBROADCASTD 52(R8), Y8
+	VPADDD 416(DI), Y8, Y8
+	VMOVDQU Y8, 416(DI)
+	VPBROADCASTD 56(R8), Y8
+	VPADDD 448(DI), Y8, Y8
+	VMOVDQU Y8, 448(DI)
+	VPBROADCASTD 60(R8), Y8
+	VPADDD 480(DI), Y8, Y8
+	VMOVDQU Y8, 480(DI)
+	VPBROADCASTD 64(R8), Y8
+	VPADDD 512(DI), Y8, Y8
+	VMOVDQU Y8, 512(DI)
+	VPBROADCASTD 68(R8), Y8
+	VPADDD 544(DI), Y8, Y8
+	VMOVDQU Y8, 544(DI)
+	VPBROADCASTD 72(R8), Y8
+	VPADDD 576(DI), Y8, Y8
+	VMOVDQU Y8, 576(DI)
+	VPBROADCASTD 76(R8), Y8
+	VPADDD 608(DI), Y8, Y8
+	VMOVDQU Y8, 608(DI)
+	VPBROADCASTD 80(R8), Y8
+	VPADDD 640(DI), Y8, Y8
+	VMOVDQU Y8, 640(DI)
+	VPBROADCASTD 84(R8), Y8
+	VPADDD 672(DI), Y8, Y8
+	VMOVDQU Y8, 672(DI)
+	VPBROADCASTD 88(R8), Y8
+	VPADDD 704(DI), Y8, Y8
+	VMOVDQU Y8, 704(DI)
+	VPBROADCASTD 92(R8), Y8
+	VPADDD 736(DI), Y8, Y8
+	VMOVDQU Y8, 736(DI)
+	VPBROADCASTD 96(R8), Y8
+	VPADDD 768(DI), Y8, Y8
+	VMOVDQU Y8, 768(DI)
+	VPBROADCASTD 100(R8), Y8
+	VPADDD 800(DI), Y8, Y8
+	VMOVDQU Y8, 800(DI)
+	VPBROADCASTD 104(R8), Y8
+	VPADDD 832(DI), Y8, Y8
+	VMOVDQU Y8, 832(DI)
+	VPBROADCASTD 108(R8), Y8
+	VPADDD 864(DI), Y8, Y8
+	VMOVDQU Y8, 864(DI)
+	VPBROADCASTD 112(R8), Y8
+	VPADDD 896(DI), Y8, Y8
+	VMOVDQU Y8, 896(DI)
+	VPBROADCASTD 116(R8), Y8
+	VPADDD 928(DI), Y8, Y8
+	VMOVDQU Y8, 928(DI)
+	VPBROADCASTD 120(R8), Y8
+	VPADDD 960(DI), Y8, Y8
+	VMOVDQU Y8, 960(DI)
+	VPBROADCASTD 124(R8), Y8
+	VPADDD 992(DI), Y8, Y8
+	VMOVDQU Y8, 992(DI)
+	VPBROADCASTD 128(R8), Y8
+	VPADDD 1024(DI), Y8, Y8
+	VMOVDQU Y8, 1024(DI)
+	VPBROADCASTD 132(R8), Y8
+	VPADDD 1056(DI), Y8, Y8
+	VMOVDQU Y8, 1056(DI)
+	VPBROADCASTD 136(R8), Y8
+	VPADDD 1088(DI), Y8, Y8
+	VMOVDQU Y8, 1088(DI)
+	VPBROADCASTD 140(R8), Y8
+	VPADDD 1120(DI), Y8, Y8
+	VMOVDQU Y8, 1120(DI)
+	VPBROADCASTD 144(R8), Y8
+	VPADDD 1152(DI), Y8, Y8
+	VMOVDQU Y8, 1152(DI)
+	VPBROADCASTD 148(R8), Y8
+	VPADDD 1184(DI), Y8, Y8
+	VMOVDQU Y8, 1184(DI)
+	VPBROADCASTD 152(R8), Y8
+	VPADDD 1216(DI), Y8, Y8
+	VMOVDQU Y8, 1216(DI)
+	VPBROADCASTD 156(R8), Y8
+	VPADDD 1248(DI), Y8, Y8
+	VMOVDQU Y8, 1248(DI)
+	VPBROADCASTD 160(R8), Y8
+	VPADDD 1280(DI), Y8, Y8
+	VMOVDQU Y8, 1280(DI)
+	VPBROADCASTD 164(R8), Y8
+	VPADDD 1312(DI), Y8, Y8
+	VMOVDQU Y8, 1312(DI)
+	VPBROADCASTD 168(R8), Y8
+	VPADDD 1344(DI), Y8, Y8
+	VMOVDQU Y8, 1344(DI)
+	VPBROADCASTD 172(R8), Y8
+	VPADDD 1376(DI), Y8, Y8
+	VMOVDQU Y8, 1376(DI)
+	VPBROADCASTD 176(R8), Y8
+	VPADDD 1408(DI), Y8, Y8
+	VMOVDQU Y8, 1408(DI)
+	VPBROADCASTD 180(R8), Y8
+	VPADDD 1440(DI), Y8, Y8
+	VMOVDQU Y8, 1440(DI)
+	VPBROADCASTD 184(R8), Y8
+	VPADDD 1472(DI), Y8, Y8
+	VMOVDQU Y8, 1472(DI)
+	VPBROADCASTD 188(R8), Y8
+	VPADDD 1504(DI), Y8, Y8
+	VMOVDQU Y8, 1504(DI)
+	VPBROADCASTD 192(R8), Y8
+	VPADDD 1536(DI), Y8, Y8
+	VMOVDQU Y8, 1536(DI)
+	VPBROADCASTD 196(R8), Y8
+	VPADDD 1568(DI), Y8, Y8
+	VMOVDQU Y8, 1568(DI)
+	VPBROADCASTD 200(R8), Y8
+	VPADDD 1600(DI), Y8, Y8
+	VMOVDQU Y8, 1600(DI)
+	VPBROADCASTD 204(R8), Y8
+	VPADDD 1632(DI), Y8, Y8
+	VMOVDQU Y8, 1632(DI)
+	VPBROADCASTD 208(R8), Y8
+	VPADDD 1664(DI), Y8, Y8
+	VMOVDQU Y8, 1664(DI)
+	VPBROADCASTD 212(R8), Y8
+	VPADDD 1696(DI), Y8, Y8
+	VMOVDQU Y8, 1696(DI)
+	VPBROADCASTD 216(R8), Y8
+	VPADDD 1728(DI), Y8, Y8
+	VMOVDQU Y8, 1728(DI)
+	VPBROADCASTD 220(R8), Y8
+	VPADDD 1760(DI), Y8, Y8
+	VMOVDQU Y8, 1760(DI)
+	VPBROADCASTD 224(R8), Y8
+	VPADDD 1792(DI), Y8, Y8
+	VMOVDQU Y8, 1792(DI)
+	VPBROADCASTD 228(R8), Y8
+	VPADDD 1824(DI), Y8, Y8
+	VMOVDQU Y8, 1824(DI)
+	VPBROADCASTD 232(R8), Y8
+	VPADDD 1856(DI), Y8, Y8
+	VMOVDQU Y8, 1856(DI)
+	VPBROADCASTD 236(R8), Y8
+	VPADDD 1888(DI), Y8, Y8
+	VMOVDQU Y8, 1888(DI)
+	VPBROADCASTD 240(R8), Y8
+	VPADDD 1920(DI), Y8, Y8
+	VMOVDQU Y8, 1920(DI)
+	VPBROADCASTD 244(R8), Y8
+	VPADDD 1952(DI), Y8, Y8
+	VMOVDQU Y8, 1952(DI)
+	VPBROADCASTD 248(R8), Y8
+	VPADDD 1984(DI), Y8, Y8
+	VMOVDQU Y8, 1984(DI)
+	VPBROADCASTD 252(R8), Y8
+	VPADDD 2016(DI), Y8, Y8
+	VMOVDQU Y8, 2016(DI)
+
+	VZEROUPPER
+	RET
+
+// func rounds8AVX512(h *state256, s *sched256)
+TEXT ·rounds8AVX512(SB), NOSPLIT, $0-16
+	MOVQ h+0(FP), DI
+	MOVQ s+8(FP), SI
+
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 128(DI), Y4
+	VMOVDQU 160(DI), Y5
+	VMOVDQU 192(DI), Y6
+	VMOVDQU 224(DI), Y7
+
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 0, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 32, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 64, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 96, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 128, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 160, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 192, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 224, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 256, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 288, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 320, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 352, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 384, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 416, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 448, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 480, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 512, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 544, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 576, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 608, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 640, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 672, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 704, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 736, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 768, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 800, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 832, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 864, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 896, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 928, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 960, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 992, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1024, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1056, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1088, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1120, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1152, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1184, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1216, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1248, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1280, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1312, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1344, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1376, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1408, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1440, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1472, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1504, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1536, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1568, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1600, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1632, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1664, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1696, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1728, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1760, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1792, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1824, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1856, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1888, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1920, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1952, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1984, 6, 11, 25, 2, 13, 22)
+	ROUND_AVX512(VPRORD, VPTERNLOGD, VPADDD, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2016, 6, 11, 25, 2, 13, 22)
+
+	// add the compressed block to the hash values
+	VPADDD 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VPADDD 32(DI), Y1, Y1
+	VMOVDQU Y1, 32(DI)
+	VPADDD 64(DI), Y2, Y2
+	VMOVDQU Y2, 64(DI)
+	VPADDD 96(DI), Y3, Y3
+	VMOVDQU Y3, 96(DI)
+	VPADDD 128(DI), Y4, Y4
+	VMOVDQU Y4, 128(DI)
+	VPADDD 160(DI), Y5, Y5
+	VMOVDQU Y5, 160(DI)
+	VPADDD 192(DI), Y6, Y6
+	VMOVDQU Y6, 192(DI)
+	VPADDD 224(DI), Y7, Y7
+	VMOVDQU Y7, 224(DI)
+
+	VZEROUPPER
+	RET
+
+// func expand4AVX2(w *block512, s *sched512)
+TEXT ·expand4AVX2(SB), NOSPLIT, $0-16
+	MOVQ w+0(FP), SI
+	MOVQ s+8(FP), DI
+	LEAQ ·k512(SB), R8
+
+	// the message block, then the rest of the schedule
+	VMOVDQU 0(SI), Y8
+	VMOVDQU Y8, 0(DI)
+	VMOVDQU 32(SI), Y8
+	VMOVDQU Y8, 32(DI)
+	VMOVDQU 64(SI), Y8
+	VMOVDQU Y8, 64(DI)
+	VMOVDQU 96(SI), Y8
+	VMOVDQU Y8, 96(DI)
+	VMOVDQU 128(SI), Y8
+	VMOVDQU Y8, 128(DI)
+	VMOVDQU 160(SI), Y8
+	VMOVDQU Y8, 160(DI)
+	VMOVDQU 192(SI), Y8
+	VMOVDQU Y8, 192(DI)
+	VMOVDQU 224(SI), Y8
+	VMOVDQU Y8, 224(DI)
+	VMOVDQU 256(SI), Y8
+	VMOVDQU Y8, 256(DI)
+	VMOVDQU 288(SI), Y8
+	VMOVDQU Y8, 288(DI)
+	VMOVDQU 320(SI), Y8
+	VMOVDQU Y8, 320(DI)
+	VMOVDQU 352(SI), Y8
+	VMOVDQU Y8, 352(DI)
+	VMOVDQU 384(SI), Y8
+	VMOVDQU Y8, 384(DI)
+	VMOVDQU 416(SI), Y8
+	VMOVDQU Y8, 416(DI)
+	VMOVDQU 448(SI), Y8
+	VMOVDQU Y8, 448(DI)
+	VMOVDQU 480(SI), Y8
+	VMOVDQU Y8, 480(DI)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 448, 288, 32, 0, 512, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 480, 320, 64, 32, 544, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 512, 352, 96, 64, 576, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 544, 384, 128, 96, 608, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 576, 416, 160, 128, 640, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 608, 448, 192, 160, 672, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 640, 480, 224, 192, 704, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 672, 512, 256, 224, 736, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 704, 544, 288, 256, 768, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 736, 576, 320, 288, 800, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 768, 608, 352, 320, 832, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 800, 640, 384, 352, 864, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 832, 672, 416, 384, 896, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 864, 704, 448, 416, 928, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 896, 736, 480, 448, 960, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 928, 768, 512, 480, 992, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 960, 800, 544, 512, 1024, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 992, 832, 576, 544, 1056, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1024, 864, 608, 576, 1088, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1056, 896, 640, 608, 1120, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1088, 928, 672, 640, 1152, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1120, 960, 704, 672, 1184, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1152, 992, 736, 704, 1216, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1184, 1024, 768, 736, 1248, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1216, 1056, 800, 768, 1280, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1248, 1088, 832, 800, 1312, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1280, 1120, 864, 832, 1344, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1312, 1152, 896, 864, 1376, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1344, 1184, 928, 896, 1408, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1376, 1216, 960, 928, 1440, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1408, 1248, 992, 960, 1472, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1440, 1280, 1024, 992, 1504, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1472, 1312, 1056, 1024, 1536, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1504, 1344, 1088, 1056, 1568, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1536, 1376, 1120, 1088, 1600, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1568, 1408, 1152, 1120, 1632, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1600, 1440, 1184, 1152, 1664, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1632, 1472, 1216, 1184, 1696, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1664, 1504, 1248, 1216, 1728, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1696, 1536, 1280, 1248, 1760, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1728, 1568, 1312, 1280, 1792, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1760, 1600, 1344, 1312, 1824, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1792, 1632, 1376, 1344, 1856, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1824, 1664, 1408, 1376, 1888, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1856, 1696, 1440, 1408, 1920, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1888, 1728, 1472, 1440, 1952, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1920, 1760, 1504, 1472, 1984, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1952, 1792, 1536, 1504, 2016, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 1984, 1824, 1568, 1536, 2048, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2016, 1856, 1600, 1568, 2080, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2048, 1888, 1632, 1600, 2112, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2080, 1920, 1664, 1632, 2144, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2112, 1952, 1696, 1664, 2176, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2144, 1984, 1728, 1696, 2208, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2176, 2016, 1760, 1728, 2240, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2208, 2048, 1792, 1760, 2272, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2240, 2080, 1824, 1792, 2304, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2272, 2112, 1856, 1824, 2336, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2304, 2144, 1888, 1856, 2368, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2336, 2176, 1920, 1888, 2400, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2368, 2208, 1952, 1920, 2432, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2400, 2240, 1984, 1952, 2464, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2432, 2272, 2016, 1984, 2496, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+	SCHED_AVX2(ROTXOR_Q, VPSRLQ, VPADDQ, 2464, 2304, 2048, 2016, 2528, 19, 45, 61, 3, 6, 1, 63, 8, 56, 7)
+
+	// add the round constants
+	VPBROADCASTQ 0(R8), Y8
+	VPADDQ 0(DI), Y8, Y8
+	VMOVDQU Y8, 0(DI)
+	VPBROADCASTQ 8(R8), Y8
+	VPADDQ 32(DI), Y8, Y8
+	VMOVDQU Y8, 32(DI)
+	VPBROADCASTQ 16(R8), Y8
+	VPADDQ 64(DI), Y8, Y8
+	VMOVDQU Y8, 64(DI)
+	VPBROADCASTQ 24(R8), Y8
+	VPADDQ 96(DI), Y8, Y8
+	VMOVDQU Y8, 96(DI)
+	VPBROADCASTQ 32(R8), Y8
+	VPADDQ 128(DI), Y8, Y8
+	VMOVDQU Y8, 128(DI)
+	VPBROADCASTQ 40(R8), Y8
+	VPADDQ 160(DI), Y8, Y8
+	VMOVDQU Y8, 160(DI)
+	VPBROADCASTQ 48(R8), Y8
+	VPADDQ 192(DI), Y8, Y8
+	VMOVDQU Y8, 192(DI)
+	VPBROADCASTQ 56(R8), Y8
+	VPADDQ 224(DI), Y8, Y8
+	VMOVDQU Y8, 224(DI)
+	VPBROADCASTQ 64(R8), Y8
+	VPADDQ 256(DI), Y8, Y8
+	VMOVDQU Y8, 256(DI)
+	VPBROADCASTQ 72(R8), Y8
+	VPADDQ 288(DI), Y8, Y8
+	VMOVDQU Y8, 288(DI)
+	VPBROADCASTQ 80(R8), Y8
+	VPADDQ 320(DI), Y8, Y8
+	VMOVDQU Y8, 320(DI)
+	VPBROADCASTQ 88(R8), Y8
+	VPADDQ 352(DI), Y8, Y8
+	VMOVDQU Y8, 352(DI)
+	VPBROADCASTQ 96(R8), Y8
+	VPADDQ 384(DI), Y8, Y8
+	VMOVDQU Y8, 384(DI)
+	VPBROADCASTQ 104(R8), Y8
+	VPADDQ 416(DI), Y8, Y8
+	VMOVDQU Y8, 416(DI)
+	VPBROADCASTQ 112(R8), Y8
+	VPADDQ 448(DI), Y8, Y8
+	VMOVDQU Y8, 448(DI)
+	VPBROADCASTQ 120(R8), Y8
+	VPADDQ 480(DI), Y8, Y8
+	VMOVDQU Y8, 480(DI)
+	VPBROADCASTQ 128(R8), Y8
+	VPADDQ 512(DI), Y8, Y8
+	VMOVDQU Y8, 512(DI)
+	VPBROADCASTQ 136(R8), Y8
+	VPADDQ 544(DI), Y8, Y8
+	VMOVDQU Y8, 544(DI)
+	VPBROADCASTQ 144(R8), Y8
+	VPADDQ 576(DI), Y8, Y8
+	VMOVDQU Y8, 576(DI)
+	VPBROADCASTQ 152(R8), Y8
+	VPADDQ 608(DI), Y8, Y8
+	VMOVDQU Y8, 608(DI)
+	VPBROADCASTQ 160(R8), Y8
+	VPADDQ 640(DI), Y8, Y8
+	VMOVDQU Y8, 640(DI)
+	VPBROADCASTQ 168(R8), Y8
+	VPADDQ 672(DI), Y8, Y8
+	VMOVDQU Y8, 672(DI)
+	VPBROADCASTQ 176(R8), Y8
+	VPADDQ 704(DI), Y8, Y8
+	VMOVDQU Y8, 704(DI)
+	VPBROADCASTQ 184(R8), Y8
+	VPADDQ 736(DI), Y8, Y8
+	VMOVDQU Y8, 736(DI)
+	VPBROADCASTQ 192(R8), Y8
+	VPADDQ 768(DI), Y8, Y8
+	VMOVDQU Y8, 768(DI)
+	VPBROADCASTQ 200(R8), Y8
+	VPADDQ 800(DI), Y8, Y8
+	VMOVDQU Y8, 800(DI)
+	VPBROADCASTQ 208(R8), Y8
+	VPADDQ 832(DI), Y8, Y8
+	VMOVDQU Y8, 832(DI)
+	VPBROADCASTQ 216(R8), Y8
+	VPADDQ 864(DI), Y8, Y8
+	VMOVDQU Y8, 864(DI)
+	VPBROADCASTQ 224(R8), Y8
+	VPADDQ 896(DI), Y8, Y8
+	VMOVDQU Y8, 896(DI)
+	VPBROADCASTQ 232(R8), Y8
+	VPADDQ 928(DI), Y8, Y8
+	VMOVDQU Y8, 928(DI)
+	VPBROADCASTQ 240(R8), Y8
+	VPADDQ 960(DI), Y8, Y8
+	VMOVDQU Y8, 960(DI)
+	VPBROADCASTQ 248(R8), Y8
+	VPADDQ 992(DI), Y8, Y8
+	VMOVDQU Y8, 992(DI)
+	VPBROADCASTQ 256(R8), Y8
+	VPADDQ 1024(DI), Y8, Y8
+	VMOVDQU Y8, 1024(DI)
+	VPBROADCASTQ 264(R8), Y8
+	VPADDQ 1056(DI), Y8, Y8
+	VMOVDQU Y8, 1056(DI)
+	VPBROADCASTQ 272(R8), Y8
+	VPADDQ 1088(DI), Y8, Y8
+	VMOVDQU Y8, 1088(DI)
+	VPBROADCASTQ 280(R8), Y8
+	VPADDQ 1120(DI), Y8, Y8
+	VMOVDQU Y8, 1120(DI)
+	VPBROADCASTQ 288(R8), Y8
+	VPADDQ 1152(DI), Y8, Y8
+	VMOVDQU Y8, 1152(DI)
+	VPBROADCASTQ 296(R8), Y8
+	VPADDQ 1184(DI), Y8, Y8
+	VMOVDQU Y8, 1184(DI)
+	VPBROADCASTQ 304(R8), Y8
+	VPADDQ 1216(DI), Y8, Y8
+	VMOVDQU Y8, 1216(DI)
+	VPBROADCASTQ 312(R8), Y8
+	VPADDQ 1248(DI), Y8, Y8
+	VMOVDQU Y8, 1248(DI)
+	VPBROADCASTQ 320(R8), Y8
+	VPADDQ 1280(DI), Y8, Y8
+	VMOVDQU Y8, 1280(DI)
+	VPBROADCASTQ 328(R8), Y8
+	VPADDQ 1312(DI), Y8, Y8
+	VMOVDQU Y8, 1312(DI)
+	VPBROADCASTQ 336(R8), Y8
+	VPADDQ 1344(DI), Y8, Y8
+	VMOVDQU Y8, 1344(DI)
+	VPBROADCASTQ 344(R8), Y8
+	VPADDQ 1376(DI), Y8, Y8
+	VMOVDQU Y8, 1376(DI)
+	VPBROADCASTQ 352(R8), Y8
+	VPADDQ 1408(DI), Y8, Y8
+	VMOVDQU Y8, 1408(DI)
+	VPBROADCASTQ 360(R8), Y8
+	VPADDQ 1440(DI), Y8, Y8
+	VMOVDQU Y8, 1440(DI)
+	VPBROADCASTQ 368(R8), Y8
+	VPADDQ 1472(DI), Y8, Y8
+	VMOVDQU Y8, 1472(DI)
+	VPBROADCASTQ 376(R8), Y8
+	VPADDQ 1504(DI), Y8, Y8
+	VMOVDQU Y8, 1504(DI)
+	VPBROADCASTQ 384(R8), Y8
+	VPADDQ 1536(DI), Y8, Y8
+	VMOVDQU Y8, 1536(DI)
+	VPBROADCASTQ 392(R8), Y8
+	VPADDQ 1568(DI), Y8, Y8
+	VMOVDQU Y8, 1568(DI)
+	VPBROADCASTQ 400(R8), Y8
+	VPADDQ 1600(DI), Y8, Y8
+	VMOVDQU Y8, 1600(DI)
+	VPBROADCASTQ 408(R8), Y8
+	VPADDQ 1632(DI), Y8, Y8
+	VMOVDQU Y8, 1632(DI)
+	VPBROADCASTQ 416(R8), Y8
+	VPADDQ 1664(DI), Y8, Y8
+	VMOVDQU Y8, 1664(DI)
+	VPBROADCASTQ 424(R8), Y8
+	VPADDQ 1696(DI), Y8, Y8
+	VMOVDQU Y8, 1696(DI)
+	VPBROADCASTQ 432(R8), Y8
+	VPADDQ 1728(DI), Y8, Y8
+	VMOVDQU Y8, 1728(DI)
+	VPBROADCASTQ 440(R8), Y8
+	VPADDQ 1760(DI), Y8, Y8
+	VMOVDQU Y8, 1760(DI)
+	VPBROADCASTQ 448(R8), Y8
+	VPADDQ 1792(DI), Y8, Y8
+	VMOVDQU Y8, 1792(DI)
+	VPBROADCASTQ 456(R8), Y8
+	VPADDQ 1824(DI), Y8, Y8
+	VMOVDQU Y8, 1824(DI)
+	VPBROADCASTQ 464(R8), Y8
+	VPADDQ 1856(DI), Y8, Y8
+	VMOVDQU Y8, 1856(DI)
+	VPBROADCASTQ 472(R8), Y8
+	VPADDQ 1888(DI), Y8, Y8
+	VMOVDQU Y8, 1888(DI)
+	VPBROADCASTQ 480(R8), Y8
+	VPADDQ 1920(DI), Y8, Y8
+	VMOVDQU Y8, 1920(DI)
+	VPBROADCASTQ 488(R8), Y8
+	VPADDQ 1952(DI), Y8, Y8
+	VMOVDQU Y8, 1952(DI)
+	VPBROADCASTQ 496(R8), Y8
+	VPADDQ 1984(DI), Y8, Y8
+	VMOVDQU Y8, 1984(DI)
+	VPBROADCASTQ 504(R8), Y8
+	VPADDQ 2016(DI), Y8, Y8
+	VMOVDQU Y8, 2016(DI)
+	VPBROADCASTQ 512(R8), Y8
+	VPADDQ 2048(DI), Y8, Y8
+	VMOVDQU Y8, 2048(DI)
+	VPBROADCASTQ 520(R8), Y8
+	VPADDQ 2080(DI), Y8, Y8
+	VMOVDQU Y8, 2080(DI)
+	VPBROADCASTQ 528(R8), Y8
+	VPADDQ 2112(DI), Y8, Y8
+	VMOVDQU Y8, 2112(DI)
+	VPBROADCASTQ 536(R8), Y8
+	VPADDQ 2144(DI), Y8, Y8
+	VMOVDQU Y8, 2144(DI)
+	VPBROADCASTQ 544(R8), Y8
+	VPADDQ 2176(DI), Y8, Y8
+	VMOVDQU Y8, 2176(DI)
+	VPBROADCASTQ 552(R8), Y8
+	VPADDQ 2208(DI), Y8, Y8
+	VMOVDQU Y8, 2208(DI)
+	VPBROADCASTQ 560(R8), Y8
+	VPADDQ 2240(DI), Y8, Y8
+	VMOVDQU Y8, 2240(DI)
+	VPBROADCASTQ 568(R8), Y8
+	VPADDQ 2272(DI), Y8, Y8
+	VMOVDQU Y8, 2272(DI)
+	VPBROADCASTQ 576(R8), Y8
+	VPADDQ 2304(DI), Y8, Y8
+	VMOVDQU Y8, 2304(DI)
+	VPBROADCASTQ 584(R8), Y8
+	VPADDQ 2336(DI), Y8, Y8
+	VMOVDQU Y8, 2336(DI)
+	VPBROADCASTQ 592(R8), Y8
+	VPADDQ 2368(DI), Y8, Y8
+	VMOVDQU Y8, 2368(DI)
+	VPBROADCASTQ 600(R8), Y8
+	VPADDQ 2400(DI), Y8, Y8
+	VMOVDQU Y8, 2400(DI)
+	VPBROADCASTQ 608(R8), Y8
+	VPADDQ 2432(DI), Y8, Y8
+	VMOVDQU Y8, 2432(DI)
+	VPBROADCASTQ 616(R8), Y8
+	VPADDQ 2464(DI), Y8, Y8
+	VMOVDQU Y8, 2464(DI)
+	VPBROADCASTQ 624(R8), Y8
+	VPADDQ 2496(DI), Y8, Y8
+	VMOVDQU Y8, 2496(DI)
+	VPBROADCASTQ 632(R8), Y8
+	VPADDQ 2528(DI), Y8, Y8
+	VMOVDQU Y8, 2528(DI)
+
+	VZEROUPPER
+	RET
+
+// func rounds4AVX2(h *state512, s *sched512)
+TEXT ·rounds4AVX2(SB), NOSPLIT, $0-16
+	MOVQ h+0(FP), DI
+	MOVQ s+8(FP), SI
+
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 128(DI), Y4
+	VMOVDQU 160(DI), Y5
+	VMOVDQU 192(DI), Y6
+	VMOVDQU 224(DI), Y7
+
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 0, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 32, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 64, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 96, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 128, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 160, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 192, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 224, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 256, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 288, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 320, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 352, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 384, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 416, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 448, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 480, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 512, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 544, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 576, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 608, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 640, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 672, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 704, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 736, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 768, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 800, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 832, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 864, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 896, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 928, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 960, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 992, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1024, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1056, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1088, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1120, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1152, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1184, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1216, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1248, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1280, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1312, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1344, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1376, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1408, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1440, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1472, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1504, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1536, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1568, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1600, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1632, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1664, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1696, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1728, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1760, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1792, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1824, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1856, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1888, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1920, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1952, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1984, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2016, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 2048, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 2080, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 2112, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 2144, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 2176, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 2208, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 2240, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2272, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 2304, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 2336, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 2368, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 2400, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 2432, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 2464, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 2496, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+	ROUND_AVX2(ROTXOR_Q, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2528, 14, 50, 18, 46, 41, 23, 28, 36, 34, 30, 39, 25)
+
+	// add the compressed block to the hash values
+	VPADDQ 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VPADDQ 32(DI), Y1, Y1
+	VMOVDQU Y1, 32(DI)
+	VPADDQ 64(DI), Y2, Y2
+	VMOVDQU Y2, 64(DI)
+	VPADDQ 96(DI), Y3, Y3
+	VMOVDQU Y3, 96(DI)
+	VPADDQ 128(DI), Y4, Y4
+	VMOVDQU Y4, 128(DI)
+	VPADDQ 160(DI), Y5, Y5
+	VMOVDQU Y5, 160(DI)
+	VPADDQ 192(DI), Y6, Y6
+	VMOVDQU Y6, 192(DI)
+	VPADDQ 224(DI), Y7, Y7
+	VMOVDQU Y7, 224(DI)
+
+	VZEROUPPER
+	RET
+
+// func expand4AVX512(w *block512, s *sched512)
+TEXT ·expand4AVX512(SB), NOSPLIT, $0-16
+	MOVQ w+0(FP), SI
+	MOVQ s+8(FP), DI
+	LEAQ ·k512(SB), R8
+
+	// the message block, then the rest of the schedule
+	VMOVDQU 0(SI), Y8
+	VMOVDQU Y8, 0(DI)
+	VMOVDQU 32(SI), Y8
+	VMOVDQU Y8, 32(DI)
+	VMOVDQU 64(SI), Y8
+	VMOVDQU Y8, 64(DI)
+	VMOVDQU 96(SI), Y8
+	VMOVDQU Y8, 96(DI)
+	VMOVDQU 128(SI), Y8
+	VMOVDQU Y8, 128(DI)
+	VMOVDQU 160(SI), Y8
+	VMOVDQU Y8, 160(DI)
+	VMOVDQU 192(SI), Y8
+	VMOVDQU Y8, 192(DI)
+	VMOVDQU 224(SI), Y8
+	VMOVDQU Y8, 224(DI)
+	VMOVDQU 256(SI), Y8
+	VMOVDQU Y8, 256(DI)
+	VMOVDQU 288(SI), Y8
+	VMOVDQU Y8, 288(DI)
+	VMOVDQU 320(SI), Y8
+	VMOVDQU Y8, 320(DI)
+	VMOVDQU 352(SI), Y8
+	VMOVDQU Y8, 352(DI)
+	VMOVDQU 384(SI), Y8
+	VMOVDQU Y8, 384(DI)
+	VMOVDQU 416(SI), Y8
+	VMOVDQU Y8, 416(DI)
+	VMOVDQU 448(SI), Y8
+	VMOVDQU Y8, 448(DI)
+	VMOVDQU 480(SI), Y8
+	VMOVDQU Y8, 480(DI)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 448, 288, 32, 0, 512, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 480, 320, 64, 32, 544, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 512, 352, 96, 64, 576, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 544, 384, 128, 96, 608, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 576, 416, 160, 128, 640, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 608, 448, 192, 160, 672, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 640, 480, 224, 192, 704, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 672, 512, 256, 224, 736, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 704, 544, 288, 256, 768, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 736, 576, 320, 288, 800, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 768, 608, 352, 320, 832, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 800, 640, 384, 352, 864, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 832, 672, 416, 384, 896, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 864, 704, 448, 416, 928, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 896, 736, 480, 448, 960, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 928, 768, 512, 480, 992, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 960, 800, 544, 512, 1024, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 992, 832, 576, 544, 1056, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1024, 864, 608, 576, 1088, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1056, 896, 640, 608, 1120, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1088, 928, 672, 640, 1152, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1120, 960, 704, 672, 1184, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1152, 992, 736, 704, 1216, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1184, 1024, 768, 736, 1248, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1216, 1056, 800, 768, 1280, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1248, 1088, 832, 800, 1312, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1280, 1120, 864, 832, 1344, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1312, 1152, 896, 864, 1376, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1344, 1184, 928, 896, 1408, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1376, 1216, 960, 928, 1440, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1408, 1248, 992, 960, 1472, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1440, 1280, 1024, 992, 1504, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1472, 1312, 1056, 1024, 1536, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1504, 1344, 1088, 1056, 1568, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1536, 1376, 1120, 1088, 1600, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1568, 1408, 1152, 1120, 1632, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1600, 1440, 1184, 1152, 1664, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1632, 1472, 1216, 1184, 1696, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1664, 1504, 1248, 1216, 1728, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1696, 1536, 1280, 1248, 1760, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1728, 1568, 1312, 1280, 1792, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1760, 1600, 1344, 1312, 1824, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1792, 1632, 1376, 1344, 1856, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1824, 1664, 1408, 1376, 1888, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1856, 1696, 1440, 1408, 1920, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1888, 1728, 1472, 1440, 1952, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1920, 1760, 1504, 1472, 1984, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1952, 1792, 1536, 1504, 2016, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 1984, 1824, 1568, 1536, 2048, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2016, 1856, 1600, 1568, 2080, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2048, 1888, 1632, 1600, 2112, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2080, 1920, 1664, 1632, 2144, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2112, 1952, 1696, 1664, 2176, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2144, 1984, 1728, 1696, 2208, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2176, 2016, 1760, 1728, 2240, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2208, 2048, 1792, 1760, 2272, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2240, 2080, 1824, 1792, 2304, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2272, 2112, 1856, 1824, 2336, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2304, 2144, 1888, 1856, 2368, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2336, 2176, 1920, 1888, 2400, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2368, 2208, 1952, 1920, 2432, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2400, 2240, 1984, 1952, 2464, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2432, 2272, 2016, 1984, 2496, 19, 61, 6, 1, 8, 7)
+	SCHED_AVX512(VPRORQ, VPSRLQ, VPTERNLOGQ, VPADDQ, 2464, 2304, 2048, 2016, 2528, 19, 61, 6, 1, 8, 7)
+
+	// add the round constants
+	VPBROADCASTQ 0(R8), Y8
+	VPADDQ 0(DI), Y8, Y8
+	VMOVDQU Y8, 0(DI)
+	VPBROADCASTQ 8(R8), Y8
+	VPADDQ 32(DI), Y8, Y8
+	VMOVDQU Y8, 32(DI)
+	VPBROADCASTQ 16(R8), Y8
+	VPADDQ 64(DI), Y8, Y8
+	VMOVDQU Y8, 64(DI)
+	VPBROADCASTQ 24(R8), Y8
+	VPADDQ 96(DI), Y8, Y8
+	VMOVDQU Y8, 96(DI)
+	VPBROADCASTQ 32(R8), Y8
+	VPADDQ 128(DI), Y8, Y8
+	VMOVDQU Y8, 128(DI)
+	VPBROADCASTQ 40(R8), Y8
+	VPADDQ 160(DI), Y8, Y8
+	VMOVDQU Y8, 160(DI)
+	VPBROADCASTQ 48(R8), Y8
+	VPADDQ 192(DI), Y8, Y8
+	VMOVDQU Y8, 192(DI)
+	VPBROADCASTQ 56(R8), Y8
+	VPADDQ 224(DI), Y8, Y8
+	VMOVDQU Y8, 224(DI)
+	VPBROADCASTQ 64(R8), Y8
+	VPADDQ 256(DI), Y8, Y8
+	VMOVDQU Y8, 256(DI)
+	VPBROADCASTQ 72(R8), Y8
+	VPADDQ 288(DI), Y8, Y8
+	VMOVDQU Y8, 288(DI)
+	VPBROADCASTQ 80(R8), Y8
+	VPADDQ 320(DI), Y8, Y8
+	VMOVDQU Y8, 320(DI)
+	VPBROADCASTQ 88(R8), Y8
+	VPADDQ 352(DI), Y8, Y8
+	VMOVDQU Y8, 352(DI)
+	VPBROADCASTQ 96(R8), Y8
+	VPADDQ 384(DI), Y8, Y8
+	VMOVDQU Y8, 384(DI)
+	VPBROADCASTQ 104(R8), Y8
+	VPADDQ 416(DI), Y8, Y8
+	VMOVDQU Y8, 416(DI)
+	VPBROADCASTQ 112(R8), Y8
+	VPADDQ 448(DI), Y8, Y8
+	VMOVDQU Y8, 448(DI)
+	VPBROADCASTQ 120(R8), Y8
+	VPADDQ 480(DI), Y8, Y8
+	VMOVDQU Y8, 480(DI)
+	VPBROADCASTQ 128(R8), Y8
+	VPADDQ 512(DI), Y8, Y8
+	VMOVDQU Y8, 512(DI)
+	VPBROADCASTQ 136(R8), Y8
+	VPADDQ 544(DI), Y8, Y8
+	VMOVDQU Y8, 544(DI)
+	VPBROADCASTQ 144(R8), Y8
+	VPADDQ 576(DI), Y8, Y8
+	VMOVDQU Y8, 576(DI)
+	VPBROADCASTQ 152(R8), Y8
+	VPADDQ 608(DI), Y8, Y8
+	VMOVDQU Y8, 608(DI)
+	VPBROADCASTQ 160(R8), Y8
+	VPADDQ 640(DI), Y8, Y8
+	VMOVDQU Y8, 640(DI)
+	VPBROADCASTQ 168(R8), Y8
+	VPADDQ 672(DI), Y8, Y8
+	VMOVDQU Y8, 672(DI)
+	VPBROADCASTQ 176(R8), Y8
+	VPADDQ 704(DI), Y8, Y8
+	VMOVDQU Y8, 704(DI)
+	VPBROADCASTQ 184(R8), Y8
+	VPADDQ 736(DI), Y8, Y8
+	VMOVDQU Y8, 736(DI)
+	VPBROADCASTQ 192(R8), Y8
+	VPADDQ 768(DI), Y8, Y8
+	VMOVDQU Y8, 768(DI)
+	VPBROADCASTQ 200(R8), Y8
+	VPADDQ 800(DI), Y8, Y8
+	VMOVDQU Y8, 800(DI)
+	VPBROADCASTQ 208(R8), Y8
+	VPADDQ 832(DI), Y8, Y8
+	VMOVDQU Y8, 832(DI)
+	VPBROADCASTQ 216(R8), Y8
+	VPADDQ 864(DI), Y8, Y8
+	VMOVDQU Y8, 864(DI)
+	VPBROADCASTQ 224(R8), Y8
+	VPADDQ 896(DI), Y8, Y8
+	VMOVDQU Y8, 896(DI)
+	VPBROADCASTQ 232(R8), Y8
+	VPADDQ 928(DI), Y8, Y8
+	VMOVDQU Y8, 928(DI)
+	VPBROADCASTQ 240(R8), Y8
+	VPADDQ 960(DI), Y8, Y8
+	VMOVDQU Y8, 960(DI)
+	VPBROADCASTQ 248(R8), Y8
+	VPADDQ 992(DI), Y8, Y8
+	VMOVDQU Y8, 992(DI)
+	VPBROADCASTQ 256(R8), Y8
+	VPADDQ 1024(DI), Y8, Y8
+	VMOVDQU Y8, 1024(DI)
+	VPBROADCASTQ 264(R8), Y8
+	VPADDQ 1056(DI), Y8, Y8
+	VMOVDQU Y8, 1056(DI)
+	VPBROADCASTQ 272(R8), Y8
+	VPADDQ 1088(DI), Y8, Y8
+	VMOVDQU Y8, 1088(DI)
+	VPBROADCASTQ 280(R8), Y8
+	VPADDQ 1120(DI), Y8, Y8
+	VMOVDQU Y8, 1120(DI)
+	VPBROADCASTQ 288(R8), Y8
+	VPADDQ 1152(DI), Y8, Y8
+	VMOVDQU Y8, 1152(DI)
+	VPBROADCASTQ 296(R8), Y8
+	VPADDQ 1184(DI), Y8, Y8
+	VMOVDQU Y8, 1184(DI)
+	VPBROADCASTQ 304(R8), Y8
+	VPADDQ 1216(DI), Y8, Y8
+	VMOVDQU Y8, 1216(DI)
+	VPBROADCASTQ 312(R8), Y8
+	VPADDQ 1248(DI), Y8, Y8
+	VMOVDQU Y8, 1248(DI)
+	VPBROADCASTQ 320(R8), Y8
+	VPADDQ 1280(DI), Y8, Y8
+	VMOVDQU Y8, 1280(DI)
+	VPBROADCASTQ 328(R8), Y8
+	VPADDQ 1312(DI), Y8, Y8
+	VMOVDQU Y8, 1312(DI)
+	VPBROADCASTQ 336(R8), Y8
+	VPADDQ 1344(DI), Y8, Y8
+	VMOVDQU Y8, 1344(DI)
+	VPBROADCASTQ 344(R8), Y8
+	VPADDQ 1376(DI), Y8, Y8
+	VMOVDQU Y8, 1376(DI)
+	VPBROADCASTQ 352(R8), Y8
+	VPADDQ 1408(DI), Y8, Y8
+	VMOVDQU Y8, 1408(DI)
+	VPBROADCASTQ 360(R8), Y8
+	VPADDQ 1440(DI), Y8, Y8
+	VMOVDQU Y8, 1440(DI)
+	VPBROADCASTQ 368(R8), Y8
+	VPADDQ 1472(DI), Y8, Y8
+	VMOVDQU Y8, 1472(DI)
+	VPBROADCASTQ 376(R8), Y8
+	VPADDQ 1504(DI), Y8, Y8
+	VMOVDQU Y8, 1504(DI)
+	VPBROADCASTQ 384(R8), Y8
+	VPADDQ 1536(DI), Y8, Y8
+	VMOVDQU Y8, 1536(DI)
+	VPBROADCASTQ 392(R8), Y8
+	VPADDQ 1568(DI), Y8, Y8
+	VMOVDQU Y8, 1568(DI)
+	VPBROADCASTQ 400(R8), Y8
+	VPADDQ 1600(DI), Y8, Y8
+	VMOVDQU Y8, 1600(DI)
+	VPBROADCASTQ 408(R8), Y8
+	VPADDQ 1632(DI), Y8, Y8
+	VMOVDQU Y8, 1632(DI)
+	VPBROADCASTQ 416(R8), Y8
+	VPADDQ 1664(DI), Y8, Y8
+	VMOVDQU Y8, 1664(DI)
+	VPBROADCASTQ 424(R8), Y8
+	VPADDQ 1696(DI), Y8, Y8
+	VMOVDQU Y8, 1696(DI)
+	VPBROADCASTQ 432(R8), Y8
+	VPADDQ 1728(DI), Y8, Y8
+	VMOVDQU Y8, 1728(DI)
+	VPBROADCASTQ 440(R8), Y8
+	VPADDQ 1760(DI), Y8, Y8
+	VMOVDQU Y8, 1760(DI)
+	VPBROADCASTQ 448(R8), Y8
+	VPADDQ 1792(DI), Y8, Y8
+	VMOVDQU Y8, 1792(DI)
+	VPBROADCASTQ 456(R8), Y8
+	VPADDQ 1824(DI), Y8, Y8
+	VMOVDQU Y8, 1824(DI)
+	VPBROADCASTQ 464(R8), Y8
+	VPADDQ 1856(DI), Y8, Y8
+	VMOVDQU Y8, 1856(DI)
+	VPBROADCASTQ 472(R8), Y8
+	VPADDQ 1888(DI), Y8, Y8
+	VMOVDQU Y8, 1888(DI)
+	VPBROADCASTQ 480(R8), Y8
+	VPADDQ 1920(DI), Y8, Y8
+	VMOVDQU Y8, 1920(DI)
+	VPBROADCASTQ 488(R8), Y8
+	VPADDQ 1952(DI), Y8, Y8
+	VMOVDQU Y8, 1952(DI)
+	VPBROADCASTQ 496(R8), Y8
+	VPADDQ 1984(DI), Y8, Y8
+	VMOVDQU Y8, 1984(DI)
+	VPBROADCASTQ 504(R8), Y8
+	VPADDQ 2016(DI), Y8, Y8
+	VMOVDQU Y8, 2016(DI)
+	VPBROADCASTQ 512(R8), Y8
+	VPADDQ 2048(DI), Y8, Y8
+	VMOVDQU Y8, 2048(DI)
+	VPBROADCASTQ 520(R8), Y8
+	VPADDQ 2080(DI), Y8, Y8
+	VMOVDQU Y8, 2080(DI)
+	VPBROADCASTQ 528(R8), Y8
+	VPADDQ 2112(DI), Y8, Y8
+	VMOVDQU Y8, 2112(DI)
+	VPBROADCASTQ 536(R8), Y8
+	VPADDQ 2144(DI), Y8, Y8
+	VMOVDQU Y8, 2144(DI)
+	VPBROADCASTQ 544(R8), Y8
+	VPADDQ 2176(DI), Y8, Y8
+	VMOVDQU Y8, 2176(DI)
+	VPBROADCASTQ 552(R8), Y8
+	VPADDQ 2208(DI), Y8, Y8
+	VMOVDQU Y8, 2208(DI)
+	VPBROADCASTQ 560(R8), Y8
+	VPADDQ 2240(DI), Y8, Y8
+	VMOVDQU Y8, 2240(DI)
+	VPBROADCASTQ 568(R8), Y8
+	VPADDQ 2272(DI), Y8, Y8
+	VMOVDQU Y8, 2272(DI)
+	VPBROADCASTQ 576(R8), Y8
+	VPADDQ 2304(DI), Y8, Y8
+	VMOVDQU Y8, 2304(DI)
+	VPBROADCASTQ 584(R8), Y8
+	VPADDQ 2336(DI), Y8, Y8
+	VMOVDQU Y8, 2336(DI)
+	VPBROADCASTQ 592(R8), Y8
+	VPADDQ 2368(DI), Y8, Y8
+	VMOVDQU Y8, 2368(DI)
+	VPBROADCASTQ 600(R8), Y8
+	VPADDQ 2400(DI), Y8, Y8
+	VMOVDQU Y8, 2400(DI)
+	VPBROADCASTQ 608(R8), Y8
+	VPADDQ 2432(DI), Y8, Y8
+	VMOVDQU Y8, 2432(DI)
+	VPBROADCASTQ 616(R8), Y8
+	VPADDQ 2464(DI), Y8, Y8
+	VMOVDQU Y8, 2464(DI)
+	VPBROADCASTQ 624(R8), Y8
+	VPADDQ 2496(DI), Y8, Y8
+	VMOVDQU Y8, 2496(DI)
+	VPBROADCASTQ 632(R8), Y8
+	VPADDQ 2528(DI), Y8, Y8
+	VMOVDQU Y8, 2528(DI)
+
+	VZEROUPPER
+	RET
+
+// func rounds4AVX512(h *state512, s *sched512)
+TEXT ·rounds4AVX512(SB), NOSPLIT, $0-16
+	MOVQ h+0(FP), DI
+	MOVQ s+8(FP), SI
+
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 128(DI), Y4
+	VMOVDQU 160(DI), Y5
+	VMOVDQU 192(DI), Y6
+	VMOVDQU 224(DI), Y7
+
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 0, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 32, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 64, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 96, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 128, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 160, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 192, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 224, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 256, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 288, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 320, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 352, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 384, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 416, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 448, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 480, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 512, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 544, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 576, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 608, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 640, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 672, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 704, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 736, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 768, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 800, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 832, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 864, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 896, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 928, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 960, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 992, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1024, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1056, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1088, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1120, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1152, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1184, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1216, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1248, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1280, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1312, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1344, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1376, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1408, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1440, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1472, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1504, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1536, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1568, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1600, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1632, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1664, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1696, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1728, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 1760, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 1792, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 1824, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 1856, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 1888, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 1920, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 1952, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 1984, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2016, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 2048, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 2080, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 2112, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 2144, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 2176, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 2208, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 2240, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2272, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y0, Y1, Y2, Y3, Y4, Y5, Y6, Y7, 2304, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y7, Y0, Y1, Y2, Y3, Y4, Y5, Y6, 2336, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y6, Y7, Y0, Y1, Y2, Y3, Y4, Y5, 2368, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y5, Y6, Y7, Y0, Y1, Y2, Y3, Y4, 2400, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y4, Y5, Y6, Y7, Y0, Y1, Y2, Y3, 2432, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y3, Y4, Y5, Y6, Y7, Y0, Y1, Y2, 2464, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y2, Y3, Y4, Y5, Y6, Y7, Y0, Y1, 2496, 14, 18, 41, 28, 34, 39)
+	ROUND_AVX512(VPRORQ, VPTERNLOGQ, VPADDQ, Y1, Y2, Y3, Y4, Y5, Y6, Y7, Y0, 2528, 14, 18, 41, 28, 34, 39)
+
+	// add the compressed block to the hash values
+	VPADDQ 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VPADDQ 32(DI), Y1, Y1
+	VMOVDQU Y1, 32(DI)
+	VPADDQ 64(DI), Y2, Y2
+	VMOVDQU Y2, 64(DI)
+	VPADDQ 96(DI), Y3, Y3
+	VMOVDQU Y3, 96(DI)
+	VPADDQ 128(DI), Y4, Y4
+	VMOVDQU Y4, 128(DI)
+	VPADDQ 160(DI), Y5, Y5
+	VMOVDQU Y5, 160(DI)
+	VPADDQ 192(DI), Y6, Y6
+	VMOVDQU Y6, 192(DI)
+	VPADDQ 224(DI), Y7, Y7
+	VMOVDQU Y7, 224(DI)
+
+	VZEROUPPER
+	RET
+
+DATA hexDigits<>+0(SB)/8, $"01234567"
+DATA hexDigits<>+8(SB)/8, $"89abcdef"
+DATA hexDigits<>+16(SB)/8, $"01234567"
+DATA hexDigits<>+24(SB)/8, $"89abcdef"
+GLOBL hexDigits<>(SB), RODATA|NOPTR, $32
+
+// SPREAD_D spreads the 16-bit value in each 32-bit word of x into its 4
+// nibbles, one per byte: the most significant first (in the highest byte)
+#define SPREAD_D(x) \
+	VPSLLD $8, x, Y9; VPAND Y12, x, x; VPAND Y13, Y9, Y9; VPOR Y9, x, x; \
+	VPSLLD $4, x, Y9; VPAND Y14, x, x; VPSLLD $8, Y14, Y10; VPAND Y10, Y9, Y9; VPOR Y9, x, x
+
+// func hex8AVX2(h *state256, w *block256, words int)
+TEXT ·hex8AVX2(SB), NOSPLIT, $0-24
+	MOVQ h+0(FP), SI
+	MOVQ w+8(FP), DI
+	MOVQ words+16(FP), CX
+
+	VMOVDQU hexDigits<>(SB), Y15
+	MOVL $0x000000ff, AX
+	VMOVD AX, X12
+	VPBROADCASTD X12, Y12
+	MOVL $0x00ff0000, AX
+	VMOVD AX, X13
+	VPBROADCASTD X13, Y13
+	MOVL $0x000f000f, AX
+	VMOVD AX, X14
+	VPBROADCASTD X14, Y14
+
+hex8loop:
+	VMOVDQU (SI), Y0
+
+	// the upper half of the word is the first 4 characters
+	VPSRLD $16, Y0, Y1
+	SPREAD_D(Y1)
+	VPSHUFB Y1, Y15, Y1
+	VMOVDQU Y1, (DI)
+
+	VPSLLD $16, Y0, Y1
+	VPSRLD $16, Y1, Y1
+	SPREAD_D(Y1)
+	VPSHUFB Y1, Y15, Y1
+	VMOVDQU Y1, 32(DI)
+
+	ADDQ $32, SI
+	ADDQ $64, DI
+	DECQ CX
+	JNZ hex8loop
+
+	VZEROUPPER
+	RET
+
+// SPREAD_Q spreads the 32-bit value in each 64-bit word of x into its 8
+// nibbles, one per byte: the most significant first (in the highest byte)
+#define SPREAD_Q(x) \
+	VPSLLQ $16, x, Y9; VPAND Y11, x, x; VPSLLQ $32, Y11, Y10; VPAND Y10, Y9, Y9; VPOR Y9, x, x; \
+	VPSLLQ $8, x, Y9; VPAND Y12, x, x; VPAND Y13, Y9, Y9; VPOR Y9, x, x; \
+	VPSLLQ $4, x, Y9; VPAND Y14, x, x; VPSLLQ $8, Y14, Y10; VPAND Y10, Y9, Y9; VPOR Y9, x, x
+
+// func hex4AVX2(h *state512, w *block512, data int)
+TEXT ·hex4AVX2(SB), NOSPLIT, $0-24
+	MOVQ h+0(FP), SI
+	MOVQ w+8(FP), DI
+	MOVQ data+16(FP), CX
+
+	VMOVDQU hexDigits<>(SB), Y15
+	MOVQ $0x000000000000ffff, AX
+	VMOVQ AX, X11
+	VPBROADCASTQ X11, Y11
+	MOVQ $0x000000ff000000ff, AX
+	VMOVQ AX, X12
+	VPBROADCASTQ X12, Y12
+	MOVQ $0x00ff000000ff0000, AX
+	VMOVQ AX, X13
+	VPBROADCASTQ X13, Y13
+	MOVQ $0x000f000f000f000f, AX
+	VMOVQ AX, X14
+	VPBROADCASTQ X14, Y14
+
+hex4loop:
+	VMOVDQU (SI), Y0
+
+	// the upper half of the word is the first 8 characters
+	VPSRLQ $32, Y0, Y1
+	SPREAD_Q(Y1)
+	VPSHUFB Y1, Y15, Y1
+	VMOVDQU Y1, (DI)
+
+	// the hash of SHA-512/224 ends half-way through its last word
+	DECQ CX
+	JZ hex4done
+
+	VPSLLQ $32, Y0, Y1
+	VPSRLQ $32, Y1, Y1
+	SPREAD_Q(Y1)
+	VPSHUFB Y1, Y15, Y1
+	VMOVDQU Y1, 32(DI)
+
+	ADDQ $32, SI
+	ADDQ $64, DI
+	DECQ CX
+	JNZ hex4loop
+
+hex4done:
+	VZEROUPPER
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
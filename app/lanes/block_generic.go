@@ -0,0 +1,36 @@
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+package lanes
+
+// level is always the pure-Go implementation, without the amd64 one
+var level = levelGeneric
+
+// maxLevel function returns the fastest implementation supported by the CPU
+func maxLevel() int {
+	return levelGeneric
+}
+
+func hex8(h *state256, w *block256, words int) {
+	hex8Generic(h, w, words)
+}
+
+func expand8(w *block256, s *sched256) {
+	expand8Generic(w, s)
+}
+
+func rounds8(h *state256, s *sched256) {
+	rounds8Generic(h, s)
+}
+
+func hex4(h *state512, w *block512, data int) {
+	hex4Generic(h, w, data)
+}
+
+func expand4(w *block512, s *sched512) {
+	expand4Generic(w, s)
+}
+
+func rounds4(h *state512, s *sched512) {
+	rounds4Generic(h, s)
+}
@@ -0,0 +1,172 @@
+// Package lanes advances several independent hash chains at once, with
+// multi-buffer implementations of SHA-256 and SHA-512: each call runs the same
+// step on every lane (one chain per lane), with SIMD instructions on amd64
+// CPUs which support AVX2 (or AVX-512) -- and a pure-Go fallback elsewhere, or
+// when built with the `purego` tag.
+//
+// The SHA-256 functions (SHA224, SHA256) have 8 lanes, and the SHA-512
+// functions (SHA384, SHA512, SHA512_224, SHA512_256) have 4. The chains follow
+// the same rules as the `clock` package: each step hashes the hex-encoded
+// previous hash (or its raw bytes, in the raw chaining mode).
+//
+// Every lane is hashed on each step, whether it holds a chain or not; so a
+// `Chains` is only faster than hashing the chains one by one when most of its
+// lanes are in use, and when `Accelerated` returns true.
+package lanes
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// modeHex and modeRaw are the chaining modes, as `clock.ModeHex` and
+	// `clock.ModeRaw`
+	modeHex string = "hex"
+	modeRaw string = "raw"
+)
+
+// implementations of the lanes, from the slowest to the fastest
+const (
+	levelGeneric int = iota
+	levelAVX2
+	levelAVX512
+)
+
+// ErrUnsupported error is returned for the algorithms without a multi-lane
+// implementation (MD5 and SHA1)
+var ErrUnsupported = errors.New("algorithm does not support multi-lane hashing")
+
+// hexTable maps each byte to its (two-character) hex encoding
+var hexTable = func() (t [256]uint32) {
+	const digits = "0123456789abcdef"
+	for b := range t {
+		t[b] = uint32(digits[b>>4])<<8 | uint32(digits[b&0x0f])
+	}
+	return t
+}()
+
+// core interface is implemented by the multi-lane states of each family of
+// hash functions
+type core interface {
+	// width returns the number of lanes, and digestSize the size of a
+	// digest (in bytes)
+	width() int
+	digestSize() int
+
+	// set and get write and read the (raw) digest of a lane
+	set(lane int, digest []byte)
+	get(lane int, digest []byte)
+
+	// step advances all lanes by the input number of steps
+	step(n int)
+}
+
+// Chains struct is a set of hash chains of the same algorithm and chaining
+// mode, advanced together -- one chain per lane
+type Chains struct {
+	algorithm string
+	size      int
+	core      core
+	digest    []byte
+}
+
+// New function creates a `Chains` for the input algorithm (as named in
+// `clock.HasherMapVals`, lower-case or upper-case) and chaining mode ("hex"
+// or "raw"; hex if empty). All of its lanes start with an all-zero hash
+func New(alg, mode string) (*Chains, error) {
+	var raw bool
+	switch mode {
+	case "", modeHex:
+	case modeRaw:
+		raw = true
+	default:
+		return nil, fmt.Errorf("invalid chaining mode %q", mode)
+	}
+
+	alg = strings.ToUpper(alg)
+
+	var c core
+	switch alg {
+	case "SHA224":
+		c = newSHA256(&iv224, 28, raw)
+	case "SHA256":
+		c = newSHA256(&iv256, 32, raw)
+	case "SHA384":
+		c = newSHA512(&iv384, 48, raw)
+	case "SHA512":
+		c = newSHA512(&iv512, 64, raw)
+	case "SHA512_224":
+		c = newSHA512(&iv512_224, 28, raw)
+	case "SHA512_256":
+		c = newSHA512(&iv512_256, 32, raw)
+	case "MD5", "SHA1":
+		return nil, ErrUnsupported
+	default:
+		return nil, errors.New("invalid hasher reference")
+	}
+
+	return &Chains{
+		algorithm: alg,
+		size:      c.digestSize(),
+		core:      c,
+		digest:    make([]byte, c.digestSize()),
+	}, nil
+}
+
+// Supported function returns true if the input algorithm has a multi-lane
+// implementation
+func Supported(alg string) bool {
+	_, err := New(alg, "")
+	return err == nil
+}
+
+// Accelerated function returns true if the lanes are hashed with SIMD
+// instructions on this CPU, rather than one after the other
+func Accelerated() bool {
+	return level > levelGeneric
+}
+
+// Algorithm method returns the name of the hash function
+func (c *Chains) Algorithm() string {
+	return c.algorithm
+}
+
+// Width method returns the number of lanes
+func (c *Chains) Width() int {
+	return c.core.width()
+}
+
+// Set method starts the input lane's chain from the input (hex-encoded) hash
+func (c *Chains) Set(lane int, hash []byte) error {
+	if lane < 0 || lane >= c.core.width() {
+		return fmt.Errorf("invalid lane %d", lane)
+	}
+	if len(hash) != hex.EncodedLen(c.size) {
+		return errors.New("hash length does not match the hash function's digest size")
+	}
+	if _, err := hex.Decode(c.digest, hash); err != nil {
+		return fmt.Errorf("hex encoder: invalid string -- %s", err)
+	}
+
+	c.core.set(lane, c.digest)
+	return nil
+}
+
+// Hash method returns the current (hex-encoded) hash of the input lane
+func (c *Chains) Hash(lane int) []byte {
+	c.core.get(lane, c.digest)
+
+	out := make([]byte, hex.EncodedLen(c.size))
+	hex.Encode(out, c.digest)
+	return out
+}
+
+// Step method advances the chains of all lanes by the input number of steps
+func (c *Chains) Step(n int) {
+	if n > 0 {
+		c.core.step(n)
+	}
+}
@@ -0,0 +1,136 @@
+package lanes
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"testing"
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"SHA224":     sha256.New224,
+	"SHA256":     sha256.New,
+	"SHA384":     sha512.New384,
+	"SHA512":     sha512.New,
+	"SHA512_224": sha512.New512_224,
+	"SHA512_256": sha512.New512_256,
+}
+
+// chain function returns the hash `steps` steps after the input
+// (hex-encoded) hash, one step at a time
+func chain(fn func() hash.Hash, start []byte, steps int, raw bool) []byte {
+	h := fn()
+	prev := start
+	for i := 0; i < steps; i++ {
+		msg := prev
+		if raw {
+			msg, _ = hex.DecodeString(string(prev))
+		}
+		h.Reset()
+		h.Write(msg)
+		prev = []byte(hex.EncodeToString(h.Sum(nil)))
+	}
+	return prev
+}
+
+func testChains(t *testing.T, name string) {
+	for alg, fn := range algorithms {
+		for _, mode := range []string{modeHex, modeRaw} {
+			c, err := New(alg, mode)
+			if err != nil {
+				t.Fatalf("FAILED -- [Lanes] %s: New(%s, %s) failed: %s", name, alg, mode, err)
+			}
+
+			// each lane starts from a different hash
+			starts := make([][]byte, c.Width())
+			for l := range starts {
+				h := fn()
+				fmt.Fprintf(h, "lane %d", l)
+				starts[l] = []byte(hex.EncodeToString(h.Sum(nil)))
+
+				if err := c.Set(l, starts[l]); err != nil {
+					t.Fatalf("FAILED -- [Lanes] %s: Set(%d) failed: %s", name, l, err)
+				}
+			}
+
+			c.Step(1)
+			c.Step(99)
+
+			for l := range starts {
+				want := chain(fn, starts[l], 100, mode == modeRaw)
+				if got := c.Hash(l); string(got) != string(want) {
+					t.Errorf("FAILED -- [Lanes] %s: %s (%s) lane %d hash mismatch: wanted %s ; got %s", name, alg, mode, l, want, got)
+				}
+			}
+		}
+	}
+}
+
+var levels = map[int]string{
+	levelGeneric: "generic",
+	levelAVX2:    "AVX2",
+	levelAVX512:  "AVX-512",
+}
+
+// withLevel function runs the input function with each implementation that
+// the CPU supports
+func withLevel(fn func(name string)) {
+	defer func(v int) { level = v }(level)
+
+	for l := levelGeneric; l <= maxLevel(); l++ {
+		level = l
+		fn(levels[l])
+	}
+}
+
+func TestChains(t *testing.T) {
+	withLevel(func(name string) {
+		testChains(t, name)
+	})
+}
+
+func TestChainsInvalid(t *testing.T) {
+	for _, alg := range []string{"MD5", "sha1"} {
+		if _, err := New(alg, ""); err != ErrUnsupported {
+			t.Errorf("FAILED -- [Lanes] New(%s) should return ErrUnsupported; got %v", alg, err)
+		}
+	}
+	if _, err := New("sha3", ""); err == nil {
+		t.Errorf("FAILED -- [Lanes] New() with an invalid algorithm should fail")
+	}
+	if _, err := New("sha256", "base64"); err == nil {
+		t.Errorf("FAILED -- [Lanes] New() with an invalid chaining mode should fail")
+	}
+
+	c, _ := New("sha256", "")
+	for _, hash := range []string{"abcd", "zz" + string(make([]byte, 62))} {
+		if err := c.Set(0, []byte(hash)); err == nil {
+			t.Errorf("FAILED -- [Lanes] Set(%q) should fail", hash)
+		}
+	}
+	if err := c.Set(8, make([]byte, 64)); err == nil {
+		t.Errorf("FAILED -- [Lanes] Set() with an invalid lane should fail")
+	}
+}
+
+// benchmarkLanes function reports the time per hash (of a single chain), with
+// all lanes in use
+func benchmarkLanes(b *testing.B, alg string) {
+	c, _ := New(alg, "")
+	b.ResetTimer()
+	for i := 0; i < b.N; i += c.Width() {
+		c.Step(1)
+	}
+}
+
+func BenchmarkChains(b *testing.B) {
+	withLevel(func(name string) {
+		for _, alg := range []string{"SHA224", "SHA256", "SHA384", "SHA512", "SHA512_224", "SHA512_256"} {
+			b.Run(name+"/"+alg, func(b *testing.B) {
+				benchmarkLanes(b, alg)
+			})
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package lanes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// width256 is the number of lanes of the SHA-256 functions: 8 32-bit words
+// in a 256-bit register
+const width256 int = 8
+
+// initial hash values of SHA-224 and SHA-256 (FIPS 180-4, 5.3.2 and 5.3.3)
+var (
+	iv224 = [8]uint32{0xc1059ed8, 0x367cd507, 0x3070dd17, 0xf70e5939, 0xffc00b31, 0x68581511, 0x64f98fa7, 0xbefa4fa4}
+	iv256 = [8]uint32{0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19}
+)
+
+// k256 are the SHA-256 round constants (FIPS 180-4, 4.2.2)
+var k256 = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// state256, block256 and sched256 types are the hash values, a message block
+// and its expanded message schedule (with the round constants added) of every
+// lane, transposed: `[word][lane]`, so that each word is a vector
+type (
+	state256 [8][width256]uint32
+	block256 [16][width256]uint32
+	sched256 [64][width256]uint32
+)
+
+// sha256Lanes struct is the multi-lane state of a SHA-256 function
+type sha256Lanes struct {
+	size  int
+	words int
+	raw   bool
+
+	h  state256
+	iv state256
+
+	// block is the first block of a step's message: its first `data` words
+	// are the previous hash, and the rest are constant; and consts are the
+	// (constant) schedules of the rest of its blocks
+	block  block256
+	data   int
+	consts []sched256
+	s      sched256
+}
+
+// newSHA256 function creates the multi-lane state of the SHA-256 function
+// with the input initial hash values and digest size (in bytes)
+func newSHA256(iv *[8]uint32, size int, raw bool) *sha256Lanes {
+	c := &sha256Lanes{size: size, words: size / 4, raw: raw}
+
+	for k, v := range iv {
+		for l := range c.iv[k] {
+			c.iv[k][l] = v
+		}
+	}
+
+	msg := size
+	if !raw {
+		msg *= 2
+	}
+	c.data = msg / 4
+
+	words := pad(msg, 64, 8)
+	for b := 0; b < len(words)/16; b++ {
+		block := &c.block
+		if b > 0 {
+			block = &block256{}
+		}
+		for k := 0; k < 16; k++ {
+			for l := range block[k] {
+				block[k][l] = uint32(words[b*16+k])
+			}
+		}
+		if b > 0 {
+			c.consts = append(c.consts, sched256{})
+			expand8Generic(block, &c.consts[b-1])
+		}
+	}
+	return c
+}
+
+// pad function returns the (big-endian) words of the padding of a message of
+// the input length, in bytes: a message of zeros, followed by a one bit and
+// the message's length (in bits), in blocks of the input size. The length
+// field takes the input number of bytes (8 for SHA-256, 16 for SHA-512)
+func pad(msg, block, lenSize int) []uint64 {
+	n := (msg + 1 + lenSize + block - 1) / block * block
+
+	buf := make([]byte, n)
+	buf[msg] = 0x80
+	binary.BigEndian.PutUint64(buf[n-8:], uint64(msg)*8)
+
+	wordSize := block / 16
+	out := make([]uint64, n/wordSize)
+	for idx := range out {
+		if wordSize == 4 {
+			out[idx] = uint64(binary.BigEndian.Uint32(buf[idx*4:]))
+			continue
+		}
+		out[idx] = binary.BigEndian.Uint64(buf[idx*8:])
+	}
+	return out
+}
+
+func (c *sha256Lanes) width() int {
+	return width256
+}
+
+func (c *sha256Lanes) digestSize() int {
+	return c.size
+}
+
+func (c *sha256Lanes) set(lane int, digest []byte) {
+	for k := 0; k < c.words; k++ {
+		c.h[k][lane] = binary.BigEndian.Uint32(digest[k*4:])
+	}
+}
+
+func (c *sha256Lanes) get(lane int, digest []byte) {
+	for k := 0; k < c.words; k++ {
+		binary.BigEndian.PutUint32(digest[k*4:], c.h[k][lane])
+	}
+}
+
+func (c *sha256Lanes) step(n int) {
+	for ; n > 0; n-- {
+		// the message is the previous hash
+		if c.raw {
+			copy(c.block[:c.words], c.h[:c.words])
+		} else {
+			hex8(&c.h, &c.block, c.words)
+		}
+
+		c.h = c.iv
+		expand8(&c.block, &c.s)
+		rounds8(&c.h, &c.s)
+		for idx := range c.consts {
+			rounds8(&c.h, &c.consts[idx])
+		}
+	}
+}
+
+// hex8Generic function writes the hex encoding of the first `words` hash
+// values of every lane to the input message block
+func hex8Generic(h *state256, w *block256, words int) {
+	for k := 0; k < words; k++ {
+		hi, lo := &w[2*k], &w[2*k+1]
+		for l, x := range h[k] {
+			hi[l] = hexTable[x>>24]<<16 | hexTable[x>>16&0xff]
+			lo[l] = hexTable[x>>8&0xff]<<16 | hexTable[x&0xff]
+		}
+	}
+}
+
+// expand8Generic function expands the message schedule of the input block of
+// every lane, adding the round constants
+func expand8Generic(w *block256, s *sched256) {
+	copy(s[:16], w[:])
+
+	for t := 16; t < 64; t++ {
+		for l := 0; l < width256; l++ {
+			v1 := s[t-2][l]
+			t1 := bits.RotateLeft32(v1, -17) ^ bits.RotateLeft32(v1, -19) ^ (v1 >> 10)
+			v2 := s[t-15][l]
+			t2 := bits.RotateLeft32(v2, -7) ^ bits.RotateLeft32(v2, -18) ^ (v2 >> 3)
+			s[t][l] = t1 + s[t-7][l] + t2 + s[t-16][l]
+		}
+	}
+
+	for t := range s {
+		for l := range s[t] {
+			s[t][l] += k256[t]
+		}
+	}
+}
+
+// rounds8Generic function compresses the input message schedule of every lane
+// into the lanes' hash values, one lane after the other
+func rounds8Generic(h *state256, s *sched256) {
+	for l := 0; l < width256; l++ {
+		a, b, c, d, e, f, g, hh := h[0][l], h[1][l], h[2][l], h[3][l], h[4][l], h[5][l], h[6][l], h[7][l]
+
+		for t := 0; t < 64; t++ {
+			t1 := hh + (bits.RotateLeft32(e, -6) ^ bits.RotateLeft32(e, -11) ^ bits.RotateLeft32(e, -25)) + ((e & f) ^ (^e & g)) + s[t][l]
+			t2 := (bits.RotateLeft32(a, -2) ^ bits.RotateLeft32(a, -13) ^ bits.RotateLeft32(a, -22)) + ((a & b) ^ (a & c) ^ (b & c))
+
+			hh = g
+			g = f
+			f = e
+			e = d + t1
+			d = c
+			c = b
+			b = a
+			a = t1 + t2
+		}
+
+		h[0][l] += a
+		h[1][l] += b
+		h[2][l] += c
+		h[3][l] += d
+		h[4][l] += e
+		h[5][l] += f
+		h[6][l] += g
+		h[7][l] += hh
+	}
+}
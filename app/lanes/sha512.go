@@ -0,0 +1,237 @@
+package lanes
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// width512 is the number of lanes of the SHA-512 functions: 4 64-bit words
+// in a 256-bit register
+const width512 int = 4
+
+// initial hash values of SHA-384, SHA-512, SHA-512/224 and SHA-512/256
+// (FIPS 180-4, 5.3.4 to 5.3.6)
+var (
+	iv384     = [8]uint64{0xcbbb9d5dc1059ed8, 0x629a292a367cd507, 0x9159015a3070dd17, 0x152fecd8f70e5939, 0x67332667ffc00b31, 0x8eb44a8768581511, 0xdb0c2e0d64f98fa7, 0x47b5481dbefa4fa4}
+	iv512     = [8]uint64{0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1, 0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179}
+	iv512_224 = [8]uint64{0x8c3d37c819544da2, 0x73e1996689dcd4d6, 0x1dfab7ae32ff9c82, 0x679dd514582f9fcf, 0x0f6d2b697bd44da8, 0x77e36f7304c48942, 0x3f9d85a86a1d36c8, 0x1112e6ad91d692a1}
+	iv512_256 = [8]uint64{0x22312194fc2bf72c, 0x9f555fa3c84c64c2, 0x2393b86b6f53b151, 0x963877195940eabd, 0x96283ee2a88effe3, 0xbe5e1e2553863992, 0x2b0199fc2c85b8aa, 0x0eb72ddc81c52ca2}
+)
+
+// k512 are the SHA-512 round constants (FIPS 180-4, 4.2.3)
+var k512 = [80]uint64{
+	0x428a2f98d728ae22, 0x7137449123ef65cd, 0xb5c0fbcfec4d3b2f, 0xe9b5dba58189dbbc,
+	0x3956c25bf348b538, 0x59f111f1b605d019, 0x923f82a4af194f9b, 0xab1c5ed5da6d8118,
+	0xd807aa98a3030242, 0x12835b0145706fbe, 0x243185be4ee4b28c, 0x550c7dc3d5ffb4e2,
+	0x72be5d74f27b896f, 0x80deb1fe3b1696b1, 0x9bdc06a725c71235, 0xc19bf174cf692694,
+	0xe49b69c19ef14ad2, 0xefbe4786384f25e3, 0x0fc19dc68b8cd5b5, 0x240ca1cc77ac9c65,
+	0x2de92c6f592b0275, 0x4a7484aa6ea6e483, 0x5cb0a9dcbd41fbd4, 0x76f988da831153b5,
+	0x983e5152ee66dfab, 0xa831c66d2db43210, 0xb00327c898fb213f, 0xbf597fc7beef0ee4,
+	0xc6e00bf33da88fc2, 0xd5a79147930aa725, 0x06ca6351e003826f, 0x142929670a0e6e70,
+	0x27b70a8546d22ffc, 0x2e1b21385c26c926, 0x4d2c6dfc5ac42aed, 0x53380d139d95b3df,
+	0x650a73548baf63de, 0x766a0abb3c77b2a8, 0x81c2c92e47edaee6, 0x92722c851482353b,
+	0xa2bfe8a14cf10364, 0xa81a664bbc423001, 0xc24b8b70d0f89791, 0xc76c51a30654be30,
+	0xd192e819d6ef5218, 0xd69906245565a910, 0xf40e35855771202a, 0x106aa07032bbd1b8,
+	0x19a4c116b8d2d0c8, 0x1e376c085141ab53, 0x2748774cdf8eeb99, 0x34b0bcb5e19b48a8,
+	0x391c0cb3c5c95a63, 0x4ed8aa4ae3418acb, 0x5b9cca4f7763e373, 0x682e6ff3d6b2b8a3,
+	0x748f82ee5defb2fc, 0x78a5636f43172f60, 0x84c87814a1f0ab72, 0x8cc702081a6439ec,
+	0x90befffa23631e28, 0xa4506cebde82bde9, 0xbef9a3f7b2c67915, 0xc67178f2e372532b,
+	0xca273eceea26619c, 0xd186b8c721c0c207, 0xeada7dd6cde0eb1e, 0xf57d4f7fee6ed178,
+	0x06f067aa72176fba, 0x0a637dc5a2c898a6, 0x113f9804bef90dae, 0x1b710b35131c471b,
+	0x28db77f523047d84, 0x32caab7b40c72493, 0x3c9ebe0a15c9bebc, 0x431d67c49c100d4c,
+	0x4cc5d4becb3e42b6, 0x597f299cfc657e2a, 0x5fcb6fab3ad6faec, 0x6c44198c4a475817,
+}
+
+// state512, block512 and sched512 types are the hash values, a message block
+// and its expanded message schedule (with the round constants added) of every
+// lane, transposed: `[word][lane]`, so that each word is a vector
+type (
+	state512 [8][width512]uint64
+	block512 [16][width512]uint64
+	sched512 [80][width512]uint64
+)
+
+// sha512Lanes struct is the multi-lane state of a SHA-512 function
+type sha512Lanes struct {
+	size int
+	raw  bool
+
+	h  state512
+	iv state512
+
+	// block is the first block of a step's message: its first `data` words
+	// are the previous hash, and the rest are constant; and consts are the
+	// (constant) schedules of the rest of its blocks. In the raw chaining
+	// mode, the last word of the hash of SHA-512/224 only takes its first
+	// half; the rest is its padding, kept in `tail`
+	block  block512
+	data   int
+	tail   uint64
+	consts []sched512
+	s      sched512
+}
+
+// newSHA512 function creates the multi-lane state of the SHA-512 function
+// with the input initial hash values and digest size (in bytes)
+func newSHA512(iv *[8]uint64, size int, raw bool) *sha512Lanes {
+	c := &sha512Lanes{size: size, raw: raw}
+
+	for k, v := range iv {
+		for l := range c.iv[k] {
+			c.iv[k][l] = v
+		}
+	}
+
+	msg := size
+	if !raw {
+		msg *= 2
+	}
+	c.data = (msg + 7) / 8
+
+	words := pad(msg, 128, 16)
+	for b := 0; b < len(words)/16; b++ {
+		block := &c.block
+		if b > 0 {
+			block = &block512{}
+		}
+		for k := 0; k < 16; k++ {
+			for l := range block[k] {
+				block[k][l] = words[b*16+k]
+			}
+		}
+		if b > 0 {
+			c.consts = append(c.consts, sched512{})
+			expand4Generic(block, &c.consts[b-1])
+		}
+	}
+	if msg%8 != 0 {
+		c.tail = words[c.data-1]
+	}
+	return c
+}
+
+func (c *sha512Lanes) width() int {
+	return width512
+}
+
+func (c *sha512Lanes) digestSize() int {
+	return c.size
+}
+
+func (c *sha512Lanes) set(lane int, digest []byte) {
+	var buf [8]byte
+	for k := 0; k*8 < c.size; k++ {
+		buf = [8]byte{}
+		copy(buf[:], digest[k*8:])
+		c.h[k][lane] = binary.BigEndian.Uint64(buf[:])
+	}
+}
+
+func (c *sha512Lanes) get(lane int, digest []byte) {
+	var buf [8]byte
+	for k := 0; k*8 < c.size; k++ {
+		binary.BigEndian.PutUint64(buf[:], c.h[k][lane])
+		copy(digest[k*8:], buf[:])
+	}
+}
+
+func (c *sha512Lanes) step(n int) {
+	for ; n > 0; n-- {
+		// the message is the previous hash
+		if c.raw {
+			copy(c.block[:c.data], c.h[:c.data])
+			if c.tail != 0 {
+				last := &c.block[c.data-1]
+				for l := range last {
+					last[l] = last[l]&0xffffffff00000000 | c.tail
+				}
+			}
+		} else {
+			hex4(&c.h, &c.block, c.data)
+		}
+
+		c.h = c.iv
+		expand4(&c.block, &c.s)
+		rounds4(&c.h, &c.s)
+		for idx := range c.consts {
+			rounds4(&c.h, &c.consts[idx])
+		}
+	}
+}
+
+// hex64 function returns the (8-character) hex encoding of the input word, as
+// a big-endian 64-bit word
+func hex64(x uint32) uint64 {
+	return uint64(hexTable[x>>24])<<48 | uint64(hexTable[x>>16&0xff])<<32 | uint64(hexTable[x>>8&0xff])<<16 | uint64(hexTable[x&0xff])
+}
+
+// hex4Generic function writes the hex encoding of the hash values of every
+// lane to the first `data` words of the input message block
+func hex4Generic(h *state512, w *block512, data int) {
+	for k := 0; 2*k < data; k++ {
+		hi, lo := &w[2*k], &w[2*k+1]
+		for l, x := range h[k] {
+			hi[l] = hex64(uint32(x >> 32))
+		}
+		if 2*k+1 == data {
+			// the hash of SHA-512/224 ends half-way through the word
+			break
+		}
+		for l, x := range h[k] {
+			lo[l] = hex64(uint32(x))
+		}
+	}
+}
+
+// expand4Generic function expands the message schedule of the input block of
+// every lane, adding the round constants
+func expand4Generic(w *block512, s *sched512) {
+	copy(s[:16], w[:])
+
+	for t := 16; t < 80; t++ {
+		for l := 0; l < width512; l++ {
+			v1 := s[t-2][l]
+			t1 := bits.RotateLeft64(v1, -19) ^ bits.RotateLeft64(v1, -61) ^ (v1 >> 6)
+			v2 := s[t-15][l]
+			t2 := bits.RotateLeft64(v2, -1) ^ bits.RotateLeft64(v2, -8) ^ (v2 >> 7)
+			s[t][l] = t1 + s[t-7][l] + t2 + s[t-16][l]
+		}
+	}
+
+	for t := range s {
+		for l := range s[t] {
+			s[t][l] += k512[t]
+		}
+	}
+}
+
+// rounds4Generic function compresses the input message schedule of every lane
+// into the lanes' hash values, one lane after the other
+func rounds4Generic(h *state512, s *sched512) {
+	for l := 0; l < width512; l++ {
+		a, b, c, d, e, f, g, hh := h[0][l], h[1][l], h[2][l], h[3][l], h[4][l], h[5][l], h[6][l], h[7][l]
+
+		for t := 0; t < 80; t++ {
+			t1 := hh + (bits.RotateLeft64(e, -14) ^ bits.RotateLeft64(e, -18) ^ bits.RotateLeft64(e, -41)) + ((e & f) ^ (^e & g)) + s[t][l]
+			t2 := (bits.RotateLeft64(a, -28) ^ bits.RotateLeft64(a, -34) ^ bits.RotateLeft64(a, -39)) + ((a & b) ^ (a & c) ^ (b & c))
+
+			hh = g
+			g = f
+			f = e
+			e = d + t1
+			d = c
+			c = b
+			b = a
+			a = t1 + t2
+		}
+
+		h[0][l] += a
+		h[1][l] += b
+		h[2][l] += c
+		h[3][l] += d
+		h[4][l] += e
+		h[5][l] += f
+		h[6][l] += g
+		h[7][l] += hh
+	}
+}
@@ -0,0 +1,403 @@
+// Package ledger persists a running hash chain as an append-only, on-disk
+// ledger -- a Proof-of-History record of checkpoints and events.
+//
+// A ledger is a directory with a manifest (the chain's seed and algorithm)
+// and numbered segment files. Each segment is a write-ahead log of records:
+// a length and CRC-32 header, followed by a JSON-encoded `Entry`. When a
+// ledger is opened, a record left incomplete by a crash is truncated from the
+// last segment, so that appending can resume from the last complete entry.
+//
+// `Verify` re-checks every entry against the chain rules, in parallel by
+// segment, and reports the first corrupt or forged record.
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	manifestFile  string = "ledger.json"
+	segmentSuffix string = ".seg"
+
+	// Version is the ledger format version, set in the manifest
+	Version int = 1
+)
+
+var (
+	// ErrExists error is returned when creating a ledger in a directory which
+	// already holds one
+	ErrExists = errors.New("ledger already exists")
+
+	// ErrNotExist error is returned when opening a directory without a ledger
+	ErrNotExist = errors.New("ledger does not exist")
+
+	// ErrClosed error is returned when appending to a closed ledger
+	ErrClosed = errors.New("ledger is closed")
+)
+
+// Entry struct is a single ledger record: the hash at an index of the chain,
+// the (hex-encoded) digest of the event mixed into it -- if any -- and the
+// wall time when it was recorded
+type Entry struct {
+	Index    int       `json:"index"`
+	Hash     string    `json:"hash"`
+	Event    string    `json:"event,omitempty"`
+	WallTime time.Time `json:"wall_time"`
+}
+
+// Manifest struct describes a ledger's chain and layout; it is stored in the
+// ledger's directory when it is created
+type Manifest struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"algorithm"`
+	Seed      string `json:"seed"`
+
+	// Interval is the number of hashes between checkpoint entries, when
+	// recording a running chain
+	Interval int `json:"interval"`
+
+	// SegmentSize is the maximum number of entries per segment file
+	SegmentSize int `json:"segment_size"`
+}
+
+// Config struct defines the configuration for a new ledger
+type Config struct {
+	Dir       string
+	Algorithm string
+	Seed      string
+
+	// Interval is the number of hashes between checkpoint entries; 1000000
+	// by default
+	Interval int
+
+	// SegmentSize is the maximum number of entries per segment file; 10000
+	// by default
+	SegmentSize int
+}
+
+// Ledger struct is an open, append-only ledger
+type Ledger struct {
+	dir      string
+	manifest *Manifest
+
+	// Sync sets whether each appended entry is flushed to stable storage
+	// (with fsync) before `Append` returns. Otherwise, segments are synced
+	// when they are full, and when the ledger is closed
+	Sync bool
+
+	mu      sync.Mutex
+	file    *os.File
+	segment int // number of the current segment
+	count   int // entries in the current segment
+	last    *Entry
+	closed  bool
+}
+
+// segmentName function returns the file name of the segment with the input
+// number
+func segmentName(n int) string {
+	return fmt.Sprintf("%08d%s", n, segmentSuffix)
+}
+
+// segments function lists the segment files in the input directory, sorted
+func segments(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), segmentSuffix) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadManifest function reads the manifest of the ledger in the input
+// directory
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid ledger manifest: %s", err)
+	}
+
+	if m.Version != Version {
+		return nil, fmt.Errorf("unsupported ledger version %d", m.Version)
+	}
+	if _, err := clock.NewChain(m.Algorithm, m.Seed); err != nil {
+		return nil, fmt.Errorf("invalid ledger manifest: %s", err)
+	}
+	if m.Interval <= 0 || m.SegmentSize <= 0 {
+		return nil, errors.New("invalid ledger manifest: interval and segment size must be greater than zero")
+	}
+
+	return m, nil
+}
+
+// writeFileSync function writes a file atomically: to a temporary file which
+// is synced and renamed
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Create function creates a new, empty ledger in the input configuration's
+// directory (which is created if needed)
+func Create(cfg *Config) (*Ledger, error) {
+	if cfg == nil || cfg.Dir == "" {
+		return nil, errors.New("ledger directory is required")
+	}
+
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "sha256"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 1000000
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = 10000
+	}
+
+	chain, err := clock.NewChain(cfg.Algorithm, cfg.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.Dir, manifestFile)); err == nil {
+		return nil, ErrExists
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:     Version,
+		Algorithm:   chain.Algorithm(),
+		Seed:        cfg.Seed,
+		Interval:    cfg.Interval,
+		SegmentSize: cfg.SegmentSize,
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileSync(filepath.Join(cfg.Dir, manifestFile), b); err != nil {
+		return nil, err
+	}
+
+	return Open(cfg.Dir)
+}
+
+// Open function opens the ledger in the input directory for appending.
+//
+// The last segment is scanned for its last complete entry; a record torn by a
+// crash at its end is truncated. A corrupt record (with a checksum or length
+// mismatch) fails to open, leaving the segment as it is
+func Open(dir string) (*Ledger, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Ledger{
+		dir:      dir,
+		manifest: m,
+	}
+
+	names, err := segments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// the last complete entry may be in the previous segment, if the last
+	// one is empty
+	for idx := len(names) - 1; idx >= 0 && l.last == nil; idx-- {
+		scan, err := scanSegment(filepath.Join(dir, names[idx]))
+		if err != nil {
+			return nil, err
+		}
+
+		// only the last segment can end in a torn record; as readRecord only
+		// returns ErrTruncated at the end of the file
+		torn := idx == len(names)-1 && errors.Is(scan.err, ErrTruncated)
+		if scan.err != nil && !torn {
+			return nil, fmt.Errorf("corrupt segment %s at offset %d: %w", names[idx], scan.errOffset, scan.err)
+		}
+
+		if idx == len(names)-1 {
+			if torn {
+				if err := os.Truncate(filepath.Join(dir, names[idx]), scan.end); err != nil {
+					return nil, err
+				}
+			}
+			l.count = len(scan.entries)
+		}
+
+		if n := len(scan.entries); n > 0 {
+			l.last = scan.entries[n-1]
+		}
+	}
+
+	l.segment = len(names)
+	if l.segment == 0 || l.count >= m.SegmentSize {
+		if err := l.rotate(); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	l.file, err = os.OpenFile(filepath.Join(dir, names[len(names)-1]), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// rotate method syncs and closes the current segment (if any) and starts the
+// next one. It must be called while holding the lock
+func (l *Ledger) rotate() error {
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil {
+			return err
+		}
+		if err := l.file.Close(); err != nil {
+			return err
+		}
+	}
+
+	l.segment++
+	l.count = 0
+
+	f, err := os.OpenFile(filepath.Join(l.dir, segmentName(l.segment)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	l.file = f
+
+	// persist the new segment's directory entry
+	if d, err := os.Open(l.dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// Manifest method returns the ledger's manifest
+func (l *Ledger) Manifest() *Manifest {
+	return l.manifest
+}
+
+// Dir method returns the ledger's directory
+func (l *Ledger) Dir() string {
+	return l.dir
+}
+
+// Last method returns the last entry in the ledger; or false if it is empty
+func (l *Ledger) Last() (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.last == nil {
+		return Entry{}, false
+	}
+	return *l.last, true
+}
+
+// Append method writes the input entry at the end of the ledger. Its index
+// must be greater than the last entry's index; the chain itself is not
+// checked (see `Verify`)
+func (l *Ledger) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	if e.Index <= 0 || (l.last != nil && e.Index <= l.last.Index) {
+		return fmt.Errorf("entry index %d does not follow the last index in the ledger", e.Index)
+	}
+
+	if l.count >= l.manifest.SegmentSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	rec, err := encodeRecord(&e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.file.Write(rec); err != nil {
+		return err
+	}
+	if l.Sync {
+		if err := l.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	l.count++
+	l.last = &e
+	return nil
+}
+
+// Close method syncs and closes the ledger
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
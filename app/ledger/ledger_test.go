@@ -0,0 +1,244 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const testSeed string = "genesis_string"
+
+// newTestLedger function creates a ledger in a temporary directory, with the
+// input number of entries (one every 10 hashes) and segment size
+func newTestLedger(t *testing.T, entries, segmentSize int) *Ledger {
+	t.Helper()
+
+	l, err := Create(&Config{
+		Dir:         t.TempDir(),
+		Seed:        testSeed,
+		Interval:    10,
+		SegmentSize: segmentSize,
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [Ledger] Create() failed: %s", err)
+	}
+
+	chain, _ := clock.NewChain("sha256", testSeed)
+	for idx := 0; idx < entries; idx++ {
+		for i := 0; i < 10; i++ {
+			chain.Next()
+		}
+
+		err := l.Append(Entry{Index: chain.Index(), Hash: chain.Hash(), WallTime: time.Now().UTC()})
+		if err != nil {
+			t.Fatalf("FAILED -- [Ledger] Append() failed: %s", err)
+		}
+	}
+	return l
+}
+
+func verify(t *testing.T, dir string) *Report {
+	t.Helper()
+
+	report, err := Verify(context.Background(), dir, 2)
+	if err != nil {
+		t.Fatalf("FAILED -- [Ledger] Verify() failed: %s", err)
+	}
+	return report
+}
+
+func TestLedger(t *testing.T) {
+	l := newTestLedger(t, 25, 10)
+	dir := l.Dir()
+
+	if err := l.Append(Entry{Index: 250, Hash: "00"}); err == nil {
+		t.Errorf("FAILED -- [Ledger] Append() should reject an index which does not follow the last one")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("FAILED -- [Ledger] Close() failed: %s", err)
+	}
+
+	names, _ := segments(dir)
+	if len(names) != 3 {
+		t.Errorf("FAILED -- [Ledger] segment count mismatch: wanted 3 ; got %v", len(names))
+	}
+
+	if _, err := Create(&Config{Dir: dir, Seed: testSeed}); err != ErrExists {
+		t.Errorf("FAILED -- [Ledger] Create() on an existing ledger: wanted %v ; got %v", ErrExists, err)
+	}
+	if _, err := Open(t.TempDir()); err != ErrNotExist {
+		t.Errorf("FAILED -- [Ledger] Open() on an empty directory: wanted %v ; got %v", ErrNotExist, err)
+	}
+
+	report := verify(t, dir)
+	if !report.Valid || report.Entries != 25 || report.LastIndex != 250 || report.Segments != 3 {
+		t.Errorf("FAILED -- [Ledger] unexpected report: %+v", report)
+	}
+}
+
+func TestLedgerRecovery(t *testing.T) {
+	l := newTestLedger(t, 5, 10)
+	dir := l.Dir()
+	l.Close()
+
+	// a record torn by a crash, in the middle of its payload
+	rec, _ := encodeRecord(&Entry{Index: 60, Hash: "00"})
+	path := filepath.Join(dir, segmentName(1))
+
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write(rec[:len(rec)/2])
+	f.Close()
+
+	l, err := Open(dir)
+	if err != nil {
+		t.Fatalf("FAILED -- [Ledger] Open() failed: %s", err)
+	}
+
+	last, ok := l.Last()
+	if !ok || last.Index != 50 {
+		t.Errorf("FAILED -- [Ledger] last index mismatch: wanted 50 ; got %v", last.Index)
+	}
+
+	// appending resumes after the last complete entry
+	rec2, err := NewRecorder(l)
+	if err != nil {
+		t.Fatalf("FAILED -- [Ledger] NewRecorder() failed: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec2.OnEntry = func(e Entry) {
+		if e.Index >= 100 {
+			cancel()
+		}
+	}
+	rec2.Record(ctx, []byte("event"))
+
+	if err := rec2.Run(ctx); err != context.Canceled {
+		t.Errorf("FAILED -- [Ledger] Run() should stop with the context: %v", err)
+	}
+	l.Close()
+
+	report := verify(t, dir)
+	if !report.Valid || report.LastIndex < 100 {
+		t.Errorf("FAILED -- [Ledger] unexpected report: %+v", report)
+	}
+
+	// the event is mixed into the step after the last entry
+	scan, _ := scanSegment(path)
+	if e := scan.entries[5]; e.Index != 51 || e.Event == "" {
+		t.Errorf("FAILED -- [Ledger] expected an event entry at index 51: %+v", e)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		pos  int64 // position in the 2nd record of the last segment
+	}{
+		{"payload", int64(headerSize) + 2},
+		{"length", 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l := newTestLedger(t, 25, 10)
+			dir := l.Dir()
+			l.Close()
+
+			path := filepath.Join(dir, segmentName(3))
+			scan, _ := scanSegment(path)
+
+			b, _ := os.ReadFile(path)
+			b[scan.offsets[1]+test.pos] ^= 0xff
+			os.WriteFile(path, b, 0o644)
+
+			if l, err := Open(dir); err == nil {
+				l.Close()
+				t.Fatalf("FAILED -- [Ledger] Open() should fail for a corrupt segment")
+			}
+
+			// the corrupt record, and the ones after it, are left as they are
+			if after, _ := os.ReadFile(path); !bytes.Equal(after, b) {
+				t.Errorf("FAILED -- [Ledger] Open() should not modify a corrupt segment")
+			}
+		})
+	}
+}
+
+func TestVerifyForged(t *testing.T) {
+	l := newTestLedger(t, 25, 10)
+	dir := l.Dir()
+	l.Close()
+
+	// rewrite the 4th record of the second segment with a forged hash
+	path := filepath.Join(dir, segmentName(2))
+	scan, _ := scanSegment(path)
+
+	var out []byte
+	for idx, e := range scan.entries {
+		if idx == 3 {
+			e.Hash = scan.entries[2].Hash
+		}
+		rec, _ := encodeRecord(e)
+		out = append(out, rec...)
+	}
+	os.WriteFile(path, out, 0o644)
+
+	report := verify(t, dir)
+	if report.Valid || report.Bad == nil {
+		t.Fatalf("FAILED -- [Ledger] forged ledger should be invalid: %+v", report)
+	}
+	if bad := report.Bad; bad.Segment != segmentName(2) || bad.Record != 3 || bad.Index != 140 {
+		t.Errorf("FAILED -- [Ledger] unexpected bad record: %+v", bad)
+	}
+	if report.Entries != 13 || report.LastIndex != 130 {
+		t.Errorf("FAILED -- [Ledger] unexpected report: %+v", report)
+	}
+}
+
+func TestVerifyCorrupt(t *testing.T) {
+	l := newTestLedger(t, 25, 10)
+	dir := l.Dir()
+	l.Close()
+
+	// flip a byte in the payload of the 2nd record of the third segment
+	path := filepath.Join(dir, segmentName(3))
+	scan, _ := scanSegment(path)
+
+	b, _ := os.ReadFile(path)
+	b[scan.offsets[1]+int64(headerSize)+2] ^= 0xff
+	os.WriteFile(path, b, 0o644)
+
+	report := verify(t, dir)
+	if report.Valid || report.Bad == nil {
+		t.Fatalf("FAILED -- [Ledger] corrupt ledger should be invalid: %+v", report)
+	}
+	if bad := report.Bad; bad.Segment != segmentName(3) || bad.Record != 1 || bad.Offset != scan.offsets[1] {
+		t.Errorf("FAILED -- [Ledger] unexpected bad record: %+v", bad)
+	}
+}
+
+func TestReadSegments(t *testing.T) {
+	l := newTestLedger(t, 25, 10)
+	defer l.Close()
+
+	var batches, entries int
+	err := ReadSegments(l.Dir(), 150, func(e []Entry) error {
+		batches++
+		entries += len(e)
+		return nil
+	})
+	if err != nil || batches != 2 || entries != 10 {
+		t.Errorf("FAILED -- [Ledger] ReadSegments() read %v entries in %v batches: %v", entries, batches, err)
+	}
+
+	if e, err := Find(l.Dir(), 120); err != nil || e == nil || e.Index != 120 {
+		t.Errorf("FAILED -- [Ledger] Find(120) = %+v, %v", e, err)
+	}
+	if e, err := Find(l.Dir(), 125); err != nil || e != nil {
+		t.Errorf("FAILED -- [Ledger] Find(125) = %+v, %v ; expected no entry", e, err)
+	}
+}
@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// errStop error is returned by a `SegmentFunc` to stop reading, without
+// failing
+var errStop = errors.New("stop reading")
+
+// SegmentFunc type describes a function called with the entries read from
+// a segment; returning an error stops reading
+type SegmentFunc func(entries []Entry) error
+
+// ReadSegments function reads the ledger in the input directory, calling the
+// input function with the entries of each segment which come after the input
+// index (skipping segments without any). It can be called while the ledger is
+// being appended to: an incomplete record at the end of the last segment is
+// ignored, while a corrupt record in any other segment is returned as an error
+func ReadSegments(dir string, from int, fn SegmentFunc) error {
+	names, err := segments(dir)
+	if err != nil {
+		return err
+	}
+
+	for idx, name := range names {
+		scan, err := scanSegment(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+
+		if scan.err != nil && idx < len(names)-1 {
+			return scan.err
+		}
+
+		var entries []Entry
+		for _, e := range scan.entries {
+			if e.Index > from {
+				entries = append(entries, *e)
+			}
+		}
+
+		if len(entries) == 0 {
+			continue
+		}
+
+		if err := fn(entries); err != nil {
+			if err == errStop {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Find function returns the entry with the input index in the ledger in the
+// input directory; or nil if there is none
+func Find(dir string, index int) (*Entry, error) {
+	var found *Entry
+
+	err := ReadSegments(dir, index-1, func(entries []Entry) error {
+		if entries[0].Index == index {
+			found = &entries[0]
+		}
+		return errStop
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// headerSize is the size of a record's header: the payload's length and its
+// CRC-32 checksum, as big-endian 32-bit integers
+const headerSize int = 8
+
+// maxRecordSize is the maximum size of a record's payload; larger lengths
+// are treated as corruption
+const maxRecordSize int = 1 << 16
+
+var (
+	// ErrTruncated error is returned when a segment ends in the middle of a
+	// record, as left by a crash while writing it
+	ErrTruncated = errors.New("truncated record")
+
+	// ErrChecksum error is returned when a record's checksum does not match
+	// its payload
+	ErrChecksum = errors.New("record checksum mismatch")
+)
+
+// encodeRecord function encodes the input entry as a record: an 8-byte header
+// followed by the JSON-encoded entry
+func encodeRecord(e *Entry) ([]byte, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, headerSize, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+
+	return append(buf, payload...), nil
+}
+
+// readRecord function reads the next record from the input reader, returning
+// its entry and size. It returns `io.EOF` at the end of the segment, and
+// `ErrTruncated` or `ErrChecksum` for a torn or corrupt record
+func readRecord(r io.Reader) (*Entry, int, error) {
+	var header [headerSize]byte
+
+	n, err := io.ReadFull(r, header[:])
+	if err == io.EOF {
+		return nil, 0, io.EOF
+	}
+	if err != nil {
+		return nil, n, ErrTruncated
+	}
+
+	size := int(binary.BigEndian.Uint32(header[0:4]))
+	if size == 0 || size > maxRecordSize {
+		return nil, n, fmt.Errorf("%w: invalid record length %d", ErrChecksum, size)
+	}
+
+	payload := make([]byte, size)
+	if m, err := io.ReadFull(r, payload); err != nil {
+		return nil, n + m, ErrTruncated
+	}
+	n += size
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, n, ErrChecksum
+	}
+
+	e := &Entry{}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, n, fmt.Errorf("invalid record: %s", err)
+	}
+
+	return e, n, nil
+}
+
+// segmentScan struct holds the result of reading a segment: its valid
+// entries, the offset after the last valid record, and the error which
+// stopped the scan (if any) along with its offset
+type segmentScan struct {
+	entries   []*Entry
+	offsets   []int64
+	end       int64
+	err       error
+	errOffset int64
+}
+
+// scanSegment function reads all records in the segment file at the input
+// path, stopping at the first invalid record
+func scanSegment(path string) (*segmentScan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	scan := &segmentScan{}
+
+	for {
+		e, n, err := readRecord(r)
+		if err == io.EOF {
+			return scan, nil
+		}
+		if err != nil {
+			scan.err = err
+			scan.errOffset = scan.end
+			return scan, nil
+		}
+
+		scan.entries = append(scan.entries, e)
+		scan.offsets = append(scan.offsets, scan.end)
+		scan.end += int64(n)
+	}
+}
@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// eventBuffer is the number of events which can be queued in a `Recorder`
+// before `Record` blocks
+const eventBuffer int = 1024
+
+// EntryFunc type describes a function called with each entry appended by a
+// `Recorder`
+type EntryFunc func(e Entry)
+
+// Recorder struct runs the hash chain described in a ledger's manifest,
+// appending a checkpoint entry every `Interval` hashes -- and an event entry
+// for every recorded event, which is mixed into the chain
+type Recorder struct {
+	ledger *Ledger
+	chain  *clock.Chain
+	events chan []byte
+
+	// OnEntry is called with each appended entry, if set
+	OnEntry EntryFunc
+}
+
+// NewRecorder function creates a `Recorder` for the input ledger, which
+// resumes the chain from its last entry
+func NewRecorder(l *Ledger) (*Recorder, error) {
+	m := l.Manifest()
+
+	chain, err := clock.NewChain(m.Algorithm, m.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if last, ok := l.Last(); ok {
+		if err := chain.Reset(last.Index, last.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Recorder{
+		ledger: l,
+		chain:  chain,
+		events: make(chan []byte, eventBuffer),
+	}, nil
+}
+
+// Record method queues the input event data, to be mixed into the chain on
+// one of the next steps. It blocks while the queue is full, or until the
+// input context is cancelled
+func (r *Recorder) Record(ctx context.Context, data []byte) error {
+	select {
+	case r.events <- data:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// append method appends the chain's current state as an entry, with the input
+// event digest (if any)
+func (r *Recorder) append(event []byte) error {
+	e := Entry{
+		Index:    r.chain.Index(),
+		Hash:     r.chain.Hash(),
+		Event:    string(event),
+		WallTime: time.Now().UTC(),
+	}
+
+	if err := r.ledger.Append(e); err != nil {
+		return err
+	}
+
+	if r.OnEntry != nil {
+		r.OnEntry(e)
+	}
+	return nil
+}
+
+// Run method calculates the chain indefinitely, until the input context is
+// cancelled (returning its error) or an entry fails to be appended
+func (r *Recorder) Run(ctx context.Context) error {
+	interval := r.ledger.Manifest().Interval
+
+	for {
+		select {
+		case data := <-r.events:
+			digest := r.chain.Digest(data)
+			r.chain.Mix(digest)
+
+			if err := r.append(digest); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+
+		r.chain.Next()
+		index := r.chain.Index()
+
+		if index%interval == 0 {
+			if err := r.append(nil); err != nil {
+				return err
+			}
+		}
+
+		if index%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+	}
+}
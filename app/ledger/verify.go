@@ -0,0 +1,278 @@
+package ledger
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// checkInterval is the number of hashes calculated between checks for a
+// cancelled context, while verifying
+const checkInterval int = 1024
+
+// BadRecord struct pinpoints a corrupt or forged ledger record
+type BadRecord struct {
+	// Segment is the name of the segment file
+	Segment string `json:"segment"`
+
+	// Record is the position of the record in the segment, starting at 0
+	Record int `json:"record"`
+
+	// Offset is the position (in bytes) of the record in the segment file
+	Offset int64 `json:"offset"`
+
+	// Index is the record's chain index; or 0 if the record is unreadable
+	Index int `json:"index,omitempty"`
+
+	// Reason describes why the record is invalid
+	Reason string `json:"reason"`
+}
+
+// Report struct is the result of verifying a ledger
+type Report struct {
+	Algorithm string `json:"algorithm"`
+	Seed      string `json:"seed"`
+	Segments  int    `json:"segments"`
+
+	// Entries is the number of entries verified, before the first bad
+	// record (if any)
+	Entries int `json:"entries"`
+
+	// LastIndex is the index of the last verified entry
+	LastIndex int `json:"last_index"`
+
+	Valid bool       `json:"valid"`
+	Bad   *BadRecord `json:"bad,omitempty"`
+}
+
+// segmentResult struct holds the result of verifying a single segment
+type segmentResult struct {
+	entries int
+	last    *Entry
+	bad     *BadRecord
+}
+
+// CheckEntry function checks that the input entry follows the input previous
+// entry (nil for the seed) in the chain: its index is greater, its wall time
+// is not earlier, and its hash is the result of hashing from the previous
+// entry's hash -- mixing in its event digest on the last step, if set.
+//
+// The chain is used to calculate the hashes, and is left at the entry's index
+func CheckEntry(ctx context.Context, chain *clock.Chain, prev, e *Entry) error {
+	prevIndex := 0
+	if prev != nil {
+		prevIndex = prev.Index
+
+		if e.WallTime.Before(prev.WallTime) {
+			return errors.New("wall time is earlier than the previous entry's")
+		}
+	}
+
+	if e.Index <= prevIndex {
+		return fmt.Errorf("index %d does not follow the previous index %d", e.Index, prevIndex)
+	}
+
+	if e.Event != "" {
+		if _, err := hex.DecodeString(e.Event); err != nil {
+			return errors.New("event digest is not hex-encoded")
+		}
+	}
+
+	if prev == nil {
+		chain.Reset(0, "")
+	} else if chain.Index() != prev.Index || chain.Hash() != prev.Hash {
+		if err := chain.Reset(prev.Index, prev.Hash); err != nil {
+			return fmt.Errorf("invalid previous entry: %s", err)
+		}
+	}
+
+	for i := prevIndex + 1; i < e.Index; i++ {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		chain.Next()
+	}
+
+	if e.Event != "" {
+		chain.Mix([]byte(e.Event))
+	} else {
+		chain.Next()
+	}
+
+	if chain.Hash() != e.Hash {
+		return errors.New("hash does not match the chain")
+	}
+	return nil
+}
+
+// verifySegment function verifies all entries in a segment, starting from the
+// input anchor
+func verifySegment(ctx context.Context, m *Manifest, dir, name string, from *Entry) (*segmentResult, error) {
+	scan, err := scanSegment(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := clock.NewChain(m.Algorithm, m.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &segmentResult{last: from}
+
+	for idx, e := range scan.entries {
+		if err := CheckEntry(ctx, chain, res.last, e); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			res.bad = &BadRecord{
+				Segment: name,
+				Record:  idx,
+				Offset:  scan.offsets[idx],
+				Index:   e.Index,
+				Reason:  err.Error(),
+			}
+			return res, nil
+		}
+
+		res.entries++
+		res.last = e
+	}
+
+	if scan.err != nil {
+		res.bad = &BadRecord{
+			Segment: name,
+			Record:  len(scan.entries),
+			Offset:  scan.errOffset,
+			Reason:  "corrupt record: " + scan.err.Error(),
+		}
+	}
+
+	return res, nil
+}
+
+// lastEntry function returns the last readable entry in a segment, to be used
+// as the anchor of the following segment
+func lastEntry(dir, name string) (*Entry, error) {
+	scan, err := scanSegment(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(scan.entries) == 0 {
+		return nil, nil
+	}
+	return scan.entries[len(scan.entries)-1], nil
+}
+
+// Verify function re-checks every entry of the ledger in the input directory
+// against the chain rules (see `CheckEntry`), with up to `workers` segments
+// verified at the same time (the number of CPUs, if zero or below).
+//
+// Each segment is verified from the last entry of the previous segment, which
+// is in turn verified with its own segment. The returned report holds the
+// first bad record in the ledger, if any
+func Verify(ctx context.Context, dir string, workers int) (*Report, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := segments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, workers)
+		results = make([]*segmentResult, len(names))
+
+		once     sync.Once
+		firstErr error
+	)
+
+	// the first error stops the verification
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for idx := range names {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+
+			// find the anchor: the last entry of the closest previous
+			// segment which is not empty
+			var from *Entry
+			for prev := idx - 1; prev >= 0 && from == nil; prev-- {
+				e, err := lastEntry(dir, names[prev])
+				if err != nil {
+					fail(err)
+					return
+				}
+				from = e
+			}
+
+			res, err := verifySegment(ctx, m, dir, names[idx], from)
+			if err != nil {
+				fail(err)
+				return
+			}
+			results[idx] = res
+		}(idx)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	report := &Report{
+		Algorithm: m.Algorithm,
+		Seed:      m.Seed,
+		Segments:  len(names),
+		Valid:     true,
+	}
+
+	for _, res := range results {
+		report.Entries += res.entries
+		if res.last != nil && res.entries > 0 {
+			report.LastIndex = res.last.Index
+		}
+
+		if res.bad != nil {
+			report.Valid = false
+			report.Bad = res.bad
+			break
+		}
+	}
+
+	return report, nil
+}
@@ -18,9 +18,20 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+
 	"github.com/ZalgoNoise/hashclock/cmd"
 )
 
 func main() {
-	cmd.Run()
+	// interrupting the program (Ctrl+C) cancels the running command,
+	// which will exit gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	code := cmd.Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+
+	stop()
+	os.Exit(code)
 }
@@ -0,0 +1,275 @@
+// Package metrics collects the state of running clocks and method calls, and
+// exposes it in the Prometheus text exposition format -- so that dashboards can
+// track a clock's progress and alert when it stalls or its rate drops.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Verification outcomes, used as the `outcome` label
+const (
+	OutcomeMatch    string = "match"
+	OutcomeMismatch string = "mismatch"
+	OutcomeTimeout  string = "timeout"
+)
+
+// rateWindow is the minimum time between two updates of the hashing rate, to
+// smooth it out
+const rateWindow = time.Second
+
+// HashBuckets are the upper bounds of the buckets in the histogram of hashes
+// calculated per method call
+var HashBuckets = []float64{1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9}
+
+// histogram struct is a cumulative histogram with the `HashBuckets` bounds
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(HashBuckets))
+	}
+	for idx, bound := range HashBuckets {
+		if v <= bound {
+			h.counts[idx]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Metrics struct holds the state of a running clock (index, rate and last
+// checkpoint) and of the method calls made to a server
+type Metrics struct {
+	mu sync.Mutex
+
+	// running clock
+	index          int
+	rate           float64
+	lastCheckpoint time.Time
+	windowIndex    int
+	windowStart    time.Time
+
+	hashesTotal   map[string]float64
+	hashes        map[string]*histogram
+	verifications map[string]uint64
+	inFlight      int
+}
+
+// New function creates an empty `Metrics`
+func New() *Metrics {
+	return &Metrics{
+		hashesTotal: map[string]float64{},
+		hashes:      map[string]*histogram{},
+		verifications: map[string]uint64{
+			OutcomeMatch:    0,
+			OutcomeMismatch: 0,
+			OutcomeTimeout:  0,
+		},
+	}
+}
+
+// ProgressFunc method returns a `clock.TickFunc` to be set as a running
+// clock's progress function (with `SetProgressFunc`), tracking its index,
+// hashing rate and total hashes for the input algorithm
+func (m *Metrics) ProgressFunc(alg string) clock.TickFunc {
+	alg = strings.ToUpper(alg)
+
+	return func(t clock.Tick) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if t.Index > m.index {
+			m.hashesTotal[alg] += float64(t.Index - m.index)
+		}
+		m.index = t.Index
+
+		if m.windowStart.IsZero() {
+			m.windowStart = t.WallTime
+			m.windowIndex = t.Index
+			return
+		}
+
+		if elapsed := t.WallTime.Sub(m.windowStart); elapsed >= rateWindow {
+			m.rate = float64(t.Index-m.windowIndex) / elapsed.Seconds()
+			m.windowStart = t.WallTime
+			m.windowIndex = t.Index
+		}
+	}
+}
+
+// CheckpointFunc method returns a `clock.TickFunc` to be set as a running
+// clock's tick function (with `SetTickFunc`), tracking the time of its last
+// checkpoint (logged hash)
+func (m *Metrics) CheckpointFunc() clock.TickFunc {
+	return func(t clock.Tick) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.lastCheckpoint = t.WallTime
+	}
+}
+
+// Start method marks a method call as in-flight; returning the function to
+// call once it is done
+func (m *Metrics) Start() func() {
+	m.mu.Lock()
+	m.inFlight++
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}
+}
+
+// Outcome function returns the outcome of a verification: a match, a
+// mismatch, or a timeout -- when the timer ran out before the hash (or its
+// index) was reached
+func Outcome(r *api.Request, res *clock.HashClockResponse) string {
+	switch {
+	case res.Match:
+		return OutcomeMatch
+	case r.Timeout > 0 && (r.Iterations == 0 || res.Iterations < r.Iterations):
+		return OutcomeTimeout
+	default:
+		return OutcomeMismatch
+	}
+}
+
+// Observe method records a finished method call: the number of hashes it
+// calculated (from its checkpoint, if set) and, for verifications, its
+// outcome. Failed calls are not recorded
+func (m *Metrics) Observe(r *api.Request, res *clock.HashClockResponse, err error) {
+	if err != nil || res == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.hashes[res.Algorithm]
+	if !ok {
+		h = &histogram{}
+		m.hashes[res.Algorithm] = h
+	}
+	hashes := res.Iterations
+	if res.Checkpoint != nil {
+		hashes -= res.Checkpoint.Index
+	}
+	h.observe(float64(hashes))
+	m.hashesTotal[res.Algorithm] += float64(hashes)
+
+	if strings.HasPrefix(r.Method, "Verify") {
+		m.verifications[Outcome(r, res)]++
+	}
+}
+
+// formatFloat function formats a sample value as expected by Prometheus
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys function returns the keys of the input map, sorted
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case map[string]float64:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]uint64:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]*histogram:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// header function writes the HELP and TYPE lines of a metric
+func header(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// WriteTo method writes all metrics to the input writer, in the Prometheus
+// text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sb := &strings.Builder{}
+
+	header(sb, "hashclock_current_index", "gauge", "Index of the last hash reported by the running clock.")
+	fmt.Fprintf(sb, "hashclock_current_index %d\n", m.index)
+
+	// a stalled clock stops updating its rate, which then decays to zero
+	rate := m.rate
+	if !m.windowStart.IsZero() {
+		if elapsed := time.Since(m.windowStart); elapsed >= 2*rateWindow {
+			rate = float64(m.index-m.windowIndex) / elapsed.Seconds()
+		}
+	}
+
+	header(sb, "hashclock_hashes_per_second", "gauge", "Hashing rate of the running clock.")
+	fmt.Fprintf(sb, "hashclock_hashes_per_second %s\n", formatFloat(rate))
+
+	header(sb, "hashclock_last_checkpoint_timestamp_seconds", "gauge", "Unix time of the last checkpoint (logged hash) of the running clock.")
+	var last float64
+	if !m.lastCheckpoint.IsZero() {
+		last = float64(m.lastCheckpoint.UnixNano()) / 1e9
+	}
+	fmt.Fprintf(sb, "hashclock_last_checkpoint_timestamp_seconds %s\n", formatFloat(last))
+
+	header(sb, "hashclock_hashes_total", "counter", "Total hashes calculated, by algorithm.")
+	for _, alg := range sortedKeys(m.hashesTotal) {
+		fmt.Fprintf(sb, "hashclock_hashes_total{algorithm=%q} %s\n", alg, formatFloat(m.hashesTotal[alg]))
+	}
+
+	header(sb, "hashclock_call_hashes", "histogram", "Hashes calculated per method call, by algorithm.")
+	for _, alg := range sortedKeys(m.hashes) {
+		h := m.hashes[alg]
+		for idx, bound := range HashBuckets {
+			fmt.Fprintf(sb, "hashclock_call_hashes_bucket{algorithm=%q,le=%q} %d\n", alg, formatFloat(bound), h.counts[idx])
+		}
+		fmt.Fprintf(sb, "hashclock_call_hashes_bucket{algorithm=%q,le=\"+Inf\"} %d\n", alg, h.count)
+		fmt.Fprintf(sb, "hashclock_call_hashes_sum{algorithm=%q} %s\n", alg, formatFloat(h.sum))
+		fmt.Fprintf(sb, "hashclock_call_hashes_count{algorithm=%q} %d\n", alg, h.count)
+	}
+
+	header(sb, "hashclock_verifications_total", "counter", "Verifications, by outcome.")
+	for _, outcome := range sortedKeys(m.verifications) {
+		fmt.Fprintf(sb, "hashclock_verifications_total{outcome=%q} %d\n", outcome, m.verifications[outcome])
+	}
+
+	header(sb, "hashclock_jobs_in_flight", "gauge", "Method calls currently running.")
+	fmt.Fprintf(sb, "hashclock_jobs_in_flight %d\n", m.inFlight)
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP method serves the metrics, as the `/metrics` endpoint
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		req     *api.Request
+		res     *clock.HashClockResponse
+		outcome string
+	}{
+		{
+			req:     &api.Request{Method: "Verify"},
+			res:     &clock.HashClockResponse{Iterations: 10, Match: true},
+			outcome: OutcomeMatch,
+		}, {
+			req:     &api.Request{Method: "VerifyIndex", Iterations: 10},
+			res:     &clock.HashClockResponse{Iterations: 10},
+			outcome: OutcomeMismatch,
+		}, {
+			req:     &api.Request{Method: "VerifyTimeout", Timeout: 1},
+			res:     &clock.HashClockResponse{Iterations: 5000},
+			outcome: OutcomeTimeout,
+		}, {
+			req:     &api.Request{Method: "VerifyIndexTimeout", Iterations: 10, Timeout: 1},
+			res:     &clock.HashClockResponse{Iterations: 10},
+			outcome: OutcomeMismatch,
+		}, {
+			req:     &api.Request{Method: "VerifyIndexTimeout", Iterations: 1e9, Timeout: 1},
+			res:     &clock.HashClockResponse{Iterations: 5000},
+			outcome: OutcomeTimeout,
+		},
+	}
+
+	for id, test := range tests {
+		if outcome := Outcome(test.req, test.res); outcome != test.outcome {
+			t.Errorf("#%v Outcome(%s) = %s ; expected %s", id, test.req.Method, outcome, test.outcome)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	m := New()
+
+	start := time.Now()
+	progress := m.ProgressFunc("sha256")
+	progress(clock.Tick{Index: 1024, WallTime: start})
+	progress(clock.Tick{Index: 3072, WallTime: start.Add(2 * time.Second)})
+
+	m.CheckpointFunc()(clock.Tick{Index: 3000, WallTime: time.Unix(1700000000, 0)})
+
+	m.Observe(&api.Request{Method: "RecHash", Iterations: 500}, &clock.HashClockResponse{Algorithm: "SHA256", Iterations: 500}, nil)
+	m.Observe(&api.Request{Method: "Verify"}, &clock.HashClockResponse{Algorithm: "SHA1", Iterations: 20, Match: true}, nil)
+	m.Observe(&api.Request{Method: "Verify"}, nil, context.Canceled)
+
+	// a call continued from a checkpoint only calculated the hashes after it
+	m.Observe(&api.Request{Method: "VerifyIndex"}, &clock.HashClockResponse{
+		Algorithm:  "SHA1",
+		Iterations: 120,
+		Checkpoint: &clock.Checkpoint{Index: 100, Hash: "00"},
+	}, nil)
+
+	done := m.Start()
+	m.Start()
+	done()
+
+	sb := &strings.Builder{}
+	if _, err := m.WriteTo(sb); err != nil {
+		t.Fatalf("WriteTo() resulted in an unexpected error: %s", err)
+	}
+	out := sb.String()
+
+	for _, line := range []string{
+		"hashclock_current_index 3072\n",
+		"hashclock_hashes_per_second 1024\n",
+		"hashclock_last_checkpoint_timestamp_seconds 1.7e+09\n",
+		`hashclock_hashes_total{algorithm="SHA256"} 3572` + "\n",
+		`hashclock_hashes_total{algorithm="SHA1"} 40` + "\n",
+		`hashclock_call_hashes_sum{algorithm="SHA1"} 40` + "\n",
+		`hashclock_call_hashes_bucket{algorithm="SHA256",le="100"} 0` + "\n",
+		`hashclock_call_hashes_bucket{algorithm="SHA256",le="1000"} 1` + "\n",
+		`hashclock_call_hashes_bucket{algorithm="SHA256",le="+Inf"} 1` + "\n",
+		`hashclock_call_hashes_sum{algorithm="SHA256"} 500` + "\n",
+		`hashclock_verifications_total{outcome="match"} 1` + "\n",
+		`hashclock_verifications_total{outcome="timeout"} 0` + "\n",
+		"hashclock_jobs_in_flight 1\n",
+		"# TYPE hashclock_call_hashes histogram\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("WriteTo() output is missing %q:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package poh structures a hash chain like Solana's Proof-of-History: hashes
+// are grouped into ticks of a fixed number of hashes, and ticks into slots.
+//
+// Unlike the `clock` package (which hashes the hex-encoded previous hash), the
+// chain hashes the raw binary digest of the previous hash; and data is mixed
+// in by hashing the previous digest concatenated with the data's digest (its
+// mixin). The chain is recorded as a stream of entries in the format Solana
+// uses: the number of hashes since the previous entry, the resulting hash and
+// the mixins recorded at its last step. An entry without mixins is a tick.
+package poh
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+)
+
+const (
+	// DefaultHashesPerTick is the default number of hashes per tick, as in
+	// Solana's genesis configuration
+	DefaultHashesPerTick uint64 = 12500
+
+	// DefaultTicksPerSlot is the default number of ticks per slot, as in
+	// Solana's genesis configuration
+	DefaultTicksPerSlot uint64 = 64
+)
+
+var (
+	// ErrTickDue error is returned when recording a mixin on the last hash of
+	// a tick, which is reserved for the tick itself
+	ErrTickDue = errors.New("tick is due; cannot record a mixin on the tick's last hash")
+
+	// ErrTickNotDue error is returned when ticking before the tick's last
+	// hash
+	ErrTickNotDue = errors.New("tick is not due")
+)
+
+// hashFuncs maps each algorithm (as named in `clock.HasherMapVals`) to its
+// hash function
+var hashFuncs = map[string]func() hash.Hash{
+	"MD5":        md5.New,
+	"SHA1":       sha1.New,
+	"SHA224":     sha256.New224,
+	"SHA256":     sha256.New,
+	"SHA384":     sha512.New384,
+	"SHA512":     sha512.New,
+	"SHA512_224": sha512.New512_224,
+	"SHA512_256": sha512.New512_256,
+}
+
+// Hasher struct calculates raw binary digests with one of the supported hash
+// functions
+type Hasher struct {
+	algorithm string
+	h         hash.Hash
+	sum       []byte
+}
+
+// NewHasher function creates a `Hasher` for the input algorithm (lower-case or
+// upper-case); SHA256 if empty
+func NewHasher(alg string) (*Hasher, error) {
+	if alg == "" {
+		alg = "SHA256"
+	}
+	alg = strings.ToUpper(alg)
+
+	fn, ok := hashFuncs[alg]
+	if !ok {
+		return nil, errors.New("invalid hasher reference")
+	}
+
+	return &Hasher{algorithm: alg, h: fn()}, nil
+}
+
+// Algorithm method returns the name of the hash function
+func (h *Hasher) Algorithm() string {
+	return h.algorithm
+}
+
+// Size method returns the size of a digest, in bytes
+func (h *Hasher) Size() int {
+	return h.h.Size()
+}
+
+// Hash method returns the digest of the concatenation of the inputs. The
+// returned slice is reused by the next call
+func (h *Hasher) Hash(data ...[]byte) []byte {
+	h.h.Reset()
+	for _, d := range data {
+		h.h.Write(d)
+	}
+	h.sum = h.h.Sum(h.sum[:0])
+	return h.sum
+}
+
+// MixinRoot method returns the digest which is mixed into the chain for the
+// input mixins: the root of their Merkle tree, as Solana calculates it for an
+// entry's transactions. Leaves are hashed with a 0x00 prefix and inner nodes
+// with a 0x01 prefix; a level's odd node is paired with itself
+func (h *Hasher) MixinRoot(mixins [][]byte) []byte {
+	if len(mixins) == 0 {
+		return make([]byte, h.Size())
+	}
+
+	level := make([][]byte, len(mixins))
+	for idx, m := range mixins {
+		level[idx] = append([]byte(nil), h.Hash([]byte{0}, m)...)
+	}
+
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for idx := 0; idx < len(level); idx += 2 {
+			right := level[idx]
+			if idx+1 < len(level) {
+				right = level[idx+1]
+			}
+			next = append(next, append([]byte(nil), h.Hash([]byte{1}, level[idx], right)...))
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// Entry struct is a single entry of the chain, as in Solana's ledger: the
+// number of hashes since the previous entry, the resulting (hex-encoded)
+// hash, and the (hex-encoded) mixins recorded at its last step -- which are
+// empty for a tick
+type Entry struct {
+	NumHashes uint64   `json:"num_hashes"`
+	Hash      string   `json:"hash"`
+	Mixins    []string `json:"mixins"`
+}
+
+// IsTick method returns true if the entry is a tick
+func (e *Entry) IsTick() bool {
+	return len(e.Mixins) == 0
+}
+
+// Config struct defines the structure of the chain
+type Config struct {
+	Algorithm string
+
+	// HashesPerTick is the number of hashes in each tick (including any
+	// mixins recorded in it); `DefaultHashesPerTick` by default
+	HashesPerTick uint64
+
+	// TicksPerSlot is the number of ticks in each slot;
+	// `DefaultTicksPerSlot` by default
+	TicksPerSlot uint64
+}
+
+// PoH struct generates the chain, one hash at a time
+type PoH struct {
+	hasher *Hasher
+	cfg    Config
+
+	hash       []byte
+	numHashes  uint64 // since the last entry
+	remaining  uint64 // until the next tick, including it
+	tickHeight uint64
+}
+
+// New function creates a `PoH` which starts from the input (raw) hash
+func New(start []byte, cfg *Config) (*PoH, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.HashesPerTick == 0 {
+		c.HashesPerTick = DefaultHashesPerTick
+	}
+	if c.TicksPerSlot == 0 {
+		c.TicksPerSlot = DefaultTicksPerSlot
+	}
+
+	h, err := NewHasher(c.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	c.Algorithm = h.Algorithm()
+
+	if len(start) != h.Size() {
+		return nil, errors.New("start hash length does not match the hash function's digest size")
+	}
+
+	return &PoH{
+		hasher:    h,
+		cfg:       c,
+		hash:      append([]byte(nil), start...),
+		remaining: c.HashesPerTick,
+	}, nil
+}
+
+// Config method returns the chain's configuration
+func (p *PoH) Config() Config {
+	return p.cfg
+}
+
+// Hash method calculates up to `max` hashes, stopping before the tick's last
+// hash; returning true if a tick is due
+func (p *PoH) Hash(max uint64) bool {
+	n := p.remaining - 1
+	if max < n {
+		n = max
+	}
+
+	for i := uint64(0); i < n; i++ {
+		p.hash = append(p.hash[:0], p.hasher.Hash(p.hash)...)
+	}
+	p.numHashes += n
+	p.remaining -= n
+
+	return p.remaining == 1
+}
+
+// Record method mixes the input mixins (raw digests; at least one) into the
+// next hash, returning the resulting entry. It fails with `ErrTickDue` if the next hash
+// is a tick's last
+func (p *PoH) Record(mixins ...[]byte) (*Entry, error) {
+	if len(mixins) == 0 {
+		return nil, errors.New("at least one mixin is required")
+	}
+	if p.remaining == 1 {
+		return nil, ErrTickDue
+	}
+
+	root := p.hasher.MixinRoot(mixins)
+	p.hash = append(p.hash[:0], p.hasher.Hash(p.hash, root)...)
+	p.numHashes++
+	p.remaining--
+
+	e := &Entry{
+		NumHashes: p.numHashes,
+		Hash:      hex.EncodeToString(p.hash),
+		Mixins:    make([]string, len(mixins)),
+	}
+	for idx, m := range mixins {
+		e.Mixins[idx] = hex.EncodeToString(m)
+	}
+
+	p.numHashes = 0
+	return e, nil
+}
+
+// Tick method calculates the tick's last hash, returning the tick entry. It
+// fails with `ErrTickNotDue` if `Hash` has not reached it yet
+func (p *PoH) Tick() (*Entry, error) {
+	if p.remaining != 1 {
+		return nil, ErrTickNotDue
+	}
+
+	p.hash = append(p.hash[:0], p.hasher.Hash(p.hash)...)
+
+	e := &Entry{
+		NumHashes: p.numHashes + 1,
+		Hash:      hex.EncodeToString(p.hash),
+		Mixins:    []string{},
+	}
+
+	p.numHashes = 0
+	p.remaining = p.cfg.HashesPerTick
+	p.tickHeight++
+
+	return e, nil
+}
+
+// TickHeight method returns the number of ticks so far
+func (p *PoH) TickHeight() uint64 {
+	return p.tickHeight
+}
+
+// Slot method returns the current slot: the number of complete slots so far
+func (p *PoH) Slot() uint64 {
+	return p.tickHeight / p.cfg.TicksPerSlot
+}
+
+// Digest method returns the raw digest of the input data, with the chain's
+// hash function -- to be recorded as a mixin. Unlike the other methods, it is
+// safe to call while the chain runs
+func (p *PoH) Digest(data []byte) []byte {
+	h, _ := NewHasher(p.cfg.Algorithm)
+	return append([]byte(nil), h.Hash(data)...)
+}
+
+// StartHash function returns the start hash of a chain from the input seed:
+// its raw digest with the input algorithm
+func StartHash(alg, seed string) ([]byte, error) {
+	h, err := NewHasher(alg)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), h.Hash([]byte(seed))...), nil
+}
+
+// EntryFunc type describes a function called with each entry generated by
+// `Run`; returning an error stops it
+type EntryFunc func(e *Entry) error
+
+// Run method generates the chain until the input context is cancelled
+// (returning its error), calling the input function with each entry. Mixins
+// received from the input channel are recorded as soon as possible, each
+// batch in its own entry.
+//
+// If `slots` is set, it returns once the chain reaches that slot
+func (p *PoH) Run(ctx context.Context, mixins <-chan [][]byte, slots uint64, fn EntryFunc) error {
+	done := func() bool {
+		return slots > 0 && p.Slot() >= slots
+	}
+
+	tick := func() error {
+		e, err := p.Tick()
+		if err != nil {
+			return err
+		}
+		return fn(e)
+	}
+
+	for !done() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		select {
+		case m := <-mixins:
+			// the tick's last hash cannot be mixed
+			if p.remaining == 1 {
+				if err := tick(); err != nil {
+					return err
+				}
+				if done() {
+					return nil
+				}
+			}
+
+			e, err := p.Record(m...)
+			if err != nil {
+				return err
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+
+		if p.Hash(checkInterval) {
+			if err := tick(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package poh
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func sha(data ...[]byte) []byte {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func TestPoH(t *testing.T) {
+	start, _ := StartHash("sha256", "genesis_string")
+	if want := sha([]byte("genesis_string")); hex.EncodeToString(start) != hex.EncodeToString(want) {
+		t.Fatalf("FAILED -- [PoH] start hash mismatch: wanted %x ; got %x", want, start)
+	}
+
+	p, err := New(start, &Config{HashesPerTick: 4, TicksPerSlot: 2})
+	if err != nil {
+		t.Fatalf("FAILED -- [PoH] New() failed: %s", err)
+	}
+
+	if _, err := p.Tick(); err != ErrTickNotDue {
+		t.Errorf("FAILED -- [PoH] Tick() before it is due: wanted %v ; got %v", ErrTickNotDue, err)
+	}
+
+	// the chain hashes raw digests
+	want := start
+	for i := 0; i < 4; i++ {
+		want = sha(want)
+	}
+
+	if !p.Hash(10) {
+		t.Errorf("FAILED -- [PoH] Hash() should stop when a tick is due")
+	}
+	if _, err := p.Record([]byte("x")); err != ErrTickDue {
+		t.Errorf("FAILED -- [PoH] Record() on the tick's last hash: wanted %v ; got %v", ErrTickDue, err)
+	}
+
+	tick, err := p.Tick()
+	if err != nil {
+		t.Fatalf("FAILED -- [PoH] Tick() failed: %s", err)
+	}
+	if tick.NumHashes != 4 || tick.Hash != hex.EncodeToString(want) || !tick.IsTick() {
+		t.Errorf("FAILED -- [PoH] unexpected tick: %+v ; wanted hash %x", tick, want)
+	}
+
+	// a mixin is hashed with the previous digest, as a Merkle leaf
+	mixin := sha([]byte("event"))
+	p.Hash(1)
+	e, err := p.Record(mixin)
+	if err != nil {
+		t.Fatalf("FAILED -- [PoH] Record() failed: %s", err)
+	}
+
+	want = sha(sha(want), sha([]byte{0}, mixin))
+	if e.NumHashes != 2 || e.Hash != hex.EncodeToString(want) || e.Mixins[0] != hex.EncodeToString(mixin) {
+		t.Errorf("FAILED -- [PoH] unexpected entry: %+v ; wanted hash %x", e, want)
+	}
+
+	// the tick counts the hashes since the last entry
+	p.Hash(10)
+	tick, _ = p.Tick()
+	if tick.NumHashes != 2 || p.TickHeight() != 2 || p.Slot() != 1 {
+		t.Errorf("FAILED -- [PoH] unexpected tick: %+v at height %v", tick, p.TickHeight())
+	}
+}
+
+func TestMixinRoot(t *testing.T) {
+	h, _ := NewHasher("sha256")
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	la, lb, lc := sha([]byte{0}, a), sha([]byte{0}, b), sha([]byte{0}, c)
+	want := sha([]byte{1}, sha([]byte{1}, la, lb), sha([]byte{1}, lc, lc))
+
+	if root := h.MixinRoot([][]byte{a, b, c}); hex.EncodeToString(root) != hex.EncodeToString(want) {
+		t.Errorf("FAILED -- [PoH] Merkle root mismatch: wanted %x ; got %x", want, root)
+	}
+}
+
+// generate function runs a chain for the input number of slots, recording
+// the input mixins
+func generate(t *testing.T, start []byte, cfg *Config, slots uint64, mixins ...[]byte) []Entry {
+	t.Helper()
+
+	p, err := New(start, cfg)
+	if err != nil {
+		t.Fatalf("FAILED -- [PoH] New() failed: %s", err)
+	}
+
+	ch := make(chan [][]byte, len(mixins))
+	for _, m := range mixins {
+		ch <- [][]byte{m}
+	}
+
+	var entries []Entry
+	err = p.Run(context.Background(), ch, slots, func(e *Entry) error {
+		entries = append(entries, *e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [PoH] Run() failed: %s", err)
+	}
+	return entries
+}
+
+func TestVerifier(t *testing.T) {
+	start, _ := StartHash("sha256", "genesis_string")
+	cfg := &Config{HashesPerTick: 100, TicksPerSlot: 2}
+
+	entries := generate(t, start, cfg, 2, sha([]byte("a")), sha([]byte("b")))
+	if len(entries) != 6 {
+		t.Fatalf("FAILED -- [PoH] expected 4 ticks and 2 entries ; got %v entries", len(entries))
+	}
+
+	v, _ := NewVerifier("sha256", start, 100, 2)
+	for _, batch := range [][]Entry{entries[:3], entries[3:]} {
+		if err := v.Verify(context.Background(), batch); err != nil {
+			t.Fatalf("FAILED -- [PoH] Verify() failed: %s", err)
+		}
+	}
+	if v.Entries() != 6 || v.Ticks() != 4 || v.Hashes() != 400 || v.LastHash() != entries[5].Hash {
+		t.Errorf("FAILED -- [PoH] unexpected verifier state: %v entries, %v ticks, %v hashes", v.Entries(), v.Ticks(), v.Hashes())
+	}
+
+	tests := []struct {
+		name     string
+		tamper   func(e []Entry)
+		position uint64
+	}{
+		{
+			name:     "forged hash",
+			tamper:   func(e []Entry) { e[4].Hash = e[3].Hash },
+			position: 4,
+		}, {
+			name:     "forged mixin",
+			tamper:   func(e []Entry) { e[0].Mixins[0] = hex.EncodeToString(sha([]byte("c"))) },
+			position: 0,
+		}, {
+			name: "tick spacing",
+			tamper: func(e []Entry) {
+				e[5].NumHashes++
+			},
+			position: 5,
+		},
+	}
+
+	for _, test := range tests {
+		tampered := make([]Entry, len(entries))
+		for idx, e := range entries {
+			tampered[idx] = e
+			tampered[idx].Mixins = append([]string{}, e.Mixins...)
+		}
+		test.tamper(tampered)
+
+		v, _ := NewVerifier("sha256", start, 100, 2)
+		err := v.Verify(context.Background(), tampered)
+
+		var eErr *EntryError
+		if !errors.As(err, &eErr) || eErr.Position != test.position {
+			t.Errorf("FAILED -- [PoH] %s: expected an invalid entry #%v ; got %v", test.name, test.position, err)
+		}
+	}
+}
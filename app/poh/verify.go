@@ -0,0 +1,249 @@
+package poh
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// checkInterval is the number of hashes calculated between checks for a
+// cancelled context
+const checkInterval uint64 = 1024
+
+// EntryError struct is returned when an entry fails verification
+type EntryError struct {
+	// Position is the entry's position in the stream, starting at 0
+	Position uint64
+
+	Reason string
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("invalid entry #%d: %s", e.Position, e.Reason)
+}
+
+// Verifier struct verifies a stream of entries, in batches. Within a batch,
+// entries are verified in parallel -- as each entry starts from the previous
+// entry's hash
+type Verifier struct {
+	algorithm     string
+	hashesPerTick uint64
+	workers       int
+
+	last       []byte
+	entries    uint64
+	ticks      uint64
+	hashes     uint64
+	tickHashes uint64 // since the last tick
+}
+
+// NewVerifier function creates a `Verifier` of a chain with the input
+// algorithm and (raw) start hash, with up to `workers` entries verified at the
+// same time (the number of CPUs, if zero or below). If `hashesPerTick` is
+// set, the ticks' spacing is checked too
+func NewVerifier(alg string, start []byte, hashesPerTick uint64, workers int) (*Verifier, error) {
+	h, err := NewHasher(alg)
+	if err != nil {
+		return nil, err
+	}
+	if len(start) != h.Size() {
+		return nil, errors.New("start hash length does not match the hash function's digest size")
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Verifier{
+		algorithm:     h.Algorithm(),
+		hashesPerTick: hashesPerTick,
+		workers:       workers,
+		last:          append([]byte(nil), start...),
+	}, nil
+}
+
+// decoded struct holds an entry's decoded hash and mixins
+type decoded struct {
+	hash   []byte
+	mixins [][]byte
+}
+
+// check method checks an entry's structure, before its hash is verified
+func (v *Verifier) check(e *Entry, size int) (*decoded, error) {
+	if e.NumHashes == 0 {
+		return nil, errors.New("num_hashes must be greater than zero")
+	}
+
+	d := &decoded{}
+
+	var err error
+	if d.hash, err = hex.DecodeString(e.Hash); err != nil || len(d.hash) != size {
+		return nil, errors.New("hash is not a hex-encoded digest")
+	}
+
+	for _, m := range e.Mixins {
+		b, err := hex.DecodeString(m)
+		if err != nil {
+			return nil, errors.New("mixin is not hex-encoded")
+		}
+		d.mixins = append(d.mixins, b)
+	}
+
+	if v.hashesPerTick == 0 {
+		return d, nil
+	}
+
+	hashes := v.tickHashes + e.NumHashes
+	switch {
+	case e.IsTick() && hashes != v.hashesPerTick:
+		return nil, fmt.Errorf("tick after %d hashes; expected %d", hashes, v.hashesPerTick)
+	case !e.IsTick() && hashes >= v.hashesPerTick:
+		return nil, fmt.Errorf("entry after %d hashes crosses the tick at %d hashes", hashes, v.hashesPerTick)
+	}
+	return d, nil
+}
+
+// verifyHash function checks that hashing from the input start hash results
+// in the entry's hash
+func verifyHash(ctx context.Context, h *Hasher, start []byte, e *Entry, d *decoded) error {
+	cur := append([]byte(nil), start...)
+
+	for i := uint64(1); i < e.NumHashes; i++ {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		cur = append(cur[:0], h.Hash(cur)...)
+	}
+
+	if len(d.mixins) > 0 {
+		cur = append(cur[:0], h.Hash(cur, h.MixinRoot(d.mixins))...)
+	} else {
+		cur = append(cur[:0], h.Hash(cur)...)
+	}
+
+	if !bytes.Equal(cur, d.hash) {
+		return errors.New("hash does not match the chain")
+	}
+	return nil
+}
+
+// Verify method verifies the input batch of entries, which follow the ones
+// verified so far. It returns an `EntryError` for the first invalid entry;
+// the entries before it are still counted as verified, but the verifier must
+// not be used afterwards
+func (v *Verifier) Verify(ctx context.Context, entries []Entry) error {
+	h, _ := NewHasher(v.algorithm)
+
+	// structure checks are sequential, as they depend on the previous
+	// entries; hashes are only verified up to the first malformed entry
+	var (
+		decodedEntries = make([]*decoded, 0, len(entries))
+		starts         = make([][]byte, 0, len(entries))
+		firstErr       error
+	)
+
+	prev := v.last
+	for idx := range entries {
+		d, err := v.check(&entries[idx], h.Size())
+		if err != nil {
+			firstErr = &EntryError{Position: v.entries + uint64(idx), Reason: err.Error()}
+			break
+		}
+
+		decodedEntries = append(decodedEntries, d)
+		starts = append(starts, prev)
+		prev = d.hash
+
+		if entries[idx].IsTick() {
+			v.tickHashes = 0
+		} else {
+			v.tickHashes += entries[idx].NumHashes
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		next = make(chan int)
+		errs = make([]error, len(decodedEntries))
+	)
+
+	for w := 0; w < v.workers && w < len(decodedEntries); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			h, _ := NewHasher(v.algorithm)
+			for idx := range next {
+				errs[idx] = verifyHash(ctx, h, starts[idx], &entries[idx], decodedEntries[idx])
+			}
+		}()
+	}
+
+	for idx := range decodedEntries {
+		select {
+		case next <- idx:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	valid := len(decodedEntries)
+	for idx, err := range errs {
+		if err != nil {
+			valid = idx
+			firstErr = &EntryError{Position: v.entries + uint64(idx), Reason: err.Error()}
+			break
+		}
+	}
+
+	for idx := 0; idx < valid; idx++ {
+		v.hashes += entries[idx].NumHashes
+		if entries[idx].IsTick() {
+			v.ticks++
+		}
+		v.last = decodedEntries[idx].hash
+	}
+	v.entries += uint64(valid)
+
+	return firstErr
+}
+
+// Algorithm method returns the name of the chain's hash function
+func (v *Verifier) Algorithm() string {
+	return v.algorithm
+}
+
+// Entries method returns the number of entries verified so far
+func (v *Verifier) Entries() uint64 {
+	return v.entries
+}
+
+// Ticks method returns the number of ticks verified so far
+func (v *Verifier) Ticks() uint64 {
+	return v.ticks
+}
+
+// Hashes method returns the number of hashes verified so far
+func (v *Verifier) Hashes() uint64 {
+	return v.hashes
+}
+
+// LastHash method returns the (hex-encoded) hash of the last verified entry;
+// or the start hash
+func (v *Verifier) LastHash() string {
+	return hex.EncodeToString(v.last)
+}
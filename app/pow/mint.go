@@ -0,0 +1,161 @@
+package pow
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// DefaultBits is the default difficulty of minted stamps, as in hashcash
+	DefaultBits int = 20
+
+	// randSize is the number of random bytes in a minted stamp's rand field
+	randSize int = 12
+
+	// checkInterval is the number of counters each worker tries between
+	// checks for a found stamp or a cancelled context
+	checkInterval uint64 = 1024
+)
+
+// Minter struct mints stamps of a fixed hash function and difficulty, trying
+// counters on several workers at once
+type Minter struct {
+	algorithm string
+	bits      int
+	workers   int
+
+	// now returns the minted stamps' date; the current time by default
+	now func() time.Time
+}
+
+// NewMinter function creates a `Minter` for the input algorithm (as named in
+// `clock.HasherMapVals`, lower-case or upper-case) and number of leading zero
+// bits, with up to `workers` counters tried at the same time (the number of
+// CPUs, if zero or below)
+func NewMinter(alg string, bits, workers int) (*Minter, error) {
+	e, err := clock.NewEngine(alg, clock.ModeHex)
+	if err != nil {
+		return nil, err
+	}
+	if max := e.Size() * 4; bits <= 0 || bits > max {
+		return nil, fmt.Errorf("number of bits must be between 1 and %d", max)
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Minter{
+		algorithm: strings.ToUpper(alg),
+		bits:      bits,
+		workers:   workers,
+		now:       time.Now,
+	}, nil
+}
+
+// Algorithm method returns the name of the minter's hash function
+func (m *Minter) Algorithm() string {
+	return m.algorithm
+}
+
+// Bits method returns the difficulty of the minted stamps
+func (m *Minter) Bits() int {
+	return m.bits
+}
+
+// Mint method mints a stamp for the input resource, which cannot contain a
+// colon: it tries counters until the stamp's digest has the minter's number
+// of leading zero bits -- about 2^bits hashes, spread across the workers. If
+// the input context is cancelled first, its error is returned
+func (m *Minter) Mint(ctx context.Context, resource string) (*Stamp, error) {
+	if resource == "" || strings.Contains(resource, ":") {
+		return nil, errors.New("resource cannot be empty or contain a colon")
+	}
+
+	r := make([]byte, randSize)
+	if _, err := rand.Read(r); err != nil {
+		return nil, err
+	}
+
+	stamp := &Stamp{
+		Version:  Version,
+		Bits:     m.bits,
+		Date:     m.now().UTC().Format(dateFormat),
+		Resource: resource,
+		Rand:     base64.RawStdEncoding.EncodeToString(r),
+	}
+	if m.algorithm != DefaultAlgorithm {
+		stamp.Ext = algExt + "=" + m.algorithm
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		prefix = []byte(stamp.String()) // with an empty counter
+		found  = make(chan string, m.workers)
+		wg     sync.WaitGroup
+	)
+
+	for w := 0; w < m.workers; w++ {
+		wg.Add(1)
+		go func(first uint64) {
+			defer wg.Done()
+			if counter, ok := m.search(ctx, prefix, first, uint64(m.workers)); ok {
+				found <- counter
+				cancel()
+			}
+		}(uint64(w))
+	}
+	wg.Wait()
+
+	select {
+	case stamp.Counter = <-found:
+		return stamp, nil
+	default:
+		return nil, ctx.Err()
+	}
+}
+
+// search method tries the counters from `first`, every `step`, appended to
+// the input stamp prefix; returning the (encoded) first one whose digest has
+// the minter's number of leading zero bits. It stops once the context is done
+func (m *Minter) search(ctx context.Context, prefix []byte, first, step uint64) (string, bool) {
+	e, _ := clock.NewEngine(m.algorithm, clock.ModeHex)
+
+	var (
+		ctr [8]byte
+		buf = make([]byte, len(prefix), len(prefix)+base64.RawStdEncoding.EncodedLen(len(ctr)))
+	)
+	copy(buf, prefix)
+
+	for n, counter := uint64(0), first; ; n, counter = n+1, counter+step {
+		if n%checkInterval == 0 && ctx.Err() != nil {
+			return "", false
+		}
+
+		// the counter is encoded in base64, without its leading zero bytes
+		binary.BigEndian.PutUint64(ctr[:], counter)
+		skip := 0
+		for skip < len(ctr)-1 && ctr[skip] == 0 {
+			skip++
+		}
+
+		enc := buf[len(prefix) : len(prefix)+base64.RawStdEncoding.EncodedLen(len(ctr)-skip)]
+		base64.RawStdEncoding.Encode(enc, ctr[skip:])
+
+		if ZeroBits(e.Hash(buf[:len(prefix)+len(enc)])) >= m.bits {
+			return string(enc), true
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package pow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testResource string = "user@example.com"
+
+// mint function mints a stamp for the test resource, failing the test on
+// errors
+func mint(t *testing.T, alg string, bits int) *Stamp {
+	t.Helper()
+
+	m, err := NewMinter(alg, bits, 0)
+	if err != nil {
+		t.Fatalf("FAILED -- [PoW] NewMinter(%s, %d) failed: %s", alg, bits, err)
+	}
+
+	s, err := m.Mint(context.Background(), testResource)
+	if err != nil {
+		t.Fatalf("FAILED -- [PoW] Mint() failed: %s", err)
+	}
+	return s
+}
+
+func TestMintVerify(t *testing.T) {
+	for _, alg := range []string{"sha1", "SHA256", "sha512_256", "md5"} {
+		s := mint(t, alg, 12)
+
+		h, err := s.Hash()
+		if err != nil {
+			t.Fatalf("FAILED -- [PoW] Hash() failed: %s", err)
+		}
+		if n := ZeroBits([]byte(h)); n < 12 {
+			t.Errorf("FAILED -- [PoW] %s stamp %s has %d leading zero bits; wanted at least 12", alg, s, n)
+		}
+
+		if s.Algorithm() != strings.ToUpper(alg) {
+			t.Errorf("FAILED -- [PoW] unexpected algorithm: wanted %s ; got %s", strings.ToUpper(alg), s.Algorithm())
+		}
+		if alg == "sha1" && s.Ext != "" {
+			t.Errorf("FAILED -- [PoW] SHA1 stamps should have no extension: %s", s)
+		}
+
+		p, err := Parse(s.String())
+		if err != nil || *p != *s {
+			t.Fatalf("FAILED -- [PoW] Parse() round-trip mismatch: wanted %+v ; got %+v (%v)", s, p, err)
+		}
+
+		v, err := NewVerifier(Config{Bits: 12, Algorithms: []string{alg}})
+		if err != nil {
+			t.Fatalf("FAILED -- [PoW] NewVerifier() failed: %s", err)
+		}
+		if _, err := v.Verify(s.String(), testResource); err != nil {
+			t.Errorf("FAILED -- [PoW] Verify() of a minted %s stamp failed: %s", alg, err)
+		}
+		if _, err := v.Verify(s.String(), testResource); !errors.Is(err, ErrSpent) {
+			t.Errorf("FAILED -- [PoW] Verify() of a spent stamp should fail with ErrSpent: %v", err)
+		}
+	}
+}
+
+func TestMintCancel(t *testing.T) {
+	m, _ := NewMinter("sha256", 200, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.Mint(ctx, testResource); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FAILED -- [PoW] Mint() should stop once the context is done: %v", err)
+	}
+}
+
+func TestMinterInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		alg  string
+		bits int
+	}{
+		{"sha3", 20},
+		{"sha1", 0},
+		{"sha1", 161},
+		{"md5", 129},
+	} {
+		if _, err := NewMinter(tc.alg, tc.bits, 1); err == nil {
+			t.Errorf("FAILED -- [PoW] NewMinter(%s, %d) should fail", tc.alg, tc.bits)
+		}
+	}
+
+	m, _ := NewMinter("sha1", 1, 1)
+	for _, res := range []string{"", "a:b"} {
+		if _, err := m.Mint(context.Background(), res); err == nil {
+			t.Errorf("FAILED -- [PoW] Mint() with resource %q should fail", res)
+		}
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	s := mint(t, "sha1", 8)
+
+	v, _ := NewVerifier(Config{Bits: 8})
+
+	// a stamp whose digest does not have the bits it claims
+	forged := *s
+	forged.Bits = 64
+
+	// a stamp with an unaccepted algorithm
+	other := mint(t, "sha256", 8)
+
+	// a stamp with a weaker difficulty than required
+	strict, _ := NewVerifier(Config{Bits: 16})
+
+	for _, tc := range []struct {
+		v        *Verifier
+		text     string
+		resource string
+		want     error
+	}{
+		{v, "1:8:220101:res::abc", testResource, ErrMalformed},
+		{v, "2:8:220101:res::abc:1", testResource, ErrMalformed},
+		{v, "1:x:220101:res::abc:1", testResource, ErrMalformed},
+		{v, "1:8:2201:res::abc:1", testResource, ErrMalformed},
+		{v, "1:8:220101::abc:1", testResource, ErrMalformed},
+		{v, s.String(), "other@example.com", ErrResource},
+		{v, other.String(), testResource, ErrAlgorithm},
+		{strict, s.String(), testResource, ErrDifficulty},
+		{v, forged.String(), testResource, ErrDifficulty},
+	} {
+		if _, err := tc.v.Check(tc.text, tc.resource); !errors.Is(err, tc.want) {
+			t.Errorf("FAILED -- [PoW] Check(%q) should fail with %v: got %v", tc.text, tc.want, err)
+		}
+	}
+
+	if _, err := NewVerifier(Config{Bits: 8, Algorithms: []string{"sha3"}}); err == nil {
+		t.Errorf("FAILED -- [PoW] NewVerifier() with an invalid algorithm should fail")
+	}
+	if _, err := NewVerifier(Config{Bits: 0}); err == nil {
+		t.Errorf("FAILED -- [PoW] NewVerifier() with zero bits should fail")
+	}
+}
+
+func TestVerifyWindow(t *testing.T) {
+	m, _ := NewMinter("sha1", 8, 1)
+	v, _ := NewVerifier(Config{Bits: 8, Window: time.Hour})
+
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	v.now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		date time.Time
+		ok   bool
+	}{
+		{now, true},
+		{now.Add(-59 * time.Minute), true},
+		{now.Add(59 * time.Minute), true},
+		{now.Add(-61 * time.Minute), false},
+		{now.Add(61 * time.Minute), false},
+	} {
+		date := tc.date
+		m.now = func() time.Time { return date }
+
+		s, err := m.Mint(context.Background(), testResource)
+		if err != nil {
+			t.Fatalf("FAILED -- [PoW] Mint() failed: %s", err)
+		}
+
+		_, err = v.Check(s.String(), testResource)
+		if tc.ok && err != nil {
+			t.Errorf("FAILED -- [PoW] stamp dated %s should be valid: %s", date, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrExpired) {
+			t.Errorf("FAILED -- [PoW] stamp dated %s should fail with ErrExpired: %v", date, err)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spent")
+
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("FAILED -- [PoW] OpenStore() failed: %s", err)
+	}
+
+	now := time.Now()
+	for _, tc := range []struct {
+		stamp   string
+		expires time.Time
+		ok      bool
+	}{
+		{"a", now.Add(time.Hour), true},
+		{"b", now.Add(-time.Hour), true},
+		{"a", now.Add(time.Hour), false},
+		{"b", now.Add(time.Hour), true}, // expired, so it can be spent again
+	} {
+		ok, err := store.Spend(tc.stamp, tc.expires)
+		if err != nil || ok != tc.ok {
+			t.Errorf("FAILED -- [PoW] Spend(%s) mismatch: wanted %v ; got %v (%v)", tc.stamp, tc.ok, ok, err)
+		}
+	}
+	if _, err := store.Spend("c", now.Add(-time.Hour)); err != nil {
+		t.Fatalf("FAILED -- [PoW] Spend() failed: %s", err)
+	}
+	store.Close()
+
+	// the expired stamp is dropped when the store is reopened
+	store, err = OpenStore(path)
+	if err != nil {
+		t.Fatalf("FAILED -- [PoW] OpenStore() failed: %s", err)
+	}
+	defer store.Close()
+
+	if store.Len() != 2 {
+		t.Errorf("FAILED -- [PoW] reopened store should have 2 stamps; got %d", store.Len())
+	}
+	for _, stamp := range []string{"a", "b"} {
+		if ok, _ := store.Spend(stamp, now.Add(time.Hour)); ok {
+			t.Errorf("FAILED -- [PoW] stamp %s should still be spent after reopening the store", stamp)
+		}
+	}
+	if ok, _ := store.Spend("c", now.Add(time.Hour)); !ok {
+		t.Errorf("FAILED -- [PoW] expired stamp c should be spendable after reopening the store")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v, _ := NewVerifier(Config{Bits: 8})
+	h := Middleware(v, func(r *http.Request) string { return r.URL.Path }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	m, _ := NewMinter("sha1", 8, 1)
+	s, _ := m.Mint(context.Background(), "/v1/signup")
+	wrong, _ := m.Mint(context.Background(), "/v1/login")
+
+	for _, tc := range []struct {
+		stamp  string
+		status int
+	}{
+		{"", http.StatusForbidden},
+		{wrong.String(), http.StatusForbidden},
+		{s.String(), http.StatusNoContent},
+		{s.String(), http.StatusForbidden}, // spent
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/signup", nil)
+		if tc.stamp != "" {
+			req.Header.Set(Header, tc.stamp)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("FAILED -- [PoW] stamp %q: wanted status %d ; got %d", tc.stamp, tc.status, rec.Code)
+		}
+		if rec.Code == http.StatusForbidden && rec.Header().Get(BitsHeader) != "8" {
+			t.Errorf("FAILED -- [PoW] rejected requests should have a %s header of 8; got %q", BitsHeader, rec.Header().Get(BitsHeader))
+		}
+	}
+}
+
+func TestZeroBits(t *testing.T) {
+	for _, tc := range []struct {
+		hash string
+		want int
+	}{
+		{"ffff", 0},
+		{"7fff", 1},
+		{"3fff", 2},
+		{"1fff", 3},
+		{"0fff", 4},
+		{"00ff", 8},
+		{"0001", 15},
+		{"0000", 16},
+	} {
+		if n := ZeroBits([]byte(tc.hash)); n != tc.want {
+			t.Errorf("FAILED -- [PoW] ZeroBits(%s) mismatch: wanted %d ; got %d", tc.hash, tc.want, n)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package pow mints and verifies proof-of-work stamps in the hashcash format
+// (`ver:bits:date:resource:ext:rand:counter`), with any of the `clock`
+// package's hash functions -- for anti-abuse checks, such as rate-limiting the
+// requests of an HTTP service.
+//
+// A stamp is valid if the digest of its text has at least as many leading
+// zero bits as it claims: minting one takes about 2^bits hashes, which are
+// spread across all CPU cores; while verifying it takes a single hash. A
+// `Verifier` also checks the stamp's resource, its date (within a window) and
+// that it was not spent before, in a `Store` of spent stamps
+package pow
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// Version is the hashcash format version of the minted stamps
+	Version int = 1
+
+	// DefaultAlgorithm is the hash function of stamps which do not set one in
+	// their extension field: SHA1, as in hashcash
+	DefaultAlgorithm string = "SHA1"
+
+	// dateFormat is the format of the minted stamps' dates (YYMMDDhhmmss, in
+	// UTC); stamps with a date of only YYMMDD or YYMMDDhhmm are verified too
+	dateFormat string = "060102150405"
+
+	// algExt is the name of the extension which sets a stamp's hash function
+	algExt string = "alg"
+)
+
+// ErrMalformed error is wrapped by the errors of stamps which cannot be parsed
+var ErrMalformed = errors.New("malformed stamp")
+
+// Stamp struct is a hashcash stamp, as its seven fields. Its text (and so its
+// digest) is the fields joined by colons, as returned by `String`
+type Stamp struct {
+	Version  int
+	Bits     int
+	Date     string
+	Resource string
+	Ext      string
+	Rand     string
+	Counter  string
+}
+
+// Parse function parses the input stamp text
+func Parse(text string) (*Stamp, error) {
+	fields := strings.Split(text, ":")
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("%w: expected 7 fields (ver:bits:date:resource:ext:rand:counter); got %d", ErrMalformed, len(fields))
+	}
+
+	ver, err := strconv.Atoi(fields[0])
+	if err != nil || ver != Version {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrMalformed, fields[0])
+	}
+
+	bits, err := strconv.Atoi(fields[1])
+	if err != nil || bits <= 0 {
+		return nil, fmt.Errorf("%w: invalid number of bits %q", ErrMalformed, fields[1])
+	}
+
+	s := &Stamp{
+		Version:  ver,
+		Bits:     bits,
+		Date:     fields[2],
+		Resource: fields[3],
+		Ext:      fields[4],
+		Rand:     fields[5],
+		Counter:  fields[6],
+	}
+
+	if _, err := s.Time(); err != nil {
+		return nil, fmt.Errorf("%w: invalid date %q", ErrMalformed, s.Date)
+	}
+	if s.Resource == "" || s.Rand == "" || s.Counter == "" {
+		return nil, fmt.Errorf("%w: the resource, rand and counter fields cannot be empty", ErrMalformed)
+	}
+	return s, nil
+}
+
+// String method returns the stamp's text
+func (s *Stamp) String() string {
+	return strings.Join([]string{
+		strconv.Itoa(s.Version),
+		strconv.Itoa(s.Bits),
+		s.Date,
+		s.Resource,
+		s.Ext,
+		s.Rand,
+		s.Counter,
+	}, ":")
+}
+
+// Time method returns the stamp's date, in UTC
+func (s *Stamp) Time() (time.Time, error) {
+	switch len(s.Date) {
+	case 6, 10, 12:
+		return time.Parse(dateFormat[:len(s.Date)], s.Date)
+	}
+	return time.Time{}, errors.New("expected a YYMMDD[hhmm[ss]] date")
+}
+
+// Algorithm method returns the name of the stamp's hash function: as set in
+// its `alg` extension (e.g. `alg=SHA256`), or `DefaultAlgorithm`
+func (s *Stamp) Algorithm() string {
+	for _, ext := range strings.Split(s.Ext, ";") {
+		if kv := strings.SplitN(ext, "=", 2); len(kv) == 2 && kv[0] == algExt {
+			return strings.ToUpper(kv[1])
+		}
+	}
+	return DefaultAlgorithm
+}
+
+// Hash method returns the (hex-encoded) digest of the stamp's text, with its
+// hash function
+func (s *Stamp) Hash() (string, error) {
+	e, err := clock.NewEngine(s.Algorithm(), clock.ModeHex)
+	if err != nil {
+		return "", err
+	}
+	return string(e.Hash([]byte(s.String()))), nil
+}
+
+// ZeroBits function returns the number of leading zero bits of the input
+// (hex-encoded) digest
+func ZeroBits(hash []byte) int {
+	bits := 0
+	for _, c := range hash {
+		n := nibble(c)
+		if n != 0 {
+			// the leading zero bits of the first non-zero nibble
+			for mask := 8; n >= 0 && n&mask == 0; mask >>= 1 {
+				bits++
+			}
+			return bits
+		}
+		bits += 4
+	}
+	return bits
+}
+
+// nibble function returns the value of the input (lower-case) hex character;
+// or -1 if it is not one
+func nibble(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	}
+	return -1
+}
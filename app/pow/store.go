@@ -0,0 +1,158 @@
+package pow
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store interface is a set of spent stamps, to reject stamps which are used
+// twice (double-spent)
+type Store interface {
+	// Spend method records the input stamp (text) as spent, until it expires;
+	// returning false if it was already spent
+	Spend(stamp string, expires time.Time) (bool, error)
+}
+
+// FileStore struct is a `Store` kept in memory, and appended to a file if it
+// has one. Spent stamps are forgotten once they expire -- by then, their date
+// is outside of the verifier's window anyway
+type FileStore struct {
+	mu    sync.Mutex
+	spent map[string]time.Time
+	file  *os.File
+
+	// now returns the current time, to find the expired stamps
+	now func() time.Time
+}
+
+// OpenStore function opens the spent-stamp store at the input path, creating
+// it if it does not exist; or an in-memory store if the path is empty. The
+// file is compacted on open, dropping its expired stamps
+func OpenStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		spent: map[string]time.Time{},
+		now:   time.Now,
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+
+	// rewrite the unexpired stamps to a new file, replacing the old one
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(f)
+	for stamp, expires := range s.spent {
+		fmt.Fprintf(w, "%d\t%s\n", expires.Unix(), stamp)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	if s.file, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load method reads the unexpired stamps of the file at the input path, if
+// it exists: one per line, after their expiry time (as a Unix timestamp) and
+// a tab
+func (s *FileStore) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	now := s.now()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid spent-stamp store %s: malformed line %d", path, line)
+		}
+
+		unix, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid spent-stamp store %s: malformed line %d", path, line)
+		}
+
+		if expires := time.Unix(unix, 0); expires.After(now) {
+			s.spent[fields[1]] = expires
+		}
+	}
+	return scanner.Err()
+}
+
+// Spend method implements the `Store` interface
+func (s *FileStore) Spend(stamp string, expires time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.spent[stamp]; ok && e.After(now) {
+		return false, nil
+	}
+
+	if s.file != nil {
+		if _, err := fmt.Fprintf(s.file, "%d\t%s\n", expires.Unix(), stamp); err != nil {
+			return false, err
+		}
+	}
+	s.spent[stamp] = expires
+
+	// expired stamps are pruned every so often, to keep the store's size
+	// bound by the stamps spent within a window
+	if len(s.spent)%1024 == 0 {
+		for k, e := range s.spent {
+			if !e.After(now) {
+				delete(s.spent, k)
+			}
+		}
+	}
+	return true, nil
+}
+
+// Len method returns the number of spent stamps in the store, including any
+// expired ones which were not pruned yet
+func (s *FileStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.spent)
+}
+
+// Close method closes the store's file, if it has one
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,229 @@
+package pow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const (
+	// DefaultWindow is the default maximum difference between a stamp's date
+	// and the time it is verified, in either direction
+	DefaultWindow time.Duration = 48 * time.Hour
+
+	// Header is the HTTP request header carrying the stamp checked by
+	// `Middleware`
+	Header string = "X-Hashcash"
+
+	// BitsHeader is the HTTP response header with the required difficulty,
+	// set when `Middleware` rejects a request
+	BitsHeader string = "X-Hashcash-Bits"
+)
+
+var (
+	// ErrResource error is returned for stamps minted for another resource
+	ErrResource = errors.New("stamp resource does not match")
+
+	// ErrAlgorithm error is returned for stamps of a hash function which is
+	// not accepted
+	ErrAlgorithm = errors.New("stamp hash function is not accepted")
+
+	// ErrDifficulty error is returned for stamps which claim fewer leading zero
+	// bits than required, or whose digest does not have the bits they claim
+	ErrDifficulty = errors.New("stamp does not meet the difficulty")
+
+	// ErrExpired error is returned for stamps dated outside of the window
+	ErrExpired = errors.New("stamp date is outside of the window")
+
+	// ErrSpent error is returned for stamps which were already spent
+	ErrSpent = errors.New("stamp was already spent")
+)
+
+// Config struct holds the settings of a `Verifier`
+type Config struct {
+	// Bits is the minimum number of leading zero bits of a valid stamp
+	Bits int
+
+	// Window is the maximum difference between a stamp's date and the current
+	// time; `DefaultWindow` if zero
+	Window time.Duration
+
+	// Algorithms lists the accepted hash functions; only `DefaultAlgorithm` if
+	// empty
+	Algorithms []string
+
+	// Store keeps the spent stamps; an in-memory store if nil
+	Store Store
+}
+
+// Verifier struct checks stamps against a difficulty and a date window, and
+// spends them once verified. A Verifier is safe for concurrent use, if its
+// store is
+type Verifier struct {
+	bits       int
+	window     time.Duration
+	algorithms map[string]bool
+	store      Store
+
+	// now returns the current time, to check the stamps' dates
+	now func() time.Time
+}
+
+// NewVerifier function creates a `Verifier` from the input configuration
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if cfg.Bits <= 0 {
+		return nil, errors.New("number of bits must be greater than zero")
+	}
+	if cfg.Window < 0 {
+		return nil, errors.New("window cannot be negative")
+	}
+
+	v := &Verifier{
+		bits:       cfg.Bits,
+		window:     cfg.Window,
+		algorithms: map[string]bool{},
+		store:      cfg.Store,
+		now:        time.Now,
+	}
+	if v.window == 0 {
+		v.window = DefaultWindow
+	}
+	if v.store == nil {
+		v.store, _ = OpenStore("")
+	}
+
+	algs := cfg.Algorithms
+	if len(algs) == 0 {
+		algs = []string{DefaultAlgorithm}
+	}
+	for _, alg := range algs {
+		e, err := clock.NewEngine(alg, clock.ModeHex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, alg)
+		}
+		if max := e.Size() * 4; v.bits > max {
+			return nil, fmt.Errorf("number of bits is greater than the %s digest size of %d bits", alg, max)
+		}
+		v.algorithms[strings.ToUpper(alg)] = true
+	}
+	return v, nil
+}
+
+// Bits method returns the verifier's minimum number of leading zero bits
+func (v *Verifier) Bits() int {
+	return v.bits
+}
+
+// Check method checks the input stamp (text) for the input resource, without
+// spending it: its resource, hash function, difficulty and date. The returned
+// errors wrap `ErrMalformed`, `ErrResource`, `ErrAlgorithm`, `ErrDifficulty`
+// or `ErrExpired`
+func (v *Verifier) Check(text, resource string) (*Stamp, error) {
+	s, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Resource != resource {
+		return s, fmt.Errorf("%w: expected %q; got %q", ErrResource, resource, s.Resource)
+	}
+
+	if alg := s.Algorithm(); !v.algorithms[alg] {
+		return s, fmt.Errorf("%w: %s", ErrAlgorithm, alg)
+	}
+
+	if s.Bits < v.bits {
+		return s, fmt.Errorf("%w: %d bits are required; the stamp claims %d", ErrDifficulty, v.bits, s.Bits)
+	}
+
+	// the date was validated by Parse
+	date, _ := s.Time()
+	if d := v.now().Sub(date); d > v.window || d < -v.window {
+		return s, fmt.Errorf("%w: dated %s", ErrExpired, date.Format(time.RFC3339))
+	}
+
+	// the stamp's own text is hashed, and not its fields (re-)joined
+	e, _ := clock.NewEngine(s.Algorithm(), clock.ModeHex)
+	if n := ZeroBits(e.Hash([]byte(text))); n < s.Bits {
+		return s, fmt.Errorf("%w: the stamp claims %d bits; its digest has %d", ErrDifficulty, s.Bits, n)
+	}
+	return s, nil
+}
+
+// Verify method checks the input stamp (text) for the input resource, as in
+// `Check`; and spends it, failing with `ErrSpent` if it was spent before. A
+// stamp is kept in the store until its date is outside of the window
+func (v *Verifier) Verify(text, resource string) (*Stamp, error) {
+	s, err := v.Check(text, resource)
+	if err != nil {
+		return s, err
+	}
+
+	date, _ := s.Time()
+	ok, err := v.store.Spend(text, date.Add(v.window))
+	if err != nil {
+		return s, err
+	}
+	if !ok {
+		return s, ErrSpent
+	}
+	return s, nil
+}
+
+// errorResponse struct is the JSON object returned on rejected requests
+type errorResponse struct {
+	Error string `json:"error"`
+	Bits  int    `json:"bits"`
+}
+
+// Middleware function wraps the input handler, so that it is only called for
+// requests with a valid stamp in their `Header`; as verified by the input
+// verifier, for the resource returned by the input function (e.g. the request
+// path). Other requests are rejected with a `403 Forbidden` status, and the
+// required difficulty in the `BitsHeader` response header
+func Middleware(v *Verifier, resource func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		text := r.Header.Get(Header)
+		if text == "" {
+			reject(w, v, http.StatusForbidden, fmt.Errorf("%w: missing %s header", ErrMalformed, Header))
+			return
+		}
+
+		if _, err := v.Verify(text, resource(r)); err != nil {
+			status := http.StatusForbidden
+			if !IsInvalid(err) {
+				// the stamp could not be spent
+				status = http.StatusInternalServerError
+			}
+			reject(w, v, status, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsInvalid function returns whether the input error (of `Check` or `Verify`)
+// is caused by the stamp, and not by the verifier's store
+func IsInvalid(err error) bool {
+	for _, target := range []error{ErrMalformed, ErrResource, ErrAlgorithm, ErrDifficulty, ErrExpired, ErrSpent} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// reject function writes the input error as a JSON response, with the input
+// status code
+func reject(w http.ResponseWriter, v *Verifier, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(BitsHeader, strconv.Itoa(v.bits))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&errorResponse{Error: err.Error(), Bits: v.bits})
+}
@@ -0,0 +1,113 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// errStop error is returned by a `ledger.SegmentFunc` to stop reading once
+// the anchor is found
+var errStop = errors.New("stop reading")
+
+// Issue function stamps the input file digest (see `Digest`) in the input
+// ledger: it runs the ledger's chain, records the digest as an event, and
+// keeps running until the next checkpoint is appended -- returning the
+// receipt, anchored by that checkpoint.
+//
+// The ledger cannot be run by another process at the same time. If the input
+// context is cancelled first, its error is returned
+func Issue(ctx context.Context, l *ledger.Ledger, digest string) (*Receipt, error) {
+	var prev *ledger.Entry
+	if last, ok := l.Last(); ok {
+		prev = &last
+	}
+
+	rec, err := ledger.NewRecorder(l)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the recorder's first step mixes in the event, which is queued before
+	// it runs; so the entries are the event's, up to the anchor. The recorder
+	// only checks the context every so often, and may append a few more
+	var (
+		entries  []ledger.Entry
+		anchored bool
+	)
+	rec.OnEntry = func(e ledger.Entry) {
+		if anchored {
+			return
+		}
+		entries = append(entries, e)
+		if e.Event == "" {
+			anchored = true
+			cancel()
+		}
+	}
+
+	if err := rec.Record(ctx, []byte(digest)); err != nil {
+		return nil, err
+	}
+
+	if err := rec.Run(ctx); !anchored {
+		return nil, err
+	}
+	return newReceipt(l.Manifest(), digest, prev, entries), nil
+}
+
+// Build function returns the receipt of the input file digest (see `Digest`)
+// from the ledger in the input directory, for the first event which recorded
+// it -- e.g. piped to a running `ledger run -events` command. It fails with
+// `ErrNotFound` if there is no such event, or with `ErrNotAnchored` if there
+// is no checkpoint after it yet
+func Build(dir, digest string) (*Receipt, error) {
+	m, err := ledger.ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := clock.NewChain(m.Algorithm, m.Seed)
+	if err != nil {
+		return nil, err
+	}
+	event := string(chain.Digest([]byte(digest)))
+
+	var (
+		prev    *ledger.Entry
+		entries []ledger.Entry
+	)
+	err = ledger.ReadSegments(dir, 0, func(segment []ledger.Entry) error {
+		for idx := range segment {
+			e := segment[idx]
+			switch {
+			case len(entries) > 0:
+				entries = append(entries, e)
+				if e.Event == "" {
+					return errStop
+				}
+			case e.Event == event:
+				entries = append(entries, e)
+			default:
+				prev = &e
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+
+	switch n := len(entries); {
+	case n == 0:
+		return nil, ErrNotFound
+	case n < 2 || entries[n-1].Event != "":
+		return nil, ErrNotAnchored
+	}
+	return newReceipt(m, digest, prev, entries), nil
+}
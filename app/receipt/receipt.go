@@ -0,0 +1,205 @@
+// Package receipt issues and verifies timestamping receipts: proofs that a
+// file existed before a point on a ledger's clock.
+//
+// A file is stamped by recording its (hex-encoded) SHA256 digest as an event
+// in the ledger, so that the event's digest is mixed into the chain. Its
+// receipt is a self-contained JSON document with the chain's seed and
+// algorithm, the file's digest, the index and hash of the step which mixed it
+// in (the mixin), and the ledger entries from the one before the event up to
+// the first checkpoint after it -- the anchor.
+//
+// A receipt is checked offline, by replaying that segment of the chain from
+// its first entry -- which the receipt itself holds, so a forged segment
+// replays just as well. The anchor (and its wall time) is only confirmed by
+// comparing it with a copy of the ledger, or with any other record of the
+// chain's hashes, such as a replica or a published beacon: only then was the
+// file stamped before the anchor's hash was calculated
+package receipt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// Version is the receipt format version
+const Version int = 1
+
+var (
+	// ErrNotFound error is returned when the file's event is not in the ledger
+	ErrNotFound = errors.New("event not found in the ledger")
+
+	// ErrNotAnchored error is returned when there is no checkpoint after the
+	// file's event in the ledger yet
+	ErrNotAnchored = errors.New("event is not anchored by a checkpoint yet")
+
+	// ErrDigest error is returned when a file does not match the receipt's
+	// digest
+	ErrDigest = errors.New("file does not match the receipt's digest")
+
+	// ErrInvalid error is returned for receipts whose chain segment does not
+	// replay, or does not match their event and anchor
+	ErrInvalid = errors.New("invalid receipt")
+
+	// ErrAnchor error is returned when the receipt's anchor is not in a ledger
+	ErrAnchor = errors.New("anchor does not match the ledger")
+)
+
+// Receipt struct is a self-contained timestamping receipt of a file
+type Receipt struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"algorithm"`
+	Seed      string `json:"seed"`
+
+	// Digest is the file's (hex-encoded) SHA256 digest
+	Digest string `json:"digest"`
+
+	// Index is the chain index of the step which mixed in the file's event,
+	// and Mixin is its hash
+	Index int    `json:"index"`
+	Mixin string `json:"mixin"`
+
+	// Anchor is the first checkpoint entry after the file's event
+	Anchor ledger.Entry `json:"anchor"`
+
+	// Segment is the ledger entries from the one before the file's event (if
+	// the event does not follow the seed) up to the anchor
+	Segment []ledger.Entry `json:"segment"`
+}
+
+// Digest function returns the (hex-encoded) SHA256 digest of the input file,
+// as stamped -- the same as `sha256sum`'s
+func Digest(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newReceipt function creates the receipt of the input file digest, from the
+// input ledger entries: the one before the event (or nil, for the seed), and
+// the ones from the event up to the anchor
+func newReceipt(m *ledger.Manifest, digest string, prev *ledger.Entry, entries []ledger.Entry) *Receipt {
+	r := &Receipt{
+		Version:   Version,
+		Algorithm: m.Algorithm,
+		Seed:      m.Seed,
+		Digest:    digest,
+		Index:     entries[0].Index,
+		Mixin:     entries[0].Hash,
+		Anchor:    entries[len(entries)-1],
+	}
+
+	if prev != nil {
+		r.Segment = append(r.Segment, *prev)
+	}
+	r.Segment = append(r.Segment, entries...)
+	return r
+}
+
+// Verify method checks the receipt against the input file: that the file
+// matches its digest (failing with `ErrDigest`); and that its chain segment
+// replays up to its anchor, with the file's event mixed in at its index
+// (failing with `ErrInvalid`). If the input context is cancelled first, its
+// error is returned.
+//
+// The segment's first entry is trusted as it is, so it does not confirm the
+// anchor or its wall time: see `CheckAnchor`
+func (r *Receipt) Verify(ctx context.Context, file io.Reader) error {
+	digest, err := Digest(file)
+	if err != nil {
+		return err
+	}
+	if digest != r.Digest {
+		return fmt.Errorf("%w: expected %s; got %s", ErrDigest, r.Digest, digest)
+	}
+
+	if r.Version != Version {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalid, r.Version)
+	}
+
+	chain, err := clock.NewChain(r.Algorithm, r.Seed)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+	event := string(chain.Digest([]byte(r.Digest)))
+
+	// the segment starts at the entry before the event, unless the event
+	// follows the seed
+	segment := r.Segment
+	var prev *ledger.Entry
+	if len(segment) > 0 && segment[0].Index < r.Index {
+		prev = &segment[0]
+		segment = segment[1:]
+	}
+
+	switch {
+	case len(segment) < 2:
+		return fmt.Errorf("%w: the chain segment must hold the event and the anchor", ErrInvalid)
+	case segment[0].Index != r.Index || segment[0].Hash != r.Mixin || segment[0].Event != event:
+		return fmt.Errorf("%w: the file's event is not at index %d of the chain segment", ErrInvalid, r.Index)
+	case !sameEntry(segment[len(segment)-1], r.Anchor):
+		return fmt.Errorf("%w: the anchor is not the last entry of the chain segment", ErrInvalid)
+	case r.Anchor.Event != "":
+		return fmt.Errorf("%w: the anchor is not a checkpoint", ErrInvalid)
+	}
+
+	for idx := range segment {
+		e := &segment[idx]
+		if err := ledger.CheckEntry(ctx, chain, prev, e); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w: entry #%d: %s", ErrInvalid, e.Index, err)
+		}
+		prev = e
+	}
+	return nil
+}
+
+// CheckAnchor method checks that the receipt's anchor is in the ledger in the
+// input directory, with the same hash
+func (r *Receipt) CheckAnchor(dir string) error {
+	m, err := ledger.ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	if m.Seed != r.Seed || m.Algorithm != r.Algorithm {
+		return fmt.Errorf("%w: the ledger's chain has another seed or algorithm", ErrAnchor)
+	}
+
+	e, err := ledger.Find(dir, r.Anchor.Index)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return fmt.Errorf("%w: there is no entry #%d in the ledger", ErrAnchor, r.Anchor.Index)
+	}
+	if e.Hash != r.Anchor.Hash || e.Event != "" {
+		return fmt.Errorf("%w: entry #%d has the hash %s", ErrAnchor, e.Index, e.Hash)
+	}
+	return nil
+}
+
+// IsInvalid function returns whether the input error (of `Verify` or
+// `CheckAnchor`) is caused by the file or the receipt, and not by reading them
+func IsInvalid(err error) bool {
+	for _, target := range []error{ErrDigest, ErrInvalid, ErrAnchor} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameEntry function returns whether the input entries are the same
+func sameEntry(a, b ledger.Entry) bool {
+	return a.Index == b.Index && a.Hash == b.Hash && a.Event == b.Event && a.WallTime.Equal(b.WallTime)
+}
@@ -0,0 +1,250 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+const testSeed string = "genesis_string"
+
+// newTestLedger function creates an empty ledger in a temporary directory,
+// with a checkpoint every 10 hashes
+func newTestLedger(t *testing.T) *ledger.Ledger {
+	t.Helper()
+
+	l, err := ledger.Create(&ledger.Config{
+		Dir:         t.TempDir(),
+		Seed:        testSeed,
+		Interval:    10,
+		SegmentSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [Receipt] ledger.Create() failed: %s", err)
+	}
+	return l
+}
+
+// issue function stamps the input file contents in the input ledger
+func issue(t *testing.T, l *ledger.Ledger, file string) *Receipt {
+	t.Helper()
+
+	digest, _ := Digest(strings.NewReader(file))
+	r, err := Issue(context.Background(), l, digest)
+	if err != nil {
+		t.Fatalf("FAILED -- [Receipt] Issue() failed: %s", err)
+	}
+	return r
+}
+
+func TestIssue(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+
+	// the first event follows the seed
+	first := issue(t, l, "first file")
+	if first.Index != 1 || first.Anchor.Index != 10 || len(first.Segment) != 2 {
+		t.Errorf("FAILED -- [Receipt] Issue() returned the event #%d, anchored at #%d with %d entries; expected #1, #10 and 2",
+			first.Index, first.Anchor.Index, len(first.Segment))
+	}
+
+	// the recorder may append a few more checkpoints after the anchor
+	last, _ := l.Last()
+	second := issue(t, l, "second file")
+	if second.Segment[0].Index != last.Index || second.Index != last.Index+1 {
+		t.Errorf("FAILED -- [Receipt] Issue() returned a segment from #%d with the event #%d; expected #%d and #%d",
+			second.Segment[0].Index, second.Index, last.Index, last.Index+1)
+	}
+	if second.Anchor.Index%10 != 0 || second.Anchor.Event != "" {
+		t.Errorf("FAILED -- [Receipt] Issue() returned an anchor which is not a checkpoint: %+v", second.Anchor)
+	}
+
+	// the seed is only replayed for events which follow it
+	tampered := *first
+	tampered.Seed = "another_seed"
+	if err := tampered.Verify(context.Background(), strings.NewReader("first file")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("FAILED -- [Receipt] Verify() should fail with ErrInvalid for another seed; got %v", err)
+	}
+
+	for _, test := range []struct {
+		r    *Receipt
+		file string
+	}{
+		{first, "first file"},
+		{second, "second file"},
+	} {
+		if err := test.r.Verify(context.Background(), strings.NewReader(test.file)); err != nil {
+			t.Errorf("FAILED -- [Receipt] Verify() failed for the receipt of %q: %s", test.file, err)
+		}
+		if err := test.r.CheckAnchor(l.Dir()); err != nil {
+			t.Errorf("FAILED -- [Receipt] CheckAnchor() failed for the receipt of %q: %s", test.file, err)
+		}
+	}
+
+	// receipts built from the ledger are the same as the issued ones
+	built, err := Build(l.Dir(), second.Digest)
+	if err != nil {
+		t.Fatalf("FAILED -- [Receipt] Build() failed: %s", err)
+	}
+	a, _ := json.Marshal(built)
+	b, _ := json.Marshal(second)
+	if !bytes.Equal(a, b) {
+		t.Errorf("FAILED -- [Receipt] Build() returned %s; expected %s", a, b)
+	}
+
+	digest, _ := Digest(strings.NewReader("another file"))
+	if _, err := Build(l.Dir(), digest); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FAILED -- [Receipt] Build() should fail with ErrNotFound for a file which was not stamped; got %v", err)
+	}
+}
+
+func TestBuildNotAnchored(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+
+	issue(t, l, "file")
+
+	// an event without a checkpoint after it
+	last, _ := l.Last()
+	chain, _ := clock.NewChain("sha256", testSeed)
+	chain.Reset(last.Index, last.Hash)
+
+	digest, _ := Digest(strings.NewReader("pending file"))
+	event := chain.Digest([]byte(digest))
+	chain.Mix(event)
+
+	err := l.Append(ledger.Entry{Index: chain.Index(), Hash: chain.Hash(), Event: string(event), WallTime: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("FAILED -- [Receipt] Append() failed: %s", err)
+	}
+
+	if _, err := Build(l.Dir(), digest); !errors.Is(err, ErrNotAnchored) {
+		t.Errorf("FAILED -- [Receipt] Build() should fail with ErrNotAnchored for an event without a checkpoint after it; got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+
+	issue(t, l, "first file")
+	r := issue(t, l, "file")
+
+	// receipts are verified as JSON documents
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("FAILED -- [Receipt] json.Marshal() failed: %s", err)
+	}
+
+	for _, test := range []struct {
+		name   string
+		file   string
+		tamper func(r *Receipt)
+		err    error
+	}{
+		{"valid", "file", func(r *Receipt) {}, nil},
+		{"another file", "another file", func(r *Receipt) {}, ErrDigest},
+		{"mixin", "file", func(r *Receipt) { r.Mixin = r.Anchor.Hash }, ErrInvalid},
+		{"index", "file", func(r *Receipt) { r.Index++ }, ErrInvalid},
+		{"anchor", "file", func(r *Receipt) { r.Anchor.Index += 10 }, ErrInvalid},
+		{"algorithm", "file", func(r *Receipt) { r.Algorithm = "SHA512" }, ErrInvalid},
+		{"version", "file", func(r *Receipt) { r.Version = 2 }, ErrInvalid},
+		{"no anchor", "file", func(r *Receipt) { r.Segment = r.Segment[:2] }, ErrInvalid},
+		{"segment hash", "file", func(r *Receipt) {
+			last := len(r.Segment) - 1
+			r.Segment[last].Hash = r.Segment[0].Hash
+			r.Anchor.Hash = r.Segment[0].Hash
+		}, ErrInvalid},
+		{"segment wall time", "file", func(r *Receipt) {
+			r.Segment[0].WallTime = r.Segment[0].WallTime.Add(time.Hour)
+		}, ErrInvalid},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tampered := &Receipt{}
+			json.Unmarshal(data, tampered)
+			test.tamper(tampered)
+
+			err := tampered.Verify(context.Background(), strings.NewReader(test.file))
+			if test.err == nil && err != nil {
+				t.Errorf("FAILED -- [Receipt] Verify() failed: %s", err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("FAILED -- [Receipt] Verify() should fail with %v; got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckAnchor(t *testing.T) {
+	l := newTestLedger(t)
+	defer l.Close()
+
+	issue(t, l, "first file")
+	r := issue(t, l, "file")
+
+	// a receipt whose segment replays from a forged first entry: it verifies
+	// offline, but its anchor is not in the ledger
+	forged := func() *Receipt {
+		c := *r
+		c.Segment = append([]ledger.Entry{}, r.Segment...)
+		c.Segment[0].Hash = strings.Repeat("ab", 32)
+
+		chain, _ := clock.NewChain(c.Algorithm, c.Seed)
+		chain.Reset(c.Segment[0].Index, c.Segment[0].Hash)
+		for idx := 1; idx < len(c.Segment); idx++ {
+			e := &c.Segment[idx]
+			for chain.Index() < e.Index-1 {
+				chain.Next()
+			}
+			if e.Event != "" {
+				chain.Mix([]byte(e.Event))
+			} else {
+				chain.Next()
+			}
+			e.Hash = chain.Hash()
+		}
+		c.Mixin, c.Anchor = c.Segment[1].Hash, c.Segment[len(c.Segment)-1]
+
+		if err := c.Verify(context.Background(), strings.NewReader("file")); err != nil {
+			t.Fatalf("FAILED -- [Receipt] Verify() of a forged segment failed: %s", err)
+		}
+		return &c
+	}
+
+	// a ledger of another chain
+	other := newTestLedger(t)
+	defer other.Close()
+	issue(t, other, "another file")
+
+	for _, test := range []struct {
+		name string
+		dir  string
+		r    func() *Receipt
+	}{
+		{"another ledger", other.Dir(), func() *Receipt { return r }},
+		{"missing entry", l.Dir(), func() *Receipt {
+			c := *r
+			c.Anchor.Index += 1000
+			return &c
+		}},
+		{"another hash", l.Dir(), func() *Receipt {
+			c := *r
+			c.Anchor.Hash = c.Mixin
+			return &c
+		}},
+		{"forged segment", l.Dir(), forged},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.r().CheckAnchor(test.dir); !errors.Is(err, ErrAnchor) {
+				t.Errorf("FAILED -- [Receipt] CheckAnchor() should fail with ErrAnchor; got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package replica
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// maxMessageSize is the maximum size of a message received by a follower
+const maxMessageSize int = 64 << 20
+
+// Follower struct replicates a leader's ledger into a local copy, verifying
+// each received segment before appending it
+type Follower struct {
+	// Addr is the leader's TCP address
+	Addr string
+
+	// Dir is the directory of the local copy of the ledger; which is created
+	// from the leader's manifest if it does not exist
+	Dir string
+
+	// Retry is the time to wait before reconnecting to the leader, after a
+	// disconnect; 1 second by default
+	Retry time.Duration
+
+	// Sync sets whether each appended entry is flushed to stable storage
+	// (see `ledger.Ledger`)
+	Sync bool
+
+	// OnEntry is called with each verified and appended entry, if set
+	OnEntry ledger.EntryFunc
+
+	// OnDisconnect is called with the error which ended a connection to the
+	// leader, before reconnecting, if set
+	OnDisconnect func(err error)
+
+	ledger *ledger.Ledger
+	chain  *clock.Chain
+	last   *ledger.Entry
+}
+
+// NewFollower function creates a `Follower` of the leader at the input
+// address, with its local copy of the ledger in the input directory
+func NewFollower(addr, dir string) *Follower {
+	return &Follower{
+		Addr:  addr,
+		Dir:   dir,
+		Retry: time.Second,
+	}
+}
+
+// Run method replicates the leader's ledger until the input context is
+// cancelled (returning its error), reconnecting after each disconnect.
+//
+// It returns a `ForkError` if the leader's chain diverges from the local
+// copy, and a `SegmentError` if a received segment fails verification
+func (f *Follower) Run(ctx context.Context) error {
+	defer func() {
+		if f.ledger != nil {
+			f.ledger.Close()
+			f.ledger = nil
+		}
+	}()
+
+	for {
+		err := f.sync(ctx)
+
+		var (
+			fErr *ForkError
+			sErr *SegmentError
+		)
+		if errors.As(err, &fErr) || errors.As(err, &sErr) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if f.OnDisconnect != nil {
+			f.OnDisconnect(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.Retry):
+		}
+	}
+}
+
+// open method opens the local copy of the ledger, if it exists and it is not
+// open yet
+func (f *Follower) open() error {
+	if f.ledger != nil {
+		return nil
+	}
+
+	l, err := ledger.Open(f.Dir)
+	if errors.Is(err, ledger.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return f.setLedger(l)
+}
+
+// setLedger method sets the local copy of the ledger, resuming verification
+// from its last entry
+func (f *Follower) setLedger(l *ledger.Ledger) error {
+	m := l.Manifest()
+
+	chain, err := clock.NewChain(m.Algorithm, m.Seed)
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	f.ledger = l
+	f.chain = chain
+	f.last = nil
+
+	if last, ok := l.Last(); ok {
+		f.last = &last
+	}
+	l.Sync = f.Sync
+	return nil
+}
+
+// handshake method checks the leader's manifest against the local copy of
+// the ledger, creating it if it does not exist
+func (f *Follower) handshake(m *ledger.Manifest) error {
+	if m == nil {
+		return errors.New("leader did not send its manifest")
+	}
+
+	if f.ledger == nil {
+		l, err := ledger.Create(&ledger.Config{
+			Dir:         f.Dir,
+			Algorithm:   m.Algorithm,
+			Seed:        m.Seed,
+			Interval:    m.Interval,
+			SegmentSize: m.SegmentSize,
+		})
+		if err != nil {
+			return err
+		}
+		return f.setLedger(l)
+	}
+
+	local := f.ledger.Manifest()
+	if local.Seed != m.Seed || local.Algorithm != m.Algorithm {
+		return &ForkError{
+			Reason: fmt.Sprintf(
+				"leader's chain (%s, seed %q) differs from the local ledger's (%s, seed %q)",
+				m.Algorithm, m.Seed, local.Algorithm, local.Seed,
+			),
+		}
+	}
+	return nil
+}
+
+// checkAnchor method checks the leader's entry at the local copy's last index
+func (f *Follower) checkAnchor(anchor *ledger.Entry) error {
+	if f.last == nil {
+		return nil
+	}
+
+	fork := &ForkError{
+		Index: f.last.Index,
+		Local: f.last.Hash,
+	}
+
+	switch {
+	case anchor == nil:
+		fork.Reason = "the leader's ledger has no entry at the index"
+	case anchor.Index != f.last.Index:
+		fork.Reason = fmt.Sprintf("the leader sent an anchor at index %d", anchor.Index)
+	case anchor.Hash != f.last.Hash:
+		fork.Remote = anchor.Hash
+		fork.Reason = fmt.Sprintf("local hash %s differs from the leader's %s", f.last.Hash, anchor.Hash)
+	default:
+		return nil
+	}
+	return fork
+}
+
+// apply method verifies a segment received from the leader; appending all
+// of its entries if they are valid, or none of them otherwise
+func (f *Follower) apply(ctx context.Context, entries []ledger.Entry) error {
+	prev := f.last
+
+	for idx := range entries {
+		e := &entries[idx]
+
+		if err := ledger.CheckEntry(ctx, f.chain, prev, e); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &SegmentError{Index: e.Index, Reason: err.Error()}
+		}
+		prev = e
+	}
+
+	for idx := range entries {
+		if err := f.ledger.Append(entries[idx]); err != nil {
+			return err
+		}
+		f.last = &entries[idx]
+
+		if f.OnEntry != nil {
+			f.OnEntry(entries[idx])
+		}
+	}
+	return nil
+}
+
+// sync method connects to the leader and replicates its ledger, until the
+// connection ends or the input context is cancelled
+func (f *Follower) sync(ctx context.Context) error {
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	d := &net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", f.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	from := 0
+	if f.last != nil {
+		from = f.last.Index
+	}
+	if err := json.NewEncoder(conn).Encode(&Message{Type: TypeHello, From: from}); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	for step := 0; scanner.Scan(); step++ {
+		msg := &Message{}
+		if err := json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			return fmt.Errorf("invalid message from the leader: %s", err)
+		}
+
+		switch {
+		case msg.Type == TypeError:
+			return fmt.Errorf("leader error: %s", msg.Error)
+		case step == 0 && msg.Type == TypeManifest:
+			if err := f.handshake(msg.Manifest); err != nil {
+				return err
+			}
+		case step == 1 && msg.Type == TypeAnchor:
+			if err := f.checkAnchor(msg.Anchor); err != nil {
+				return err
+			}
+		case step > 1 && msg.Type == TypeSegment:
+			if err := f.apply(ctx, msg.Entries); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected %q message from the leader", msg.Type)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("leader closed the connection")
+}
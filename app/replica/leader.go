@@ -0,0 +1,252 @@
+package replica
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+const (
+	// subscriberBuffer is the number of live entries buffered for each
+	// follower; a follower which falls further behind is disconnected, and
+	// catches up from the ledger when it reconnects
+	subscriberBuffer int = 1024
+
+	// helloTimeout is the time a follower has to send its hello message
+	helloTimeout = 10 * time.Second
+)
+
+// subscriber struct is a follower's queue of live entries
+type subscriber struct {
+	entries chan ledger.Entry
+}
+
+// Leader struct runs a ledger-backed clock, and serves its entries to
+// followers
+type Leader struct {
+	ledger   *ledger.Ledger
+	recorder *ledger.Recorder
+
+	// OnEntry is called with each recorded entry, if set
+	OnEntry ledger.EntryFunc
+
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// NewLeader function creates a `Leader` for the input (open) ledger, which
+// resumes its chain from the last entry
+func NewLeader(l *ledger.Ledger) (*Leader, error) {
+	rec, err := ledger.NewRecorder(l)
+	if err != nil {
+		return nil, err
+	}
+
+	ld := &Leader{
+		ledger:   l,
+		recorder: rec,
+		subs:     map[*subscriber]struct{}{},
+	}
+	rec.OnEntry = ld.broadcast
+
+	return ld, nil
+}
+
+// Recorder method returns the leader's recorder, to record events
+func (ld *Leader) Recorder() *ledger.Recorder {
+	return ld.recorder
+}
+
+// Run method runs the leader's clock, until the input context is cancelled
+// (returning its error) or an entry fails to be appended
+func (ld *Leader) Run(ctx context.Context) error {
+	return ld.recorder.Run(ctx)
+}
+
+// broadcast method queues the input entry for every follower; dropping the
+// ones whose queue is full
+func (ld *Leader) broadcast(e ledger.Entry) {
+	if ld.OnEntry != nil {
+		ld.OnEntry(e)
+	}
+
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+
+	for sub := range ld.subs {
+		select {
+		case sub.entries <- e:
+		default:
+			close(sub.entries)
+			delete(ld.subs, sub)
+		}
+	}
+}
+
+func (ld *Leader) subscribe() *subscriber {
+	sub := &subscriber{entries: make(chan ledger.Entry, subscriberBuffer)}
+
+	ld.mu.Lock()
+	ld.subs[sub] = struct{}{}
+	ld.mu.Unlock()
+
+	return sub
+}
+
+func (ld *Leader) unsubscribe(sub *subscriber) {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+
+	if _, ok := ld.subs[sub]; ok {
+		close(sub.entries)
+		delete(ld.subs, sub)
+	}
+}
+
+// Serve method accepts followers on the input listener, serving each of them
+// until it disconnects; or until the input context is cancelled
+func (ld *Leader) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		conns = map[net.Conn]struct{}{}
+		wg    sync.WaitGroup
+	)
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
+
+	var err error
+	for {
+		conn, acceptErr := l.Accept()
+		if acceptErr != nil {
+			if ctx.Err() == nil {
+				err = acceptErr
+			}
+			break
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ld.ServeConn(ctx, conn)
+			conn.Close()
+
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}()
+	}
+
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// ServeConn method serves a single follower on the input connection: it reads
+// its hello message, and sends the manifest, the anchor entry and every later
+// entry -- until the follower disconnects or the input context is cancelled
+func (ld *Leader) ServeConn(ctx context.Context, conn net.Conn) error {
+	enc := json.NewEncoder(conn)
+
+	conn.SetReadDeadline(time.Now().Add(helloTimeout))
+
+	hello := &Message{}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(hello); err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if hello.Type != TypeHello || hello.From < 0 {
+		err := errors.New("expected a hello message")
+		enc.Encode(&Message{Type: TypeError, Error: err.Error()})
+		return err
+	}
+
+	if err := enc.Encode(&Message{Type: TypeManifest, Manifest: ld.ledger.Manifest()}); err != nil {
+		return err
+	}
+
+	// subscribe before reading the ledger, so that no entry is missed
+	// between the two; duplicates are skipped by index
+	sub := ld.subscribe()
+	defer ld.unsubscribe(sub)
+
+	dir := ld.ledger.Dir()
+
+	anchor := &Message{Type: TypeAnchor}
+	if hello.From > 0 {
+		e, err := ledger.Find(dir, hello.From)
+		if err != nil {
+			return err
+		}
+		anchor.Anchor = e
+	}
+	if err := enc.Encode(anchor); err != nil {
+		return err
+	}
+	if hello.From > 0 && anchor.Anchor == nil {
+		// the follower detects the fork
+		return nil
+	}
+
+	last := hello.From
+
+	err := ledger.ReadSegments(dir, last, func(entries []ledger.Entry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(&Message{Type: TypeSegment, Entries: entries}); err != nil {
+			return err
+		}
+		last = entries[len(entries)-1].Index
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-sub.entries:
+			if !ok {
+				err := fmt.Errorf("follower fell more than %d entries behind", subscriberBuffer)
+				enc.Encode(&Message{Type: TypeError, Error: err.Error()})
+				return err
+			}
+
+			if e.Index <= last {
+				continue
+			}
+
+			if err := enc.Encode(&Message{Type: TypeSegment, Entries: []ledger.Entry{e}}); err != nil {
+				return err
+			}
+			last = e.Index
+		}
+	}
+}
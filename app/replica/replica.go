@@ -0,0 +1,85 @@
+// Package replica replicates a ledger-backed clock from one leader process to
+// any number of follower processes, over TCP -- for Proof-of-History setups
+// where followers independently verify the leader's chain.
+//
+// The leader runs the clock (see `ledger.Recorder`) and serves its ledger.
+// A follower connects with the index of its last entry, and receives the
+// leader's entry at that index (its anchor) followed by every later entry: the
+// ones already in the leader's ledger, one segment at a time, and then each
+// new entry as it is recorded.
+//
+// Followers verify each segment against the chain rules before appending it
+// to their own copy of the ledger; and reconnect after a disconnect, catching
+// up from their last entry. A leader whose chain diverges from the follower's
+// copy is reported as a `ForkError`; and an invalid segment as a
+// `SegmentError` -- neither is ever appended.
+package replica
+
+import (
+	"fmt"
+
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+// Message types, set in the `Type` field of each message
+const (
+	// TypeHello is sent by a follower when it connects, with the index of
+	// its last entry
+	TypeHello string = "hello"
+
+	// TypeManifest is sent by the leader, in response to a hello
+	TypeManifest string = "manifest"
+
+	// TypeAnchor is sent by the leader after its manifest, with its entry at
+	// the follower's last index (if any)
+	TypeAnchor string = "anchor"
+
+	// TypeSegment is sent by the leader with a batch of consecutive entries
+	TypeSegment string = "segment"
+
+	// TypeError is sent by the leader before closing the connection, when it
+	// cannot serve the follower
+	TypeError string = "error"
+)
+
+// Message struct is a single message of the replication protocol. Messages
+// are JSON-encoded, one per line
+type Message struct {
+	Type     string           `json:"type"`
+	From     int              `json:"from,omitempty"`
+	Manifest *ledger.Manifest `json:"manifest,omitempty"`
+	Anchor   *ledger.Entry    `json:"anchor,omitempty"`
+	Entries  []ledger.Entry   `json:"entries,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// ForkError struct is returned by a follower when the leader's chain
+// diverges from its own copy of the ledger
+type ForkError struct {
+	// Index is the index where the chains diverge
+	Index int
+
+	// Local and Remote are the follower's and the leader's hash at the index;
+	// Remote is empty if the leader has no entry at the index
+	Local  string
+	Remote string
+
+	Reason string
+}
+
+func (e *ForkError) Error() string {
+	return fmt.Sprintf("fork at index %d: %s", e.Index, e.Reason)
+}
+
+// SegmentError struct is returned by a follower when a segment received from
+// the leader fails verification
+type SegmentError struct {
+	// Index is the index of the first invalid entry in the segment
+	Index int
+
+	Reason string
+}
+
+func (e *SegmentError) Error() string {
+	return fmt.Sprintf("invalid segment at index %d: %s", e.Index, e.Reason)
+}
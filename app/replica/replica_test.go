@@ -0,0 +1,207 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/ledger"
+)
+
+const testSeed string = "genesis_string"
+
+// startLeader function runs a leader on a new ledger, serving it on a local
+// TCP port; until the test ends
+func startLeader(t *testing.T) (*Leader, string) {
+	t.Helper()
+
+	l, err := ledger.Create(&ledger.Config{
+		Dir:         t.TempDir(),
+		Seed:        testSeed,
+		Interval:    1000,
+		SegmentSize: 5,
+	})
+	if err != nil {
+		t.Fatalf("FAILED -- [Replica] ledger.Create() failed: %s", err)
+	}
+
+	ld, err := NewLeader(l)
+	if err != nil {
+		t.Fatalf("FAILED -- [Replica] NewLeader() failed: %s", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAILED -- [Replica] net.Listen() failed: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ld.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		ld.Serve(ctx, lis)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		l.Close()
+	})
+	return ld, lis.Addr().String()
+}
+
+// follow function runs a follower until it has appended the input number of
+// entries, returning them
+func follow(t *testing.T, f *Follower, n int) []ledger.Entry {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var entries []ledger.Entry
+	f.OnEntry = func(e ledger.Entry) {
+		entries = append(entries, e)
+		if len(entries) == n {
+			cancel()
+		}
+	}
+
+	if err := f.Run(ctx); err != context.Canceled {
+		t.Fatalf("FAILED -- [Replica] Follower.Run() failed: %v", err)
+	}
+	return entries
+}
+
+func TestReplicate(t *testing.T) {
+	ld, addr := startLeader(t)
+	ld.Recorder().Record(context.Background(), []byte("event"))
+
+	f := NewFollower(addr, t.TempDir())
+	first := follow(t, f, 20)
+
+	// a follower which reconnects catches up from its last entry
+	time.Sleep(50 * time.Millisecond)
+	second := follow(t, f, 20)
+
+	if second[0].Index <= first[len(first)-1].Index {
+		t.Errorf("FAILED -- [Replica] resumed at index %v ; expected it after %v", second[0].Index, first[len(first)-1].Index)
+	}
+
+	var event bool
+	for _, e := range append(first, second...) {
+		remote, err := ledger.Find(ld.ledger.Dir(), e.Index)
+		if err != nil || remote == nil || remote.Hash != e.Hash {
+			t.Errorf("FAILED -- [Replica] entry #%v differs from the leader's: %+v", e.Index, remote)
+		}
+		event = event || e.Event != ""
+	}
+	if !event {
+		t.Errorf("FAILED -- [Replica] the recorded event was not replicated")
+	}
+
+	report, err := ledger.Verify(context.Background(), f.Dir, 0)
+	if err != nil || !report.Valid || report.Entries != 40 {
+		t.Errorf("FAILED -- [Replica] follower's ledger is invalid: %+v ; %v", report, err)
+	}
+}
+
+func TestFork(t *testing.T) {
+	_, addr := startLeader(t)
+
+	// a local ledger with the same seed, but a different history
+	dir := t.TempDir()
+	l, _ := ledger.Create(&ledger.Config{Dir: dir, Seed: testSeed, Interval: 1000})
+	chain, _ := clock.NewChain("sha256", testSeed)
+	chain.Next()
+	chain.Mix(chain.Digest([]byte("forged")))
+	l.Append(ledger.Entry{Index: chain.Index(), Hash: chain.Hash(), WallTime: time.Now()})
+	l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := NewFollower(addr, dir).Run(ctx)
+
+	var fErr *ForkError
+	if !errors.As(err, &fErr) || fErr.Index != 2 {
+		t.Errorf("FAILED -- [Replica] expected a fork at index 2 ; got %v", err)
+	}
+}
+
+func TestInvalidSegment(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAILED -- [Replica] net.Listen() failed: %s", err)
+	}
+	defer lis.Close()
+
+	chain, _ := clock.NewChain("sha256", testSeed)
+	valid := ledger.Entry{Index: 1, Hash: string(chain.Next()), WallTime: time.Now()}
+	forged := ledger.Entry{Index: 2, Hash: valid.Hash, WallTime: time.Now()}
+
+	manifest := &ledger.Manifest{Version: ledger.Version, Algorithm: "SHA256", Seed: testSeed, Interval: 1, SegmentSize: 10}
+
+	// a leader which disconnects after the first entry, and then sends a
+	// forged one
+	hellos := make(chan int, 2)
+	go func() {
+		for conn := 0; conn < 2; conn++ {
+			c, err := lis.Accept()
+			if err != nil {
+				return
+			}
+
+			hello := &Message{}
+			json.NewDecoder(c).Decode(hello)
+			hellos <- hello.From
+
+			enc := json.NewEncoder(c)
+			enc.Encode(&Message{Type: TypeManifest, Manifest: manifest})
+			if conn == 0 {
+				enc.Encode(&Message{Type: TypeAnchor})
+				enc.Encode(&Message{Type: TypeSegment, Entries: []ledger.Entry{valid}})
+			} else {
+				enc.Encode(&Message{Type: TypeAnchor, Anchor: &valid})
+				enc.Encode(&Message{Type: TypeSegment, Entries: []ledger.Entry{forged}})
+			}
+			c.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	f := NewFollower(lis.Addr().String(), t.TempDir())
+	f.Retry = 10 * time.Millisecond
+
+	var disconnects int
+	f.OnDisconnect = func(err error) { disconnects++ }
+
+	err = f.Run(ctx)
+
+	var sErr *SegmentError
+	if !errors.As(err, &sErr) || sErr.Index != 2 {
+		t.Errorf("FAILED -- [Replica] expected an invalid segment at index 2 ; got %v", err)
+	}
+	if disconnects != 1 {
+		t.Errorf("FAILED -- [Replica] expected 1 disconnect ; got %v", disconnects)
+	}
+	if from := []int{<-hellos, <-hellos}; from[0] != 0 || from[1] != 1 {
+		t.Errorf("FAILED -- [Replica] expected the follower to catch up from index 1 ; got hellos from %v", from)
+	}
+
+	report, _ := ledger.Verify(context.Background(), f.Dir, 0)
+	if report.LastIndex != 1 || !report.Valid {
+		t.Errorf("FAILED -- [Replica] the invalid segment should not be appended: %+v", report)
+	}
+}
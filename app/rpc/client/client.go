@@ -0,0 +1,219 @@
+// Package client is a Go client for the hashclock JSON-RPC server (in
+// `hashclock/rpc`), calling the `clock.HashClockService` methods over a TCP
+// connection, a Unix domain socket or any other stream -- such as the
+// standard input / output of a `hashclock rpc` process.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/rpc"
+)
+
+// ErrClosed error is returned by calls on a closed client, or on a client
+// whose connection was closed by the server
+var ErrClosed = errors.New("rpc client is closed")
+
+// ProgressFunc type is a function which receives the progress notifications
+// of a call
+type ProgressFunc func(t clock.Tick)
+
+// call struct holds the state of a pending call
+type call struct {
+	done     chan *rpc.Response
+	progress ProgressFunc
+}
+
+// Client struct is a JSON-RPC client for the hashclock server. It is safe
+// for concurrent use, with any number of calls in flight at the same time
+type Client struct {
+	conn io.ReadWriteCloser
+
+	wmu sync.Mutex // guards writes
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]*call
+	err     error
+}
+
+// Dial function connects to a hashclock JSON-RPC server on the input network
+// ("tcp" or "unix") and address
+func Dial(network, addr string) (*Client, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn), nil
+}
+
+// New function creates a `Client` over the input connection, which is closed
+// when the client is closed
+func New(conn io.ReadWriteCloser) *Client {
+	c := &Client{
+		conn:    conn,
+		pending: map[string]*call{},
+	}
+	go c.readLoop()
+
+	return c
+}
+
+// Close method closes the client's connection; pending calls return
+// `ErrClosed`
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call method calls the input `clock.HashClockService` method (e.g. "RecHash")
+// with the parameters in the input request, waiting for its response.
+//
+// If a progress function is set, the server is asked to report the call's
+// progress, which is passed to it. If the input context is cancelled before
+// the call is done, a cancellation is sent to the server and the context's
+// error is returned
+func (c *Client) Call(ctx context.Context, method string, req *api.Request, progress ProgressFunc) (*clock.HashClockResponse, error) {
+	params, err := json.Marshal(&rpc.Params{
+		Request:  *req,
+		Progress: progress != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.FormatInt(c.nextID, 10))
+	pc := &call{
+		done:     make(chan *rpc.Response, 1),
+		progress: progress,
+	}
+	c.pending[string(id)] = pc
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(&rpc.Request{
+		JSONRPC: rpc.Version,
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res, ok := <-pc.done:
+		if !ok {
+			return nil, ErrClosed
+		}
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		out := &clock.HashClockResponse{}
+		if err := json.Unmarshal(res.Result, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case <-ctx.Done():
+		cancelParams, _ := json.Marshal(&rpc.CancelParams{ID: id})
+		c.write(&rpc.Request{
+			JSONRPC: rpc.Version,
+			Method:  rpc.MethodCancel,
+			Params:  cancelParams,
+		})
+		return nil, ctx.Err()
+	}
+}
+
+// write method sends the input message as a single line of JSON
+func (c *Client) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err = c.conn.Write(append(b, '\n'))
+	return err
+}
+
+// message struct holds the fields of either a response or a notification
+// from the server
+type message struct {
+	rpc.Response
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// readLoop method reads the server's messages, passing responses and
+// progress notifications to their pending calls
+func (c *Client) readLoop() {
+	br := bufio.NewReader(c.conn)
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+
+		msg := &message{}
+		if err := json.Unmarshal(line, msg); err != nil {
+			continue
+		}
+
+		if msg.Method == rpc.MethodProgress {
+			p := &rpc.ProgressParams{}
+			if err := json.Unmarshal(msg.Params, p); err != nil {
+				continue
+			}
+
+			c.mu.Lock()
+			pc, ok := c.pending[string(p.ID)]
+			c.mu.Unlock()
+
+			if ok && pc.progress != nil {
+				pc.progress(p.Tick)
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		pc, ok := c.pending[string(msg.ID)]
+		c.mu.Unlock()
+
+		if ok {
+			res := msg.Response
+			pc.done <- &res
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.err = ErrClosed
+	for _, pc := range c.pending {
+		close(pc.done)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/rpc"
+)
+
+// serve function starts a JSON-RPC server on the input network, stopped
+// when the test ends, and returns its address
+func serve(t *testing.T, network, addr string) string {
+	t.Helper()
+
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		t.Fatalf("unable to listen on %s %s: %s", network, addr, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		rpc.New(&rpc.Config{ProgressInterval: time.Millisecond}).Serve(ctx, l)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return l.Addr().String()
+}
+
+func TestCall(t *testing.T) {
+	for _, network := range []string{"tcp", "unix"} {
+		addr := "127.0.0.1:0"
+		if network == "unix" {
+			addr = filepath.Join(t.TempDir(), "hashclock.sock")
+		}
+
+		c, err := Dial(network, serve(t, network, addr))
+		if err != nil {
+			t.Fatalf("Dial(%s) resulted in an unexpected error: %s", network, err)
+		}
+		defer c.Close()
+
+		res, err := c.Call(context.Background(), "RecHash", &api.Request{Seed: "Hello World!", Iterations: 10}, nil)
+		if err != nil {
+			t.Fatalf("%s: RecHash resulted in an unexpected error: %s", network, err)
+		}
+		if res.Hash != "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705" {
+			t.Errorf("%s: RecHash = %s ; expected the 10th hash", network, res.Hash)
+		}
+
+		_, err = c.Call(context.Background(), "RecHash", &api.Request{Seed: "Hello World!"}, nil)
+		var rpcErr *rpc.Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != rpc.CodeInvalidParams {
+			t.Errorf("%s: RecHash without iterations = %v ; expected code %v", network, err, rpc.CodeInvalidParams)
+		}
+
+		t.Logf("-- TESTED -- %s: RecHash = %s", network, res.Hash)
+	}
+}
+
+func TestCallCancel(t *testing.T) {
+	c, err := Dial("tcp", serve(t, "tcp", "127.0.0.1:0"))
+	if err != nil {
+		t.Fatalf("Dial() resulted in an unexpected error: %s", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan clock.Tick, 1)
+
+	errCh := make(chan error, 1)
+	go func() {
+		// a hash which is not in the chain; runs until it is cancelled
+		_, err := c.Call(ctx, "Verify", &api.Request{Seed: "Hello World!", Hash: strings.Repeat("0", 64)}, func(t clock.Tick) {
+			select {
+			case ticks <- t:
+			default:
+			}
+		})
+		errCh <- err
+	}()
+
+	select {
+	case tick := <-ticks:
+		if tick.Index <= 0 || tick.Hash == "" {
+			t.Errorf("invalid progress notification: %v", tick)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no progress notification received")
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Verify = %v ; expected %v", err, context.Canceled)
+	}
+
+	// the connection is still usable
+	res, err := c.Call(context.Background(), "Hash", &api.Request{Seed: "Hello World!"}, nil)
+	if err != nil {
+		t.Fatalf("Hash resulted in an unexpected error: %s", err)
+	}
+	if res.Hash != "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069" {
+		t.Errorf("Hash = %s ; expected the seed's hash", res.Hash)
+	}
+}
@@ -0,0 +1,99 @@
+// Package rpc exposes the `clock.HashClockService` methods as a JSON-RPC 2.0
+// service, over any stream: standard input / output, a TCP listener or a
+// Unix domain socket.
+//
+// Messages are JSON objects (or batches, as JSON arrays), delimited by
+// newlines. Each method takes the parameters of an `api.Request` as a named
+// parameters object, and returns a `clock.HashClockResponse`.
+//
+// Long-running calls can report their progress, when their parameters have
+// `"progress": true`, as `$/progress` notifications; and can be cancelled
+// with a `$/cancelRequest` notification with the request's ID.
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Version is the JSON-RPC version set in all messages
+const Version string = "2.0"
+
+// Notification methods
+const (
+	// MethodCancel is sent by the client to cancel an in-flight request
+	MethodCancel string = "$/cancelRequest"
+
+	// MethodProgress is sent by the server to report the progress of a
+	// request
+	MethodProgress string = "$/progress"
+)
+
+// Error codes, as defined by JSON-RPC 2.0; and `CodeRequestCancelled`
+// for requests which were cancelled by the client
+const (
+	CodeParseError       int = -32700
+	CodeInvalidRequest   int = -32600
+	CodeMethodNotFound   int = -32601
+	CodeInvalidParams    int = -32602
+	CodeInternalError    int = -32603
+	CodeRequestCancelled int = -32800
+)
+
+// Request struct is a JSON-RPC request or, without an ID, a notification
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response struct is a JSON-RPC response, with either a result or an error
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error struct is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error method implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc: %s (code %d)", e.Message, e.Code)
+}
+
+// Params struct holds the parameters for a method call: the fields of an
+// `api.Request`, and whether to report the call's progress
+type Params struct {
+	api.Request
+
+	Progress bool `json:"progress,omitempty"`
+}
+
+// CancelParams struct holds the parameters for a `$/cancelRequest`
+// notification
+type CancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// ProgressParams struct holds the parameters for a `$/progress`
+// notification: the request's ID, and the last hash calculated
+type ProgressParams struct {
+	ID json.RawMessage `json:"id"`
+	clock.Tick
+}
+
+// Notification struct is a JSON-RPC notification sent by the server
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
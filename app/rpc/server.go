@@ -0,0 +1,439 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Config struct defines the configuration for a JSON-RPC server
+type Config struct {
+	// MaxJobs is the maximum number of method calls running at the same
+	// time, across all connections; further calls wait for a free slot
+	MaxJobs int
+
+	// ProgressInterval is the minimum time between two `$/progress`
+	// notifications for the same request
+	ProgressInterval time.Duration
+
+	// Cache is a cache of checkpoints shared by all method calls, so that
+	// repeated calls for the same seed resume from the nearest checkpoint;
+	// nil does not cache any checkpoints
+	Cache clock.CheckpointCache
+
+	// CacheInterval is the number of indices between two cached checkpoints
+	CacheInterval int
+}
+
+// DefaultConfig function returns a `Config` with default values, running as
+// many calls as CPUs at the same time and reporting progress every second
+func DefaultConfig() *Config {
+	return &Config{
+		MaxJobs:          runtime.NumCPU(),
+		ProgressInterval: time.Second,
+	}
+}
+
+// Server struct is a JSON-RPC 2.0 server for the `clock.HashClockService`
+type Server struct {
+	cfg *Config
+	sem chan struct{}
+}
+
+// New function creates a `Server` with the input configuration; unset
+// values are replaced by the defaults in `DefaultConfig`
+func New(cfg *Config) *Server {
+	def := DefaultConfig()
+	if cfg == nil {
+		cfg = def
+	}
+	if cfg.MaxJobs <= 0 {
+		cfg.MaxJobs = def.MaxJobs
+	}
+	if cfg.ProgressInterval <= 0 {
+		cfg.ProgressInterval = def.ProgressInterval
+	}
+
+	return &Server{
+		cfg: cfg,
+		sem: make(chan struct{}, cfg.MaxJobs),
+	}
+}
+
+// Serve method accepts connections on the input listener, serving each of
+// them with `ServeConn`, until the input context is cancelled -- when the
+// listener and all connections are closed
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		conns = map[net.Conn]struct{}{}
+		wg    sync.WaitGroup
+	)
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
+
+	var err error
+	for {
+		conn, acceptErr := l.Accept()
+		if acceptErr != nil {
+			if ctx.Err() == nil {
+				err = acceptErr
+			}
+			break
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s.ServeConn(ctx, conn, conn)
+			conn.Close()
+
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}()
+	}
+
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// ServeConn method reads requests from the input reader and writes the
+// responses and notifications to the input writer, until the reader is
+// exhausted (and all in-flight requests are answered) or the input context
+// is cancelled -- in which case in-flight requests are cancelled and the
+// context's error is returned
+func (s *Server) ServeConn(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := &conn{
+		srv:      s,
+		w:        w,
+		cancel:   cancel,
+		inflight: map[string]context.CancelFunc{},
+	}
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var err error
+
+loop:
+	for {
+		select {
+		case line := <-lines:
+			c.handle(ctx, line)
+		case err = <-readErr:
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			break loop
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		}
+	}
+
+	if err != nil {
+		cancel()
+	}
+	c.wg.Wait()
+
+	return err
+}
+
+// conn struct holds the state of a connection: its writer and the
+// cancellation functions of its in-flight requests
+type conn struct {
+	srv    *Server
+	cancel context.CancelFunc
+
+	wmu sync.Mutex // guards writes
+	w   io.Writer
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// write method writes the input message as a single line of JSON. A
+// connection which cannot be written to is cancelled
+func (c *conn) write(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if _, err := c.w.Write(append(b, '\n')); err != nil {
+		c.cancel()
+	}
+}
+
+// newError function builds an error response for the input request ID
+func newError(id json.RawMessage, code int, msg string) *Response {
+	return &Response{
+		JSONRPC: Version,
+		ID:      id,
+		Error:   &Error{Code: code, Message: msg},
+	}
+}
+
+// handle method parses a single message or a batch, calling each request in
+// its own goroutine
+func (c *conn) handle(ctx context.Context, line []byte) {
+	line = bytes.TrimSpace(line)
+
+	if !json.Valid(line) {
+		c.write(newError(nil, CodeParseError, "parse error"))
+		return
+	}
+
+	// single request
+	if line[0] != '[' {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			if res := c.call(ctx, line); res != nil {
+				c.write(res)
+			}
+		}()
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil || len(batch) == 0 {
+		c.write(newError(nil, CodeInvalidRequest, "invalid request: empty batch"))
+		return
+	}
+
+	// the batch's responses are sent together, once all of its requests
+	// are done
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		var (
+			mu        sync.Mutex
+			wg        sync.WaitGroup
+			responses = make([]*Response, 0, len(batch))
+		)
+
+		for _, raw := range batch {
+			wg.Add(1)
+			go func(raw json.RawMessage) {
+				defer wg.Done()
+
+				if res := c.call(ctx, raw); res != nil {
+					mu.Lock()
+					responses = append(responses, res)
+					mu.Unlock()
+				}
+			}(raw)
+		}
+		wg.Wait()
+
+		if len(responses) > 0 {
+			c.write(responses)
+		}
+	}()
+}
+
+// call method runs a single request, returning its response; or nil for
+// notifications
+func (c *conn) call(ctx context.Context, raw json.RawMessage) *Response {
+	req := &Request{}
+	if err := json.Unmarshal(raw, req); err != nil {
+		return newError(nil, CodeInvalidRequest, "invalid request: "+err.Error())
+	}
+
+	if req.JSONRPC != Version || req.Method == "" {
+		if req.ID == nil {
+			return nil
+		}
+		return newError(req.ID, CodeInvalidRequest, "invalid request: expected jsonrpc 2.0 with a method")
+	}
+
+	// notifications
+	if req.ID == nil {
+		if req.Method == MethodCancel {
+			p := &CancelParams{}
+			if err := json.Unmarshal(req.Params, p); err == nil {
+				c.cancelRequest(p.ID)
+			}
+		}
+		return nil
+	}
+
+	m, ok := api.Methods[req.Method]
+	if !ok {
+		return newError(req.ID, CodeMethodNotFound, "method not found: "+req.Method)
+	}
+
+	p := &Params{}
+	if len(req.Params) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(req.Params))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(p); err != nil {
+			return newError(req.ID, CodeInvalidParams, "invalid params: "+err.Error())
+		}
+	}
+	p.Request.Method = m.Name
+
+	if _, err := api.Validate(&p.Request); err != nil {
+		return newError(req.ID, CodeInvalidParams, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !c.register(req.ID, cancel) {
+		return newError(req.ID, CodeInvalidRequest, "invalid request: duplicate request ID")
+	}
+	defer c.unregister(req.ID)
+
+	res, err := c.run(ctx, m, p, req.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, api.ErrInvalidRequest):
+			return newError(req.ID, CodeInvalidParams, err.Error())
+		case errors.Is(err, context.Canceled):
+			return newError(req.ID, CodeRequestCancelled, "request cancelled")
+		default:
+			return newError(req.ID, CodeInternalError, err.Error())
+		}
+	}
+
+	result, err := json.Marshal(res)
+	if err != nil {
+		return newError(req.ID, CodeInternalError, err.Error())
+	}
+
+	return &Response{
+		JSONRPC: Version,
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+// run method waits for a free slot and calls the method, sending progress
+// notifications if requested
+func (c *conn) run(ctx context.Context, m *api.Method, p *Params, id json.RawMessage) (*clock.HashClockResponse, error) {
+	select {
+	case c.srv.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.srv.sem }()
+
+	svc, err := api.NewService(ctx, &p.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.srv.cfg.Cache != nil {
+		if err := svc.SetCheckpointCache(c.srv.cfg.Cache, c.srv.cfg.CacheInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Progress {
+		var last time.Time
+		svc.SetProgressFunc(func(t clock.Tick) {
+			if t.WallTime.Sub(last) < c.srv.cfg.ProgressInterval {
+				return
+			}
+			last = t.WallTime
+
+			c.write(&Notification{
+				JSONRPC: Version,
+				Method:  MethodProgress,
+				Params:  &ProgressParams{ID: id, Tick: t},
+			})
+		})
+	}
+
+	return m.Run(svc, &p.Request)
+}
+
+// register method stores the cancellation function of an in-flight request;
+// returning false if the ID is already in use
+func (c *conn) register(id json.RawMessage, cancel context.CancelFunc) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.inflight[string(id)]; ok {
+		return false
+	}
+	c.inflight[string(id)] = cancel
+	return true
+}
+
+func (c *conn) unregister(id json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.inflight, string(id))
+}
+
+// cancelRequest method cancels the in-flight request with the input ID, if
+// any
+func (c *conn) cancelRequest(id json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cancel, ok := c.inflight[string(bytes.TrimSpace(id))]; ok {
+		cancel()
+	}
+}
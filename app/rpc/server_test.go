@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeConn(t *testing.T) {
+	tests := []struct {
+		input string
+		id    string
+		code  int
+		hash  string
+	}{
+		{
+			input: `{"jsonrpc":"2.0","id":1,"method":"Hash","params":{"seed":"Hello World!"}}`,
+			id:    "1",
+			hash:  "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069",
+		}, {
+			input: `{"jsonrpc":"2.0","id":"a","method":"RecHash","params":{"seed":"Hello World!","iterations":10}}`,
+			id:    `"a"`,
+			hash:  "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705",
+		}, {
+			input: `{"jsonrpc":"2.0","id":2,"method":"RecHash","params":{"seed":"Hello World!","iterations":0}}`,
+			id:    "2",
+			code:  CodeInvalidParams,
+		}, {
+			input: `{"jsonrpc":"2.0","id":3,"method":"Hash","params":{"seed":"Hello World!","unknown":true}}`,
+			id:    "3",
+			code:  CodeInvalidParams,
+		}, {
+			input: `{"jsonrpc":"2.0","id":4,"method":"SHA256"}`,
+			id:    "4",
+			code:  CodeMethodNotFound,
+		}, {
+			input: `{"jsonrpc":"1.0","id":5,"method":"Hash"}`,
+			id:    "5",
+			code:  CodeInvalidRequest,
+		}, {
+			input: `{"jsonrpc":"2.0","id":6,`,
+			id:    "null",
+			code:  CodeParseError,
+		}, {
+			input: `[]`,
+			id:    "null",
+			code:  CodeInvalidRequest,
+		},
+	}
+
+	for id, test := range tests {
+		out := &bytes.Buffer{}
+
+		err := New(nil).ServeConn(context.Background(), strings.NewReader(test.input+"\n"), out)
+		if err != nil {
+			t.Errorf("#%v ServeConn() resulted in an unexpected error: %s", id, err)
+			continue
+		}
+
+		res := &Response{}
+		if err := json.Unmarshal(out.Bytes(), res); err != nil {
+			t.Errorf("#%v invalid response %q: %s", id, out.String(), err)
+			continue
+		}
+
+		if string(res.ID) != test.id {
+			t.Errorf("#%v response ID = %s ; expected %s", id, res.ID, test.id)
+		}
+
+		if test.code != 0 {
+			if res.Error == nil || res.Error.Code != test.code {
+				t.Errorf("#%v response error = %v ; expected code %v", id, res.Error, test.code)
+			}
+		} else if !strings.Contains(string(res.Result), test.hash) {
+			t.Errorf("#%v response result = %s ; expected hash %s", id, res.Result, test.hash)
+		}
+
+		t.Logf("#%v -- TESTED -- %s => %s", id, test.input, strings.TrimSpace(out.String()))
+	}
+}
+
+func TestServeConnBatch(t *testing.T) {
+	input := `[{"jsonrpc":"2.0","id":1,"method":"Hash","params":{"seed":"Hello World!"}},` +
+		`{"jsonrpc":"2.0","method":"Hash","params":{"seed":"Hello World!"}},` +
+		`{"jsonrpc":"2.0","id":2,"method":"Unknown"}]` + "\n"
+	out := &bytes.Buffer{}
+
+	if err := New(nil).ServeConn(context.Background(), strings.NewReader(input), out); err != nil {
+		t.Fatalf("ServeConn() resulted in an unexpected error: %s", err)
+	}
+
+	var res []*Response
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("invalid batch response %q: %s", out.String(), err)
+	}
+
+	// the notification is not answered
+	if len(res) != 2 {
+		t.Fatalf("batch response has %v responses ; expected %v", len(res), 2)
+	}
+
+	for _, r := range res {
+		switch string(r.ID) {
+		case "1":
+			if r.Error != nil {
+				t.Errorf("response #1 error = %v ; expected a result", r.Error)
+			}
+		case "2":
+			if r.Error == nil || r.Error.Code != CodeMethodNotFound {
+				t.Errorf("response #2 error = %v ; expected code %v", r.Error, CodeMethodNotFound)
+			}
+		default:
+			t.Errorf("unexpected response ID %s", r.ID)
+		}
+	}
+}
+
+// syncBuffer struct is a writer which can be read while being written to
+type syncBuffer struct {
+	lines chan string
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lines <- string(p)
+	return len(p), nil
+}
+
+func TestServeConnCancel(t *testing.T) {
+	r, w := io.Pipe()
+	out := &syncBuffer{lines: make(chan string, 16)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- New(&Config{ProgressInterval: time.Millisecond}).ServeConn(context.Background(), r, out)
+	}()
+
+	// a hash which is not in the chain; runs until it is cancelled
+	io.WriteString(w, `{"jsonrpc":"2.0","id":7,"method":"Verify","params":{"seed":"Hello World!","hash":"`+strings.Repeat("0", 64)+`","progress":true}}`+"\n")
+
+	select {
+	case line := <-out.lines:
+		if !strings.Contains(line, MethodProgress) {
+			t.Fatalf("first message = %s ; expected a progress notification", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no progress notification received")
+	}
+
+	io.WriteString(w, `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":7}}`+"\n")
+	w.Close()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case line := <-out.lines:
+			if strings.Contains(line, MethodProgress) {
+				continue
+			}
+
+			res := &Response{}
+			json.Unmarshal([]byte(line), res)
+			if res.Error == nil || res.Error.Code != CodeRequestCancelled {
+				t.Errorf("response = %s ; expected code %v", line, CodeRequestCancelled)
+			}
+
+			if err := <-done; err != nil {
+				t.Errorf("ServeConn() resulted in an unexpected error: %s", err)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("the request was not cancelled")
+		}
+	}
+}
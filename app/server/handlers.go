@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ZalgoNoise/hashclock/api"
+)
+
+// endpoints maps each method endpoint to the `clock.HashClockService`
+// method it calls
+var endpoints = map[string]string{
+	"/v1/hash":                 "Hash",
+	"/v1/rechash":              "RecHash",
+	"/v1/rechash/timeout":      "RecHashTimeout",
+	"/v1/verify":               "Verify",
+	"/v1/verify/index":         "VerifyIndex",
+	"/v1/verify/timeout":       "VerifyTimeout",
+	"/v1/verify/index/timeout": "VerifyIndexTimeout",
+	"/v1/search":               "SearchPrefix",
+	"/v1/search/timeout":       "SearchPrefixTimeout",
+}
+
+// routes method registers all API handlers in the server's mux
+func (s *Server) routes() {
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.Handle("/metrics", s.cfg.Metrics)
+	s.mux.HandleFunc("/v1/jobs", s.handleJobs)
+	s.mux.HandleFunc("/v1/jobs/", s.handleJob)
+
+	for path, method := range endpoints {
+		s.mux.HandleFunc(path, s.handleMethod(method))
+	}
+}
+
+// errorResponse struct is the JSON object returned on errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON function writes the input object as the JSON response body, with
+// the input status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError function writes the input error as a JSON response, picking the
+// status code from the type of error
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, api.ErrInvalidRequest):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrQueueFull):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+// decodeRequest method reads the JSON request body into an `api.Request`
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request) (*api.Request, error) {
+	req := &api.Request{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(req); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON body -- %s", api.ErrInvalidRequest, err)
+	}
+	return req, nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleMethod method returns the handler for a method endpoint. The method
+// is called synchronously, unless the `async` query parameter is set to
+// `true` -- in which case a job is created
+func (s *Server) handleMethod(method string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+			return
+		}
+
+		req, err := s.decodeRequest(w, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		req.Method = method
+
+		if r.URL.Query().Get("async") == "true" {
+			s.createJob(w, req)
+			return
+		}
+
+		if _, err := api.Validate(req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := s.acquire(r.Context()); err != nil {
+			writeError(w, err)
+			return
+		}
+		defer s.release()
+
+		res, err := s.call(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleJobs method lists all jobs (GET) or creates a new job (POST), with
+// the method set in the request body
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.jobs.list())
+	case http.MethodPost:
+		req, err := s.decodeRequest(w, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		s.createJob(w, req)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+	}
+}
+
+// handleJob method returns (GET) or cancels (DELETE) the job with the ID
+// in the request path
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/jobs/")
+
+	switch r.Method {
+	case http.MethodGet:
+		j, err := s.jobs.get(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, j)
+	case http.MethodDelete:
+		j, err := s.jobs.cancel(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, j)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+	}
+}
+
+// createJob method validates the input request and starts it as an
+// asynchronous job, responding with the new job's ID and status
+func (s *Server) createJob(w http.ResponseWriter, req *api.Request) {
+	if _, err := api.Validate(req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	j, err := s.jobs.add(req, cancel, s.cfg.MaxQueue)
+	if err != nil {
+		cancel()
+		writeError(w, err)
+		return
+	}
+
+	s.wg.Add(1)
+	go s.runJob(ctx, cancel, j.ID, req)
+
+	w.Header().Set("Location", "/v1/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, j)
+}
+
+// runJob method waits for a free slot and runs the job's method call,
+// storing its result
+func (s *Server) runJob(ctx context.Context, cancel context.CancelFunc, id string, req *api.Request) {
+	defer s.wg.Done()
+	defer cancel()
+
+	if err := s.acquire(ctx); err != nil {
+		s.jobs.finish(id, nil, err)
+		return
+	}
+	defer s.release()
+
+	s.jobs.start(id)
+	res, err := s.call(ctx, req)
+	s.jobs.finish(id, res, err)
+}
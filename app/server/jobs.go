@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Job status values
+const (
+	StatusQueued    string = "queued"
+	StatusRunning   string = "running"
+	StatusDone      string = "done"
+	StatusFailed    string = "failed"
+	StatusCancelled string = "cancelled"
+)
+
+var (
+	// ErrJobNotFound error is returned when a job ID is unknown (or the job
+	// has expired)
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrQueueFull error is returned when there are too many pending jobs
+	// to accept a new one
+	ErrQueueFull = errors.New("job queue is full")
+)
+
+// Job struct describes an asynchronous `clock.HashClockService` method call,
+// and is the JSON object returned by the jobs endpoints
+type Job struct {
+	ID       string                   `json:"id"`
+	Status   string                   `json:"status"`
+	Request  *api.Request             `json:"request"`
+	Response *clock.HashClockResponse `json:"response,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+	Created  time.Time                `json:"created"`
+	Started  *time.Time               `json:"started,omitempty"`
+	Finished *time.Time               `json:"finished,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// jobStore struct keeps track of all asynchronous jobs, which are kept
+// after finishing for a certain amount of time (the TTL)
+type jobStore struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	pending int
+	ttl     time.Duration
+}
+
+func newJobStore(ttl time.Duration) *jobStore {
+	return &jobStore{
+		jobs: map[string]*Job{},
+		ttl:  ttl,
+	}
+}
+
+// newID function generates a random 16-byte, hex-encoded job ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// add method registers a new queued job for the input request, if there
+// are fewer than `maxPending` queued or running jobs; returning a copy of it
+func (s *jobStore) add(r *api.Request, cancel context.CancelFunc, maxPending int) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+
+	if s.pending >= maxPending {
+		return nil, ErrQueueFull
+	}
+
+	j := &Job{
+		ID:      id,
+		Status:  StatusQueued,
+		Request: r,
+		Created: time.Now(),
+		cancel:  cancel,
+	}
+
+	s.jobs[id] = j
+	s.pending++
+
+	c := *j
+	return &c, nil
+}
+
+// start method marks the job as running
+func (s *jobStore) start(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.jobs[id]; ok && j.Status == StatusQueued {
+		now := time.Now()
+		j.Status = StatusRunning
+		j.Started = &now
+	}
+}
+
+// finish method stores the result of the job. A job which was cancelled
+// keeps its status
+func (s *jobStore) finish(id string, res *clock.HashClockResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	j.Finished = &now
+	s.pending--
+
+	switch {
+	case j.Status == StatusCancelled:
+	case err != nil:
+		j.Status = StatusFailed
+		j.Error = err.Error()
+	default:
+		j.Status = StatusDone
+		j.Response = res
+	}
+}
+
+// cancel method cancels a queued or running job; a finished job is not
+// affected
+func (s *jobStore) cancel(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	if j.Status == StatusQueued || j.Status == StatusRunning {
+		j.Status = StatusCancelled
+		j.cancel()
+	}
+
+	c := *j
+	return &c, nil
+}
+
+// get method returns a copy of the job with the input ID
+func (s *jobStore) get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	c := *j
+	return &c, nil
+}
+
+// list method returns a copy of all jobs, sorted by creation time
+func (s *jobStore) list() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		c := *j
+		jobs = append(jobs, &c)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+	return jobs
+}
+
+// expire method removes the jobs which finished longer than the TTL ago.
+// It must be called while holding the lock
+func (s *jobStore) expire() {
+	for id, j := range s.jobs {
+		if j.Finished != nil && time.Since(*j.Finished) > s.ttl {
+			delete(s.jobs, id)
+		}
+	}
+}
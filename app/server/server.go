@@ -0,0 +1,258 @@
+// Package server exposes the `clock.HashClockService` methods as a HTTP/JSON
+// API, so that hashes can be calculated and verified without the hashclock
+// binary.
+//
+// Short methods can be called synchronously, while long-running methods
+// (with a timeout, or searching for a hash) can also run as asynchronous jobs,
+// which are polled for their status and result, and can be cancelled.
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/api"
+	"github.com/ZalgoNoise/hashclock/clock"
+	"github.com/ZalgoNoise/hashclock/metrics"
+)
+
+// Config struct defines the configuration for a hashclock HTTP server
+type Config struct {
+	// Addr is the TCP address to listen on (e.g. ":8080")
+	Addr string
+
+	// MaxJobs is the maximum number of method calls (synchronous or
+	// asynchronous) running at the same time; further calls wait for
+	// a free slot
+	MaxJobs int
+
+	// MaxQueue is the maximum number of asynchronous jobs queued or
+	// running at the same time; further jobs are rejected
+	MaxQueue int
+
+	// JobTTL is how long a finished job is kept for polling
+	JobTTL time.Duration
+
+	// ShutdownTimeout is how long a graceful shutdown waits for open
+	// connections and running jobs, before cancelling them
+	ShutdownTimeout time.Duration
+
+	// MaxBodySize is the maximum size (in bytes) of a request body
+	MaxBodySize int64
+
+	// Metrics collects the server's metrics, served on `/metrics`
+	Metrics *metrics.Metrics
+
+	// Cache is a cache of checkpoints shared by all method calls, so that
+	// repeated calls for the same seed resume from the nearest checkpoint;
+	// nil does not cache any checkpoints
+	Cache clock.CheckpointCache
+
+	// CacheInterval is the number of indices between two cached checkpoints
+	CacheInterval int
+}
+
+// DefaultConfig function returns a `Config` with default values, listening
+// on port 8080 and running as many jobs as CPUs at the same time
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:            ":8080",
+		MaxJobs:         runtime.NumCPU(),
+		MaxQueue:        100,
+		JobTTL:          10 * time.Minute,
+		ShutdownTimeout: 10 * time.Second,
+		MaxBodySize:     1 << 20,
+		Metrics:         metrics.New(),
+	}
+}
+
+// Server struct is a HTTP/JSON server for the `clock.HashClockService`
+type Server struct {
+	cfg  *Config
+	mux  *http.ServeMux
+	jobs *jobStore
+	sem  chan struct{}
+	wg   sync.WaitGroup
+
+	// jobs run under this context, which is cancelled on shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New function creates a `Server` with the input configuration; unset
+// values are replaced by the defaults in `DefaultConfig`
+func New(cfg *Config) *Server {
+	def := DefaultConfig()
+	if cfg == nil {
+		cfg = def
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = def.Addr
+	}
+	if cfg.MaxJobs <= 0 {
+		cfg.MaxJobs = def.MaxJobs
+	}
+	if cfg.MaxQueue <= 0 {
+		cfg.MaxQueue = def.MaxQueue
+	}
+	if cfg.JobTTL <= 0 {
+		cfg.JobTTL = def.JobTTL
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = def.ShutdownTimeout
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = def.MaxBodySize
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = def.Metrics
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Server{
+		cfg:    cfg,
+		mux:    http.NewServeMux(),
+		jobs:   newJobStore(cfg.JobTTL),
+		sem:    make(chan struct{}, cfg.MaxJobs),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	s.routes()
+
+	return s
+}
+
+// Handle method registers an additional handler in the server's mux, for
+// features which are served alongside the API
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Metrics method returns the server's metrics, so that features served
+// alongside the API can report to them
+func (s *Server) Metrics() *metrics.Metrics {
+	return s.cfg.Metrics
+}
+
+// Handler method returns the server's HTTP handler
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe method listens on the configured address and serves the
+// API until the input context is cancelled. It then shuts down gracefully:
+// no new connections are accepted, open requests have up to the configured
+// shutdown timeout to complete, and any remaining jobs are cancelled
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve method serves the API on the input listener until the input context
+// is cancelled, like `ListenAndServe`
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-errCh:
+		s.cancel()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		// connections still open after the timeout are closed
+		srv.Close()
+	}
+
+	// give asynchronous jobs the rest of the shutdown timeout to finish
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		s.cancel()
+		<-done
+	}
+	s.cancel()
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return err
+}
+
+// acquire method waits for a free slot to run a method call, until the
+// input context is done
+func (s *Server) acquire(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release method frees a slot taken with `acquire`
+func (s *Server) release() {
+	<-s.sem
+}
+
+// call method calls the method in the input request, recording it in the
+// server's metrics
+func (s *Server) call(ctx context.Context, req *api.Request) (*clock.HashClockResponse, error) {
+	done := s.cfg.Metrics.Start()
+	defer done()
+
+	res, err := s.run(ctx, req)
+	s.cfg.Metrics.Observe(req, res, err)
+
+	return res, err
+}
+
+// run method validates the input request and calls its method on a new
+// `clock.HashClockService`, using the server's checkpoint cache (if set)
+func (s *Server) run(ctx context.Context, req *api.Request) (*clock.HashClockResponse, error) {
+	m, err := api.Validate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := api.NewService(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.Cache != nil {
+		if err := svc.SetCheckpointCache(s.cfg.Cache, s.cfg.CacheInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.Run(svc, req)
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+func post(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+
+	res, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("POST %s resulted in an unexpected error: %s", url, err)
+	}
+	return res
+}
+
+func TestMethods(t *testing.T) {
+	ts := httptest.NewServer(New(nil).Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		body   string
+		status int
+		hash   string
+		match  bool
+	}{
+		{
+			path:   "/v1/hash",
+			body:   `{"seed":"Hello World!"}`,
+			status: http.StatusOK,
+			hash:   "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069",
+		}, {
+			path:   "/v1/rechash",
+			body:   `{"seed":"Hello World!","iterations":10}`,
+			status: http.StatusOK,
+			hash:   "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705",
+		}, {
+			path:   "/v1/verify/index",
+			body:   `{"seed":"Hello World!","iterations":10,"hash":"1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705"}`,
+			status: http.StatusOK,
+			hash:   "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705",
+			match:  true,
+		}, {
+			path:   "/v1/verify",
+			body:   `{"seed":"Hello World!","algorithm":"sha256","hash":"1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705"}`,
+			status: http.StatusOK,
+			hash:   "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705",
+			match:  true,
+		}, {
+			path:   "/v1/rechash",
+			body:   `{"iterations":10,"checkpoint":{"index":2,"hash":"4163fb4ab9e1e0a51709a51bc7e13ab6792907905960145c722d2c1479caac42"}}`,
+			status: http.StatusOK,
+			hash:   "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705",
+		}, {
+			path:   "/v1/verify/index",
+			body:   `{"iterations":2,"hash":"1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705","checkpoint":{"index":2,"hash":"4163fb4ab9e1e0a51709a51bc7e13ab6792907905960145c722d2c1479caac42"}}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/hash",
+			body:   `{"seed":""}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/rechash",
+			body:   `{"seed":"Hello World!","iterations":0}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/verify/timeout",
+			body:   `{"seed":"Hello World!","hash":"zzz","timeout":1}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/hash",
+			body:   `{"seed":"Hello World!","algorithm":"sha3"}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/verify/index",
+			body:   `{"seed":"Hello World!","iterations":10,"hash":"1fada6a9"}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/search",
+			body:   `{"seed":"Hello World!","prefix":{"hex":"abc"}}`,
+			status: http.StatusOK,
+			hash:   "abcdeb177b17a0382821ab6acb03273ae010c876e6362ff5fc9716be4330efc4",
+			match:  true,
+		}, {
+			path:   "/v1/search/timeout",
+			body:   `{"seed":"Hello World!","prefix":{"bits":300},"timeout":1}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/hash",
+			body:   `{"seed":"Hello World!","unknown":true}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/rechash",
+			body:   `{"seed":"Hello World!","iterations":10,"algorithm":"memhard","memory_hard":{"memory":1048576,"cost":1}}`,
+			status: http.StatusBadRequest,
+		}, {
+			path:   "/v1/rechash",
+			body:   `{"seed":"Hello World!","iterations":10,"algorithm":"memhard","memory_hard":{"memory":1,"cost":9223372036854775807}}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for id, test := range tests {
+		res := post(t, ts.URL+test.path, test.body)
+
+		if res.StatusCode != test.status {
+			t.Errorf("#%v POST %s %s = %v ; expected %v", id, test.path, test.body, res.StatusCode, test.status)
+		}
+
+		if test.status == http.StatusOK {
+			out := &clock.HashClockResponse{}
+			if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+				t.Errorf("#%v POST %s: invalid JSON response: %s", id, test.path, err)
+			}
+
+			if out.Hash != test.hash || out.Match != test.match {
+				t.Errorf("#%v POST %s = %s (match: %v) ; expected %s (match: %v)", id, test.path, out.Hash, out.Match, test.hash, test.match)
+			}
+		}
+		res.Body.Close()
+
+		t.Logf("#%v -- TESTED -- POST %s %s = %v", id, test.path, test.body, res.StatusCode)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	ts := httptest.NewServer(New(&Config{MaxJobs: 1, MaxQueue: 2}).Handler())
+	defer ts.Close()
+
+	getJob := func(id string) *Job {
+		res, err := http.Get(ts.URL + "/v1/jobs/" + id)
+		if err != nil {
+			t.Fatalf("GET job %s resulted in an unexpected error: %s", id, err)
+		}
+		defer res.Body.Close()
+
+		j := &Job{}
+		if err := json.NewDecoder(res.Body).Decode(j); err != nil {
+			t.Fatalf("GET job %s: invalid JSON response: %s", id, err)
+		}
+		return j
+	}
+
+	// a hash which is not in the chain; runs until it is cancelled
+	res := post(t, ts.URL+"/v1/verify?async=true", `{"seed":"Hello World!","hash":"`+strings.Repeat("0", 64)+`"}`)
+	if res.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST async verify = %v ; expected %v", res.StatusCode, http.StatusAccepted)
+	}
+	long := &Job{}
+	json.NewDecoder(res.Body).Decode(long)
+	res.Body.Close()
+
+	// queued behind the first job, as only one job runs at a time
+	res = post(t, ts.URL+"/v1/jobs", `{"method":"RecHash","seed":"Hello World!","iterations":10}`)
+	if res.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST job = %v ; expected %v", res.StatusCode, http.StatusAccepted)
+	}
+	short := &Job{}
+	json.NewDecoder(res.Body).Decode(short)
+	res.Body.Close()
+
+	// the queue is full
+	res = post(t, ts.URL+"/v1/jobs", `{"method":"Hash","seed":"Hello World!"}`)
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("POST job = %v ; expected %v", res.StatusCode, http.StatusTooManyRequests)
+	}
+	res.Body.Close()
+
+	if j := getJob(short.ID); j.Status != StatusQueued {
+		t.Errorf("job %s status = %s ; expected %s", short.ID, j.Status, StatusQueued)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/v1/jobs/"+long.ID, nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE job %s resulted in an unexpected error: %s", long.ID, err)
+	}
+	res.Body.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j := getJob(short.ID)
+		if j.Status == StatusDone {
+			if j.Response == nil || j.Response.Hash != "1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705" {
+				t.Errorf("job %s response = %v ; expected the 10th hash", short.ID, j.Response)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s status = %s ; expected it to be done", short.ID, j.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if j := getJob(long.ID); j.Status != StatusCancelled {
+		t.Errorf("job %s status = %s ; expected %s", long.ID, j.Status, StatusCancelled)
+	}
+
+	res, err = http.Get(ts.URL + "/v1/jobs/unknown")
+	if err != nil {
+		t.Fatalf("GET unknown job resulted in an unexpected error: %s", err)
+	}
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("GET unknown job = %v ; expected %v", res.StatusCode, http.StatusNotFound)
+	}
+	res.Body.Close()
+}
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(New(nil).Handler())
+	defer ts.Close()
+
+	post(t, ts.URL+"/v1/verify/index", `{"seed":"Hello World!","iterations":10,"hash":"1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705"}`).Body.Close()
+	post(t, ts.URL+"/v1/verify/index", `{"seed":"Hello World!","iterations":9,"hash":"1fada6a9b8084eff6347baeae0812aac1c14fcc0a97759a6bfa2d8a2ea087705"}`).Body.Close()
+
+	res, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics resulted in an unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	out := &bytes.Buffer{}
+	out.ReadFrom(res.Body)
+
+	for _, line := range []string{
+		`hashclock_verifications_total{outcome="match"} 1`,
+		`hashclock_verifications_total{outcome="mismatch"} 1`,
+		`hashclock_call_hashes_count{algorithm="SHA256"} 2`,
+		`hashclock_jobs_in_flight 0`,
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("GET /metrics is missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err)
+	}
+
+	s := New(&Config{ShutdownTimeout: 100 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Serve(ctx, l)
+	}()
+
+	res := post(t, "http://"+l.Addr().String()+"/v1/verify?async=true", `{"seed":"Hello World!","hash":"`+strings.Repeat("0", 64)+`"}`)
+	res.Body.Close()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Serve() = %s ; expected a graceful shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Serve() did not shut down")
+	}
+}
@@ -0,0 +1,240 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// keepAlive is the interval between SSE comments sent to keep idle
+// connections open
+const keepAlive = 15 * time.Second
+
+// Mux interface is implemented by the types which can register the clock's
+// HTTP handlers, such as `*http.ServeMux` and `*server.Server`
+type Mux interface {
+	Handle(pattern string, h http.Handler)
+}
+
+// Register method registers the clock's HTTP handlers in the input mux:
+//
+//   - `GET /v1/ticks` streams the ticks as Server-Sent Events
+//   - `GET /v1/ticks/ws` streams the ticks as WebSocket text messages
+//   - `GET /v1/ticks/recent` returns the ticks in the ring buffer
+//
+// All of them accept a `from` query parameter, with the index of the first
+// tick to return
+func (c *Clock) Register(mux Mux) {
+	mux.Handle("/v1/ticks", http.HandlerFunc(c.handleSSE))
+	mux.Handle("/v1/ticks/ws", http.HandlerFunc(c.handleWebSocket))
+	mux.Handle("/v1/ticks/recent", http.HandlerFunc(c.handleRecent))
+}
+
+// errorResponse struct is the JSON object returned on errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError function writes the input error as a JSON response; a starting
+// index which is no longer buffered results in a 410 (Gone)
+func writeError(w http.ResponseWriter, status int, err error) {
+	if errors.Is(err, ErrNotFound) {
+		status = http.StatusGone
+	}
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+// parseFrom function reads the starting index from the `from` query
+// parameter or, when resuming an event stream, from the `Last-Event-ID`
+// header (starting at the following tick)
+func parseFrom(r *http.Request) (int, error) {
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, err := strconv.Atoi(v)
+		if err != nil || from < 0 {
+			return 0, fmt.Errorf("invalid starting index %q", v)
+		}
+		return from, nil
+	}
+
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		last, err := strconv.Atoi(v)
+		if err != nil || last < 0 {
+			return 0, fmt.Errorf("invalid Last-Event-ID %q", v)
+		}
+		return last + 1, nil
+	}
+
+	return 0, nil
+}
+
+// subscribe method registers a subscriber for the request, writing an error
+// response if it fails
+func (c *Clock) subscribe(w http.ResponseWriter, r *http.Request) (*Subscriber, bool) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return nil, false
+	}
+
+	from, err := parseFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	sub, err := c.Subscribe(from)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return sub, true
+}
+
+func (c *Clock) handleRecent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	from, err := parseFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ticks, err := c.Recent(from)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ticks)
+}
+
+// writeEvent function writes the input tick as a `tick` event, identified
+// by its index
+func writeEvent(w http.ResponseWriter, t clock.Tick) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: tick\ndata: %s\n\n", t.Index, data)
+	return err
+}
+
+// handleSSE method streams the ticks as Server-Sent Events, until the client
+// disconnects or the clock stops. A subscriber which is dropped for falling
+// behind receives a final `dropped` event
+func (c *Clock) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	sub, ok := c.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer c.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, t := range sub.Backlog {
+		if err := writeEvent(w, t); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case t, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					fmt.Fprint(w, "event: dropped\ndata: {\"error\":\"slow consumer\"}\n\n")
+					flusher.Flush()
+				}
+				return
+			}
+			if err := writeEvent(w, t); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleWebSocket method streams the ticks as WebSocket text messages (one
+// JSON object per tick), until the client disconnects or the clock stops.
+// A subscriber which is dropped for falling behind is closed with a policy
+// violation status
+func (c *Clock) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sub, ok := c.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer c.Unsubscribe(sub)
+
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	send := func(t clock.Tick) error {
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		return ws.WriteText(data)
+	}
+
+	for _, t := range sub.Backlog {
+		if err := send(t); err != nil {
+			ws.shutdown()
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-ws.Done():
+			return
+		case t, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					ws.Close(closePolicyViolation, "slow consumer")
+					return
+				}
+				ws.Close(closeGoingAway, "clock stopped")
+				return
+			}
+			if err := send(t); err != nil {
+				ws.shutdown()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Package stream runs a single, continuous hash chain (a `RecHashLoop`) and
+// broadcasts each of its breakpoints, as a `clock.Tick`, to any number of
+// subscribers -- over Server-Sent Events or WebSocket.
+//
+// The most recent ticks are kept in a ring buffer, so that new subscribers can
+// start from a given index. Subscribers which cannot keep up with the clock are
+// dropped, instead of stalling the hashing goroutine.
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// ErrNotFound error is returned when a starting index is no longer held in
+// the ring buffer
+var ErrNotFound = errors.New("tick is no longer in the buffer of recent ticks")
+
+// Config struct defines the configuration for a streaming `Clock`
+type Config struct {
+	// Seed is the chain's seed string
+	Seed string
+
+	// Algorithm is the hashing algorithm, as accepted by
+	// `clock.HashClockService.SetHasher`
+	Algorithm string
+
+	// Breakpoint sets the frequency of the ticks; one for every
+	// # of hashes
+	Breakpoint int
+
+	// BufferSize is the number of recent ticks kept in the ring buffer
+	BufferSize int
+
+	// SubscriberBuffer is the number of ticks which can be pending for a
+	// subscriber, before it is dropped
+	SubscriberBuffer int
+
+	// OnTick is an optional function called on every tick, before it is
+	// published; and OnProgress is an optional function set as the clock's
+	// progress function (see `clock.HashClockService.SetProgressFunc`).
+	// Both are called from the hashing goroutine
+	OnTick     clock.TickFunc
+	OnProgress clock.TickFunc
+}
+
+// Clock struct runs a continuous hash chain and broadcasts its ticks
+type Clock struct {
+	cfg     *Config
+	service *clock.HashClockService
+
+	mu      sync.Mutex
+	ring    []clock.Tick
+	next    int // position of the next tick in the ring
+	size    int // number of ticks in the ring
+	subs    map[*Subscriber]struct{}
+	stopped bool
+}
+
+// Subscriber struct receives the ticks of a `Clock`, through its channel `C`.
+//
+// `C` is closed when the subscriber is removed, when the clock stops, or when
+// the subscriber falls behind by more than `Config.SubscriberBuffer` ticks --
+// in which case `Dropped` returns true
+type Subscriber struct {
+	C <-chan clock.Tick
+
+	// Backlog holds the buffered ticks from the requested starting index,
+	// which precede the ticks sent on `C`
+	Backlog []clock.Tick
+
+	ch      chan clock.Tick
+	mu      sync.Mutex
+	dropped bool
+}
+
+// Dropped method returns true if the subscriber was removed because it was
+// not consuming the ticks fast enough
+func (s *Subscriber) Dropped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// New function creates a `Clock` with the input configuration, validating
+// the seed, algorithm and breakpoint. Unset buffer sizes default to 1024 ticks
+// in the ring buffer, and 64 ticks per subscriber
+func New(cfg *Config) (*Clock, error) {
+	if cfg == nil {
+		return nil, errors.New("stream configuration cannot be nil")
+	}
+
+	if err := clock.ValidateSeed(cfg.Seed); err != nil {
+		return nil, err
+	}
+
+	if cfg.Breakpoint <= 0 {
+		return nil, errors.New("logging frequency cannot be zero or below")
+	}
+
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "sha256"
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1024
+	}
+	if cfg.SubscriberBuffer <= 0 {
+		cfg.SubscriberBuffer = 64
+	}
+
+	service := clock.NewService()
+	if err := service.SetHasher(cfg.Algorithm); err != nil {
+		return nil, err
+	}
+	service.SetWriter(nil)
+
+	c := &Clock{
+		cfg:     cfg,
+		service: service,
+		ring:    make([]clock.Tick, cfg.BufferSize),
+		subs:    map[*Subscriber]struct{}{},
+	}
+	service.SetTickFunc(c.publish)
+	service.SetProgressFunc(cfg.OnProgress)
+
+	return c, nil
+}
+
+// Run method runs the hash chain until the input context is cancelled, when
+// all subscribers are removed. It returns the context's error
+func (c *Clock) Run(ctx context.Context) error {
+	if err := c.service.SetContext(ctx); err != nil {
+		return err
+	}
+
+	err := c.service.RecHashLoop(c.cfg.Seed, c.cfg.Breakpoint)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stopped = true
+	for s := range c.subs {
+		delete(c.subs, s)
+		close(s.ch)
+	}
+
+	return err
+}
+
+// publish method stores the input tick in the ring buffer and sends it to all
+// subscribers. Subscribers with a full channel are dropped
+func (c *Clock) publish(t clock.Tick) {
+	if c.cfg.OnTick != nil {
+		c.cfg.OnTick(t)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ring[c.next] = t
+	c.next = (c.next + 1) % len(c.ring)
+	if c.size < len(c.ring) {
+		c.size++
+	}
+
+	for s := range c.subs {
+		select {
+		case s.ch <- t:
+		default:
+			s.mu.Lock()
+			s.dropped = true
+			s.mu.Unlock()
+
+			delete(c.subs, s)
+			close(s.ch)
+		}
+	}
+}
+
+// recent method returns the ticks in the ring buffer with an index of at
+// least `from`, in order. It must be called while holding the lock
+func (c *Clock) recent(from int) ([]clock.Tick, error) {
+	ticks := make([]clock.Tick, 0, c.size)
+
+	start := (c.next - c.size + len(c.ring)) % len(c.ring)
+	for i := 0; i < c.size; i++ {
+		t := c.ring[(start+i)%len(c.ring)]
+		if t.Index >= from {
+			ticks = append(ticks, t)
+		}
+	}
+
+	// the requested index is older than the oldest tick in the buffer, which
+	// has already overwritten older ticks
+	if from > 0 && c.size == len(c.ring) && c.ring[start].Index > from {
+		return nil, ErrNotFound
+	}
+
+	return ticks, nil
+}
+
+// Recent method returns the ticks in the ring buffer with an index of at
+// least `from`, in order. An error is returned if the ticks starting at
+// `from` are no longer in the buffer
+func (c *Clock) Recent(from int) ([]clock.Tick, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.recent(from)
+}
+
+// Subscribe method registers a new subscriber. If `from` is greater than
+// zero, the subscriber's backlog holds the buffered ticks starting at that
+// index; otherwise only new ticks are received
+func (c *Clock) Subscribe(from int) (*Subscriber, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan clock.Tick, c.cfg.SubscriberBuffer)
+	s := &Subscriber{
+		C:  ch,
+		ch: ch,
+	}
+
+	if from > 0 {
+		backlog, err := c.recent(from)
+		if err != nil {
+			return nil, err
+		}
+		s.Backlog = backlog
+	}
+
+	if c.stopped {
+		close(ch)
+		return s, nil
+	}
+
+	c.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Unsubscribe method removes the input subscriber, closing its channel
+func (c *Clock) Unsubscribe(s *Subscriber) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.subs[s]; ok {
+		delete(c.subs, s)
+		close(s.ch)
+	}
+}
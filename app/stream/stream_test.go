@@ -0,0 +1,275 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const testSeed string = "Hello World!"
+
+// runClock function starts a streaming clock, stopped when the test ends
+func runClock(t *testing.T, cfg *Config) *Clock {
+	t.Helper()
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() resulted in an unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return c
+}
+
+// verifyTick function checks that the tick's hash is the hash at its index
+// in the test seed's chain
+func verifyTick(t *testing.T, tick clock.Tick) {
+	t.Helper()
+
+	res, err := clock.NewService().VerifyIndex(testSeed, tick.Hash, tick.Index)
+	if err != nil {
+		t.Fatalf("VerifyIndex(%v) resulted in an unexpected error: %s", tick.Index, err)
+	}
+	if !res.Match {
+		t.Errorf("tick #%v: %s is not the hash at its index", tick.Index, tick.Hash)
+	}
+}
+
+// waitFor function polls the input condition until it is met or a few
+// seconds have passed
+func waitFor(t *testing.T, desc string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	c := runClock(t, &Config{Seed: testSeed, Breakpoint: 10, SubscriberBuffer: 1 << 16})
+
+	sub, err := c.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Subscribe(0) resulted in an unexpected error: %s", err)
+	}
+	defer c.Unsubscribe(sub)
+
+	last := 0
+	for i := 0; i < 5; i++ {
+		tick, ok := <-sub.C
+		if !ok {
+			t.Fatalf("subscriber channel closed unexpectedly")
+		}
+		if tick.Index%10 != 0 || tick.Index <= last {
+			t.Errorf("tick #%v: unexpected index after #%v", tick.Index, last)
+		}
+		last = tick.Index
+
+		verifyTick(t, tick)
+	}
+}
+
+func TestSubscribeFrom(t *testing.T) {
+	c := runClock(t, &Config{Seed: testSeed, Breakpoint: 1, BufferSize: 8})
+
+	// wait for the ring buffer to wrap around
+	waitFor(t, "the ring buffer to fill", func() bool {
+		ticks, _ := c.Recent(0)
+		return len(ticks) == 8 && ticks[0].Index > 2
+	})
+
+	if _, err := c.Subscribe(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Subscribe(1) = %v ; expected %v", err, ErrNotFound)
+	}
+
+	ticks, err := c.Recent(0)
+	if err != nil {
+		t.Fatalf("Recent(0) resulted in an unexpected error: %s", err)
+	}
+	from := ticks[len(ticks)-2].Index
+
+	sub, err := c.Subscribe(from)
+	if err != nil {
+		t.Fatalf("Subscribe(%v) resulted in an unexpected error: %s", from, err)
+	}
+	defer c.Unsubscribe(sub)
+
+	if len(sub.Backlog) == 0 || sub.Backlog[0].Index != from {
+		t.Fatalf("Subscribe(%v) backlog = %v ; expected it to start at #%v", from, sub.Backlog, from)
+	}
+	for i, tick := range sub.Backlog {
+		if tick.Index != from+i {
+			t.Errorf("backlog tick #%v: expected #%v", tick.Index, from+i)
+		}
+		verifyTick(t, tick)
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	c := runClock(t, &Config{Seed: testSeed, Breakpoint: 1, SubscriberBuffer: 2})
+
+	slow, err := c.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Subscribe(0) resulted in an unexpected error: %s", err)
+	}
+
+	// the subscriber never reads; the clock keeps running and drops it
+	waitFor(t, "the subscriber to be dropped", slow.Dropped)
+
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("dropped subscriber received %v ticks ; expected %v", n, 2)
+	}
+
+	// the clock is still running for other subscribers
+	sub, err := c.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Subscribe(0) resulted in an unexpected error: %s", err)
+	}
+	defer c.Unsubscribe(sub)
+
+	if _, ok := <-sub.C; !ok {
+		t.Errorf("subscriber channel closed unexpectedly")
+	}
+}
+
+func TestSSE(t *testing.T) {
+	c := runClock(t, &Config{Seed: testSeed, Breakpoint: 100, SubscriberBuffer: 1 << 16})
+
+	mux := http.NewServeMux()
+	c.Register(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/v1/ticks")
+	if err != nil {
+		t.Fatalf("GET /v1/ticks resulted in an unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("GET /v1/ticks Content-Type = %s ; expected text/event-stream", ct)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	var id, data string
+	for scanner.Scan() && data == "" {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+
+	tick := clock.Tick{}
+	if err := json.Unmarshal([]byte(data), &tick); err != nil {
+		t.Fatalf("invalid event data %q: %s", data, err)
+	}
+	if id != strconv.Itoa(tick.Index) {
+		t.Errorf("event ID %q does not match the tick %s", id, data)
+	}
+	verifyTick(t, tick)
+
+	res, err = http.Get(ts.URL + "/v1/ticks/recent?from=1")
+	if err != nil {
+		t.Fatalf("GET /v1/ticks/recent resulted in an unexpected error: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusGone {
+		t.Errorf("GET /v1/ticks/recent = %v ; expected %v or %v", res.StatusCode, http.StatusOK, http.StatusGone)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	c := runClock(t, &Config{Seed: testSeed, Breakpoint: 100, SubscriberBuffer: 1 << 16})
+
+	mux := http.NewServeMux()
+	c.Register(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("unable to connect: %s", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// example key from RFC 6455
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	io.WriteString(conn, "GET /v1/ticks/ws HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: "+key+"\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("invalid handshake response: %s", err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %v ; expected %v", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if accept := res.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %s ; expected the RFC 6455 example value", accept)
+	}
+
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatalf("unable to read frame: %s", err)
+	}
+	if head[0] != 0x80|opText {
+		t.Fatalf("frame header = %x ; expected a final text frame", head[0])
+	}
+
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("unable to read frame payload: %s", err)
+	}
+
+	tick := clock.Tick{}
+	if err := json.Unmarshal(payload, &tick); err != nil {
+		t.Fatalf("invalid frame payload %q: %s", payload, err)
+	}
+	verifyTick(t, tick)
+}
@@ -0,0 +1,263 @@
+package stream
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the client's key to compute the handshake's
+// accept key, as defined in RFC 6455
+const websocketGUID string = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	opText  byte = 0x1
+	opClose byte = 0x8
+	opPing  byte = 0x9
+	opPong  byte = 0xA
+)
+
+// WebSocket close status codes
+const (
+	closeGoingAway       uint16 = 1001
+	closePolicyViolation uint16 = 1008
+)
+
+// maxControlPayload is the maximum payload size for a control frame; larger
+// client frames are treated as a protocol error
+const maxControlPayload int = 125
+
+var errNotWebSocket = errors.New("not a websocket handshake request")
+
+// wsConn struct is a minimal, server-side WebSocket connection which sends
+// text frames and answers the client's control frames
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+
+	mu     sync.Mutex // guards writes
+	closed chan struct{}
+	once   sync.Once
+}
+
+// acceptKey function computes the `Sec-WebSocket-Accept` header value for
+// the input `Sec-WebSocket-Key`
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains function checks if a comma-separated header contains the
+// input token (case-insensitive)
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgrade function performs the WebSocket opening handshake and hijacks the
+// HTTP connection. On failure, the HTTP error response is already written
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		key == "" {
+		http.Error(w, errNotWebSocket.Error(), http.StatusBadRequest)
+		return nil, errNotWebSocket
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errNotWebSocket
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket is not supported", http.StatusInternalServerError)
+		return nil, errors.New("response writer does not support hijacking")
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\n")
+	rw.WriteString("Connection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &wsConn{
+		conn:   conn,
+		rw:     rw,
+		closed: make(chan struct{}),
+	}
+	go ws.readLoop()
+
+	return ws, nil
+}
+
+// writeFrame method writes a single, unmasked and final frame
+func (ws *wsConn) writeFrame(op byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | op
+
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+
+	if _, err := ws.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(payload); err != nil {
+		return err
+	}
+	return ws.rw.Flush()
+}
+
+// WriteText method sends the input payload as a text frame
+func (ws *wsConn) WriteText(payload []byte) error {
+	return ws.writeFrame(opText, payload)
+}
+
+// Close method sends a close frame with the input status code and reason,
+// and closes the connection
+func (ws *wsConn) Close(code uint16, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	payload = append(payload, reason...)
+
+	err := ws.writeFrame(opClose, payload)
+	ws.shutdown()
+	return err
+}
+
+// Done method returns a channel which is closed when the connection is closed,
+// by either side
+func (ws *wsConn) Done() <-chan struct{} {
+	return ws.closed
+}
+
+func (ws *wsConn) shutdown() {
+	ws.once.Do(func() {
+		close(ws.closed)
+		ws.conn.Close()
+	})
+}
+
+// readLoop method reads the client's frames until the connection is closed.
+// Data frames are discarded, pings are answered and a close frame is echoed
+func (ws *wsConn) readLoop() {
+	defer ws.shutdown()
+
+	for {
+		op, payload, err := ws.readFrame()
+		if err != nil {
+			return
+		}
+
+		switch op {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return
+			}
+		case opClose:
+			ws.writeFrame(opClose, payload)
+			return
+		}
+	}
+}
+
+// readFrame method reads a single client frame, unmasking its payload.
+// Payloads of data frames are discarded rather than returned
+func (ws *wsConn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(ws.rw, head[:]); err != nil {
+		return 0, nil, err
+	}
+
+	op := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	// client frames must be masked
+	if !masked {
+		return 0, nil, errors.New("unmasked client frame")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+
+	// control frames are read and unmasked; anything else is discarded
+	if op < opClose {
+		_, err := io.CopyN(io.Discard, ws.rw, int64(length))
+		return op, nil, err
+	}
+
+	if length > uint64(maxControlPayload) {
+		return 0, nil, errors.New("control frame is too large")
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return op, payload, nil
+}
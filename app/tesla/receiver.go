@@ -0,0 +1,206 @@
+package tesla
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Receiver struct authenticates the packets of a `Sender`: it buffers each
+// packet until the key of its interval is disclosed, authenticates that key
+// against the commitment, and then verifies the packet's MAC. A Receiver is
+// safe for concurrent use
+type Receiver struct {
+	mu sync.Mutex
+
+	params Params
+	engine *clock.Engine
+	macFn  func() hash.Hash
+
+	// maxDrift is the upper bound of the sender's clock ahead of the
+	// receiver's
+	maxDrift time.Duration
+
+	// latest is the last authenticated key, and its interval; the commitment
+	// at interval 0
+	latest    int
+	latestKey []byte
+
+	// buffer holds the packets waiting for their interval's key
+	buffer   map[int][]*Packet
+	buffered int
+
+	// now returns the current time, to check that packets are safe
+	now func() time.Time
+}
+
+// NewReceiver function creates a `Receiver` which trusts the input commitment;
+// and whose clock is behind the sender's by at most `maxDrift`. A packet is
+// only accepted if, by then, the sender cannot have disclosed its key yet
+func NewReceiver(c *Commitment, maxDrift time.Duration) (*Receiver, error) {
+	p := c.Params
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	if maxDrift < 0 {
+		return nil, errors.New("maximum clock drift cannot be negative")
+	}
+
+	e, err := clock.NewEngine(p.Algorithm, clock.ModeHex)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKey(c.Key, e.Size()); err != nil {
+		return nil, fmt.Errorf("invalid commitment: %w", err)
+	}
+
+	return &Receiver{
+		params:    p,
+		engine:    e,
+		macFn:     hashFuncs[p.Algorithm],
+		maxDrift:  maxDrift,
+		latestKey: []byte(c.Key),
+		buffer:    map[int][]*Packet{},
+		now:       time.Now,
+	}, nil
+}
+
+// checkKey function checks that the input key is a hex-encoded hash of the
+// input length
+func checkKey(key string, size int) error {
+	if len(key) != size {
+		return fmt.Errorf("key length %d does not match the hash function's digest size %d", len(key), size)
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return fmt.Errorf("hex encoder: invalid string -- %s", err)
+	}
+	return nil
+}
+
+// Latest method returns the last authenticated key's interval; 0 if none was
+// authenticated yet
+func (r *Receiver) Latest() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latest
+}
+
+// Buffered method returns the number of packets waiting for their key
+func (r *Receiver) Buffered() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buffered
+}
+
+// Receive method handles a packet from the sender: its message is buffered
+// (if it is safe), and the key it discloses is authenticated. It returns the
+// buffered packets which the key authenticates, in the order of their
+// intervals.
+//
+// The error is set (wrapping `ErrUnsafe`, `ErrKey` or `ErrMAC`) if the
+// packet's message is dropped as unsafe, its key is not authentic, or a
+// buffered packet's MAC does not match -- even when other packets are
+// authenticated. A disclosed key whose interval is not after the last
+// authenticated one is ignored
+func (r *Receiver) Receive(p *Packet) ([]*Packet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	if p.MAC != "" {
+		err = r.push(p)
+	}
+
+	if p.Key != nil {
+		authentic, kErr := r.disclose(*p.Key)
+		if kErr != nil {
+			err = kErr
+		}
+		return authentic, err
+	}
+	return nil, err
+}
+
+// push method buffers the input packet, if the sender cannot have disclosed
+// its interval's key yet: it is not authenticated, and the sender's latest
+// possible time is still before that key's disclosure interval
+func (r *Receiver) push(p *Packet) error {
+	if p.Interval < 1 || p.Interval > r.params.Length {
+		return fmt.Errorf("%w: interval %d is outside of the key chain's 1 to %d", ErrUnsafe, p.Interval, r.params.Length)
+	}
+	if p.Interval <= r.latest {
+		return fmt.Errorf("%w: the key of interval %d was already disclosed", ErrUnsafe, p.Interval)
+	}
+	if sender := r.params.IntervalAt(r.now().Add(r.maxDrift)); sender >= p.Interval+r.params.Delay {
+		return fmt.Errorf("%w: the key of interval %d is disclosed in interval %d; the sender may be in interval %d",
+			ErrUnsafe, p.Interval, p.Interval+r.params.Delay, sender)
+	}
+
+	r.buffer[p.Interval] = append(r.buffer[p.Interval], p)
+	r.buffered++
+	return nil
+}
+
+// disclose method authenticates the input key, by hashing it back to the last
+// authenticated key; and verifies the buffered packets of its interval and of
+// the ones before it, with the keys derived on the way
+func (r *Receiver) disclose(k Key) ([]*Packet, error) {
+	if k.Interval <= r.latest {
+		return nil, nil
+	}
+	if k.Interval > r.params.Length {
+		return nil, fmt.Errorf("%w: interval %d is outside of the key chain's 1 to %d", ErrKey, k.Interval, r.params.Length)
+	}
+	if err := checkKey(k.Key, r.engine.Size()); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrKey, err)
+	}
+
+	// the keys of the intervals with buffered packets are kept, to verify
+	// them once the disclosed key is authenticated
+	keys := map[int][]byte{}
+	hash := []byte(k.Key)
+	for interval := k.Interval; interval > r.latest; interval-- {
+		if len(r.buffer[interval]) > 0 {
+			keys[interval] = append([]byte(nil), hash...)
+		}
+		hash = r.engine.Step(hash)
+	}
+	if !hmac.Equal(hash, r.latestKey) {
+		return nil, fmt.Errorf("%w: the key of interval %d does not hash into the key of interval %d", ErrKey, k.Interval, r.latest)
+	}
+
+	var (
+		authentic []*Packet
+		forged    int
+	)
+	for interval := r.latest + 1; interval <= k.Interval; interval++ {
+		key, ok := keys[interval]
+		if !ok {
+			continue
+		}
+
+		for _, p := range r.buffer[interval] {
+			if hmac.Equal([]byte(p.MAC), []byte(mac(r.macFn, key, interval, p.Message))) {
+				authentic = append(authentic, p)
+			} else {
+				forged++
+			}
+			r.buffered--
+		}
+		delete(r.buffer, interval)
+	}
+
+	r.latest = k.Interval
+	r.latestKey = []byte(k.Key)
+
+	if forged > 0 {
+		return authentic, fmt.Errorf("%w: %d packets up to interval %d were dropped", ErrMAC, forged, k.Interval)
+	}
+	return authentic, nil
+}
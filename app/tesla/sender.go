@@ -0,0 +1,135 @@
+package tesla
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+// Sender struct MACs broadcast messages with the keys of a chain, and
+// discloses each key `Delay` intervals after its own. All the keys are kept in
+// memory, one (hex-encoded) hash per interval. A Sender is safe for concurrent
+// use
+type Sender struct {
+	params     Params
+	commitment string
+	size       int
+
+	// keys holds the key of interval i at keys[(i-1)*size:i*size]
+	keys []byte
+
+	// now returns the current time, to find the current interval
+	now func() time.Time
+}
+
+// NewSender function derives the key chain of the input parameters from the
+// input (secret) seed: as in `RecHash`, hashing it `Length+1` times. The key
+// of interval i is the hash at index `Length+1-i`, so the commitment is the
+// hash at index `Length+1`
+func NewSender(seed string, p Params) (*Sender, error) {
+	if err := clock.ValidateSeed(seed); err != nil {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	e, err := clock.NewEngine(p.Algorithm, clock.ModeHex)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sender{
+		params: p,
+		size:   e.Size(),
+		keys:   make([]byte, p.Length*e.Size()),
+		now:    time.Now,
+	}
+
+	hash := e.Hash([]byte(seed))
+	for idx := 1; idx <= p.Length; idx++ {
+		interval := p.Length + 1 - idx
+		copy(s.keys[(interval-1)*s.size:], hash)
+		hash = e.Step(hash)
+	}
+	s.commitment = string(hash)
+
+	return s, nil
+}
+
+// Params method returns the key chain's parameters
+func (s *Sender) Params() Params {
+	return s.params
+}
+
+// Commitment method returns the key chain's commitment, to distribute to the
+// receivers
+func (s *Sender) Commitment() *Commitment {
+	return &Commitment{Params: s.params, Key: s.commitment}
+}
+
+// Key method returns the (hex-encoded) key of the input interval; or the
+// commitment, for interval 0
+func (s *Sender) Key(interval int) (string, error) {
+	if interval < 0 || interval > s.params.Length {
+		return "", fmt.Errorf("interval %d is outside of the key chain's 0 to %d", interval, s.params.Length)
+	}
+	if interval == 0 {
+		return s.commitment, nil
+	}
+	return string(s.key(interval)), nil
+}
+
+// key method returns the key of the input interval (between 1 and `Length`)
+func (s *Sender) key(interval int) []byte {
+	return s.keys[(interval-1)*s.size : interval*s.size]
+}
+
+// Send method MACs the input message with the key of the current interval;
+// returning it as a packet, with the key disclosed in that interval (if any)
+func (s *Sender) Send(message []byte) (*Packet, error) {
+	interval := s.params.IntervalAt(s.now())
+	switch {
+	case interval < 1:
+		return nil, ErrNotStarted
+	case interval > s.params.Length:
+		return nil, ErrExhausted
+	}
+
+	return &Packet{
+		Interval: interval,
+		Message:  message,
+		MAC:      mac(hashFuncs[s.params.Algorithm], s.key(interval), interval, message),
+		Key:      s.disclosed(interval),
+	}, nil
+}
+
+// Disclose method returns a packet without a message, with the key disclosed
+// in the current interval -- to send in intervals without messages, so that
+// the receivers get the keys in time. Keys are still disclosed for `Delay`
+// intervals after the last one, until the last key is
+func (s *Sender) Disclose() (*Packet, error) {
+	interval := s.params.IntervalAt(s.now())
+	switch {
+	case interval <= s.params.Delay:
+		return nil, ErrNotStarted
+	case interval > s.params.Length+s.params.Delay:
+		return nil, ErrExhausted
+	}
+
+	return &Packet{
+		Interval: interval,
+		Key:      s.disclosed(interval),
+	}, nil
+}
+
+// disclosed method returns the key disclosed in the input interval: the key of
+// `Delay` intervals before it, if any
+func (s *Sender) disclosed(interval int) *Key {
+	i := interval - s.params.Delay
+	if i < 1 {
+		return nil
+	}
+	return &Key{Interval: i, Key: string(s.key(i))}
+}
@@ -0,0 +1,154 @@
+// Package tesla authenticates broadcast messages with TESLA (Timed Efficient
+// Stream Loss-tolerant Authentication): each message is MAC'ed with the key of
+// the time interval it is sent in, and each key is only disclosed a few
+// intervals later -- once no receiver can accept a message MAC'ed with it.
+//
+// The keys are a hash chain, used in reverse: the chain is calculated from a
+// secret seed as in `clock.HashClockService.RecHash`, and its last hash is the
+// commitment which receivers trust (it is distributed authentically, e.g.
+// signed). The key of interval i hashes into the key of interval i-1, so a
+// receiver authenticates a disclosed key by hashing it back to the
+// commitment, or to the last key it authenticated -- even if the keys in
+// between were lost.
+package tesla
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+	"time"
+)
+
+// macLabel is the data MAC'ed with an interval's key to derive its MAC key, so
+// that the disclosed (chain) keys are never used as MAC keys themselves
+const macLabel string = "tesla mac key"
+
+var (
+	// ErrNotStarted error is returned when sending before the first interval
+	ErrNotStarted = errors.New("key chain's first interval has not started")
+
+	// ErrExhausted error is returned when sending after the last interval
+	ErrExhausted = errors.New("key chain is exhausted")
+
+	// ErrUnsafe error is returned for packets which arrive once their key may
+	// have been disclosed, so that they cannot be authenticated
+	ErrUnsafe = errors.New("packet arrived after its key may have been disclosed")
+
+	// ErrKey error is returned for disclosed keys which do not hash into the
+	// commitment
+	ErrKey = errors.New("disclosed key is not authentic")
+
+	// ErrMAC error is returned for buffered packets whose MAC does not match
+	// their (authentic) interval key
+	ErrMAC = errors.New("packet MAC is not authentic")
+)
+
+// hashFuncs maps each algorithm (as named in `clock.HasherMapVals`) to its
+// hash function, for the MACs. The memory-hard step function is not supported
+var hashFuncs = map[string]func() hash.Hash{
+	"MD5":        md5.New,
+	"SHA1":       sha1.New,
+	"SHA224":     sha256.New224,
+	"SHA256":     sha256.New,
+	"SHA384":     sha512.New384,
+	"SHA512":     sha512.New,
+	"SHA512_224": sha512.New512_224,
+	"SHA512_256": sha512.New512_256,
+}
+
+// Params struct holds the (public) parameters of a key chain
+type Params struct {
+	// Algorithm is the hash function of the key chain and the MACs; SHA256 if
+	// empty
+	Algorithm string `json:"algorithm"`
+
+	// Start is the beginning of the first interval
+	Start time.Time `json:"start"`
+
+	// Interval is the duration of each interval
+	Interval time.Duration `json:"interval"`
+
+	// Length is the number of intervals, each with its own key
+	Length int `json:"length"`
+
+	// Delay is the number of intervals after which a key is disclosed: the key
+	// of interval i is disclosed in interval i+Delay
+	Delay int `json:"delay"`
+}
+
+// validate method checks the parameters, setting the default algorithm
+func (p *Params) validate() error {
+	if p.Algorithm == "" {
+		p.Algorithm = "SHA256"
+	}
+	p.Algorithm = strings.ToUpper(p.Algorithm)
+
+	switch {
+	case hashFuncs[p.Algorithm] == nil:
+		return errors.New("invalid hasher reference")
+	case p.Start.IsZero():
+		return errors.New("start time is required")
+	case p.Interval <= 0:
+		return errors.New("interval duration must be greater than zero")
+	case p.Length <= 0:
+		return errors.New("number of intervals must be greater than zero")
+	case p.Delay <= 0:
+		return errors.New("disclosure delay must be at least one interval")
+	}
+	return nil
+}
+
+// IntervalAt method returns the interval at the input time: 1 for the first
+// one; 0 before it starts; and above `Length` after the last one
+func (p Params) IntervalAt(t time.Time) int {
+	if t.Before(p.Start) {
+		return 0
+	}
+	return int(t.Sub(p.Start)/p.Interval) + 1
+}
+
+// Commitment struct is what a receiver trusts to authenticate the keys: the
+// key chain's parameters and its last hash (the key of interval 0). It must
+// reach the receivers authentically, e.g. signed by the sender
+type Commitment struct {
+	Params
+	Key string `json:"key"`
+}
+
+// Key struct is a disclosed key, and its interval
+type Key struct {
+	Interval int    `json:"interval"`
+	Key      string `json:"key"`
+}
+
+// Packet struct is a broadcast message, MAC'ed with the key of the interval it
+// was sent in; and the key disclosed by the sender in that interval, if any
+type Packet struct {
+	Interval int    `json:"interval"`
+	Message  []byte `json:"message,omitempty"`
+	MAC      string `json:"mac,omitempty"`
+	Key      *Key   `json:"disclosed,omitempty"`
+}
+
+// mac function returns the (hex-encoded) MAC of the input message of the
+// input interval, with the (hex-encoded) key of that interval
+func mac(fn func() hash.Hash, key []byte, interval int, message []byte) string {
+	h := hmac.New(fn, key)
+	h.Write([]byte(macLabel))
+	macKey := h.Sum(nil)
+
+	var idx [8]byte
+	binary.BigEndian.PutUint64(idx[:], uint64(interval))
+
+	h = hmac.New(fn, macKey)
+	h.Write(idx[:])
+	h.Write(message)
+	return hex.EncodeToString(h.Sum(nil))
+}
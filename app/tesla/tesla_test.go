@@ -0,0 +1,206 @@
+package tesla
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ZalgoNoise/hashclock/clock"
+)
+
+const testSeed string = "genesis_string"
+
+// clockAt function returns a clock function which returns the time of the
+// input interval's middle
+func clockAt(p Params, interval *int) func() time.Time {
+	return func() time.Time {
+		return p.Start.Add(time.Duration(*interval-1)*p.Interval + p.Interval/2)
+	}
+}
+
+// newTestChain function creates a sender and a receiver of a 20-interval key
+// chain, with a disclosure delay of 2 intervals; both in the returned
+// interval
+func newTestChain(t *testing.T, alg string) (*Sender, *Receiver, *int) {
+	t.Helper()
+
+	p := Params{
+		Algorithm: alg,
+		Start:     time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+		Interval:  time.Second,
+		Length:    20,
+		Delay:     2,
+	}
+
+	s, err := NewSender(testSeed, p)
+	if err != nil {
+		t.Fatalf("FAILED -- [TESLA] NewSender() failed: %s", err)
+	}
+	r, err := NewReceiver(s.Commitment(), 0)
+	if err != nil {
+		t.Fatalf("FAILED -- [TESLA] NewReceiver() failed: %s", err)
+	}
+
+	interval := 1
+	s.now = clockAt(s.Params(), &interval)
+	r.now = clockAt(s.Params(), &interval)
+	return s, r, &interval
+}
+
+func TestKeyChain(t *testing.T) {
+	for _, alg := range []string{"sha256", "SHA1", "sha512_256"} {
+		s, _, _ := newTestChain(t, alg)
+
+		service := clock.NewService()
+		service.SetWriter(nil)
+		service.SetHasher(alg)
+
+		// the commitment is the hash at index Length+1; and the key of
+		// interval i the hash at index Length+1-i
+		for interval := 0; interval <= s.Params().Length; interval++ {
+			want, err := service.RecHash(testSeed, s.Params().Length+1-interval)
+			if err != nil {
+				t.Fatalf("FAILED -- [TESLA] RecHash() failed: %s", err)
+			}
+
+			key, err := s.Key(interval)
+			if err != nil || key != want.Hash {
+				t.Errorf("FAILED -- [TESLA] %s key of interval %d mismatch: wanted %s ; got %s (%v)", alg, interval, want.Hash, key, err)
+			}
+		}
+	}
+}
+
+func TestSendReceive(t *testing.T) {
+	s, r, interval := newTestChain(t, "sha256")
+
+	var received []string
+	for *interval = 1; *interval <= 22; *interval++ {
+		// intervals 5 to 9 are lost, and 12 has no message
+		var (
+			p   *Packet
+			err error
+		)
+		switch {
+		case *interval >= 5 && *interval <= 9:
+			continue
+		case *interval == 12 || *interval > 20:
+			p, err = s.Disclose()
+		default:
+			p, err = s.Send([]byte(fmt.Sprintf("message %d", *interval)))
+		}
+		if err != nil {
+			t.Fatalf("FAILED -- [TESLA] interval %d: sending failed: %s", *interval, err)
+		}
+
+		authentic, err := r.Receive(p)
+		if err != nil {
+			t.Fatalf("FAILED -- [TESLA] interval %d: Receive() failed: %s", *interval, err)
+		}
+		for _, a := range authentic {
+			received = append(received, string(a.Message))
+		}
+	}
+
+	// the keys of the lost intervals are derived from the later ones
+	want := []string{"message 1", "message 2", "message 3", "message 4", "message 10", "message 11",
+		"message 13", "message 14", "message 15", "message 16", "message 17", "message 18", "message 19", "message 20"}
+	if fmt.Sprint(received) != fmt.Sprint(want) {
+		t.Errorf("FAILED -- [TESLA] authenticated messages mismatch: wanted %v ; got %v", want, received)
+	}
+	if r.Latest() != 20 || r.Buffered() != 0 {
+		t.Errorf("FAILED -- [TESLA] expected the last key authenticated and no buffered packets: latest %d ; buffered %d", r.Latest(), r.Buffered())
+	}
+
+	*interval = 23
+	if _, err := s.Disclose(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("FAILED -- [TESLA] Disclose() after the last key should fail with ErrExhausted: %v", err)
+	}
+	*interval = 21
+	if _, err := s.Send([]byte("late")); !errors.Is(err, ErrExhausted) {
+		t.Errorf("FAILED -- [TESLA] Send() after the last interval should fail with ErrExhausted: %v", err)
+	}
+	*interval = 0
+	if _, err := s.Send([]byte("early")); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("FAILED -- [TESLA] Send() before the first interval should fail with ErrNotStarted: %v", err)
+	}
+}
+
+func TestReceiveForged(t *testing.T) {
+	s, r, interval := newTestChain(t, "sha256")
+
+	p, _ := s.Send([]byte("authentic"))
+	forged := &Packet{Interval: p.Interval, Message: []byte("forged"), MAC: p.MAC}
+	for _, pkt := range []*Packet{p, forged} {
+		if _, err := r.Receive(pkt); err != nil {
+			t.Fatalf("FAILED -- [TESLA] Receive() failed: %s", err)
+		}
+	}
+
+	// a key which does not hash into the commitment
+	*interval = 3
+	other, _ := NewSender("other seed", s.Params())
+	other.now = s.now
+	fake, _ := other.Disclose()
+	if _, err := r.Receive(fake); !errors.Is(err, ErrKey) || r.Latest() != 0 || r.Buffered() != 2 {
+		t.Errorf("FAILED -- [TESLA] Receive() with a forged key should fail with ErrKey: %v", err)
+	}
+
+	d, _ := s.Disclose()
+	authentic, err := r.Receive(d)
+	if !errors.Is(err, ErrMAC) || len(authentic) != 1 || string(authentic[0].Message) != "authentic" {
+		t.Errorf("FAILED -- [TESLA] Receive() should authenticate one packet and fail with ErrMAC: %v ; %v", authentic, err)
+	}
+
+	// a packet of interval 1, once its key was disclosed
+	if _, err := r.Receive(p); !errors.Is(err, ErrUnsafe) {
+		t.Errorf("FAILED -- [TESLA] Receive() of a packet with a disclosed key should fail with ErrUnsafe: %v", err)
+	}
+}
+
+func TestReceiveUnsafe(t *testing.T) {
+	s, _, interval := newTestChain(t, "sha256")
+
+	// with the sender's clock up to 1.4 intervals ahead, a packet of interval
+	// i is no longer safe by the middle of interval i+1
+	r, _ := NewReceiver(s.Commitment(), 1400*time.Millisecond)
+	r.now = s.now
+
+	*interval = 4
+	p, _ := s.Send([]byte("on time"))
+	if _, err := r.Receive(p); err != nil {
+		t.Errorf("FAILED -- [TESLA] Receive() of a safe packet failed: %s", err)
+	}
+
+	*interval = 5
+	if _, err := r.Receive(p); !errors.Is(err, ErrUnsafe) {
+		t.Errorf("FAILED -- [TESLA] Receive() of a late packet should fail with ErrUnsafe: %v", err)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	start := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, p := range []Params{
+		{Algorithm: "sha3", Start: start, Interval: time.Second, Length: 10, Delay: 1},
+		{Algorithm: "memhard", Start: start, Interval: time.Second, Length: 10, Delay: 1},
+		{Interval: time.Second, Length: 10, Delay: 1},
+		{Start: start, Length: 10, Delay: 1},
+		{Start: start, Interval: time.Second, Delay: 1},
+		{Start: start, Interval: time.Second, Length: 10},
+	} {
+		if _, err := NewSender(testSeed, p); err == nil {
+			t.Errorf("FAILED -- [TESLA] NewSender() with invalid params #%d should fail", i)
+		}
+	}
+
+	s, _ := NewSender(testSeed, Params{Start: start, Interval: time.Second, Length: 10, Delay: 1})
+	c := s.Commitment()
+	c.Key = c.Key[:10]
+	if _, err := NewReceiver(c, 0); err == nil {
+		t.Errorf("FAILED -- [TESLA] NewReceiver() with a short commitment should fail")
+	}
+	if _, err := s.Key(11); err == nil {
+		t.Errorf("FAILED -- [TESLA] Key() beyond the last interval should fail")
+	}
+}